@@ -14,6 +14,8 @@ require (
 	github.com/urfave/cli/v3 v3.3.3
 	github.com/vinovest/sqlx v1.7.1
 	golang.org/x/crypto v0.38.0
+	golang.org/x/image v0.25.0
+	golang.org/x/time v0.11.0
 	modernc.org/sqlite v1.46.1
 )
 
@@ -37,7 +39,6 @@ require (
 	golang.org/x/sync v0.17.0 // indirect
 	golang.org/x/sys v0.37.0 // indirect
 	golang.org/x/text v0.25.0 // indirect
-	golang.org/x/time v0.11.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	modernc.org/libc v1.67.6 // indirect
 	modernc.org/mathutil v1.7.1 // indirect
@@ -104,6 +104,56 @@ func main() {
 							},
 						},
 					},
+					{
+						Name:        "import-cgm",
+						Description: "Import glucose readings from a Dexcom Clarity or LibreView CSV export",
+						Action:      cmd.CmdImportCGM,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "database-vendor",
+								Aliases:  []string{"V"},
+								Usage:    "The database vendor ('sqlite' or 'postgres')",
+								Sources:  cli.EnvVars("DATABASE_VENDOR"),
+								Required: false,
+							},
+							&cli.StringFlag{
+								Name:     "database-conn",
+								Aliases:  []string{"c"},
+								Usage:    "The database connection string",
+								Sources:  cli.EnvVars("DATABASE_CONN"),
+								Required: false,
+							},
+							&cli.StringFlag{
+								Name:     "username",
+								Aliases:  []string{"u"},
+								Usage:    "The user the readings belong to",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "file",
+								Aliases:  []string{"f"},
+								Usage:    "The file path to the CSV export",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "timezone",
+								Aliases:  []string{"t"},
+								Usage:    "The timezone of the export's timestamps (eg. America/Toronto), defaults to the user's timezone",
+								Required: false,
+							},
+							&cli.BoolFlag{
+								Name:     "day-first",
+								Usage:    "LibreView dates are DD-MM-YYYY instead of MM-DD-YYYY",
+								Required: false,
+							},
+							&cli.BoolFlag{
+								Name:     "ignore-errors",
+								Aliases:  []string{"e"},
+								Usage:    "Continue even with errors importing",
+								Required: false,
+							},
+						},
+					},
 					{
 						Name:        "create-user",
 						Description: "Creates a user",
@@ -163,6 +213,44 @@ func main() {
 								Sources:  cli.EnvVars("OUTPUT_FOLDER"),
 								Required: false,
 							},
+							&cli.StringFlag{
+								Name:     "timezone",
+								Aliases:  []string{"t"},
+								Usage:    "The timezone to export times in (eg. America/Toronto), defaults to the timezone of each row's user",
+								Required: false,
+							},
+						},
+					},
+					{
+						Name:        "migrate-photos",
+						Description: "Move every photo into the given photo storage, the server must be stopped first",
+						Action:      cmd.CmdMigratePhotos,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "database-vendor",
+								Aliases:  []string{"V"},
+								Usage:    "The database vendor ('sqlite' or 'postgres')",
+								Sources:  cli.EnvVars("DATABASE_VENDOR"),
+								Required: false,
+							},
+							&cli.StringFlag{
+								Name:     "database-conn",
+								Aliases:  []string{"c"},
+								Usage:    "The database connection string",
+								Sources:  cli.EnvVars("DATABASE_CONN"),
+								Required: false,
+							},
+							&cli.StringFlag{
+								Name:     "to",
+								Usage:    "The photo storage to move photos into ('db' or 'fs')",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "photo-dir",
+								Usage:    "The directory photos are kept in for 'fs' photo storage",
+								Sources:  cli.EnvVars("PHOTO_DIR"),
+								Required: true,
+							},
 						},
 					},
 				},
@@ -215,6 +303,19 @@ func main() {
 						Sources:  cli.EnvVars("SESSION_SECRET"),
 						Required: false,
 					},
+					&cli.StringFlag{
+						Name:     "photo-storage",
+						Usage:    "Where new photos are saved ('db' or 'fs')",
+						Value:    "db",
+						Sources:  cli.EnvVars("PHOTO_STORAGE"),
+						Required: false,
+					},
+					&cli.StringFlag{
+						Name:     "photo-dir",
+						Usage:    "The directory photos are kept in, required for 'fs' photo storage",
+						Sources:  cli.EnvVars("PHOTO_DIR"),
+						Required: false,
+					},
 					&cli.StringFlag{
 						Name:     "log-config-path",
 						Usage:    "Set a custom path for the logger config, see https://github.com/Minnowo/log4zero",
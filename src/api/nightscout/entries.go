@@ -0,0 +1,189 @@
+package nightscout
+
+import (
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	entryTypeSensor = "sgv"
+	entryTypeMeter  = "mbg"
+
+	// The source of readings from uploaders that do not name their device.
+	defaultEntrySource = "Nightscout"
+)
+
+// Entry is a Nightscout glucose reading, the glucose is always in mg/dL.
+// Sensor readings use SGV and meter readings use MBG.
+type Entry struct {
+	ID         string  `json:"_id,omitempty"`
+	Type       string  `json:"type"`
+	SGV        float64 `json:"sgv,omitempty"`
+	MBG        float64 `json:"mbg,omitempty"`
+	Date       int64   `json:"date"`
+	DateString string  `json:"dateString,omitempty"`
+	Device     string  `json:"device,omitempty"`
+	Direction  string  `json:"direction,omitempty"`
+}
+
+// newEntry converts the glucose reading into a sensor entry.
+func newEntry(reading *database.TblUserGlucose) Entry {
+
+	t := reading.UserTime.Time()
+
+	return Entry{
+		ID:         strconv.Itoa(reading.ID),
+		Type:       entryTypeSensor,
+		SGV:        math.Round(reading.Glucose * database.MgdlPerMmol),
+		Date:       t.UnixMilli(),
+		DateString: formatTime(t),
+		Device:     reading.Source,
+	}
+}
+
+// glucose returns the reading in mmol/L, or false if this is not a glucose reading.
+func (e *Entry) glucose() (float64, bool) {
+
+	var mgdl float64
+
+	switch e.Type {
+	case entryTypeSensor, "":
+		mgdl = e.SGV
+	case entryTypeMeter:
+		mgdl = e.MBG
+	}
+
+	if mgdl <= 0 {
+		return 0, false
+	}
+
+	return mgdl / database.MgdlPerMmol, true
+}
+
+// time returns when the entry was read, from the date or otherwise the dateString.
+func (e *Entry) time() (time.Time, error) {
+
+	if e.Date > 0 {
+		return time.UnixMilli(e.Date).UTC(), nil
+	}
+
+	return parseTime(e.DateString)
+}
+
+func (n *Nightscout) getEntries(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.Unauthorized(w)
+		return
+	}
+
+	q := r.URL.Query()
+
+	count, err := parseCount(q)
+
+	if err != nil {
+		api.BadReq(w, err.Error())
+		return
+	}
+
+	start, end, err := parseTimeRange(q, "date", "dateString")
+
+	if err != nil {
+		api.BadReq(w, err.Error())
+		return
+	}
+
+	var readings []database.TblUserGlucose
+
+	if err := n.Db.LoadUserGlucoseBetweenN(r.Context(), user.ID, start, end, count, &readings); err != nil {
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to load glucose for nightscout entries")
+		api.ServerErr(w, "failed to load entries")
+
+		return
+	}
+
+	entries := make([]Entry, len(readings))
+
+	for i := range readings {
+		entries[i] = newEntry(&readings[i])
+	}
+
+	api.WriteJSONArr(w, entries)
+}
+
+func (n *Nightscout) postEntries(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.Unauthorized(w)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	entries, err := decodeDocuments[Entry](r.Body)
+
+	if err != nil {
+
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+
+		return
+	}
+
+	accepted := make([]Entry, 0, len(entries))
+	readings := make([]database.TblUserGlucose, 0, len(entries))
+
+	for i, entry := range entries {
+
+		// Calibrations and other entry types have nothing to store.
+		glucose, ok := entry.glucose()
+
+		if !ok {
+			continue
+		}
+
+		t, err := entry.time()
+
+		if err != nil {
+			api.BadReqf(w, "entry %d: %s", i, err.Error())
+			return
+		}
+
+		source := strings.TrimSpace(entry.Device)
+
+		if source == "" {
+			source = defaultEntrySource
+		}
+
+		readings = append(readings, database.TblUserGlucose{
+			UserTime: database.TimeMillis(t),
+			Glucose:  glucose,
+			Source:   source,
+		})
+
+		accepted = append(accepted, entry)
+	}
+
+	if _, err := n.Db.AddUserGlucoseReadings(r.Context(), user.ID, readings); err != nil {
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to save nightscout entries")
+		api.ServerErr(w, "failed to save entries")
+
+		return
+	}
+
+	api.WriteJSONArr(w, accepted)
+}
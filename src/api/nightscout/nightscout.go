@@ -0,0 +1,158 @@
+// Package nightscout emulates the parts of the Nightscout REST API that uploaders and followers use,
+// so apps like xDrip+ or AndroidAPS can send glucose readings and treatments straight to karopon.
+//
+// Entries become glucose readings and treatments become eventlogs of the user owning the api-secret.
+package nightscout
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/api/middleware"
+	"karopon/src/database"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// The Nightscout version reported to clients, some refuse to talk to servers older than they support.
+	apiVersion = "15.0.2"
+
+	// Clients send the SHA1 hex digest of the secret in this header.
+	apiSecretHeader = "api-secret"
+
+	// Followers that cannot set headers put the secret in this query parameter.
+	apiSecretQuery = "token"
+)
+
+type Nightscout struct {
+	Db database.DB
+}
+
+func (n *Nightscout) check() {
+	if n.Db == nil {
+		log.Panic().Msg("database must not be nil")
+	}
+}
+
+func (n *Nightscout) Init() {
+	n.check()
+}
+
+func (n *Nightscout) Deinit() {
+}
+
+// Register adds the Nightscout routes under /api/v1.
+// It must be registered before the karopon API, which owns the rest of /api.
+func (n *Nightscout) Register(r *mux.Router) {
+
+	n.check()
+
+	ns := r.PathPrefix("/api/v1").Subrouter()
+	ns.Use(middleware.Cors)
+
+	ns.HandleFunc("/verifyauth", n.getVerifyAuth).Methods("GET", "OPTIONS")
+
+	get := ns.Methods("GET", "OPTIONS").Subrouter()
+	get.Use(n.requireSecret)
+	get.HandleFunc("/status", n.getStatus)
+	get.HandleFunc("/status.json", n.getStatus)
+	get.HandleFunc("/entries", n.getEntries)
+	get.HandleFunc("/entries.json", n.getEntries)
+	get.HandleFunc("/entries/sgv", n.getEntries)
+	get.HandleFunc("/entries/sgv.json", n.getEntries)
+	get.HandleFunc("/treatments", n.getTreatments)
+	get.HandleFunc("/treatments.json", n.getTreatments)
+
+	post := ns.Methods("POST", "OPTIONS").Subrouter()
+	post.Use(n.requireSecret)
+	post.HandleFunc("/entries", n.postEntries)
+	post.HandleFunc("/entries.json", n.postEntries)
+	post.HandleFunc("/treatments", n.postTreatments)
+	post.HandleFunc("/treatments.json", n.postTreatments)
+}
+
+// HashSecret returns the hash of the secret that is stored, which is the hash of its SHA1 hex digest.
+func HashSecret(secret string) string {
+	return hashDigest(hashSHA1(secret))
+}
+
+// hashSHA1 returns the SHA1 hex digest of the secret, which is what Nightscout clients send.
+func hashSHA1(secret string) string {
+
+	sum := sha1.Sum([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// hashDigest returns the SHA-256 hex digest of the SHA1 digest a client sent,
+// so a leaked database does not hold what clients can authenticate with.
+func hashDigest(digest string) string {
+
+	sum := sha256.Sum256([]byte(digest))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// secretSHA1 returns the digest of the secret a client sent.
+// Most clients send the digest already, some send the plain secret.
+func secretSHA1(secret string) string {
+
+	if len(secret) == sha1.Size*2 {
+		if _, err := hex.DecodeString(secret); err == nil {
+			return strings.ToLower(secret)
+		}
+	}
+
+	return hashSHA1(secret)
+}
+
+// authenticate returns the user owning the secret sent with the request.
+func (n *Nightscout) authenticate(r *http.Request) (*database.TblUser, bool) {
+
+	secret := strings.TrimSpace(r.Header.Get(apiSecretHeader))
+
+	if secret == "" {
+		secret = strings.TrimSpace(r.URL.Query().Get(apiSecretQuery))
+	}
+
+	if secret == "" {
+		return nil, false
+	}
+
+	var user database.TblUser
+
+	if err := n.Db.LoadUserByNightscoutSecret(r.Context(), hashDigest(secretSHA1(secret)), &user); err != nil {
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Warn().Err(err).Msg("failed to load the user for a nightscout api-secret")
+		}
+
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// requireSecret requires that the request has a valid api-secret, otherwise it returns a 401.
+func (n *Nightscout) requireSecret(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		user, ok := n.authenticate(r)
+
+		if !ok {
+			api.Unauthorized(w)
+			return
+		}
+
+		next.ServeHTTP(w, auth.PutUser(r, user))
+	})
+}
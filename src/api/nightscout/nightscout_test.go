@@ -0,0 +1,289 @@
+package nightscout
+
+import (
+	"bytes"
+	"encoding/json"
+	"karopon/src/database"
+	"karopon/src/database/sqlite"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "karopon-test-secret"
+
+// newTestRouter returns the Nightscout routes backed by a new sqlite database,
+// with a user that authenticates with testSecret.
+func newTestRouter(t *testing.T) (*mux.Router, database.DB, int) {
+
+	t.Helper()
+
+	ctx := t.Context()
+
+	db, err := sqlite.OpenSqliteDatabase(ctx, path.Join(t.TempDir(), "db.sqlite"))
+	require.NoError(t, err)
+	require.NoError(t, db.Migrate(ctx))
+
+	userID, err := db.AddUser(ctx, &database.TblUser{Name: "test_user"})
+	require.NoError(t, err)
+	require.NoError(t, db.SetUserNightscoutSecret(ctx, userID, HashSecret(testSecret)))
+
+	ns := Nightscout{Db: db}
+	ns.Init()
+
+	r := mux.NewRouter()
+	ns.Register(r)
+
+	return r, db, userID
+}
+
+// serve sends the request with the api-secret of the test user, the body is a fixture file in testdata.
+func serve(t *testing.T, r *mux.Router, method, url, fixture string) *httptest.ResponseRecorder {
+
+	t.Helper()
+
+	var body []byte
+
+	if fixture != "" {
+		var err error
+		body, err = os.ReadFile(path.Join("testdata", fixture))
+		require.NoError(t, err)
+	}
+
+	req := httptest.NewRequest(method, url, bytes.NewReader(body))
+	req.Header.Set(apiSecretHeader, hashSHA1(testSecret))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func decode[T any](t *testing.T, rr *httptest.ResponseRecorder) T {
+
+	t.Helper()
+
+	var out T
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&out))
+
+	return out
+}
+
+func TestAuth(t *testing.T) {
+
+	r, _, _ := newTestRouter(t)
+
+	get := func(secret string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/entries", nil)
+		if secret != "" {
+			req.Header.Set(apiSecretHeader, secret)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("").Code)
+	assert.Equal(t, http.StatusUnauthorized, get(hashSHA1("wrong secret")).Code)
+	assert.Equal(t, http.StatusOK, get(hashSHA1(testSecret)).Code)
+
+	// The stored hash is not what clients send, so it cannot be used as the api-secret.
+	assert.NotEqual(t, hashSHA1(testSecret), HashSecret(testSecret))
+	assert.Equal(t, http.StatusUnauthorized, get(HashSecret(testSecret)).Code)
+
+	// Some clients send the plain secret.
+	assert.Equal(t, http.StatusOK, get(testSecret).Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/verifyauth", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "UNAUTHORIZED", decode[VerifyAuth](t, rr).Message.Message)
+
+	rr = serve(t, r, http.MethodGet, "/api/v1/verifyauth", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, decode[VerifyAuth](t, rr).Message.CanWrite)
+
+	rr = serve(t, r, http.MethodGet, "/api/v1/status.json", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, unitsMmol, decode[Status](t, rr).Settings.Units)
+}
+
+func TestEntries(t *testing.T) {
+
+	r, db, userID := newTestRouter(t)
+
+	rr := serve(t, r, http.MethodPost, "/api/v1/entries", "xdrip_entries.json")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// The calibration is not a reading.
+	assert.Len(t, decode[[]Entry](t, rr), 4)
+
+	// Uploaders resend readings, they are only saved once.
+	rr = serve(t, r, http.MethodPost, "/api/v1/entries", "xdrip_entries.json")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	var readings []database.TblUserGlucose
+	require.NoError(t, db.LoadUserGlucoseBetween(t.Context(), userID, start, start.AddDate(0, 0, 1), &readings))
+	require.Len(t, readings, 4)
+	assert.InDelta(t, 126/database.MgdlPerMmol, readings[0].Glucose, 1e-9)
+	assert.InDelta(t, 135/database.MgdlPerMmol, readings[2].Glucose, 1e-9)
+	assert.Equal(t, "xDrip-DexcomG6", readings[0].Source)
+
+	rr = serve(t, r, http.MethodGet, "/api/v1/entries/sgv.json?count=2", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	entries := decode[[]Entry](t, rr)
+	require.Len(t, entries, 2)
+	assert.Equal(t, Entry{
+		ID:         entries[0].ID,
+		Type:       entryTypeSensor,
+		SGV:        140,
+		Date:       1705306200000,
+		DateString: "2024-01-15T08:10:00.000Z",
+		Device:     "xDrip-DexcomG6",
+	}, entries[0])
+	assert.Equal(t, 135.0, entries[1].SGV)
+
+	rr = serve(t, r, http.MethodGet, "/api/v1/entries.json?find[date][$gt]=1705305600000&find[date][$lte]=1705305900000", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	entries = decode[[]Entry](t, rr)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 131.0, entries[0].SGV)
+
+	rr = serve(t, r, http.MethodGet, "/api/v1/entries?count=nope", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestTreatments(t *testing.T) {
+
+	r, db, userID := newTestRouter(t)
+
+	rr := serve(t, r, http.MethodPost, "/api/v1/treatments", "aaps_treatments.json")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Len(t, decode[[]Treatment](t, rr), 3)
+
+	// Treatments that were already saved are skipped.
+	rr = serve(t, r, http.MethodPost, "/api/v1/treatments", "aaps_treatments.json")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Empty(t, decode[[]Treatment](t, rr))
+
+	var eventlogs []database.TblUserEventLog
+	require.NoError(t, db.LoadUserEventLogs(t.Context(), userID, &eventlogs))
+	require.Len(t, eventlogs, 3)
+
+	// The events are the event types, the notes are kept on the eventlog.
+	var events []database.TblUserEvent
+	require.NoError(t, db.LoadUserEvents(t.Context(), userID, &events))
+
+	names := make([]string, len(events))
+	for i := range events {
+		names[i] = events[i].Name
+	}
+	assert.ElementsMatch(t, []string{eventTypeMealBolus, eventTypeCorrectionBolus, eventTypeComboBolus}, names)
+
+	for _, eventlog := range eventlogs {
+		if eventlog.Event == eventTypeComboBolus {
+			assert.Equal(t, "Pizza", eventlog.Notes)
+		} else {
+			assert.Empty(t, eventlog.Notes)
+		}
+	}
+
+	rr = serve(t, r, http.MethodGet, "/api/v1/treatments.json?find[created_at][$gte]=2024-01-15", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	treatments := decode[[]Treatment](t, rr)
+	require.Len(t, treatments, 3)
+
+	combo := treatments[0]
+	assert.Equal(t, eventTypeComboBolus, combo.EventType)
+	assert.Equal(t, "Pizza", combo.Notes)
+	assert.Equal(t, "2024-01-15T18:00:00.000Z", combo.CreatedAt)
+	assert.InDelta(t, 3, combo.Insulin, 1e-9)
+	assert.InDelta(t, 6, combo.EnteredInsulin, 1e-9)
+	assert.InDelta(t, 50, combo.SplitExt, 1e-9)
+	assert.InDelta(t, 120, combo.Duration, 1e-9)
+	assert.InDelta(t, 60, combo.Carbs, 1e-9)
+
+	correction := treatments[1]
+	assert.Equal(t, eventTypeCorrectionBolus, correction.EventType)
+	assert.InDelta(t, 1.2, correction.Insulin, 1e-9)
+	assert.Zero(t, correction.Carbs)
+
+	meal := treatments[2]
+	assert.Equal(t, eventTypeMealBolus, meal.EventType)
+	assert.Equal(t, eventTypeMealBolus, meal.Notes)
+	assert.InDelta(t, 4.5, meal.Insulin, 1e-9)
+	assert.InDelta(t, 45, meal.Carbs, 1e-9)
+	assert.InDelta(t, 126/database.MgdlPerMmol, meal.Glucose, 1e-9)
+	assert.Equal(t, unitsMmol, meal.Units)
+
+	rr = serve(t, r, http.MethodGet, "/api/v1/treatments?count=1&find[created_at][$lt]=2024-01-15T11:30:00Z", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	treatments = decode[[]Treatment](t, rr)
+	require.Len(t, treatments, 1)
+	assert.Equal(t, eventTypeMealBolus, treatments[0].EventType)
+}
+
+func TestTreatmentsConcurrentRetries(t *testing.T) {
+
+	r, db, userID := newTestRouter(t)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	codes := make([]int, 16)
+
+	for i := range codes {
+		wg.Go(func() {
+			<-start
+			codes[i] = serve(t, r, http.MethodPost, "/api/v1/treatments", "aaps_treatments.json").Code
+		})
+	}
+
+	close(start)
+	wg.Wait()
+
+	for _, code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+
+	var eventlogs []database.TblUserEventLog
+	require.NoError(t, db.LoadUserEventLogs(t.Context(), userID, &eventlogs))
+	assert.Len(t, eventlogs, 3)
+}
+
+func TestTreatmentsInvalid(t *testing.T) {
+
+	r, db, userID := newTestRouter(t)
+
+	// The valid treatment before the negative one is not saved either.
+	rr := serve(t, r, http.MethodPost, "/api/v1/treatments", "negative_treatments.json")
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), "treatment 1")
+
+	var eventlogs []database.TblUserEventLog
+	require.NoError(t, db.LoadUserEventLogs(t.Context(), userID, &eventlogs))
+	assert.Empty(t, eventlogs)
+
+	for _, treatment := range []Treatment{
+		{Insulin: -1},
+		{Carbs: -10},
+		{EventType: eventTypeComboBolus, EnteredInsulin: -6, SplitNow: 50, SplitExt: 50},
+	} {
+		_, _, err := treatment.eventLog(&database.TblUser{ID: userID})
+		assert.Error(t, err, treatment)
+	}
+}
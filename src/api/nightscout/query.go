@@ -0,0 +1,135 @@
+package nightscout
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// How many documents are returned when the request has no count, same as Nightscout.
+	defaultCount = 10
+
+	// The most documents returned by one request.
+	maxCount = 10000
+
+	// The largest request body accepted, uploaders batch at most a few days of readings.
+	maxBodyBytes = 4 << 20
+
+	// The time format of Nightscout's dateString and created_at.
+	isoLayout = "2006-01-02T15:04:05.000Z"
+)
+
+// Every document is between these when the request does not narrow it down.
+var (
+	minTime = time.Unix(0, 0).UTC()
+	maxTime = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// parseCount reads the count query parameter, clamped to maxCount.
+func parseCount(q url.Values) (int, error) {
+
+	s := q.Get("count")
+
+	if s == "" {
+		return defaultCount, nil
+	}
+
+	n, err := strconv.Atoi(s)
+
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid count %q", s)
+	}
+
+	return min(n, maxCount), nil
+}
+
+// parseTime reads a time given as epoch milliseconds or in one of the ISO layouts Nightscout accepts.
+func parseTime(s string) (time.Time, error) {
+
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms).UTC(), nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// parseTimeRange reads Nightscout's find[field][$gte] style filters on any of the given fields into [start, end).
+func parseTimeRange(q url.Values, fields ...string) (time.Time, time.Time, error) {
+
+	start, end := minTime, maxTime
+
+	for _, field := range fields {
+		for _, op := range []string{"$gte", "$gt", "$lte", "$lt"} {
+
+			s := q.Get("find[" + field + "][" + op + "]")
+
+			if s == "" {
+				continue
+			}
+
+			t, err := parseTime(s)
+
+			if err != nil {
+				return start, end, err
+			}
+
+			switch op {
+			case "$gte":
+				start = t
+			case "$gt":
+				start = t.Add(time.Millisecond)
+			case "$lte":
+				end = t.Add(time.Millisecond)
+			case "$lt":
+				end = t
+			}
+		}
+	}
+
+	return start, end, nil
+}
+
+// formatTime formats the time the way Nightscout does in dateString and created_at.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(isoLayout)
+}
+
+// decodeDocuments reads either a single JSON document or an array of them, uploaders send both.
+func decodeDocuments[T any](r io.Reader) ([]T, error) {
+
+	body, err := io.ReadAll(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body = bytes.TrimSpace(body)
+
+	if len(body) > 0 && body[0] == '[' {
+
+		var docs []T
+
+		err := json.Unmarshal(body, &docs)
+
+		return docs, err
+	}
+
+	var doc T
+
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	return []T{doc}, nil
+}
@@ -0,0 +1,83 @@
+package nightscout
+
+import (
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"net/http"
+	"time"
+)
+
+type StatusSettings struct {
+	Units string `json:"units"`
+}
+
+// Status is the subset of Nightscout's server status that clients check before uploading.
+type Status struct {
+	Status            string         `json:"status"`
+	Name              string         `json:"name"`
+	Version           string         `json:"version"`
+	ServerTime        string         `json:"serverTime"`
+	ServerTimeEpoch   int64          `json:"serverTimeEpoch"`
+	APIEnabled        bool           `json:"apiEnabled"`
+	CareportalEnabled bool           `json:"careportalEnabled"`
+	Settings          StatusSettings `json:"settings"`
+}
+
+type VerifyAuthMessage struct {
+	CanRead  bool   `json:"canRead"`
+	CanWrite bool   `json:"canWrite"`
+	IsAdmin  bool   `json:"isAdmin"`
+	Message  string `json:"message"`
+}
+
+// VerifyAuth tells a client whether its api-secret is accepted, it is sent with a 200 either way.
+type VerifyAuth struct {
+	Status  int               `json:"status"`
+	Message VerifyAuthMessage `json:"message"`
+}
+
+func (n *Nightscout) getStatus(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.Unauthorized(w)
+		return
+	}
+
+	now := time.Now()
+
+	api.WriteJSONObj(w, Status{
+		Status:            "ok",
+		Name:              "karopon",
+		Version:           apiVersion,
+		ServerTime:        formatTime(now),
+		ServerTimeEpoch:   now.UnixMilli(),
+		APIEnabled:        true,
+		CareportalEnabled: true,
+		Settings: StatusSettings{
+			Units: statusUnits(user.GlucoseUnit),
+		},
+	})
+}
+
+func (n *Nightscout) getVerifyAuth(w http.ResponseWriter, r *http.Request) {
+
+	res := VerifyAuth{
+		Status: http.StatusOK,
+		Message: VerifyAuthMessage{
+			Message: "UNAUTHORIZED",
+		},
+	}
+
+	if _, ok := n.authenticate(r); ok {
+		res.Message = VerifyAuthMessage{
+			CanRead:  true,
+			CanWrite: true,
+			IsAdmin:  true,
+			Message:  "OK",
+		}
+	}
+
+	api.WriteJSONObj(w, res)
+}
@@ -0,0 +1,297 @@
+package nightscout
+
+import (
+	"errors"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	eventTypeMealBolus       = "Meal Bolus"
+	eventTypeCorrectionBolus = "Correction Bolus"
+	eventTypeCarbCorrection  = "Carb Correction"
+	eventTypeComboBolus      = "Combo Bolus"
+	eventTypeNote            = "Note"
+
+	// The food logged for the carbs of a treatment, Nightscout only knows the grams of carbs.
+	carbsFoodName = "Carbs"
+	carbsFoodUnit = "g"
+
+	// Readings in treatments without units are in the user's glucose unit, as reported by getStatus.
+	unitsMmol = "mmol"
+	unitsMgdl = "mg/dl"
+)
+
+// Treatment is a Nightscout careportal entry, an insulin dose or a meal or both.
+// A combo bolus gives its total in EnteredInsulin, split between now and over the Duration by percent.
+type Treatment struct {
+	ID          string  `json:"_id,omitempty"`
+	EventType   string  `json:"eventType"`
+	CreatedAt   string  `json:"created_at"`
+	Date        int64   `json:"date,omitempty"`
+	Insulin     float64 `json:"insulin,omitempty"`
+	Carbs       float64 `json:"carbs,omitempty"`
+	Glucose     float64 `json:"glucose,omitempty"`
+	GlucoseType string  `json:"glucoseType,omitempty"`
+	Units       string  `json:"units,omitempty"`
+
+	EnteredInsulin float64 `json:"enteredinsulin,omitempty"`
+	SplitNow       float64 `json:"splitNow,omitempty"`
+	SplitExt       float64 `json:"splitExt,omitempty"`
+	Duration       float64 `json:"duration,omitempty"`
+
+	Notes     string `json:"notes,omitempty"`
+	EnteredBy string `json:"enteredBy,omitempty"`
+}
+
+// statusUnits returns the Nightscout name of the glucose unit.
+func statusUnits(unit database.GlucoseUnit) string {
+
+	if unit == database.GlucoseUnitMgdl {
+		return unitsMgdl
+	}
+
+	return unitsMmol
+}
+
+// newTreatment converts the eventlog into a treatment with the glucose in the unit.
+// The notes are the eventlog's notes, or its event name if it has none.
+func newTreatment(eventlog *database.TblUserEventLog, unit database.GlucoseUnit) Treatment {
+
+	treatment := Treatment{
+		ID:        strconv.Itoa(eventlog.ID),
+		CreatedAt: formatTime(eventlog.UserTime.Time()),
+		Date:      eventlog.UserTime.Time().UnixMilli(),
+		Insulin:   eventlog.ActualInsulinTaken,
+		Carbs:     eventlog.NetCarbs,
+		Notes:     eventlog.Notes,
+		EnteredBy: "karopon",
+	}
+
+	// Eventlogs logged in karopon have no notes, their event name says what they were.
+	if treatment.Notes == "" {
+		treatment.Notes = eventlog.Event
+	}
+
+	if eventlog.BloodGlucose > 0 {
+		treatment.Glucose = unit.FromMmol(eventlog.BloodGlucose)
+		treatment.GlucoseType = "Finger"
+		treatment.Units = statusUnits(unit)
+	}
+
+	switch {
+	case eventlog.ActualExtendedInsulinTaken > 0:
+		total := eventlog.ActualInsulinTaken + eventlog.ActualExtendedInsulinTaken
+		treatment.EventType = eventTypeComboBolus
+		treatment.EnteredInsulin = total
+		treatment.SplitNow = eventlog.ActualInsulinTaken / total * 100
+		treatment.SplitExt = eventlog.ActualExtendedInsulinTaken / total * 100
+		treatment.Duration = float64(eventlog.ActualExtendedMinutes)
+	case eventlog.ActualInsulinTaken > 0 && eventlog.NetCarbs > 0:
+		treatment.EventType = eventTypeMealBolus
+	case eventlog.ActualInsulinTaken > 0:
+		treatment.EventType = eventTypeCorrectionBolus
+	case eventlog.NetCarbs > 0:
+		treatment.EventType = eventTypeCarbCorrection
+	default:
+		treatment.EventType = eventTypeNote
+	}
+
+	return treatment
+}
+
+// time returns when the treatment happened, from created_at or otherwise the date.
+func (t *Treatment) time() (time.Time, error) {
+
+	if t.CreatedAt != "" {
+		return parseTime(t.CreatedAt)
+	}
+
+	if t.Date > 0 {
+		return time.UnixMilli(t.Date).UTC(), nil
+	}
+
+	return time.Now().UTC(), nil
+}
+
+// eventName returns the name of the event the treatment is logged as, its event type.
+// The notes are free text, so they are kept on the eventlog instead of making an event for each.
+func (t *Treatment) eventName() string {
+
+	if name := strings.TrimSpace(t.EventType); name != "" {
+		return name
+	}
+
+	return eventTypeNote
+}
+
+// eventLog converts the treatment into an eventlog of the user and the food for its carbs.
+func (t *Treatment) eventLog(user *database.TblUser) (database.TblUserEventLog, []database.TblUserFoodLog, error) {
+
+	at, err := t.time()
+
+	if err != nil {
+		return database.TblUserEventLog{}, nil, err
+	}
+
+	switch {
+	case t.Insulin < 0:
+		return database.TblUserEventLog{}, nil, errors.New("insulin cannot be negative")
+	case t.Carbs < 0:
+		return database.TblUserEventLog{}, nil, errors.New("carbs cannot be negative")
+	case t.EnteredInsulin < 0:
+		return database.TblUserEventLog{}, nil, errors.New("enteredinsulin cannot be negative")
+	case t.SplitNow < 0 || t.SplitExt < 0:
+		return database.TblUserEventLog{}, nil, errors.New("split cannot be negative")
+	case t.Duration < 0:
+		return database.TblUserEventLog{}, nil, errors.New("duration cannot be negative")
+	}
+
+	eventlog := database.TblUserEventLog{
+		UserID:             user.ID,
+		UserTime:           database.TimeMillis(at),
+		Event:              t.eventName(),
+		Notes:              strings.TrimSpace(t.Notes),
+		ActualInsulinTaken: t.Insulin,
+	}
+
+	if t.EventType == eventTypeComboBolus && t.EnteredInsulin > 0 {
+		if eventlog.ActualInsulinTaken == 0 {
+			eventlog.ActualInsulinTaken = t.EnteredInsulin * t.SplitNow / 100
+		}
+		eventlog.ActualExtendedInsulinTaken = t.EnteredInsulin * t.SplitExt / 100
+		eventlog.ActualExtendedMinutes = int(t.Duration)
+	}
+
+	if t.Glucose > 0 {
+
+		unit := user.GlucoseUnit
+
+		if units := strings.ToLower(t.Units); strings.HasPrefix(units, "mg") {
+			unit = database.GlucoseUnitMgdl
+		} else if strings.HasPrefix(units, unitsMmol) {
+			unit = database.GlucoseUnitMmol
+		}
+
+		eventlog.BloodGlucose = unit.ToMmol(t.Glucose)
+	}
+
+	var foods []database.TblUserFoodLog
+
+	if t.Carbs > 0 {
+		foods = append(foods, database.TblUserFoodLog{
+			Name:    carbsFoodName,
+			Unit:    carbsFoodUnit,
+			Portion: t.Carbs,
+			Carb:    t.Carbs,
+		})
+	}
+
+	return eventlog, foods, nil
+}
+
+func (n *Nightscout) getTreatments(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.Unauthorized(w)
+		return
+	}
+
+	q := r.URL.Query()
+
+	count, err := parseCount(q)
+
+	if err != nil {
+		api.BadReq(w, err.Error())
+		return
+	}
+
+	start, end, err := parseTimeRange(q, "created_at")
+
+	if err != nil {
+		api.BadReq(w, err.Error())
+		return
+	}
+
+	var eventlogs []database.TblUserEventLog
+
+	if err := n.Db.LoadUserEventLogsBetweenN(r.Context(), user.ID, start, end, count, &eventlogs); err != nil {
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to load eventlogs for nightscout treatments")
+		api.ServerErr(w, "failed to load treatments")
+
+		return
+	}
+
+	treatments := make([]Treatment, len(eventlogs))
+
+	for i := range eventlogs {
+		treatments[i] = newTreatment(&eventlogs[i], user.GlucoseUnit)
+	}
+
+	api.WriteJSONArr(w, treatments)
+}
+
+func (n *Nightscout) postTreatments(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.Unauthorized(w)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	treatments, err := decodeDocuments[Treatment](r.Body)
+
+	if err != nil {
+
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+
+		return
+	}
+
+	eventlogs := make([]database.TblUserEventLog, len(treatments))
+	foods := make([][]database.TblUserFoodLog, len(treatments))
+
+	// Every treatment is checked before any is saved, so a bad one does not leave half the upload behind.
+	for i, treatment := range treatments {
+
+		eventlogs[i], foods[i], err = treatment.eventLog(user)
+
+		if err != nil {
+			api.BadReqf(w, "treatment %d: %s", i, err.Error())
+			return
+		}
+	}
+
+	// Uploaders resend treatments they are not sure were saved.
+	saved, err := n.Db.AddUserEventLogsOnce(r.Context(), user.ID, eventlogs, foods)
+
+	if err != nil {
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to save nightscout treatments")
+		api.ServerErr(w, "failed to save treatments")
+
+		return
+	}
+
+	out := make([]Treatment, len(saved))
+
+	for i := range saved {
+		out[i] = newTreatment(&saved[i], user.GlucoseUnit)
+	}
+
+	api.WriteJSONArr(w, out)
+}
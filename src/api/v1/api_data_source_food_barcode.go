@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+func (a *APIV1) getDataSourceFoodByBarcode(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.Unauthorized(w)
+		return
+	}
+
+	barcode, ok := database.NormalizeBarcode(mux.Vars(r)["code"])
+
+	if !ok {
+		api.BadReq(w, "The barcode must be a GTIN or UPC of 8 to 14 digits.")
+		return
+	}
+
+	var foods []database.TblDataSourceFood
+
+	if err := a.Db.LoadDataSourceFoodByBarcode(r.Context(), barcode, &foods); err != nil {
+
+		log.Warn().
+			Err(err).
+			Str("user", user.Name).
+			Str("barcode", barcode).
+			Msg("failed to read data source food by barcode")
+		api.ServerErr(w, "failed while reading from the database")
+
+		return
+	}
+
+	a.writeDataSourceFoods(w, r, user, foods)
+}
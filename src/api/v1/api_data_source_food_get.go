@@ -63,5 +63,31 @@ func (a *APIV1) getDataSourceFood(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	api.WriteJSONArr(w, dataSources)
+	a.writeDataSourceFoods(w, r, user, dataSources)
+}
+
+// writeDataSourceFoods writes the foods with their servings.
+func (a *APIV1) writeDataSourceFoods(
+	w http.ResponseWriter,
+	r *http.Request,
+	user *database.TblUser,
+	foods []database.TblDataSourceFood,
+) {
+
+	ids := make([]int, len(foods))
+	for i, f := range foods {
+		ids[i] = f.ID
+	}
+
+	var servings []database.TblDataSourceFoodServing
+
+	if err := a.Db.LoadDataSourceFoodServings(r.Context(), ids, &servings); err != nil {
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read data source food servings")
+		api.ServerErr(w, "failed while reading from the database")
+
+		return
+	}
+
+	api.WriteJSONArr(w, database.NewDataSourceFoods(foods, servings))
 }
@@ -0,0 +1,21 @@
+package v1
+
+import (
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+)
+
+// getNutrients lists the supported nutrients and their units.
+func (a *APIV1) getNutrients(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.Unauthorized(w)
+		return
+	}
+
+	api.WriteJSONArr(w, database.NutrientInfos)
+}
@@ -30,5 +30,9 @@ func (a *APIV1) getUserEventLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for i := range eventlog {
+		eventlog[i].GlucoseFromMmol(user.GlucoseUnit)
+	}
+
 	api.WriteJSONArr(w, eventlog)
 }
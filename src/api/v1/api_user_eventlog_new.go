@@ -7,7 +7,9 @@ import (
 	"karopon/src/api"
 	"karopon/src/api/auth"
 	"karopon/src/database"
+	"karopon/src/insulin"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -42,6 +44,48 @@ func (a *APIV1) createUserEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A photo can only be linked to the eventlog once, they are read back ordered by ID anyway.
+	slices.Sort(event.PhotoIDs)
+	event.PhotoIDs = slices.Compact(event.PhotoIDs)
+
+	for _, food := range event.Foods {
+		if err := food.Nutrients.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Recipes are logged with a copy of their nutrition as it is now.
+	for i := range event.Foods {
+
+		food := &event.Foods[i]
+
+		if food.RecipeID == nil {
+			continue
+		}
+
+		var recipe database.UserRecipe
+
+		if err := a.Db.LoadUserRecipe(r.Context(), user.ID, *food.RecipeID, &recipe); err != nil {
+
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "recipe does not exist", http.StatusBadRequest)
+				return
+			}
+
+			api.ServerErr(w, "Unexpected error reading from the database")
+			log.Error().
+				Err(err).
+				Int("recipe_id", *food.RecipeID).
+				Int("userid", user.ID).
+				Msg("Unexpected error reading the recipe from the database when trying to create a user event")
+
+			return
+		}
+
+		recipe.FillFoodLog(food)
+	}
+
 	event.Event.UserID = user.ID
 
 	if err := a.Db.LoadUserEventByName(r.Context(), user.ID, event.Event.Name, &event.Event); err != nil {
@@ -91,37 +135,61 @@ func (a *APIV1) createUserEvent(w http.ResponseWriter, r *http.Request) {
 	userEventLog.BloodGlucose = event.BloodGlucose
 	userEventLog.BloodGlucoseTarget = event.BloodGlucoseTarget
 	userEventLog.InsulinSensitivityFactor = event.InsulinSensitivityFactor
+	userEventLog.GlucoseToMmol(user.GlucoseUnit)
 	userEventLog.InsulinToCarbRatio = event.InsulinToCarbRatio
 	userEventLog.ActualInsulinTaken = event.ActualInsulinTaken
 	userEventLog.RecommendedInsulinAmount = event.RecommendedInsulinAmount
+	userEventLog.ActualExtendedInsulinTaken = event.ActualExtendedInsulinTaken
+	userEventLog.ActualExtendedMinutes = event.ActualExtendedMinutes
 
-	id, err := a.Db.AddUserEventLogWith(r.Context(), &userEventLog, event.Foods)
+	// Foods logged in another unit are converted first, so the totals and the recommendation match what is saved.
+	if err := a.Db.FillUserFoodLogs(r.Context(), user.ID, event.Foods); err != nil {
 
-	if err != nil {
+		api.ServerErr(w, "Unexpected error reading from the database")
+		log.Error().
+			Err(err).
+			Str("event", event.Event.Name).
+			Int("userid", user.ID).
+			Msg("Unexpected error reading the foods from the database when trying to create a user event")
 
-		api.ServerErr(w, "Unexpected error finalizing the event to the database")
+		return
+	}
+
+	// AddUserEventLogWith sets the net carbs and fat-protein units the same way.
+	for _, food := range event.Foods {
+		userEventLog.NetCarbs += food.Carb - food.Fibre
+		userEventLog.FatProteinUnits += insulin.FatProteinUnits(food.Fat, food.Protein)
+	}
+
+	if err := a.fillRecommendedInsulin(r.Context(), user, &userEventLog); err != nil {
+
+		api.ServerErr(w, "Unexpected error calculating the recommended insulin")
 		log.Error().
 			Err(err).
 			Str("event", event.Event.Name).
 			Int("userid", user.ID).
-			Msg("Unexpected error writing the event log to the database when trying to create a user event")
+			Msg("Unexpected error reading insulin doses when trying to create a user event")
 
 		return
 	}
 
-	if len(event.PhotoIDs) > 0 {
+	id, err := a.Db.AddUserEventLogWithPhotos(r.Context(), &userEventLog, event.Foods, event.PhotoIDs)
 
-		if err := a.Db.AddUserEventLogPhotos(r.Context(), id, event.PhotoIDs); err != nil {
+	if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+		api.BadReq(w, "One or more of the photos do not exist")
+		return
+	}
 
-			api.ServerErr(w, "Unexpected error linking photos to the event log")
-			log.Error().
-				Err(err).
-				Int("eventlog_id", id).
-				Int("userid", user.ID).
-				Msg("Unexpected error linking photos to the event log")
+	if err != nil {
 
-			return
-		}
+		api.ServerErr(w, "Unexpected error finalizing the event to the database")
+		log.Error().
+			Err(err).
+			Str("event", event.Event.Name).
+			Int("userid", user.ID).
+			Msg("Unexpected error writing the event log to the database when trying to create a user event")
+
+		return
 	}
 
 	var eventlogwithfoodlog database.UserEventFoodLog
@@ -142,5 +210,7 @@ func (a *APIV1) createUserEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	eventlogwithfoodlog.Eventlog.GlucoseFromMmol(user.GlucoseUnit)
+
 	api.WriteJSONObj(w, eventlogwithfoodlog)
 }
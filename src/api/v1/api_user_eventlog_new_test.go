@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"karopon/src/api/auth"
+	"karopon/src/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// eventlogMockDB saves nothing and keeps the photos linked to the new eventlog.
+// Linking a photo in notOwned fails like it belongs to another user.
+type eventlogMockDB struct {
+	insulinMockDB
+	photoIDs []int
+	notOwned []int
+}
+
+func (m *eventlogMockDB) LoadUserInsulinProfile(
+	ctx context.Context,
+	userID int,
+	out *[]database.TblUserInsulinProfileBlock,
+) error {
+	return nil
+}
+
+func (m *eventlogMockDB) LoadUserEventByName(
+	ctx context.Context,
+	userID int,
+	name string,
+	event *database.TblUserEvent,
+) error {
+	event.ID = 1
+	return nil
+}
+
+func (m *eventlogMockDB) FillUserFoodLogs(ctx context.Context, userID int, foodlogs []database.TblUserFoodLog) error {
+	return nil
+}
+
+func (m *eventlogMockDB) AddUserEventLogWithPhotos(
+	ctx context.Context,
+	event *database.TblUserEventLog,
+	foodlogs []database.TblUserFoodLog,
+	photoIDs []int,
+) (int, error) {
+	for _, id := range photoIDs {
+		if slices.Contains(m.notOwned, id) {
+			return -1, database.ErrUserDoesNotHaveThisID
+		}
+	}
+
+	m.photoIDs = photoIDs
+	return 1, nil
+}
+
+func (m *eventlogMockDB) LoadUserEventFoodLog(
+	ctx context.Context,
+	userID int,
+	eventlogID int,
+	eflog *database.UserEventFoodLog,
+) error {
+	return nil
+}
+
+func TestCreateUserEvent_DuplicatePhotoIDs(t *testing.T) {
+
+	db := &eventlogMockDB{}
+
+	body := `{"event": {"name": "Lunch"}, "photo_ids": [3, 1, 3, 1, 2]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/api/eventlog/new", strings.NewReader(body))
+	req = auth.PutUser(req, &database.TblUser{ID: 1, Name: "alice"})
+
+	rr := httptest.NewRecorder()
+	newTestAPI(db).createUserEvent(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, []int{1, 2, 3}, db.photoIDs)
+}
+
+func TestCreateUserEvent_PhotoNotOwned(t *testing.T) {
+
+	db := &eventlogMockDB{notOwned: []int{2}}
+
+	body := `{"event": {"name": "Lunch"}, "photo_ids": [1, 2]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/api/eventlog/new", strings.NewReader(body))
+	req = auth.PutUser(req, &database.TblUser{ID: 1, Name: "alice"})
+
+	rr := httptest.NewRecorder()
+	newTestAPI(db).createUserEvent(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	assert.Empty(t, db.photoIDs)
+}
@@ -2,11 +2,14 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
 	"karopon/src/api"
 	"karopon/src/api/auth"
 	"karopon/src/database"
+	"karopon/src/insulin"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -44,14 +47,34 @@ func (a *APIV1) updateUserEventFoodLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for _, food := range ueflog.Foodlogs {
+		if err := food.Nutrients.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	ueflog.Eventlog.UserID = user.ID
+	ueflog.Eventlog.GlucoseToMmol(user.GlucoseUnit)
+	ueflog.Eventlog.FatProteinUnits = 0
 	for _, food := range ueflog.Foodlogs {
 		food.Name = strings.TrimSpace(food.Name)
 		food.Unit = strings.TrimSpace(food.Unit)
+		ueflog.Eventlog.FatProteinUnits += insulin.FatProteinUnits(food.Fat, food.Protein)
 	}
 
+	// The foods may have changed, so the extended bolus is recommended again.
+	extended := insulin.RecommendExtendedBolus(ueflog.Eventlog.FatProteinUnits, ueflog.Eventlog.InsulinToCarbRatio)
+	ueflog.Eventlog.RecommendedExtendedInsulinAmount = extended.Units
+	ueflog.Eventlog.RecommendedExtendedMinutes = int(extended.Duration / time.Minute)
+
 	err = a.Db.UpdateUserEventFoodLog(r.Context(), &ueflog)
 
+	if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+		http.Error(w, "recipe does not exist", http.StatusBadRequest)
+		return
+	}
+
 	if err != nil {
 
 		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user event log")
@@ -64,6 +87,7 @@ func (a *APIV1) updateUserEventFoodLog(w http.ResponseWriter, r *http.Request) {
 		Eventlog: ueflog.Eventlog,
 		Foodlogs: ueflog.Foodlogs,
 	}
+	out.Eventlog.GlucoseFromMmol(user.GlucoseUnit)
 	for _, foodlog := range ueflog.Foodlogs {
 		out.TotalProtein += foodlog.Protein
 		out.TotalCarb += foodlog.Carb
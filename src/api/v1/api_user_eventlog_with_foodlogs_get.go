@@ -49,5 +49,9 @@ func (a *APIV1) getUserEventFoodLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for i := range eventlogs {
+		eventlogs[i].Eventlog.GlucoseFromMmol(user.GlucoseUnit)
+	}
+
 	api.WriteJSONArr(w, eventlogs)
 }
@@ -1,10 +1,11 @@
 package v1
 
 import (
+	"errors"
 	"io"
 	"karopon/src/api"
 	"karopon/src/api/auth"
-	"karopon/src/database"
+	"karopon/src/photostore"
 	"net/http"
 
 	"github.com/rs/zerolog/log"
@@ -44,12 +45,17 @@ func (a *APIV1) createUserEventLogPhoto(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	photo := database.TblUserPhoto{
-		UserID: user.ID,
-		Data:   data,
+	photo, err := a.Photos.Add(r.Context(), user.ID, data)
+
+	if errors.Is(err, photostore.ErrUnsupportedImage) {
+		api.BadReq(w, photostore.ErrUnsupportedImage.Error())
+		return
 	}
 
-	id, err := a.Db.AddUserPhoto(r.Context(), &photo)
+	if errors.Is(err, photostore.ErrImageTooLarge) {
+		api.BadReq(w, photostore.ErrImageTooLarge.Error())
+		return
+	}
 
 	if err != nil {
 		api.ServerErr(w, "failed to save photo")
@@ -59,5 +65,5 @@ func (a *APIV1) createUserEventLogPhoto(w http.ResponseWriter, r *http.Request)
 
 	api.WriteJSONObj(w, struct {
 		ID int `json:"id"`
-	}{ID: id})
+	}{ID: photo.ID})
 }
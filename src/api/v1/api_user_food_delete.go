@@ -2,6 +2,7 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
 	"karopon/src/api"
 	"karopon/src/api/auth"
 	"karopon/src/database"
@@ -40,6 +41,11 @@ func (a *APIV1) deleteUserFood(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 
+		if errors.Is(err, database.ErrFoodUsedInRecipe) {
+			api.BadReq(w, "The food is used in a recipe, remove it from the recipe first")
+			return
+		}
+
 		log.Warn().Err(err).Str("user", user.Name).Int("foodID", food.ID).Msg("failed to delete food")
 		api.ServerErr(w, "failed to delete the food in the database")
 
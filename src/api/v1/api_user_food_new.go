@@ -50,6 +50,21 @@ func (a *APIV1) addUserFood(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := food.Nutrients.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := food.Converter().Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := food.Converter().ValidateUnit(food.Unit); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	food.ID = -1
 	food.UserID = user.ID
 	food.Scale() // important!
@@ -45,6 +45,21 @@ func (a *APIV1) updateUserFood(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := food.Nutrients.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := food.Converter().Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := food.Converter().ValidateUnit(food.Unit); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if food.ID <= 0 {
 		http.Error(w, "food ID should be > 0", http.StatusBadRequest)
 		return
@@ -15,5 +15,8 @@ func (a *APIV1) getUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	api.WriteJSONObj(w, user)
+	out := *user
+	out.GlucoseFromMmol()
+
+	api.WriteJSONObj(w, out)
 }
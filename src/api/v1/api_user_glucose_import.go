@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"errors"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/cgm"
+	"karopon/src/database"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// importUserGlucose imports the readings from an uploaded Dexcom Clarity or LibreView CSV export, see cgm.Import.
+//
+// The form has the export as "file", and optionally
+// "timezone" for the export's timestamps, "day_first" for DD-MM-YYYY LibreView dates,
+// and "ignore_errors" to skip rows which cannot be read.
+func (a *APIV1) importUserGlucose(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 32<<20)
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		api.BadReq(w, "file too large")
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+
+	if err != nil {
+		api.BadReq(w, "missing file")
+		return
+	}
+
+	defer file.Close()
+
+	tz := user.Timezone
+
+	if name := r.FormValue("timezone"); name != "" {
+		if tz, err = database.NewTimezone(name); err != nil {
+			api.BadReq(w, database.ErrInvalidTimezone.Error())
+			return
+		}
+	}
+
+	reader, err := cgm.NewReader(file, cgm.Options{
+		Location: tz.Loc(),
+		DayFirst: r.FormValue("day_first") == "true",
+	})
+
+	if err != nil {
+		api.BadReq(w, err.Error())
+		return
+	}
+
+	res, err := cgm.Import(r.Context(), a.Db, user.ID, reader, r.FormValue("ignore_errors") == "true")
+
+	if err != nil {
+
+		var rowErr *cgm.RowError
+
+		if errors.As(err, &rowErr) {
+			api.BadReqf(w, "Stopped importing at %s, %d readings were added", rowErr.Error(), res.Added)
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to import user glucose readings")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	api.WriteJSONObj(w, res)
+}
@@ -0,0 +1,154 @@
+package v1
+
+import (
+	"encoding/json"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// GlucoseRequest selects the user's glucose readings between two relative time expressions, see parseStatsRange.
+type GlucoseRequest struct {
+	Start    string            `json:"start"`
+	End      string            `json:"end"`
+	Timezone database.Timezone `json:"timezone"`
+}
+
+// StatsGlucoseRequest is a GlucoseRequest with the target range in the user's glucose unit,
+// the default range is used when either is zero.
+type StatsGlucoseRequest struct {
+	GlucoseRequest
+	RangeLow  float64 `json:"range_low"`
+	RangeHigh float64 `json:"range_high"`
+}
+
+// loadGlucose reads the glucose readings for the request,
+// writing the error response and returning false if that fails.
+func (a *APIV1) loadGlucose(
+	w http.ResponseWriter,
+	r *http.Request,
+	user *database.TblUser,
+	req *GlucoseRequest,
+	out *[]database.TblUserGlucose,
+) bool {
+
+	startTime, endTime, err := parseStatsRange(user.Location(req.Timezone), user.DayShift(), req.Start, req.End)
+
+	if err != nil {
+		api.BadReq(w, err.Error())
+		return false
+	}
+
+	if err := a.Db.LoadUserGlucoseBetween(r.Context(), user.ID, startTime, endTime, out); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user glucose readings")
+		api.ServerErr(w, "failed while reading from the database")
+		return false
+	}
+
+	return true
+}
+
+func (a *APIV1) postUserGlucose(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req GlucoseRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("Invalid json.")
+		api.BadReq(w, "invalid JSON")
+		return
+	}
+
+	var readings []database.TblUserGlucose
+
+	if a.loadGlucose(w, r, user, &req, &readings) {
+
+		for i := range readings {
+			readings[i].Glucose = user.GlucoseUnit.FromMmol(readings[i].Glucose)
+		}
+
+		api.WriteJSONArr(w, readings)
+	}
+}
+
+func (a *APIV1) postStatsGlucose(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req StatsGlucoseRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("Invalid json.")
+		api.BadReq(w, "invalid JSON")
+		return
+	}
+
+	if req.RangeLow == 0 || req.RangeHigh == 0 {
+		req.RangeLow = database.DefaultGlucoseRangeLow
+		req.RangeHigh = database.DefaultGlucoseRangeHigh
+	} else {
+		req.RangeLow = user.GlucoseUnit.ToMmol(req.RangeLow)
+		req.RangeHigh = user.GlucoseUnit.ToMmol(req.RangeHigh)
+	}
+
+	if req.RangeLow < 0 || req.RangeLow >= req.RangeHigh {
+		api.BadReq(w, "The range low should be > 0 and less than the range high")
+		return
+	}
+
+	var readings []database.TblUserGlucose
+
+	if a.loadGlucose(w, r, user, &req.GlucoseRequest, &readings) {
+
+		metrics := database.CalculateGlucoseMetrics(readings, req.RangeLow, req.RangeHigh)
+		metrics.GlucoseFromMmol(user.GlucoseUnit)
+
+		api.WriteJSONObj(w, metrics)
+	}
+}
+
+// postStatsGlucoseProfile returns the hourly percentiles of the user's glucose, see database.GlucoseHourlyProfile.
+func (a *APIV1) postStatsGlucoseProfile(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req GlucoseRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("Invalid json.")
+		api.BadReq(w, "invalid JSON")
+		return
+	}
+
+	var readings []database.TblUserGlucose
+
+	if a.loadGlucose(w, r, user, &req, &readings) {
+
+		profile := database.GlucoseHourlyProfile(readings, user.Location(req.Timezone))
+
+		for i := range profile {
+			profile[i].GlucoseFromMmol(user.GlucoseUnit)
+		}
+
+		api.WriteJSONArr(w, profile)
+	}
+}
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"encoding/json"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxGlucoseReadingsPerRequest is about a month of CGM readings at one every 5 minutes.
+const maxGlucoseReadingsPerRequest = 10000
+
+// newUserGlucoseReadings adds many glucose readings at once, readings at a time the user already has are skipped.
+func (a *APIV1) newUserGlucoseReadings(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var readings []database.TblUserGlucose
+
+	if err := json.NewDecoder(r.Body).Decode(&readings); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(readings) > maxGlucoseReadingsPerRequest {
+		api.BadReqf(w, "Too many readings, at most %d can be added at once", maxGlucoseReadingsPerRequest)
+		return
+	}
+
+	for i := range readings {
+
+		if readings[i].UserTime.Time().IsZero() {
+			api.BadReq(w, "Every reading needs a time")
+			return
+		}
+
+		if readings[i].Glucose <= 0 {
+			api.BadReq(w, "Glucose should be > 0")
+			return
+		}
+
+		readings[i].Glucose = user.GlucoseUnit.ToMmol(readings[i].Glucose)
+		readings[i].Source = strings.TrimSpace(readings[i].Source)
+	}
+
+	added, err := a.Db.AddUserGlucoseReadings(r.Context(), user.ID, readings)
+
+	if err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to add user glucose readings")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	api.WriteJSONObj(w, struct {
+		Added int `json:"added"`
+	}{Added: added})
+}
+
+func (a *APIV1) deleteUserGlucose(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Db.DeleteUserGlucose(r.Context(), user.ID, req.ID); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to delete user glucose reading")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -31,5 +31,9 @@ func (a *APIV1) getUserGoals(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for i := range goals {
+		goals[i].GlucoseFromMmol(user.GlucoseUnit)
+	}
+
 	api.WriteJSONArr(w, goals)
 }
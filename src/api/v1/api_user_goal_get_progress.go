@@ -14,6 +14,7 @@ import (
 type CheckGoalProgress struct {
 	database.TblUserGoal
 
+	// Overrides the user's saved timezone when set.
 	Timezone database.Timezone
 	AsOf     database.TimeMillis `json:"as_of"`
 }
@@ -60,6 +61,7 @@ func (a *APIV1) getUserGoalProgress(w http.ResponseWriter, r *http.Request) {
 	}
 
 	goal.UserID = user.ID
+	goal.GlucoseToMmol(user.GlucoseUnit)
 
 	var baseTime time.Time
 
@@ -79,8 +81,8 @@ func (a *APIV1) getUserGoalProgress(w http.ResponseWriter, r *http.Request) {
 	// but now we need to shift this forward to account for the user's day offset again.
 	//
 	// Giving the final, correct range of start=2026-03-13 02:00 end=2026-03-14 02:00.
-	shift := time.Second * time.Duration(user.DayTimeOffsetSeconds)
-	timeNow := baseTime.Add(-shift).In(goal.Timezone.Loc())
+	shift := user.DayShift()
+	timeNow := baseTime.Add(-shift).In(user.Location(goal.Timezone))
 
 	var goalProgress database.UserGoalProgress
 
@@ -97,5 +99,10 @@ func (a *APIV1) getUserGoalProgress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if goal.TargetColumn().IsGlucose() {
+		goalProgress.CurrentValue = user.GlucoseUnit.FromMmol(goalProgress.CurrentValue)
+		goalProgress.TargetValue = user.GlucoseUnit.FromMmol(goalProgress.TargetValue)
+	}
+
 	api.WriteJSONObj(w, goalProgress)
 }
@@ -53,6 +53,7 @@ func (a *APIV1) newUserGoal(w http.ResponseWriter, r *http.Request) {
 	}
 
 	goal.UserID = user.ID
+	goal.GlucoseToMmol(user.GlucoseUnit)
 
 	id, err := a.Db.AddUserGoal(r.Context(), &goal)
 
@@ -68,6 +69,7 @@ func (a *APIV1) newUserGoal(w http.ResponseWriter, r *http.Request) {
 	}
 
 	goal.ID = id
+	goal.GlucoseFromMmol(user.GlucoseUnit)
 
 	api.WriteJSONObj(w, goal)
 }
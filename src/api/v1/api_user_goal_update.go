@@ -56,6 +56,7 @@ func (a *APIV1) updateUserGoal(w http.ResponseWriter, r *http.Request) {
 	}
 
 	goal.UserID = user.ID
+	goal.GlucoseToMmol(user.GlucoseUnit)
 
 	if err := a.Db.UpdateUserGoal(r.Context(), &goal); err != nil {
 
@@ -69,5 +70,7 @@ func (a *APIV1) updateUserGoal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	goal.GlucoseFromMmol(user.GlucoseUnit)
+
 	api.WriteJSONObj(w, goal)
 }
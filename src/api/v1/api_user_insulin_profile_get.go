@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (a *APIV1) getUserInsulinProfile(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var blocks []database.TblUserInsulinProfileBlock
+
+	if err := a.Db.LoadUserInsulinProfile(r.Context(), user.ID, &blocks); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user insulin profile")
+		api.ServerErr(w, "failed while reading from the database")
+		return
+	}
+
+	for i := range blocks {
+		blocks[i].GlucoseFromMmol(user.GlucoseUnit)
+	}
+
+	api.WriteJSONArr(w, blocks)
+}
@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"encoding/json"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// validateUserInsulinProfileBlock writes a bad request and returns false if the block cannot be saved.
+// The user's other blocks are needed to make sure no two blocks start at the same time.
+func validateUserInsulinProfileBlock(
+	w http.ResponseWriter,
+	block *database.TblUserInsulinProfileBlock,
+	others []database.TblUserInsulinProfileBlock,
+) bool {
+
+	if block.StartMinute < 0 || block.StartMinute >= database.MinutesPerDay {
+		api.BadReqf(w, "Start minute should be between 0 and %d", database.MinutesPerDay-1)
+		return false
+	}
+	if block.InsulinToCarbRatio <= 0 {
+		api.BadReq(w, "Insulin to carb ratio should be > 0")
+		return false
+	}
+	if block.InsulinSensitivityFactor <= 0 {
+		api.BadReq(w, "Insulin sensitivity factor should be > 0")
+		return false
+	}
+	if block.TargetBloodSugar <= 0 {
+		api.BadReq(w, "Target blood sugar should be > 0")
+		return false
+	}
+
+	for _, other := range others {
+		if other.ID != block.ID && other.StartMinute == block.StartMinute {
+			api.BadReq(w, "Another block already starts at this time")
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *APIV1) saveUserInsulinProfileBlock(w http.ResponseWriter, r *http.Request, isNew bool) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var block database.TblUserInsulinProfileBlock
+
+	if err := json.NewDecoder(r.Body).Decode(&block); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	var others []database.TblUserInsulinProfileBlock
+
+	if err := a.Db.LoadUserInsulinProfile(r.Context(), user.ID, &others); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user insulin profile")
+		api.ServerErr(w, "failed while reading from the database")
+		return
+	}
+
+	if isNew {
+		block.ID = 0
+	}
+
+	if !validateUserInsulinProfileBlock(w, &block, others) {
+		return
+	}
+
+	block.UserID = user.ID
+	block.GlucoseToMmol(user.GlucoseUnit)
+
+	if !isNew {
+
+		if err := a.Db.UpdateUserInsulinProfileBlock(r.Context(), &block); err != nil {
+			log.Warn().Err(err).Str("user", user.Name).Msg("failed to update user insulin profile block")
+			api.ServerErr(w, "failed while writing to the database")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	id, err := a.Db.AddUserInsulinProfileBlock(r.Context(), &block)
+
+	if err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to create user insulin profile block")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	block.ID = id
+	block.GlucoseFromMmol(user.GlucoseUnit)
+
+	api.WriteJSONObj(w, block)
+}
+
+func (a *APIV1) newUserInsulinProfileBlock(w http.ResponseWriter, r *http.Request) {
+	a.saveUserInsulinProfileBlock(w, r, true)
+}
+
+func (a *APIV1) updateUserInsulinProfileBlock(w http.ResponseWriter, r *http.Request) {
+	a.saveUserInsulinProfileBlock(w, r, false)
+}
+
+func (a *APIV1) deleteUserInsulinProfileBlock(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Db.DeleteUserInsulinProfileBlock(r.Context(), user.ID, req.ID); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to delete user insulin profile block")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,226 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"karopon/src/insulin"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// A sent recommendation this close to the server's is kept as is, so rounding on the client is fine.
+const recommendedInsulinTolerance = 0.05
+
+type InsulinRecommendRequest struct {
+	NetCarbs                 float64             `json:"net_carbs"`
+	BloodGlucose             float64             `json:"blood_glucose"`
+	BloodGlucoseTarget       float64             `json:"blood_glucose_target"`
+	InsulinSensitivityFactor float64             `json:"insulin_sensitivity_factor"`
+	InsulinToCarbRatio       float64             `json:"insulin_to_carb_ratio"`
+	Time                     database.TimeMillis `json:"time"`
+}
+
+// insulinAction returns how the user's insulin is used up, falling back to the default for invalid settings.
+func insulinAction(user *database.TblUser) insulin.Action {
+
+	action := insulin.Action{
+		Curve:    insulin.Curve(user.InsulinActionCurve),
+		Duration: time.Duration(user.InsulinDurationMinutes) * time.Minute,
+		Peak:     time.Duration(user.InsulinPeakMinutes) * time.Minute,
+	}
+
+	if action.Validate() != nil {
+		return insulin.DefaultAction
+	}
+
+	return action
+}
+
+// fillInsulinRatios sets any missing ratio or target from the user's insulin profile block in effect at the given time,
+// then from the user's settings.
+func (a *APIV1) fillInsulinRatios(
+	ctx context.Context,
+	user *database.TblUser,
+	in *insulin.BolusInput,
+	at time.Time,
+) error {
+
+	if in.InsulinToCarbRatio != 0 && in.InsulinSensitivityFactor != 0 && in.BloodGlucoseTarget != 0 {
+		return nil
+	}
+
+	var blocks []database.TblUserInsulinProfileBlock
+
+	if err := a.Db.LoadUserInsulinProfile(ctx, user.ID, &blocks); err != nil {
+		return err
+	}
+
+	if block, ok := database.ActiveInsulinProfileBlock(blocks, at.In(user.Timezone.Loc())); ok {
+
+		if in.InsulinToCarbRatio == 0 {
+			in.InsulinToCarbRatio = block.InsulinToCarbRatio
+		}
+
+		if in.InsulinSensitivityFactor == 0 {
+			in.InsulinSensitivityFactor = block.InsulinSensitivityFactor
+		}
+
+		if in.BloodGlucoseTarget == 0 {
+			in.BloodGlucoseTarget = block.TargetBloodSugar
+		}
+	}
+
+	if in.InsulinSensitivityFactor == 0 {
+		in.InsulinSensitivityFactor = user.InsulinSensitivityFactor
+	}
+
+	if in.BloodGlucoseTarget == 0 {
+		in.BloodGlucoseTarget = user.TargetBloodSugar
+	}
+
+	return nil
+}
+
+// recommendInsulin recommends a bolus for the user at the given time,
+// taking off the insulin still on board from the eventlogs before it.
+func (a *APIV1) recommendInsulin(
+	ctx context.Context,
+	user *database.TblUser,
+	in insulin.BolusInput,
+	at time.Time,
+) (insulin.Bolus, error) {
+
+	action := insulinAction(user)
+
+	if action.Curve != insulin.CurveNone {
+
+		var eventlogs []database.TblUserEventLog
+
+		// Extended boluses are still working for longer than the insulin action.
+		start := at.Add(-action.Duration - insulin.MaxExtendedDuration)
+
+		if err := a.Db.LoadUserInsulinEventLogsBetween(ctx, user.ID, start, at, &eventlogs); err != nil {
+			return insulin.Bolus{}, err
+		}
+
+		doses := make([]insulin.Dose, 0, len(eventlogs)*2)
+
+		for _, el := range eventlogs {
+
+			doses = append(doses, insulin.Dose{Time: el.UserTime.Time(), Units: el.ActualInsulinTaken})
+
+			if el.ActualExtendedInsulinTaken > 0 {
+				doses = append(doses, insulin.Dose{
+					Time:     el.UserTime.Time(),
+					Units:    el.ActualExtendedInsulinTaken,
+					Duration: time.Duration(el.ActualExtendedMinutes) * time.Minute,
+				})
+			}
+		}
+
+		in.InsulinOnBoard = insulin.OnBoard(doses, at, action)
+	}
+
+	return insulin.RecommendBolus(in), nil
+}
+
+// fillRecommendedInsulin fills the eventlog's missing ratios and target, see fillInsulinRatios,
+// then sets its RecommendedInsulinAmount from the server's calculation, unless the client sent the same answer.
+// The recommended extended bolus for the eventlog's FatProteinUnits is always the server's.
+func (a *APIV1) fillRecommendedInsulin(ctx context.Context, user *database.TblUser, el *database.TblUserEventLog) error {
+
+	in := insulin.BolusInput{
+		NetCarbs:                 el.NetCarbs,
+		BloodGlucose:             el.BloodGlucose,
+		BloodGlucoseTarget:       el.BloodGlucoseTarget,
+		InsulinToCarbRatio:       el.InsulinToCarbRatio,
+		InsulinSensitivityFactor: el.InsulinSensitivityFactor,
+	}
+
+	if err := a.fillInsulinRatios(ctx, user, &in, el.UserTime.Time()); err != nil {
+		return err
+	}
+
+	el.BloodGlucoseTarget = in.BloodGlucoseTarget
+	el.InsulinToCarbRatio = in.InsulinToCarbRatio
+	el.InsulinSensitivityFactor = in.InsulinSensitivityFactor
+
+	extended := insulin.RecommendExtendedBolus(el.FatProteinUnits, in.InsulinToCarbRatio)
+	el.RecommendedExtendedInsulinAmount = extended.Units
+	el.RecommendedExtendedMinutes = int(extended.Duration / time.Minute)
+
+	bolus, err := a.recommendInsulin(ctx, user, in, el.UserTime.Time())
+
+	if err != nil {
+		return err
+	}
+
+	if math.Abs(el.RecommendedInsulinAmount-bolus.Total) > recommendedInsulinTolerance {
+
+		if el.RecommendedInsulinAmount != 0 {
+			log.Debug().
+				Str("user", user.Name).
+				Float64("sent", el.RecommendedInsulinAmount).
+				Float64("calculated", bolus.Total).
+				Msg("replacing the recommended insulin sent by the client")
+		}
+
+		el.RecommendedInsulinAmount = bolus.Total
+	}
+
+	return nil
+}
+
+func (a *APIV1) postInsulinRecommend(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req InsulinRecommendRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("Invalid json.")
+		api.BadReq(w, "invalid JSON")
+		return
+	}
+
+	at := req.Time.Time()
+
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	in := insulin.BolusInput{
+		NetCarbs:                 req.NetCarbs,
+		BloodGlucose:             user.GlucoseUnit.ToMmol(req.BloodGlucose),
+		BloodGlucoseTarget:       user.GlucoseUnit.ToMmol(req.BloodGlucoseTarget),
+		InsulinToCarbRatio:       req.InsulinToCarbRatio,
+		InsulinSensitivityFactor: user.GlucoseUnit.ToMmol(req.InsulinSensitivityFactor),
+	}
+
+	err := a.fillInsulinRatios(r.Context(), user, &in, at)
+
+	var bolus insulin.Bolus
+
+	if err == nil {
+		bolus, err = a.recommendInsulin(r.Context(), user, in, at)
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read insulin doses")
+		api.ServerErr(w, "failed while reading from the database")
+		return
+	}
+
+	api.WriteJSONObj(w, bolus)
+}
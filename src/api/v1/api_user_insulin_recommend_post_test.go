@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"karopon/src/database"
+	"karopon/src/database/mock_db"
+	"karopon/src/insulin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// insulinMockDB returns the given eventlogs which are in the range.
+type insulinMockDB struct {
+	mock_db.BaseMockDB
+	eventlogs []database.TblUserEventLog
+}
+
+func (m *insulinMockDB) LoadUserInsulinEventLogsBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserEventLog,
+) error {
+	for _, el := range m.eventlogs {
+		if t := el.UserTime.Time(); !t.Before(start) && t.Before(end) {
+			*out = append(*out, el)
+		}
+	}
+	return nil
+}
+
+func TestRecommendInsulin_ExtendedBolusOnBoard(t *testing.T) {
+
+	now := time.Date(2026, 4, 13, 12, 0, 0, 0, time.UTC)
+
+	user := &database.TblUser{
+		ID:                     1,
+		InsulinActionCurve:     string(insulin.CurveLinear),
+		InsulinDurationMinutes: 240,
+	}
+
+	in := insulin.BolusInput{
+		NetCarbs:                 60,
+		BloodGlucose:             6,
+		BloodGlucoseTarget:       6,
+		InsulinToCarbRatio:       10,
+		InsulinSensitivityFactor: 2,
+	}
+
+	db := &insulinMockDB{eventlogs: []database.TblUserEventLog{
+		// Only an extended bolus, half delivered.
+		{
+			UserTime:                   database.TimeMillis(now.Add(-time.Hour)),
+			ActualExtendedInsulinTaken: 2,
+			ActualExtendedMinutes:      120,
+		},
+		// A combo bolus which started before the insulin action, the extended part is still working.
+		{
+			UserTime:                   database.TimeMillis(now.Add(-5 * time.Hour)),
+			ActualInsulinTaken:         4,
+			ActualExtendedInsulinTaken: 2,
+			ActualExtendedMinutes:      180,
+		},
+	}}
+
+	bolus, err := newTestAPI(db).recommendInsulin(t.Context(), user, in, now)
+	require.NoError(t, err)
+
+	assert.Greater(t, bolus.InsulinOnBoard, 1.0+0.75)
+	assert.Less(t, bolus.InsulinOnBoard, 2.0+2*0.5)
+	assert.InDelta(t, 6-bolus.InsulinOnBoard, bolus.Total, 1e-9)
+}
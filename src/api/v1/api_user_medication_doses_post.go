@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"encoding/json"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type MedicationDosesRequest struct {
+	Timezone database.Timezone   `json:"timezone"`
+	AsOf     database.TimeMillis `json:"as_of"`
+}
+
+// postUserMedicationDoses lists every dose due on the user's current day,
+// with the taken / skipped / missed / pending status from the medication log.
+func (a *APIV1) postUserMedicationDoses(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req MedicationDosesRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+
+		return
+	}
+
+	now := time.Now()
+
+	if !req.AsOf.Time().IsZero() {
+		now = req.AsOf.Time()
+	}
+
+	// Same as goal progress, the day is taken after subtracting the user's day offset,
+	// and the offset is added back to get the real start and end of the day.
+	loc := user.Location(req.Timezone)
+	shift := user.DayShift()
+	userNow := now.In(loc).Add(-shift)
+	dayStart := time.Date(userNow.Year(), userNow.Month(), userNow.Day(), 0, 0, 0, 0, loc).Add(shift)
+	dayEnd := time.Date(userNow.Year(), userNow.Month(), userNow.Day()+1, 0, 0, 0, 0, loc).Add(shift)
+
+	var meds []database.TblUserMedication
+	var schedules []database.TblUserMedicationSchedule
+	var logs []database.TblUserMedicationLog
+
+	err := a.Db.LoadUserMedications(r.Context(), user.ID, &meds)
+
+	if err == nil {
+		err = a.Db.LoadUserMedicationSchedules(r.Context(), user.ID, &schedules)
+	}
+
+	if err == nil {
+		err = a.Db.LoadUserMedicationLogsBetween(r.Context(), user.ID, dayStart, dayEnd, &logs)
+	}
+
+	if err != nil {
+
+		api.ServerErr(w, "Unexpected error reading medications from the database")
+		log.Error().
+			Err(err).
+			Int("userid", user.ID).
+			Msg("Unexpected error reading a user's medications when listing doses")
+
+		return
+	}
+
+	medsByID := make(map[int]*database.TblUserMedication, len(meds))
+
+	for i := range meds {
+		medsByID[meds[i].ID] = &meds[i]
+	}
+
+	logsBySchedule := make(map[int][]database.TblUserMedicationLog)
+
+	for _, l := range logs {
+		logsBySchedule[l.ScheduleID] = append(logsBySchedule[l.ScheduleID], l)
+	}
+
+	var doses []database.MedicationDose
+
+	for _, schedule := range schedules {
+
+		med, ok := medsByID[schedule.MedicationID]
+
+		if !ok {
+			continue
+		}
+
+		start, end := med.ClipToDates(dayStart, dayEnd, loc, shift)
+
+		dueTimes := schedule.DueTimes(start, end, loc, shift)
+
+		doses = append(doses, database.MatchMedicationDoses(
+			med.ID,
+			schedule.ID,
+			dueTimes,
+			logsBySchedule[schedule.ID],
+			now,
+		)...)
+	}
+
+	api.WriteJSONArr(w, doses)
+}
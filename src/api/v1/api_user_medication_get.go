@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (a *APIV1) getUserMedications(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var meds []database.TblUserMedication
+
+	if err := a.Db.LoadUserMedications(r.Context(), user.ID, &meds); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user medications")
+		api.ServerErr(w, "failed while reading from the database")
+		return
+	}
+
+	api.WriteJSONArr(w, meds)
+}
+
+func (a *APIV1) getUserMedicationSchedules(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var schedules []database.TblUserMedicationSchedule
+
+	if err := a.Db.LoadUserMedicationSchedules(r.Context(), user.ID, &schedules); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user medication schedules")
+		api.ServerErr(w, "failed while reading from the database")
+		return
+	}
+
+	api.WriteJSONArr(w, schedules)
+}
+
+func (a *APIV1) getUserMedicationLogs(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var logs []database.TblUserMedicationLog
+
+	if err := a.Db.LoadUserMedicationLogs(r.Context(), user.ID, &logs); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user medication logs")
+		api.ServerErr(w, "failed while reading from the database")
+		return
+	}
+
+	api.WriteJSONArr(w, logs)
+}
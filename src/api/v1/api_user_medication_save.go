@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// validateUserMedication writes a bad request and returns false if the medication cannot be saved.
+// The name is trimmed in place.
+func validateUserMedication(w http.ResponseWriter, med *database.TblUserMedication) bool {
+
+	med.Name = strings.TrimSpace(med.Name)
+
+	if med.Name == "" {
+		api.BadReq(w, "Medication name cannot be empty")
+		return false
+	}
+	if len(med.Name) > 128 {
+		api.BadReq(w, "Medication name cannot be longer than 128 characters")
+		return false
+	}
+	if med.DosageAmount < 0 {
+		api.BadReq(w, "Dosage amount should be >= 0")
+		return false
+	}
+	if !med.StartDate.Time().IsZero() && !med.EndDate.Time().IsZero() &&
+		med.EndDate.Time().Before(med.StartDate.Time()) {
+		api.BadReq(w, "Medication end date cannot be before the start date")
+		return false
+	}
+
+	return true
+}
+
+func (a *APIV1) newUserMedication(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var med database.TblUserMedication
+
+	if err := json.NewDecoder(r.Body).Decode(&med); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if !validateUserMedication(w, &med) {
+		return
+	}
+
+	med.UserID = user.ID
+
+	id, err := a.Db.AddUserMedication(r.Context(), &med)
+
+	if err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to create user medication")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	med.ID = id
+
+	api.WriteJSONObj(w, med)
+}
+
+func (a *APIV1) updateUserMedication(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var med database.TblUserMedication
+
+	if err := json.NewDecoder(r.Body).Decode(&med); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if !validateUserMedication(w, &med) {
+		return
+	}
+
+	med.UserID = user.ID
+
+	if err := a.Db.UpdateUserMedication(r.Context(), &med); err != nil {
+
+		if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+			api.BadReq(w, "Medication does not exist")
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to update user medication")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *APIV1) deleteUserMedication(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Db.DeleteUserMedication(r.Context(), user.ID, req.ID); err != nil {
+
+		if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+			api.BadReq(w, "Medication does not exist")
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to delete user medication")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
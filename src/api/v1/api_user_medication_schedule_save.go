@@ -0,0 +1,149 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// validateUserMedicationSchedule writes a bad request and returns false if the schedule masks are out of range.
+func validateUserMedicationSchedule(w http.ResponseWriter, s *database.TblUserMedicationSchedule) bool {
+
+	if s.MinutesOfHourMask < 0 || s.MinutesOfHourMask >= 1<<60 {
+		api.BadReq(w, "Minutes of hour mask must only use the lower 60 bits")
+		return false
+	}
+	if s.HoursOfDayMask < 0 || s.HoursOfDayMask >= 1<<24 {
+		api.BadReq(w, "Hours of day mask must only use the lower 24 bits")
+		return false
+	}
+	if s.DaysOfWeekMask < 0 {
+		api.BadReq(w, "Days of week mask must only use the lower 7 bits")
+		return false
+	}
+	if s.DaysOfMonthMask < 0 {
+		api.BadReq(w, "Days of month mask must only use the lower 31 bits")
+		return false
+	}
+	if s.MonthsOfYearMask < 0 || s.MonthsOfYearMask >= 1<<12 {
+		api.BadReq(w, "Month of year mask must only use the lower 12 bits")
+		return false
+	}
+
+	return true
+}
+
+func (a *APIV1) newUserMedicationSchedule(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var schedule database.TblUserMedicationSchedule
+
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if !validateUserMedicationSchedule(w, &schedule) {
+		return
+	}
+
+	id, err := a.Db.AddUserMedicationSchedule(r.Context(), user.ID, &schedule)
+
+	if err != nil {
+
+		if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+			api.BadReq(w, "Medication does not exist")
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to create user medication schedule")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	schedule.ID = id
+
+	api.WriteJSONObj(w, schedule)
+}
+
+func (a *APIV1) updateUserMedicationSchedule(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var schedule database.TblUserMedicationSchedule
+
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if !validateUserMedicationSchedule(w, &schedule) {
+		return
+	}
+
+	if err := a.Db.UpdateUserMedicationSchedule(r.Context(), user.ID, &schedule); err != nil {
+
+		if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+			api.BadReq(w, "Medication schedule does not exist")
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to update user medication schedule")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *APIV1) deleteUserMedicationSchedule(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Db.DeleteUserMedicationSchedule(r.Context(), user.ID, req.ID); err != nil {
+
+		if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+			api.BadReq(w, "Medication schedule does not exist")
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to delete user medication schedule")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (a *APIV1) newUserMedicationLog(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var medlog database.TblUserMedicationLog
+
+	if err := json.NewDecoder(r.Body).Decode(&medlog); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if medlog.TakenTime.Time().IsZero() {
+		medlog.TakenTime = database.TimeMillis(time.Now().UTC())
+	}
+
+	id, err := a.Db.AddUserMedicationLog(r.Context(), user.ID, &medlog)
+
+	if err != nil {
+
+		if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+			api.BadReq(w, "Medication schedule does not exist")
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to create user medication log")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	medlog.ID = id
+
+	api.WriteJSONObj(w, medlog)
+}
+
+func (a *APIV1) updateUserMedicationLog(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var medlog database.TblUserMedicationLog
+
+	if err := json.NewDecoder(r.Body).Decode(&medlog); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if medlog.TakenTime.Time().IsZero() {
+		api.BadReq(w, "Taken time cannot be empty")
+		return
+	}
+
+	if err := a.Db.UpdateUserMedicationLog(r.Context(), user.ID, &medlog); err != nil {
+
+		if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+			api.BadReq(w, "Medication log does not exist")
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to update user medication log")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *APIV1) deleteUserMedicationLog(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Db.DeleteUserMedicationLog(r.Context(), user.ID, req.ID); err != nil {
+
+		if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+			api.BadReq(w, "Medication log does not exist")
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to delete user medication log")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,59 @@
+package v1
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/api/nightscout"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// The number of random bytes in a Nightscout API secret, Nightscout wants at least 12 characters.
+const nightscoutSecretBytes = 16
+
+// newUserNightscoutSecret creates a new Nightscout API secret for the user, replacing the old one.
+// The secret is only returned this once, the database keeps a SHA-256 of the SHA1 that Nightscout clients send.
+func (a *APIV1) newUserNightscoutSecret(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	b := make([]byte, nightscoutSecretBytes)
+	_, _ = rand.Read(b)
+	secret := hex.EncodeToString(b)
+
+	if err := a.Db.SetUserNightscoutSecret(r.Context(), user.ID, nightscout.HashSecret(secret)); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to set user nightscout secret")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	api.WriteJSONObj(w, struct {
+		Secret string `json:"secret"`
+	}{Secret: secret})
+}
+
+func (a *APIV1) deleteUserNightscoutSecret(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	if err := a.Db.DeleteUserNightscoutSecret(r.Context(), user.ID); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to delete user nightscout secret")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
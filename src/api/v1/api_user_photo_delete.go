@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (a *APIV1) deleteUserPhoto(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Photos.Delete(r.Context(), user.ID, req.ID); err != nil {
+
+		if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+			api.NotFound(w)
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to delete user photo")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *APIV1) deleteUserEventLogPhoto(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req struct {
+		EventlogID int `json:"eventlog_id"`
+		PhotoID    int `json:"photo_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Db.DeleteUserEventLogPhoto(r.Context(), user.ID, req.EventlogID, req.PhotoID); err != nil {
+
+		if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+			api.NotFound(w)
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to unlink user eventlog photo")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+type photoLoader func(ctx context.Context, userID int, photoID int) (database.TblUserPhoto, []byte, error)
+
+func (a *APIV1) getUserPhoto(w http.ResponseWriter, r *http.Request) {
+	a.writeUserPhoto(w, r, a.Photos.Load)
+}
+
+func (a *APIV1) getUserPhotoThumbnail(w http.ResponseWriter, r *http.Request) {
+	a.writeUserPhoto(w, r, a.Photos.LoadThumbnail)
+}
+
+func (a *APIV1) writeUserPhoto(w http.ResponseWriter, r *http.Request, load photoLoader) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	photoIDStr, ok := mux.Vars(r)["id"]
+
+	if !ok {
+		api.BadReq(w, "no photo id given")
+		return
+	}
+
+	photoID, err := strconv.Atoi(photoIDStr)
+
+	if err != nil {
+		api.BadReq(w, "photo id is not a valid number")
+		return
+	}
+
+	photo, data, err := load(r.Context(), user.ID, photoID)
+
+	if err != nil {
+
+		if errors.Is(err, sql.ErrNoRows) {
+			api.NotFound(w)
+			return
+		}
+
+		log.Warn().
+			Err(err).
+			Str("user", user.Name).
+			Int("photo_id", photoID).
+			Msg("failed to read user photo")
+		api.ServerErr(w, "failed while reading the photo")
+
+		return
+	}
+
+	// The thumbnail is encoded the same way as the photo.
+	// Photos saved before the content type was recorded were never checked, so they are not trusted to render.
+	contentType := photo.ContentType
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// Photos never change once uploaded, so the browser can keep them.
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(data); err != nil {
+		log.Debug().Err(err).Int("photo_id", photoID).Msg("error while writing photo response")
+	}
+}
@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"karopon/src/api/auth"
+	"karopon/src/database"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteUserPhoto_ContentType(t *testing.T) {
+
+	for _, tc := range []struct {
+		saved, want string
+	}{
+		{"image/jpeg", "image/jpeg"},
+		{"image/png", "image/png"},
+		// Older photos were never checked, so they are not sniffed as HTML.
+		{"", "application/octet-stream"},
+	} {
+		load := func(ctx context.Context, userID int, photoID int) (database.TblUserPhoto, []byte, error) {
+			return database.TblUserPhoto{ID: photoID, UserID: userID, ContentType: tc.saved},
+				[]byte("<html><script>alert(1)</script></html>"), nil
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/photo/1", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		req = auth.PutUser(req, &database.TblUser{ID: 1, Name: "alice"})
+
+		rr := httptest.NewRecorder()
+		newTestAPI(nil).writeUserPhoto(rr, req, load)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, tc.want, rr.Header().Get("Content-Type"))
+		assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+	}
+}
@@ -0,0 +1,30 @@
+package v1
+
+import (
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (a *APIV1) getUserRecipes(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var recipes []database.UserRecipe
+
+	if err := a.Db.LoadUserRecipes(r.Context(), user.ID, &recipes); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user recipes")
+		api.ServerErr(w, "failed while reading from the database")
+		return
+	}
+
+	api.WriteJSONArr(w, recipes)
+}
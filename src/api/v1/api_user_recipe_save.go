@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// validateUserRecipe writes a bad request and returns false if the recipe cannot be saved.
+// The name and unit are trimmed in place.
+func validateUserRecipe(w http.ResponseWriter, recipe *database.UserRecipe) bool {
+
+	recipe.Recipe.Name = strings.TrimSpace(recipe.Recipe.Name)
+	recipe.Recipe.Unit = strings.TrimSpace(recipe.Recipe.Unit)
+
+	if recipe.Recipe.Name == "" {
+		api.BadReq(w, "Recipe name cannot be empty")
+		return false
+	}
+	if recipe.Recipe.Unit == "" {
+		api.BadReq(w, "Recipe unit cannot be empty")
+		return false
+	}
+	if recipe.Recipe.Yield <= 0 {
+		api.BadReq(w, "Recipe yield should be > 0")
+		return false
+	}
+	if len(recipe.Ingredients) == 0 {
+		api.BadReq(w, "Recipe needs at least one ingredient")
+		return false
+	}
+
+	for _, ing := range recipe.Ingredients {
+		if (ing.FoodID == nil) == (ing.DataSourceFoodID == nil) {
+			api.BadReq(w, "Ingredient should have either a food or a data source food")
+			return false
+		}
+		if ing.Portion <= 0 {
+			api.BadReq(w, "Ingredient portion should be > 0")
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *APIV1) saveUserRecipe(w http.ResponseWriter, r *http.Request, isNew bool) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var recipe database.UserRecipe
+
+	if err := json.NewDecoder(r.Body).Decode(&recipe); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if !validateUserRecipe(w, &recipe) {
+		return
+	}
+
+	recipe.Recipe.UserID = user.ID
+
+	ingredients := make([]database.TblUserRecipeIngredient, len(recipe.Ingredients))
+	for i, ing := range recipe.Ingredients {
+		ingredients[i] = ing.TblUserRecipeIngredient
+	}
+
+	var err error
+
+	if isNew {
+		recipe.Recipe.ID, err = a.Db.AddUserRecipe(r.Context(), &recipe.Recipe, ingredients)
+	} else {
+		err = a.Db.UpdateUserRecipe(r.Context(), &recipe.Recipe, ingredients)
+	}
+
+	if errors.Is(err, database.ErrUserDoesNotHaveThisID) {
+		api.BadReq(w, "The recipe or one of its foods does not exist")
+		return
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to save user recipe")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	// The nutrition is worked out when the recipe is loaded.
+	if err := a.Db.LoadUserRecipe(r.Context(), user.ID, recipe.Recipe.ID, &recipe); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user recipe")
+		api.ServerErr(w, "The recipe was saved, but an unexpected error occurred reading it from the database.")
+		return
+	}
+
+	api.WriteJSONObj(w, recipe)
+}
+
+func (a *APIV1) newUserRecipe(w http.ResponseWriter, r *http.Request) {
+	a.saveUserRecipe(w, r, true)
+}
+
+func (a *APIV1) updateUserRecipe(w http.ResponseWriter, r *http.Request) {
+	a.saveUserRecipe(w, r, false)
+}
+
+func (a *APIV1) deleteUserRecipe(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("invalid json")
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Db.DeleteUserRecipe(r.Context(), user.ID, req.ID); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to delete user recipe")
+		api.ServerErr(w, "failed while writing to the database")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,109 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type StatsChartRequest struct {
+	Cols          []database.ChartColumn   `json:"columns"`
+	Start         string                   `json:"start"`
+	End           string                   `json:"end"`
+	GroupBy       database.GroupBy         `json:"groupby"`
+	AggregateFunc database.AggregationFunc `json:"aggregate"`
+	Timezone      database.Timezone        `json:"timezone"`
+}
+
+// parseStatsRange resolves the start and end relative time expressions of a stats request,
+// in the user's timezone and with the user's day offset.
+func parseStatsRange(loc *time.Location, shift time.Duration, start string, end string) (time.Time, time.Time, error) {
+
+	// Mirror getUserGoalProgress: subtract the day offset so that date-component
+	// operations inside ParseRelativeTimeExpr reflect the user's perceived current
+	// day, then shift is added back inside the function.
+	// DayTimeOffsetSeconds is NOT a UTC offset — it marks when the user's day starts.
+	adjustedNow := time.Now().Add(-shift).In(loc)
+
+	startTime, err := database.ParseRelativeTimeExpr(start, adjustedNow, shift)
+
+	if err != nil {
+		return startTime, startTime, errors.New("invalid start expression")
+	}
+
+	endTime, err := database.ParseRelativeTimeExpr(end, adjustedNow, shift)
+
+	if err != nil {
+		return startTime, endTime, errors.New("invalid end expression")
+	}
+
+	return startTime, endTime, nil
+}
+
+func (a *APIV1) postStatsChart(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req StatsChartRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("Invalid json.")
+		api.BadReq(w, "invalid JSON")
+		return
+	}
+
+	if err := database.ValidateChartQuery(req.Cols, req.AggregateFunc, req.GroupBy); err != nil {
+		api.BadReq(w, err.Error())
+		return
+	}
+
+	loc := user.Location(req.Timezone)
+	shift := user.DayShift()
+
+	startTime, endTime, err := parseStatsRange(loc, shift, req.Start, req.End)
+
+	if err != nil {
+		api.BadReq(w, err.Error())
+		return
+	}
+
+	var data []database.ChartPoint
+
+	err = a.Db.LoadUserChartData(
+		r.Context(),
+		user.ID,
+		req.Cols,
+		req.AggregateFunc,
+		req.GroupBy,
+		loc,
+		shift,
+		startTime,
+		endTime,
+		&data,
+	)
+
+	if err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user chart data")
+		api.ServerErr(w, "failed while reading from the database")
+		return
+	}
+
+	for i := range data {
+		if data[i].Column.IsGlucose() {
+			data[i].Value = user.GlucoseUnit.FromMmol(data[i].Value)
+		}
+	}
+
+	api.WriteJSONArr(w, data)
+}
@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"encoding/json"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// StatsInsulinRequest selects the user's eventlogs between two relative time expressions, see parseStatsRange.
+type StatsInsulinRequest struct {
+	Start    string            `json:"start"`
+	End      string            `json:"end"`
+	GroupBy  string            `json:"groupby"`
+	Timezone database.Timezone `json:"timezone"`
+}
+
+// postStatsInsulin returns the user's insulin dosing report, see database.CalculateInsulinReport.
+func (a *APIV1) postStatsInsulin(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req StatsInsulinRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("Invalid json.")
+		api.BadReq(w, "invalid JSON")
+		return
+	}
+
+	groupby := database.GroupBy(req.GroupBy)
+
+	if !groupby.IsValid() {
+		api.BadReq(w, database.ErrInvalidGroupBy.Error())
+		return
+	}
+
+	loc := user.Location(req.Timezone)
+	shift := user.DayShift()
+
+	startTime, endTime, err := parseStatsRange(loc, shift, req.Start, req.End)
+
+	if err != nil {
+		api.BadReq(w, err.Error())
+		return
+	}
+
+	var eventlogs []database.TblUserEventLog
+
+	if err := a.Db.LoadUserEventLogsBetween(r.Context(), user.ID, startTime, endTime, &eventlogs); err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user eventlogs")
+		api.ServerErr(w, "failed while reading from the database")
+		return
+	}
+
+	api.WriteJSONObj(w, database.CalculateInsulinReport(eventlogs, startTime, groupby, loc, shift))
+}
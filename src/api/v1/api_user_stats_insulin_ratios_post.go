@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"encoding/json"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"karopon/src/insulin"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// postStatsInsulinRatios suggests an insulin sensitivity factor and insulin to carb ratio
+// fitted from how the user's glucose changed after their eventlogs, see insulin.FitRatios.
+// It is only advice, the user's settings are never changed.
+func (a *APIV1) postStatsInsulinRatios(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req GlucoseRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("Invalid json.")
+		api.BadReq(w, "invalid JSON")
+		return
+	}
+
+	startTime, endTime, err := parseStatsRange(user.Location(req.Timezone), user.DayShift(), req.Start, req.End)
+
+	if err != nil {
+		api.BadReq(w, err.Error())
+		return
+	}
+
+	window := insulinAction(user).Duration
+
+	if window == 0 {
+		window = insulin.DefaultAction.Duration
+	}
+
+	var eventlogs []database.TblUserEventLog
+	var glucose []database.TblUserGlucose
+
+	err = a.Db.LoadUserEventLogsBetween(r.Context(), user.ID, startTime, endTime, &eventlogs)
+
+	if err == nil {
+		err = a.Db.LoadUserGlucoseBetween(
+			r.Context(),
+			user.ID,
+			startTime.Add(-insulin.ReadingTolerance),
+			endTime.Add(window+insulin.ReadingTolerance),
+			&glucose,
+		)
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user eventlogs and glucose")
+		api.ServerErr(w, "failed while reading from the database")
+		return
+	}
+
+	events := make([]insulin.Event, len(eventlogs))
+
+	for i, el := range eventlogs {
+		events[i] = insulin.Event{
+			Time:    el.UserTime.Time(),
+			Insulin: el.ActualInsulinTaken + el.ActualExtendedInsulinTaken,
+			Carbs:   el.NetCarbs,
+			Glucose: el.BloodGlucose,
+		}
+	}
+
+	fit := insulin.FitRatios(insulin.Outcomes(events, glucose, window))
+
+	isf := &fit.InsulinSensitivityFactor
+	isf.Value = user.GlucoseUnit.FromMmol(isf.Value)
+	isf.Low = user.GlucoseUnit.FromMmol(isf.Low)
+	isf.High = user.GlucoseUnit.FromMmol(isf.High)
+
+	api.WriteJSONObj(w, fit)
+}
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"encoding/json"
+	"karopon/src/api"
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// StatsMealsRequest is a GlucoseRequest for the meals eaten in the range,
+// foods and events eaten fewer than MinSamples times are left out.
+type StatsMealsRequest struct {
+	GlucoseRequest
+	MinSamples int `json:"min_samples"`
+}
+
+// postStatsMeals ranks the user's foods and events by the glucose rise after them, see database.CalculateMealResponses.
+func (a *APIV1) postStatsMeals(w http.ResponseWriter, r *http.Request) {
+
+	user := auth.GetUser(r)
+
+	if user == nil {
+		api.BadReq(w, "no user session available")
+		return
+	}
+
+	var req StatsMealsRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("Invalid json.")
+		api.BadReq(w, "invalid JSON")
+		return
+	}
+
+	startTime, endTime, err := parseStatsRange(user.Location(req.Timezone), user.DayShift(), req.Start, req.End)
+
+	if err != nil {
+		api.BadReq(w, err.Error())
+		return
+	}
+
+	var eflogs []database.UserEventFoodLog
+	var readings []database.TblUserGlucose
+
+	err = a.Db.LoadUserEventFoodLogsBetween(r.Context(), user.ID, startTime, endTime, &eflogs)
+
+	if err == nil {
+		err = a.Db.LoadUserGlucoseBetween(
+			r.Context(), user.ID, startTime.Add(-database.MealBaselineTolerance), endTime.Add(database.MealResponseEnd), &readings,
+		)
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user eventlogs and glucose")
+		api.ServerErr(w, "failed while reading from the database")
+		return
+	}
+
+	res := database.CalculateMealResponses(eflogs, readings, max(req.MinSamples, 1))
+
+	for _, responses := range [][]database.MealResponse{res.Foods, res.Events} {
+		for i := range responses {
+			responses[i].MeanRise = user.GlucoseUnit.FromMmol(responses[i].MeanRise)
+			responses[i].MaxRise = user.GlucoseUnit.FromMmol(responses[i].MaxRise)
+		}
+	}
+
+	api.WriteJSONObj(w, res)
+}
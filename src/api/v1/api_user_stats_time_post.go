@@ -2,11 +2,11 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
 	"karopon/src/api"
 	"karopon/src/api/auth"
 	"karopon/src/database"
 	"net/http"
-	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -18,6 +18,8 @@ type StatsTimeRequest struct {
 	GroupBy       string   `json:"groupby"`
 	AggregateFunc string   `json:"aggregate"`
 	Tags          []string `json:"tags"`
+
+	Timezone database.Timezone `json:"timezone"`
 }
 
 func (a *APIV1) postStatsTime(w http.ResponseWriter, r *http.Request) {
@@ -37,22 +39,22 @@ func (a *APIV1) postStatsTime(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Mirror getUserGoalProgress: subtract the day offset so that date-component
-	// operations inside ParseRelativeTimeExpr reflect the user's perceived current
-	// day, then shift is added back inside the function.
-	// DayTimeOffsetSeconds is NOT a UTC offset — it marks when the user's day starts.
-	shift := time.Duration(user.DayTimeOffsetSeconds) * time.Second
-	adjustedNow := time.Now().Add(-shift)
+	if !database.GroupBy(req.GroupBy).IsValid() {
+		api.BadReq(w, database.ErrInvalidGroupBy.Error())
+		return
+	}
 
-	startTime, err := database.ParseRelativeTimeExpr(req.Start, adjustedNow, shift)
+	loc := user.Location(req.Timezone)
+	shift := user.DayShift()
+
+	startTime, endTime, err := parseStatsRange(loc, shift, req.Start, req.End)
 	if err != nil {
-		api.BadReq(w, "invalid start expression")
+		api.BadReq(w, err.Error())
 		return
 	}
 
-	endTime, err := database.ParseRelativeTimeExpr(req.End, adjustedNow, shift)
-	if err != nil {
-		api.BadReq(w, "invalid end expression")
+	if err := database.ValidateBucketCount(startTime, endTime, database.GroupBy(req.GroupBy)); err != nil {
+		api.BadReq(w, err.Error())
 		return
 	}
 
@@ -71,12 +73,22 @@ func (a *APIV1) postStatsTime(w http.ResponseWriter, r *http.Request) {
 		endTime,
 		req.Tags,
 		database.GroupBy(req.GroupBy),
+		loc,
+		shift,
 		&data,
 	)
 
 	if err != nil {
-		log.Error().Err(err).Msg("")
-	} else {
-		api.WriteJSONArr(w, data)
+
+		if errors.Is(err, database.ErrInvalidGroupBy) || errors.Is(err, database.ErrTooManyBuckets) {
+			api.BadReq(w, err.Error())
+			return
+		}
+
+		log.Warn().Err(err).Str("user", user.Name).Msg("failed to read user time data")
+		api.ServerErr(w, "failed while reading from the database")
+		return
 	}
+
+	api.WriteJSONArr(w, data)
 }
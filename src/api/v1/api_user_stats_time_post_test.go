@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"karopon/src/database/mock_db"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// statsTimeMockDB checks the bucket count like the databases do, then fails with err.
+type statsTimeMockDB struct {
+	mock_db.BaseMockDB
+	err error
+}
+
+func (m *statsTimeMockDB) LoadUserTimeData(
+	ctx context.Context,
+	userID int,
+	startTime time.Time,
+	endTime time.Time,
+	tags []string,
+	groupby database.GroupBy,
+	loc *time.Location,
+	shift time.Duration,
+	out *[]database.TimespanTagDurationPoint,
+) error {
+	if err := database.ValidateBucketCount(startTime, endTime, groupby); err != nil {
+		return err
+	}
+	return m.err
+}
+
+func postStatsTime(db database.DB, body string) *httptest.ResponseRecorder {
+
+	req := httptest.NewRequest(http.MethodPost, "/api/stats/time", strings.NewReader(body))
+	req = auth.PutUser(req, &database.TblUser{ID: 1, Name: "alice"})
+
+	rr := httptest.NewRecorder()
+	newTestAPI(db).postStatsTime(rr, req)
+
+	return rr
+}
+
+func TestPostStatsTime(t *testing.T) {
+
+	db := &statsTimeMockDB{}
+
+	rr := postStatsTime(db, `{"start": "now-1d", "end": "now", "groupby": "HOUR"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = postStatsTime(db, `{"start": "now-365d", "end": "now", "groupby": "SECOND"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), database.ErrTooManyBuckets.Error())
+
+	rr = postStatsTime(db, `{"start": "now-1d", "end": "now", "groupby": "FORTNIGHT"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	db.err = errors.New("database is gone")
+	rr = postStatsTime(db, `{"start": "now-1d", "end": "now", "groupby": "HOUR"}`)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.NotContains(t, rr.Body.String(), db.err.Error())
+}
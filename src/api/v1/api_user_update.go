@@ -8,6 +8,7 @@ import (
 	"karopon/src/config"
 	"karopon/src/constants"
 	"karopon/src/database"
+	"karopon/src/insulin"
 	"net/http"
 	"time"
 
@@ -35,6 +36,11 @@ func (a *APIV1) updateUser(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 
+		if errors.Is(err, database.ErrInvalidTimezone) {
+			api.BadReq(w, "The timezone is not a known IANA timezone.")
+			return
+		}
+
 		log.Debug().Err(err).Msg("invalid json")
 		http.Error(w, "invalid JSON", http.StatusBadRequest)
 
@@ -45,6 +51,53 @@ func (a *APIV1) updateUser(w http.ResponseWriter, r *http.Request) {
 	newUser.User.Created = user.Created
 	newUser.User.Password = user.Password
 
+	// Clients which don't know about the timezone keep the saved one.
+	if newUser.User.Timezone.Name == "" {
+		newUser.User.Timezone = user.Timezone
+	}
+
+	// And the glucose unit.
+	if newUser.User.GlucoseUnit == "" {
+		newUser.User.GlucoseUnit = user.GlucoseUnit
+	}
+
+	if newUser.User.GlucoseUnit == "" {
+		newUser.User.GlucoseUnit = database.GlucoseUnitMmol
+	}
+
+	if !newUser.User.GlucoseUnit.IsValid() {
+		api.BadReq(w, database.ErrInvalidGlucoseUnit.Error())
+		return
+	}
+
+	// The glucose settings are always in the saved unit, the one the client read them in,
+	// changing the unit only changes the unit they are sent back in.
+	newUser.User.GlucoseToMmol(user.GlucoseUnit)
+
+	// Same for the insulin action settings.
+	if newUser.User.InsulinActionCurve == "" {
+		newUser.User.InsulinActionCurve = user.InsulinActionCurve
+		newUser.User.InsulinDurationMinutes = user.InsulinDurationMinutes
+		newUser.User.InsulinPeakMinutes = user.InsulinPeakMinutes
+	}
+
+	if newUser.User.InsulinActionCurve == "" {
+		newUser.User.InsulinActionCurve = string(insulin.DefaultAction.Curve)
+		newUser.User.InsulinDurationMinutes = int(insulin.DefaultAction.Duration.Minutes())
+		newUser.User.InsulinPeakMinutes = int(insulin.DefaultAction.Peak.Minutes())
+	}
+
+	action := insulin.Action{
+		Curve:    insulin.Curve(newUser.User.InsulinActionCurve),
+		Duration: time.Duration(newUser.User.InsulinDurationMinutes) * time.Minute,
+		Peak:     time.Duration(newUser.User.InsulinPeakMinutes) * time.Minute,
+	}
+
+	if err := action.Validate(); err != nil {
+		api.BadReq(w, err.Error())
+		return
+	}
+
 	if newUser.User.SessionExpireTimeSeconds <= 0 {
 		// 1 years should be good enough
 		newUser.User.SessionExpireTimeSeconds = int64(time.Hour * time.Duration(24*365*1))
@@ -122,5 +175,8 @@ func (a *APIV1) updateUser(w http.ResponseWriter, r *http.Request) {
 		*user = newUser.User
 	}
 
-	api.WriteJSONObj(w, newUser.User)
+	out := newUser.User
+	out.GlucoseFromMmol()
+
+	api.WriteJSONObj(w, out)
 }
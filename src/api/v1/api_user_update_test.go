@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"karopon/src/api/auth"
+	"karopon/src/database"
+	"karopon/src/database/mock_db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateUserMockDB keeps the user saved by the update user handler.
+type updateUserMockDB struct {
+	mock_db.BaseMockDB
+	saved *database.TblUser
+}
+
+func (m *updateUserMockDB) UsernameTaken(ctx context.Context, userID int, username string) (bool, error) {
+	return false, nil
+}
+
+func (m *updateUserMockDB) UpdateUser(ctx context.Context, user *database.TblUser) error {
+	cpy := *user
+	m.saved = &cpy
+	return nil
+}
+
+func TestUpdateUser_ChangeGlucoseUnit(t *testing.T) {
+
+	for _, tc := range []struct {
+		saved, changed database.GlucoseUnit
+		target, isf    float64 // as the client read them, in the saved unit
+	}{
+		{database.GlucoseUnitMmol, database.GlucoseUnitMgdl, 5.6, 2.5},
+		{database.GlucoseUnitMgdl, database.GlucoseUnitMmol, 5.6 * database.MgdlPerMmol, 2.5 * database.MgdlPerMmol},
+	} {
+		t.Run(string(tc.changed), func(t *testing.T) {
+
+			db := &updateUserMockDB{}
+			a := newTestAPI(db)
+
+			user := &database.TblUser{
+				ID:                       1,
+				Name:                     "alice",
+				GlucoseUnit:              tc.saved,
+				TargetBloodSugar:         5.6,
+				InsulinSensitivityFactor: 2.5,
+			}
+
+			// The settings page sends back what it read, with only the unit changed.
+			sent := *user
+			sent.GlucoseUnit = tc.changed
+			sent.TargetBloodSugar = tc.target
+			sent.InsulinSensitivityFactor = tc.isf
+
+			body, err := json.Marshal(UpdateUser{User: sent})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/update", bytes.NewReader(body))
+			rr := httptest.NewRecorder()
+			a.updateUser(rr, auth.PutUser(req, user))
+
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			require.NotNil(t, db.saved)
+
+			assert.Equal(t, tc.changed, db.saved.GlucoseUnit)
+			assert.InDelta(t, 5.6, db.saved.TargetBloodSugar, 1e-9)
+			assert.InDelta(t, 2.5, db.saved.InsulinSensitivityFactor, 1e-9)
+
+			var out database.TblUser
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&out))
+			assert.InDelta(t, tc.changed.FromMmol(5.6), out.TargetBloodSugar, 1e-9)
+		})
+	}
+}
@@ -8,14 +8,24 @@ import (
 	"karopon/src/api/userreg"
 	"karopon/src/config"
 	"karopon/src/database"
+	"karopon/src/photostore"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/gorilla/mux"
 )
 
+const (
+	// How often photos that were never linked to an eventlog are removed.
+	photoCleanupInterval = time.Hour
+	// How long a photo can stay unlinked, so the user has time to finish the eventlog it was uploaded for.
+	photoUnlinkedTTL = 24 * time.Hour
+)
+
 type APIV1 struct {
 	Db        database.DB
+	Photos    *photostore.Photos
 	UserReg   *userreg.UserRegistry
 	rateLimit *ratelimit.RateLimiter
 	router    *mux.Router
@@ -35,6 +45,10 @@ func (a *APIV1) Init() {
 
 	a.check()
 
+	if a.Photos == nil {
+		a.Photos = photostore.New(a.Db, photostore.NewDBStore())
+	}
+
 	// a.rateLimit = ratelimit.NewRateLimiter(rate.Every(time.Second), 10)
 
 	// go func() {
@@ -49,10 +63,41 @@ func (a *APIV1) Init() {
 	// 		}
 	// 	}
 	// }()
+
+	a.exit = make(chan bool)
+
+	go func() {
+		ticker := time.NewTicker(photoCleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				a.cleanupUnlinkedPhotos()
+			case <-a.exit:
+				return
+			}
+		}
+	}()
 }
 
 func (a *APIV1) Deinit() {
-	// close(a.exit)
+	if a.exit != nil {
+		close(a.exit)
+	}
+}
+
+func (a *APIV1) cleanupUnlinkedPhotos() {
+
+	n, err := a.Photos.DeleteUnlinked(context.Background(), time.Now().Add(-photoUnlinkedTTL))
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to delete unlinked photos")
+		return
+	}
+
+	if n > 0 {
+		log.Info().Int("count", n).Msg("deleted unlinked photos")
+	}
 }
 
 func (a *APIV1) Register(r *mux.Router) {
@@ -88,6 +133,7 @@ func (a *APIV1) Register(r *mux.Router) {
 	get.HandleFunc("/whoami", a.getUser)
 	get.HandleFunc("/user", a.getUser)
 	get.HandleFunc("/foods", a.getUserFoods)
+	get.HandleFunc("/recipes", a.getUserRecipes)
 	get.HandleFunc("/events", a.getUserEvents)
 	get.HandleFunc("/events/{id}", a.getUserEvent)
 	get.HandleFunc("/eventlogs", a.getUserEventLogs)
@@ -95,6 +141,7 @@ func (a *APIV1) Register(r *mux.Router) {
 	get.HandleFunc("/bodylog", a.getUserBodyLogs)
 	get.HandleFunc("/time", a.getServerTime)
 	get.HandleFunc("/datasources", a.getDataSources)
+	get.HandleFunc("/datasources/barcode/{code}", a.getDataSourceFoodByBarcode)
 	get.HandleFunc("/datasources/{id}/{query}", a.getDataSourceFood)
 	get.HandleFunc("/goals", a.getUserGoals)
 	get.HandleFunc("/tags", a.getUserTags)
@@ -105,6 +152,13 @@ func (a *APIV1) Register(r *mux.Router) {
 	get.HandleFunc("/sessions", a.getUserSessions)
 	get.HandleFunc("/dashboards", a.getUserDashboards)
 	get.HandleFunc("/tag/colors", a.getUserTagColors)
+	get.HandleFunc("/medications", a.getUserMedications)
+	get.HandleFunc("/medications/schedules", a.getUserMedicationSchedules)
+	get.HandleFunc("/medications/logs", a.getUserMedicationLogs)
+	get.HandleFunc("/photos/{id}", a.getUserPhoto)
+	get.HandleFunc("/photos/{id}/thumbnail", a.getUserPhotoThumbnail)
+	get.HandleFunc("/insulin/profile", a.getUserInsulinProfile)
+	get.HandleFunc("/nutrients", a.getNutrients)
 
 	post := api.Methods("POST", "OPTIONS").Subrouter()
 	post.Use(auth.RequireAuth())
@@ -112,10 +166,15 @@ func (a *APIV1) Register(r *mux.Router) {
 	post.HandleFunc("/food/new", a.addUserFood)
 	post.HandleFunc("/food/update", a.updateUserFood)
 	post.HandleFunc("/food/delete", a.deleteUserFood)
+	post.HandleFunc("/recipe/new", a.newUserRecipe)
+	post.HandleFunc("/recipe/update", a.updateUserRecipe)
+	post.HandleFunc("/recipe/delete", a.deleteUserRecipe)
 	post.HandleFunc("/eventlog/new", a.createUserEvent)
 	post.HandleFunc("/eventlog/delete", a.deleteUserEventLog)
 	post.HandleFunc("/eventfoodlog/update", a.updateUserEventFoodLog)
 	post.HandleFunc("/eventlogphoto/new", a.createUserEventLogPhoto)
+	post.HandleFunc("/eventlogphoto/delete", a.deleteUserEventLogPhoto)
+	post.HandleFunc("/photo/delete", a.deleteUserPhoto)
 	post.HandleFunc("/bodylog/new", a.createUserBodyLog)
 	post.HandleFunc("/bodylog/update", a.updateUserBodyLog)
 	post.HandleFunc("/bodylog/delete", a.deleteUserBodyLog)
@@ -138,4 +197,30 @@ func (a *APIV1) Register(r *mux.Router) {
 	post.HandleFunc("/dashboard/update", a.updateUserDashboard)
 	post.HandleFunc("/dashboard/delete", a.deleteUserDashboard)
 	post.HandleFunc("/stats/time", a.postStatsTime)
+	post.HandleFunc("/stats/chart", a.postStatsChart)
+	post.HandleFunc("/insulin/recommend", a.postInsulinRecommend)
+	post.HandleFunc("/insulin/profile/new", a.newUserInsulinProfileBlock)
+	post.HandleFunc("/insulin/profile/update", a.updateUserInsulinProfileBlock)
+	post.HandleFunc("/insulin/profile/delete", a.deleteUserInsulinProfileBlock)
+	post.HandleFunc("/glucose", a.postUserGlucose)
+	post.HandleFunc("/glucose/new", a.newUserGlucoseReadings)
+	post.HandleFunc("/glucose/delete", a.deleteUserGlucose)
+	post.HandleFunc("/glucose/import", a.importUserGlucose)
+	post.HandleFunc("/nightscout/secret/new", a.newUserNightscoutSecret)
+	post.HandleFunc("/nightscout/secret/delete", a.deleteUserNightscoutSecret)
+	post.HandleFunc("/stats/glucose", a.postStatsGlucose)
+	post.HandleFunc("/stats/glucose/profile", a.postStatsGlucoseProfile)
+	post.HandleFunc("/stats/insulin", a.postStatsInsulin)
+	post.HandleFunc("/stats/insulin/ratios", a.postStatsInsulinRatios)
+	post.HandleFunc("/stats/meals", a.postStatsMeals)
+	post.HandleFunc("/medication/new", a.newUserMedication)
+	post.HandleFunc("/medication/update", a.updateUserMedication)
+	post.HandleFunc("/medication/delete", a.deleteUserMedication)
+	post.HandleFunc("/medication/schedule/new", a.newUserMedicationSchedule)
+	post.HandleFunc("/medication/schedule/update", a.updateUserMedicationSchedule)
+	post.HandleFunc("/medication/schedule/delete", a.deleteUserMedicationSchedule)
+	post.HandleFunc("/medication/log/new", a.newUserMedicationLog)
+	post.HandleFunc("/medication/log/update", a.updateUserMedicationLog)
+	post.HandleFunc("/medication/log/delete", a.deleteUserMedicationLog)
+	post.HandleFunc("/medication/doses", a.postUserMedicationDoses)
 }
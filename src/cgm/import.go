@@ -0,0 +1,148 @@
+package cgm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"karopon/src/database"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// How many readings are written to the database at once.
+const importBatchSize = 1000
+
+// ImportResult counts what happened to the readings of an export.
+type ImportResult struct {
+	Format     Format `json:"format"`
+	Unit       Unit   `json:"unit"`
+	Read       int    `json:"read"`
+	Added      int    `json:"added"`
+	Duplicates int    `json:"duplicates"`
+	Failed     int    `json:"failed"`
+}
+
+// Import adds every reading from the export to the user's glucose readings.
+//
+// Readings in the same minute as a reading the user already has, or one earlier in the export, are duplicates and skipped.
+// This drops a LibreView scan taken at the same time as a historic reading, and overlapping exports can be imported again.
+//
+// Rows which cannot be read stop the import, unless ignoreErrors is set.
+// Readings before the failing row are already saved.
+func Import(
+	ctx context.Context,
+	db database.DB,
+	userID int,
+	r *Reader,
+	ignoreErrors bool,
+) (ImportResult, error) {
+
+	res := ImportResult{Format: r.Format()}
+
+	seen := make(map[int64]struct{})
+	batch := make([]database.TblUserGlucose, 0, importBatchSize)
+
+	flush := func() error {
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		first, last := batch[0].UserTime.Time(), batch[0].UserTime.Time()
+
+		for _, g := range batch {
+			if t := g.UserTime.Time(); t.Before(first) {
+				first = t
+			} else if t.After(last) {
+				last = t
+			}
+		}
+
+		var existing []database.TblUserGlucose
+
+		start := first.Truncate(time.Minute)
+		end := last.Truncate(time.Minute).Add(time.Minute)
+
+		if err := db.LoadUserGlucoseBetween(ctx, userID, start, end, &existing); err != nil {
+			return err
+		}
+
+		for _, g := range existing {
+			seen[minuteOf(g.UserTime.Time())] = struct{}{}
+		}
+
+		readings := batch[:0]
+
+		for _, g := range batch {
+
+			minute := minuteOf(g.UserTime.Time())
+
+			if _, ok := seen[minute]; ok {
+				res.Duplicates++
+				continue
+			}
+
+			seen[minute] = struct{}{}
+			readings = append(readings, g)
+		}
+
+		added, err := db.AddUserGlucoseReadings(ctx, userID, readings)
+
+		if err != nil {
+			return err
+		}
+
+		res.Added += added
+		res.Duplicates += len(readings) - added
+		batch = batch[:0]
+
+		return nil
+	}
+
+	for {
+
+		reading, err := r.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+
+			var rowErr *RowError
+
+			if ignoreErrors && errors.As(err, &rowErr) {
+				log.Warn().Err(err).Int("user", userID).Msg("Failed to import glucose reading")
+				res.Failed++
+				continue
+			}
+
+			res.Unit = r.Unit()
+
+			return res, errors.Join(err, flush())
+		}
+
+		res.Read++
+
+		batch = append(batch, database.TblUserGlucose{
+			UserTime: database.TimeMillis(reading.Time),
+			Glucose:  reading.Glucose,
+			Source:   string(r.Format()),
+		})
+
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	res.Unit = r.Unit()
+
+	return res, flush()
+}
+
+func minuteOf(t time.Time) int64 {
+	return t.Unix() / 60
+}
@@ -0,0 +1,54 @@
+package cgm
+
+import (
+	"karopon/src/database"
+	"karopon/src/database/sqlite"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+
+	ctx := t.Context()
+
+	db, err := sqlite.OpenSqliteDatabase(ctx, path.Join(t.TempDir(), "db.sqlite"))
+	require.NoError(t, err)
+	require.NoError(t, db.Migrate(ctx))
+
+	userID, err := db.AddUser(ctx, &database.TblUser{Name: "test_user"})
+	require.NoError(t, err)
+
+	export := libreMmolDayFirst + "FreeStyle Libre 3,ABC,15-01-2024 08:15,1,,7.0,,\n" +
+		"FreeStyle Libre 3,ABC,not a time,0,7.0,,,\n"
+
+	r, err := NewReader(strings.NewReader(export), Options{DayFirst: true})
+	require.NoError(t, err)
+
+	// The bad row stops the import, after saving what came before it.
+	// The scan at 08:15 is a duplicate of the historic reading at 08:15.
+	res, err := Import(ctx, db, userID, r, false)
+	var rowErr *RowError
+	require.ErrorAs(t, err, &rowErr)
+	assert.Equal(t, 3, res.Added)
+	assert.Equal(t, 1, res.Duplicates)
+
+	r, err = NewReader(strings.NewReader(export), Options{DayFirst: true})
+	require.NoError(t, err)
+
+	// Everything is a duplicate the second time.
+	res, err = Import(ctx, db, userID, r, true)
+	require.NoError(t, err)
+	assert.Equal(t, ImportResult{Format: FormatLibre, Unit: UnitMmol, Read: 4, Duplicates: 4, Failed: 1}, res)
+
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	var readings []database.TblUserGlucose
+	require.NoError(t, db.LoadUserGlucoseBetween(ctx, userID, start, start.AddDate(0, 0, 1), &readings))
+	require.Len(t, readings, 3)
+	assert.Equal(t, string(FormatLibre), readings[0].Source)
+}
@@ -0,0 +1,319 @@
+// Package cgm reads the CSV exports of continuous glucose monitors.
+//
+// Dexcom Clarity and LibreView exports are supported.
+// Readings are always returned in mmol/L, the unit glucose is stored in.
+package cgm
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"karopon/src/database"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the program a CSV export came from.
+type Format string
+
+const (
+	FormatDexcom Format = "Dexcom Clarity"
+	FormatLibre  Format = "LibreView"
+)
+
+// Unit is the glucose unit of a CSV export.
+type Unit string
+
+const (
+	UnitUnknown Unit = ""
+	UnitMgdl    Unit = "mg/dL"
+	UnitMmol    Unit = "mmol/L"
+)
+
+const (
+	// How many rows are searched for the column headers.
+	maxHeaderRows = 10
+
+	// Dexcom writes Low and High instead of readings outside 40 to 400 mg/dL.
+	dexcomLowMgdl  = 40
+	dexcomHighMgdl = 400
+)
+
+var (
+	ErrUnknownFormat = errors.New("the file is not a Dexcom Clarity or LibreView CSV export")
+)
+
+// Reading is a single glucose reading in mmol/L.
+type Reading struct {
+	Time    time.Time
+	Glucose float64
+}
+
+// RowError is a row of the export which could not be read, reading can carry on after it.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Options changes how timestamps in an export are read.
+type Options struct {
+	// The timezone of the timestamps, which are written without one. Nil is UTC.
+	Location *time.Location
+
+	// LibreView writes dates as MM-DD-YYYY or DD-MM-YYYY depending on the country,
+	// set this for DD-MM-YYYY.
+	DayFirst bool
+}
+
+// Reader streams the readings from a CGM CSV export.
+type Reader struct {
+	csv      *csv.Reader
+	format   Format
+	unit     Unit
+	loc      *time.Location
+	dayFirst bool
+
+	timeCol   int
+	typeCol   int
+	valueCol  int
+	scanCol   int
+	columnLen int
+}
+
+// NewReader reads up to the column headers of the export to find out its format and unit.
+func NewReader(r io.Reader, opts Options) (*Reader, error) {
+
+	c := csv.NewReader(r)
+	c.FieldsPerRecord = -1
+	c.LazyQuotes = true
+	c.ReuseRecord = true
+
+	out := &Reader{
+		csv:      c,
+		loc:      opts.Location,
+		dayFirst: opts.DayFirst,
+		scanCol:  -1,
+	}
+
+	if out.loc == nil {
+		out.loc = time.UTC
+	}
+
+	for range maxHeaderRows {
+
+		rec, err := c.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if out.readHeader(rec) {
+			return out, nil
+		}
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// readHeader sets the format, unit and columns if the record is the column headers of an export.
+func (r *Reader) readHeader(rec []string) bool {
+
+	r.timeCol, r.typeCol, r.valueCol, r.scanCol = -1, -1, -1, -1
+
+	for i, field := range rec {
+
+		field = strings.TrimSpace(strings.TrimPrefix(field, "\ufeff"))
+
+		switch {
+		case strings.HasPrefix(field, "Timestamp ("):
+			r.format = FormatDexcom
+			r.timeCol = i
+		case field == "Event Type":
+			r.typeCol = i
+		case strings.HasPrefix(field, "Glucose Value ("):
+			r.valueCol = i
+			r.unit = unitOf(field)
+
+		case field == "Device Timestamp":
+			r.format = FormatLibre
+			r.timeCol = i
+		case field == "Record Type":
+			r.typeCol = i
+		case strings.HasPrefix(field, "Historic Glucose"):
+			r.valueCol = i
+			r.unit = unitOf(field)
+		case strings.HasPrefix(field, "Scan Glucose"):
+			r.scanCol = i
+		}
+	}
+
+	r.columnLen = max(r.timeCol, r.typeCol, r.valueCol, r.scanCol) + 1
+
+	return r.timeCol >= 0 && r.typeCol >= 0 && r.valueCol >= 0
+}
+
+func unitOf(header string) Unit {
+
+	switch {
+	case strings.Contains(header, "mg/dL"):
+		return UnitMgdl
+	case strings.Contains(header, "mmol/L"):
+		return UnitMmol
+	default:
+		return UnitUnknown
+	}
+}
+
+// Format returns the program the export came from.
+func (r *Reader) Format() Format {
+	return r.format
+}
+
+// Unit returns the glucose unit of the export, which is unknown until a reading is read if the headers do not say.
+func (r *Reader) Unit() Unit {
+	return r.unit
+}
+
+// Read returns the next reading, rows which are not glucose readings are skipped.
+// A *RowError is returned for a row which could not be read, and io.EOF at the end of the export.
+func (r *Reader) Read() (Reading, error) {
+
+	for {
+
+		rec, err := r.csv.Read()
+
+		if err != nil {
+
+			var parseErr *csv.ParseError
+
+			if errors.As(err, &parseErr) {
+				return Reading{}, &RowError{Line: parseErr.Line, Err: parseErr.Err}
+			}
+
+			return Reading{}, err
+		}
+
+		line, _ := r.csv.FieldPos(0)
+
+		if len(rec) < r.columnLen {
+			return Reading{}, &RowError{Line: line, Err: errors.New("missing columns")}
+		}
+
+		var value string
+
+		switch r.format {
+		case FormatDexcom:
+			if strings.TrimSpace(rec[r.typeCol]) != "EGV" {
+				continue
+			}
+			value = rec[r.valueCol]
+
+		case FormatLibre:
+			switch strings.TrimSpace(rec[r.typeCol]) {
+			default:
+				continue
+			case "0":
+				value = rec[r.valueCol]
+			case "1":
+				if r.scanCol < 0 {
+					continue
+				}
+				value = rec[r.scanCol]
+			}
+		}
+
+		value = strings.TrimSpace(value)
+
+		if value == "" {
+			continue
+		}
+
+		t, err := r.parseTime(strings.TrimSpace(rec[r.timeCol]))
+
+		if err != nil {
+			return Reading{}, &RowError{Line: line, Err: err}
+		}
+
+		glucose, err := r.parseGlucose(value)
+
+		if err != nil {
+			return Reading{}, &RowError{Line: line, Err: err}
+		}
+
+		return Reading{Time: t, Glucose: glucose}, nil
+	}
+}
+
+func (r *Reader) parseTime(value string) (time.Time, error) {
+
+	layouts := []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+	if r.format == FormatLibre {
+		if r.dayFirst {
+			layouts = append(layouts, "02-01-2006 15:04", "02-01-2006 15:04:05")
+		} else {
+			layouts = append(layouts, "01-02-2006 15:04", "01-02-2006 15:04:05")
+		}
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, r.loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
+
+// parseGlucose parses the reading and converts it to mmol/L.
+func (r *Reader) parseGlucose(value string) (float64, error) {
+
+	var mgdl float64
+
+	switch value {
+	case "Low":
+		mgdl = dexcomLowMgdl
+	case "High":
+		mgdl = dexcomHighMgdl
+	}
+
+	if mgdl != 0 {
+		return mgdl / database.MgdlPerMmol, nil
+	}
+
+	// Some countries write a decimal comma.
+	glucose, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+
+	if err != nil || glucose <= 0 {
+		return 0, fmt.Errorf("invalid glucose %q", value)
+	}
+
+	// Without a unit in the header, a reading too high for mmol/L is in mg/dL.
+	if r.unit == UnitUnknown {
+		if glucose > database.MaxMmolGlucose {
+			r.unit = UnitMgdl
+		} else {
+			r.unit = UnitMmol
+		}
+	}
+
+	if r.unit == UnitMgdl {
+		glucose /= database.MgdlPerMmol
+	}
+
+	return glucose, nil
+}
@@ -0,0 +1,114 @@
+package cgm
+
+import (
+	"errors"
+	"io"
+	"karopon/src/database"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dexcomMgdl = `Index,Timestamp (YYYY-MM-DDThh:mm:ss),Event Type,Event Subtype,Patient Info,Device Info,Source Device ID,Glucose Value (mg/dL),Insulin Value (u),Carb Value (grams),Duration (hh:mm:ss),Glucose Rate of Change (mg/dL/min),Transmitter Time (Long Integer),Transmitter ID
+1,,FirstName,,Jane,,,,,,,,,
+2,,LastName,,Doe,,,,,,,,,
+3,,Device,,,"G7 Mobile App",iOS G7,,,,,,,
+4,2024-01-15T08:00:12,EGV,,,,iOS G7,108,,,,,123,ABC
+5,2024-01-15T08:05:12,EGV,,,,iOS G7,Low,,,,,423,ABC
+6,2024-01-15T08:07:00,Carbs,,,,iOS G7,,,45,,,,
+7,2024-01-15T08:10:12,EGV,,,,iOS G7,High,,,,,723,ABC
+8,2024-01-15T08:15:12,EGV,,,,iOS G7,180,,,,,1023,ABC
+`
+
+const libreMmolDayFirst = `Glucose Data,Generated on,15-01-2024 10:00 UTC,Generated by,Jane Doe
+Device,Serial Number,Device Timestamp,Record Type,Historic Glucose mmol/L,Scan Glucose mmol/L,Non-numeric Rapid-Acting Insulin,Rapid-Acting Insulin (units)
+FreeStyle Libre 3,ABC,15-01-2024 08:00,0,6.1,,,
+FreeStyle Libre 3,ABC,15-01-2024 08:03,1,,6.4,,
+FreeStyle Libre 3,ABC,15-01-2024 08:04,4,,,,4
+FreeStyle Libre 3,ABC,15-01-2024 08:15,0,"6,8",,,
+`
+
+func readAll(t *testing.T, r *Reader) []Reading {
+
+	var out []Reading
+
+	for {
+		reading, err := r.Read()
+
+		if errors.Is(err, io.EOF) {
+			return out
+		}
+
+		require.NoError(t, err)
+		out = append(out, reading)
+	}
+}
+
+func TestReader_Dexcom(t *testing.T) {
+
+	loc, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	r, err := NewReader(strings.NewReader(dexcomMgdl), Options{Location: loc})
+	require.NoError(t, err)
+	assert.Equal(t, FormatDexcom, r.Format())
+	assert.Equal(t, UnitMgdl, r.Unit())
+
+	readings := readAll(t, r)
+	require.Len(t, readings, 4)
+
+	assert.True(t, readings[0].Time.Equal(time.Date(2024, 1, 15, 8, 0, 12, 0, loc)))
+	assert.InDelta(t, 108/database.MgdlPerMmol, readings[0].Glucose, 1e-9)
+	assert.InDelta(t, 40/database.MgdlPerMmol, readings[1].Glucose, 1e-9)
+	assert.InDelta(t, 400/database.MgdlPerMmol, readings[2].Glucose, 1e-9)
+	assert.InDelta(t, 180/database.MgdlPerMmol, readings[3].Glucose, 1e-9)
+}
+
+func TestReader_Libre(t *testing.T) {
+
+	r, err := NewReader(strings.NewReader(libreMmolDayFirst), Options{DayFirst: true})
+	require.NoError(t, err)
+	assert.Equal(t, FormatLibre, r.Format())
+	assert.Equal(t, UnitMmol, r.Unit())
+
+	readings := readAll(t, r)
+	require.Len(t, readings, 3)
+
+	assert.True(t, readings[0].Time.Equal(time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC)))
+	assert.InDelta(t, 6.1, readings[0].Glucose, 1e-9)
+	assert.InDelta(t, 6.4, readings[1].Glucose, 1e-9)
+	assert.InDelta(t, 6.8, readings[2].Glucose, 1e-9)
+
+	// Read month first, the 15th month does not exist.
+	r, err = NewReader(strings.NewReader(libreMmolDayFirst), Options{})
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	var rowErr *RowError
+	require.ErrorAs(t, err, &rowErr)
+	assert.Equal(t, 3, rowErr.Line)
+}
+
+func TestReader_DetectsUnit(t *testing.T) {
+
+	export := "Device,Serial Number,Device Timestamp,Record Type,Historic Glucose,Scan Glucose\n" +
+		"FreeStyle Libre 2,ABC,01-15-2024 08:00,0,126,\n"
+
+	r, err := NewReader(strings.NewReader(export), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, UnitUnknown, r.Unit())
+
+	readings := readAll(t, r)
+	require.Len(t, readings, 1)
+	assert.Equal(t, UnitMgdl, r.Unit())
+	assert.InDelta(t, 7, readings[0].Glucose, 0.01)
+}
+
+func TestReader_UnknownFormat(t *testing.T) {
+
+	_, err := NewReader(strings.NewReader("name,carbs\nbread,40\n"), Options{})
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
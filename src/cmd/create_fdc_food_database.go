@@ -8,6 +8,9 @@ import (
 	"karopon/src/database"
 	"karopon/src/database/connection"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
@@ -36,12 +39,57 @@ var (
 
 		"Fiber, total dietary":               FIBRE,
 		"Total dietary fiber (AOAC 2011.25)": FIBRE,
+	}
 
-		// TODO: other mappings later
-		// "Iron, Fe": IRON,
+	// grams in each of the mass units FDC uses
+	fdcUnitGrams = map[string]float64{
+		"g":   1,
+		"mg":  1e-3,
+		"µg":  1e-6,
+		"ug":  1e-6,
+		"mcg": 1e-6,
 	}
 )
 
+// fdcNutrient is the nutrient an FDC nutrient name maps to,
+// the rank is the index of the name in database.NutrientInfo.FDCNames, lower is preferred.
+type fdcNutrient struct {
+	info database.NutrientInfo
+	rank int
+}
+
+func fdcNutrientMapping() map[string]fdcNutrient {
+
+	mapping := make(map[string]fdcNutrient)
+
+	for _, info := range database.NutrientInfos {
+		for rank, name := range info.FDCNames {
+			mapping[name] = fdcNutrient{info: info, rank: rank}
+		}
+	}
+
+	return mapping
+}
+
+// convertFDCAmount converts the amount from the FDC unit into the given unit,
+// false if either is not a unit of mass, like IU.
+func convertFDCAmount(amount float64, from string, to string) (float64, bool) {
+
+	fromGrams, ok := fdcUnitGrams[strings.ToLower(from)]
+
+	if !ok {
+		return 0, false
+	}
+
+	toGrams, ok := fdcUnitGrams[strings.ToLower(to)]
+
+	if !ok {
+		return 0, false
+	}
+
+	return amount * fromGrams / toGrams, true
+}
+
 type tNutrient struct {
 	Type     string `json:"type"`
 	Nutrient struct {
@@ -61,6 +109,18 @@ type tNutrientConv struct {
 	Carbs   float32 `json:"carbohydrateValue"`
 }
 
+type tFoodPortion struct {
+	Value       float64 `json:"value"`
+	Amount      float64 `json:"amount"`
+	MeasureUnit struct {
+		Name string `json:"name"`
+	} `json:"measureUnit"`
+	Modifier           string  `json:"modifier"`
+	PortionDescription string  `json:"portionDescription"`
+	GramWeight         float64 `json:"gramWeight"`
+	SequenceNumber     int     `json:"sequenceNumber"`
+}
+
 type tFood struct {
 	FoodClass          string          `json:"foodClass"`
 	Name               string          `json:"description"`
@@ -69,6 +129,118 @@ type tFood struct {
 	NDBID              int             `json:"ndbNumber"`
 	Nutrients          []tNutrient     `json:"foodNutrients"`
 	NutrientConversion []tNutrientConv `json:"nutrientConversionFactors"`
+	Portions           []tFoodPortion  `json:"foodPortions"`
+
+	// Branded foods have a barcode and a single serving instead of portions.
+	GTINUPC                  string  `json:"gtinUpc"`
+	ServingSize              float64 `json:"servingSize"`
+	ServingSizeUnit          string  `json:"servingSizeUnit"`
+	HouseholdServingFullText string  `json:"householdServingFullText"`
+}
+
+// splitFDCServing splits a serving like "1/2 cup" into its amount and unit, the amount is 1 without a number.
+func splitFDCServing(text string) (float64, string) {
+
+	text = strings.TrimSpace(text)
+	first, rest, _ := strings.Cut(text, " ")
+
+	if num, den, ok := strings.Cut(first, "/"); ok {
+
+		n, err1 := strconv.ParseFloat(num, 64)
+		d, err2 := strconv.ParseFloat(den, 64)
+
+		if err1 == nil && err2 == nil && n > 0 && d > 0 {
+			return n / d, strings.TrimSpace(rest)
+		}
+
+	} else if n, err := strconv.ParseFloat(first, 64); err == nil && n > 0 {
+		return n, strings.TrimSpace(rest)
+	}
+
+	return 1, text
+}
+
+// fdcServings reads the servings of the food, in grams since data source foods are per 100 grams.
+// Portions without a weight or that can't be named are skipped.
+func fdcServings(food *tFood) []database.TblDataSourceFoodServing {
+
+	var servings []database.TblDataSourceFoodServing
+
+	portions := slices.Clone(food.Portions)
+	slices.SortStableFunc(portions, func(a, b tFoodPortion) int {
+		return a.SequenceNumber - b.SequenceNumber
+	})
+
+	for _, p := range portions {
+
+		if p.GramWeight <= 0 {
+			continue
+		}
+
+		amount := p.Amount
+		if amount <= 0 {
+			amount = p.Value
+		}
+
+		// SR Legacy and survey foods have an undetermined unit and put it in the modifier instead.
+		unit := strings.TrimSpace(p.MeasureUnit.Name)
+		modifier := strings.TrimSpace(p.Modifier)
+
+		switch {
+		case unit == "" || unit == "undetermined":
+			unit = modifier
+		case modifier != "":
+			unit += ", " + modifier
+		}
+
+		name := strings.TrimSpace(p.PortionDescription)
+
+		if unit == "" {
+			amount, unit = splitFDCServing(name)
+		}
+
+		if unit == "" || strings.EqualFold(unit, "Quantity not specified") {
+			continue
+		}
+
+		if amount <= 0 {
+			amount = 1
+		}
+
+		if name == "" {
+			name = strconv.FormatFloat(amount, 'f', -1, 64) + " " + unit
+		}
+
+		servings = append(servings, database.TblDataSourceFoodServing{
+			Name:    name,
+			Amount:  amount,
+			Unit:    unit,
+			Portion: p.GramWeight,
+		})
+	}
+
+	// Branded foods use GRM for grams, servings in millilitres can't be weighed.
+	servingUnit := strings.ToLower(food.ServingSizeUnit)
+	if servingUnit == "grm" {
+		servingUnit = "g"
+	}
+
+	grams, ok := convertFDCAmount(food.ServingSize, servingUnit, "g")
+	name := strings.TrimSpace(food.HouseholdServingFullText)
+
+	if ok && grams > 0 && name != "" {
+
+		amount, unit := splitFDCServing(name)
+
+		servings = append(servings, database.TblDataSourceFoodServing{
+			Name:    name,
+			Amount:  amount,
+			Unit:    unit,
+			Portion: grams,
+		})
+	}
+
+	return servings
 }
 
 func CmdCreateFDC(ctx context.Context, c *cli.Command) error {
@@ -175,6 +347,8 @@ func doImport(
 		return err
 	}
 
+	nutrientMapping := fdcNutrientMapping()
+
 	for dec.More() {
 
 		var food tFood
@@ -193,8 +367,41 @@ func doImport(
 		insertFood.Portion = 100
 		insertFood.DataSourceRowID = food.FDCID
 
+		if food.GTINUPC != "" {
+			if barcode, ok := database.NormalizeBarcode(food.GTINUPC); ok {
+				insertFood.Barcode = barcode
+			} else {
+				log.Debug().Str("name", food.Name).Str("gtinUpc", food.GTINUPC).Msg("unsupported barcode")
+			}
+		}
+
+		nutrientRanks := make(map[database.Nutrient]int)
+
 		for _, n := range food.Nutrients {
 
+			if m, ok := nutrientMapping[n.Nutrient.Name]; ok {
+
+				if rank, ok := nutrientRanks[m.info.Nutrient]; ok && rank < m.rank {
+					continue // a preferred name was already seen
+				}
+
+				amount, ok := convertFDCAmount(float64(n.Value), n.Nutrient.Unit, m.info.Unit)
+
+				if !ok {
+					log.Debug().Str("name", food.Name).Str("unit", n.Nutrient.Unit).Msg("unsupported nutrient unit")
+					continue
+				}
+
+				if insertFood.Nutrients == nil {
+					insertFood.Nutrients = make(database.Nutrients)
+				}
+
+				insertFood.Nutrients[m.info.Nutrient] = amount
+				nutrientRanks[m.info.Nutrient] = m.rank
+
+				continue
+			}
+
 			mapping, ok := nutrientsColumnMapping[n.Nutrient.Name]
 
 			if !ok {
@@ -226,6 +433,8 @@ func doImport(
 			}
 		}
 
+		servings := fdcServings(&food)
+
 		log.Debug().
 			Str("name", food.Name).
 			Int("fdcid", food.FDCID).
@@ -233,11 +442,16 @@ func doImport(
 			Float64("carb", insertFood.Carb).
 			Float64("fibre", insertFood.Fibre).
 			Float64("protein", insertFood.Protein).
+			Int("nutrients", len(insertFood.Nutrients)).
+			Int("servings", len(servings)).
 			Msg("importing food")
 
-		if _, err := conn.AddDataSourceFood(ctx, &insertFood); err != nil {
+		id, err := conn.AddDataSourceFood(ctx, &insertFood)
+
+		if err != nil {
 			if ignoreErrors {
 				log.Warn().
+					Err(err).
 					Str("name", food.Name).
 					Int("fdcid", food.FDCID).
 					Float64("fat", insertFood.Fat).
@@ -251,6 +465,26 @@ func doImport(
 
 			return err
 		}
+
+		for _, serving := range servings {
+
+			serving.DataSourceFoodID = id
+
+			if _, err := conn.AddDataSourceFoodServing(ctx, &serving); err != nil {
+				if ignoreErrors {
+					log.Warn().
+						Err(err).
+						Str("name", food.Name).
+						Int("fdcid", food.FDCID).
+						Str("serving", serving.Name).
+						Msg("Failed to import food serving")
+
+					continue
+				}
+
+				return err
+			}
+		}
 	}
 
 	return nil
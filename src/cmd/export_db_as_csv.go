@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"io"
+	"karopon/src/database"
 	"karopon/src/database/connection"
 	"os"
 	"path"
@@ -16,6 +17,7 @@ func CmdExportCsv(ctx context.Context, c *cli.Command) error {
 	dbconn := c.Value("database-conn").(string)
 	vendorStr := c.Value("database-vendor").(string)
 	outputFolder := c.Value("output-folder").(string)
+	timezone := c.Value("timezone").(string)
 
 	conn, err := connection.ConnectStr(context.Background(), vendorStr, dbconn)
 
@@ -29,6 +31,14 @@ func CmdExportCsv(ctx context.Context, c *cli.Command) error {
 		return err
 	}
 
+	var tz database.Timezone
+
+	if timezone != "" {
+		if tz, err = database.NewTimezone(timezone); err != nil {
+			return err
+		}
+	}
+
 	if outputFolder != "" {
 
 		log.Info().Str("path", outputFolder).Msg("Creating output folder")
@@ -38,7 +48,7 @@ func CmdExportCsv(ctx context.Context, c *cli.Command) error {
 		}
 	}
 
-	type DbCsvExportFunc func(context.Context, io.Writer) error
+	type DbCsvExportFunc func(context.Context, database.Timezone, io.Writer) error
 
 	tables := map[string]DbCsvExportFunc{
 		"user.csv":          conn.ExportUserCSV,
@@ -47,6 +57,7 @@ func CmdExportCsv(ctx context.Context, c *cli.Command) error {
 		"user_food.csv":     conn.ExportUserFoodsCSV,
 		"user_foodlog.csv":  conn.ExportUserFoodLogsCSV,
 		"user_bodylog.csv":  conn.ExportBodyLogCSV,
+		"user_glucose.csv":  conn.ExportUserGlucoseCSV,
 		"db_version.csv":    conn.ExportVersionCSV,
 	}
 
@@ -65,7 +76,7 @@ func CmdExportCsv(ctx context.Context, c *cli.Command) error {
 			}
 			defer file.Close()
 
-			if err := exportFunc(ctx, file); err != nil {
+			if err := exportFunc(ctx, tz, file); err != nil {
 				return err
 			}
 
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"karopon/src/cgm"
+	"karopon/src/database"
+	"karopon/src/database/connection"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+func CmdImportCGM(ctx context.Context, c *cli.Command) error {
+
+	dbconn := c.Value("database-conn").(string)
+	vendorStr := c.Value("database-vendor").(string)
+	username := c.Value("username").(string)
+	cgmCsv := c.Value("file").(string)
+	timezone := c.Value("timezone").(string)
+	dayFirst := c.Value("day-first").(bool)
+	ignoreErrors := c.Value("ignore-errors").(bool)
+
+	conn, err := connection.ConnectStr(context.Background(), vendorStr, dbconn)
+
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Migrate(ctx); err != nil {
+		return err
+	}
+
+	var user database.TblUser
+
+	if err := conn.LoadUser(ctx, username, &user); err != nil {
+		return err
+	}
+
+	tz := user.Timezone
+
+	if timezone != "" {
+		if tz, err = database.NewTimezone(timezone); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(cgmCsv) //nolint:gosec
+
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, err := cgm.NewReader(file, cgm.Options{Location: tz.Loc(), DayFirst: dayFirst})
+
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("format", string(reader.Format())).Msg("Importing glucose readings, please wait...")
+
+	res, err := cgm.Import(ctx, conn, user.ID, reader, ignoreErrors)
+
+	log.Info().
+		Str("unit", string(res.Unit)).
+		Int("read", res.Read).
+		Int("added", res.Added).
+		Int("duplicates", res.Duplicates).
+		Int("failed", res.Failed).
+		Msg("Imported glucose readings")
+
+	return err
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"karopon/src/database"
+	"karopon/src/database/connection"
+	"karopon/src/photostore"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// newPhotoStore returns Photos writing new photos to the given storage.
+// When a photo directory is given, photos in either storage can be read,
+// so switching storage does not lose access to the photos saved before.
+func newPhotoStore(db database.DB, storage string, photoDir string) (*photostore.Photos, error) {
+
+	dbStore := photostore.NewDBStore()
+
+	switch storage {
+
+	case "", photostore.StorageDB:
+
+		if photoDir == "" {
+			return photostore.New(db, dbStore), nil
+		}
+
+		fsStore, err := photostore.NewFSStore(photoDir)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return photostore.New(db, dbStore, fsStore), nil
+
+	case photostore.StorageFS:
+
+		fsStore, err := photostore.NewFSStore(photoDir)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return photostore.New(db, fsStore, dbStore), nil
+	}
+
+	return nil, fmt.Errorf("unknown photo storage %q, expected '%s' or '%s'",
+		storage, photostore.StorageDB, photostore.StorageFS)
+}
+
+func CmdMigratePhotos(ctx context.Context, c *cli.Command) error {
+
+	dbconn := c.Value("database-conn").(string)
+	vendorStr := c.Value("database-vendor").(string)
+	to := c.Value("to").(string)
+	photoDir := c.Value("photo-dir").(string)
+
+	conn, err := connection.ConnectStr(ctx, vendorStr, dbconn)
+
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Migrate(ctx); err != nil {
+		return err
+	}
+
+	photos, err := newPhotoStore(conn, to, photoDir)
+
+	if err != nil {
+		return err
+	}
+
+	from := photostore.StorageDB
+
+	if to == photostore.StorageDB {
+		from = photostore.StorageFS
+	}
+
+	// Photos only lock against adds and deletes within this process.
+	log.Warn().Msg("Make sure the server is stopped, photos it adds or deletes while moving can lose their files")
+	log.Info().Str("from", from).Str("to", to).Str("dir", photoDir).Msg("Moving photos")
+
+	n, err := photos.MoveAll(ctx, from)
+
+	log.Info().Int("count", n).Msg("Moved photos")
+
+	return err
+}
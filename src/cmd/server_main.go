@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"karopon/src/api/middleware"
+	"karopon/src/api/nightscout"
 	"karopon/src/api/userreg"
 	v1 "karopon/src/api/v1"
 	"karopon/src/config"
@@ -40,6 +41,11 @@ type ServerOptions struct {
 	// build, which has no CLI to run `db create-user` on-device.
 	DefaultUsername string
 	DefaultPassword string //nolint:gosec // not a hardcoded credential, it's a config value supplied by the caller
+
+	// PhotoStorage is where new photos are saved, 'db' (the default) or 'fs'.
+	// PhotoDir is the directory used by 'fs' photo storage.
+	PhotoStorage string
+	PhotoDir     string
 }
 
 // StartServer connects to the database, runs migrations, and starts serving
@@ -79,6 +85,13 @@ func StartServer(ctx context.Context, opts ServerOptions) (shutdown func(context
 		}
 	}
 
+	photos, err := newPhotoStore(db, opts.PhotoStorage, opts.PhotoDir)
+
+	if err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
 	sessionSecret := []byte(opts.SessionSecret)
 
 	if len(sessionSecret) == 0 {
@@ -93,13 +106,21 @@ func StartServer(ctx context.Context, opts ServerOptions) (shutdown func(context
 
 	apiv1 := v1.APIV1{
 		Db:      db,
+		Photos:  photos,
 		UserReg: userReg,
 	}
 	apiv1.Init()
 
+	ns := nightscout.Nightscout{
+		Db: db,
+	}
+	ns.Init()
+
 	r := mux.NewRouter()
 	r.Use(middleware.Recoverer)
 
+	// must happen before the karopon api, which owns the rest of /api
+	ns.Register(r)
 	apiv1.Register(r)
 
 	// must happen last
@@ -124,6 +145,7 @@ func StartServer(ctx context.Context, opts ServerOptions) (shutdown func(context
 
 	shutdown = func(shutdownCtx context.Context) error {
 		apiv1.Deinit()
+		ns.Deinit()
 		return srv.Shutdown(shutdownCtx)
 	}
 
@@ -145,6 +167,8 @@ func CmdServerMain(ctx context.Context, c *cli.Command) error {
 		DatabaseVendor: c.Value("database-vendor").(string),
 		DatabaseConn:   c.Value("database-conn").(string),
 		SessionSecret:  c.Value("session-secret").(string),
+		PhotoStorage:   c.Value("photo-storage").(string),
+		PhotoDir:       c.Value("photo-dir").(string),
 	}
 
 	if fakeAuth, ok := c.Value("fake-auth-as-user").(string); ok {
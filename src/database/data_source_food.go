@@ -0,0 +1,53 @@
+package database
+
+import "strings"
+
+// DataSourceFood is a data source food with the servings it can be measured in.
+type DataSourceFood struct {
+	TblDataSourceFood
+
+	Servings []TblDataSourceFoodServing `json:"servings"`
+}
+
+// NewDataSourceFoods gives each food its servings, keeping the order of both.
+func NewDataSourceFoods(foods []TblDataSourceFood, servings []TblDataSourceFoodServing) []DataSourceFood {
+
+	servingsByFood := make(map[int][]TblDataSourceFoodServing)
+	for _, s := range servings {
+		servingsByFood[s.DataSourceFoodID] = append(servingsByFood[s.DataSourceFoodID], s)
+	}
+
+	out := make([]DataSourceFood, len(foods))
+
+	for i, f := range foods {
+
+		out[i].TblDataSourceFood = f
+		out[i].Servings = servingsByFood[f.ID]
+
+		if out[i].Servings == nil {
+			out[i].Servings = make([]TblDataSourceFoodServing, 0)
+		}
+	}
+
+	return out
+}
+
+// NormalizeBarcode turns a GTIN or UPC into 14 digits with leading zeros,
+// so a scanned 12 digit UPC-A matches the 14 digit GTIN of the same product.
+// Spaces and dashes are ignored, false if it is not a barcode.
+func NormalizeBarcode(code string) (string, bool) {
+
+	code = strings.NewReplacer(" ", "", "-", "").Replace(code)
+
+	if len(code) < 8 || len(code) > 14 {
+		return "", false
+	}
+
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return "", false
+		}
+	}
+
+	return strings.Repeat("0", 14-len(code)) + code, true
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeBarcode(t *testing.T) {
+
+	for code, want := range map[string]string{
+		"016000275287":    "00016000275287",
+		"00016000275287":  "00016000275287",
+		"0 16000-27528 7": "00016000275287",
+		"5000112637922":   "05000112637922",
+		"96385074":        "00000096385074",
+	} {
+		got, ok := NormalizeBarcode(code)
+		assert.True(t, ok, code)
+		assert.Equal(t, want, got, code)
+	}
+
+	for _, code := range []string{"", "1234567", "123456789012345", "01600027528A"} {
+		_, ok := NormalizeBarcode(code)
+		assert.False(t, ok, code)
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -17,6 +18,7 @@ var (
 	ErrInvalidSessionTokenLength = errors.New("user session token must be 32 length")
 	ErrUserDoesNotHaveThisID     = errors.New("ID does not exist")
 	ErrFoodPortionIsZero         = errors.New("food portion cannot be zero")
+	ErrFoodUsedInRecipe          = errors.New("food is used in a recipe")
 )
 
 // DB is interface for accessing and manipulating data in database.
@@ -32,13 +34,16 @@ type DB interface {
 	/// Export functions
 	///
 
-	ExportUserCSV(ctx context.Context, w io.Writer) error
-	ExportUserEventsCSV(ctx context.Context, w io.Writer) error
-	ExportUserEventLogsCSV(ctx context.Context, w io.Writer) error
-	ExportUserFoodsCSV(ctx context.Context, w io.Writer) error
-	ExportUserFoodLogsCSV(ctx context.Context, w io.Writer) error
-	ExportBodyLogCSV(ctx context.Context, w io.Writer) error
-	ExportVersionCSV(ctx context.Context, w io.Writer) error
+	// Times are written in the timezone of the user the row belongs to,
+	// or in the given timezone if it is set.
+	ExportUserCSV(ctx context.Context, tz Timezone, w io.Writer) error
+	ExportUserEventsCSV(ctx context.Context, tz Timezone, w io.Writer) error
+	ExportUserEventLogsCSV(ctx context.Context, tz Timezone, w io.Writer) error
+	ExportUserFoodsCSV(ctx context.Context, tz Timezone, w io.Writer) error
+	ExportUserFoodLogsCSV(ctx context.Context, tz Timezone, w io.Writer) error
+	ExportBodyLogCSV(ctx context.Context, tz Timezone, w io.Writer) error
+	ExportUserGlucoseCSV(ctx context.Context, tz Timezone, w io.Writer) error
+	ExportVersionCSV(ctx context.Context, tz Timezone, w io.Writer) error
 
 	// Migrate runs migrations for this database
 	Migrate(ctx context.Context) error
@@ -118,8 +123,31 @@ type DB interface {
 	UpdateUserFood(ctx context.Context, food *TblUserFood) error
 
 	// Delete a food by it's ID.
+	// Returns ErrFoodUsedInRecipe if it is an ingredient of one of the user's recipes.
 	DeleteUserFood(ctx context.Context, userID int, foodID int) error
 
+	///
+	/// Recipe Functions
+	///
+
+	// Add the given recipe with its ingredients and return it's ID.
+	// Returns ErrUserDoesNotHaveThisID if an ingredient's food does not exist or does not belong to the user.
+	AddUserRecipe(ctx context.Context, recipe *TblUserRecipe, ingredients []TblUserRecipeIngredient) (int, error)
+
+	// Read all the users recipes, with their ingredients and nutrition, into the given array.
+	LoadUserRecipes(ctx context.Context, userID int, out *[]UserRecipe) error
+
+	// Read the recipe, with its ingredients and nutrition, into the given struct.
+	// Returns sql.ErrNoRows if the recipe does not exist or does not belong to the user.
+	LoadUserRecipe(ctx context.Context, userID int, recipeID int, out *UserRecipe) error
+
+	// Update the given recipe and replace all of its ingredients with the given ones.
+	// Returns ErrUserDoesNotHaveThisID if the recipe or an ingredient's food does not belong to the user.
+	UpdateUserRecipe(ctx context.Context, recipe *TblUserRecipe, ingredients []TblUserRecipeIngredient) error
+
+	// Delete the recipe with the given ID, foodlogs of it keep their amounts.
+	DeleteUserRecipe(ctx context.Context, userID int, recipeID int) error
+
 	///
 	/// Event Functions
 	///
@@ -151,17 +179,69 @@ type DB interface {
 
 	// Add the given event log to the database.
 	// If the TblUserEventLog.UserTime IsZero it is set as the current UTC time.
-	// The given TblUserEventLog.NetCarbs is updated with the net carbs of the given foods.
+	// The given TblUserEventLog.NetCarbs is updated with the net carbs of the given foods, see FillUserFoodLogs.
 	// The given foods are updated with the event's UserID, Event, EventLogID.
 	// The given foods UserTime are updated if their usertime IsZero.
 	// The given foods ID are updated.
 	// The given foods are also updated by any modifications made by AddUserFoodLogTx.
 	AddUserEventLogWith(ctx context.Context, event *TblUserEventLog, foodlogs []TblUserFoodLog) (int, error)
+	AddUserEventLogWithTx(tx *sqlx.Tx, event *TblUserEventLog, foodlogs []TblUserFoodLog) (int, error)
+
+	// AddUserEventLogWithPhotos is AddUserEventLogWith that also links the photos to the new eventlog,
+	// in the same transaction so a photo that is not the user's saves nothing.
+	// Returns ErrUserDoesNotHaveThisID if any of the photos do not belong to the user.
+	AddUserEventLogWithPhotos(
+		ctx context.Context,
+		event *TblUserEventLog,
+		foodlogs []TblUserFoodLog,
+		photoIDs []int,
+	) (int, error)
+
+	// AddUserEventLogsOnce adds the eventlogs of the user with their foodlogs in one transaction,
+	// where foodlogs[i] are the foodlogs of eventlogs[i], and creates their events by name.
+	// An eventlog with the same event and notes at the same time as a saved one is skipped,
+	// so a batch that is sent again is only saved once.
+	// Returns the eventlogs that were saved, with their IDs.
+	AddUserEventLogsOnce(
+		ctx context.Context,
+		userID int,
+		eventlogs []TblUserEventLog,
+		foodlogs [][]TblUserFoodLog,
+	) ([]TblUserEventLog, error)
 
 	// Read all the users eventlogs into the given array, or returns an error.
 	LoadUserEventLogs(ctx context.Context, userID int, events *[]TblUserEventLog) error
 	LoadUserEventLogsTx(tx *sqlx.Tx, userID int, events *[]TblUserEventLog) error
 
+	// Read the users eventlogs where insulin was taken, including only an extended bolus,
+	// with a UserTime in [start, end) into the given array, oldest first.
+	LoadUserInsulinEventLogsBetween(
+		ctx context.Context,
+		userID int,
+		start time.Time,
+		end time.Time,
+		out *[]TblUserEventLog,
+	) error
+
+	// Read the users eventlogs with a UserTime in [start, end) into the given array, oldest first.
+	LoadUserEventLogsBetween(
+		ctx context.Context,
+		userID int,
+		start time.Time,
+		end time.Time,
+		out *[]TblUserEventLog,
+	) error
+
+	// Read at most n of the users eventlogs with a UserTime in [start, end) into the given array, newest first.
+	LoadUserEventLogsBetweenN(
+		ctx context.Context,
+		userID int,
+		start time.Time,
+		end time.Time,
+		n int,
+		out *[]TblUserEventLog,
+	) error
+
 	// Delete the eventlog with the given ID.
 	DeleteUserEventLog(ctx context.Context, userID int, eventlogID int) error
 
@@ -181,6 +261,10 @@ type DB interface {
 	// Returns an error or nil.
 	LoadUserEventFoodLogsN(ctx context.Context, userID int, n int, eflogs *[]UserEventFoodLog) error
 
+	// Read the event logs with start <= UserTime < end and their food into the given array, ordered oldest first.
+	// Returns an error or nil.
+	LoadUserEventFoodLogsBetween(ctx context.Context, userID int, start, end time.Time, eflogs *[]UserEventFoodLog) error
+
 	// Update the given eventlog, removing the foodlogs and creating new ones from this struct.
 	// The given struct is updated with proper EventID, FoodID, and UserTime.
 	UpdateUserEventFoodLog(ctx context.Context, eflog *UpdateUserEventLog) error
@@ -192,10 +276,16 @@ type DB interface {
 	// Add the given TblUserFoodLog to the database.
 	// Sets the UserTime, FoodID, and EventID, on the given food
 	// If the EventID is null or 0, loads or creates the event based off the name.
-	// Loads or creates the given food.
+	// Loads or creates the given food, unless the foodlog is of a recipe.
+	// Returns ErrUserDoesNotHaveThisID if the foodlog's recipe does not belong to the user.
 	// Returns the TblUserFoodLog ID or an error.
 	AddUserFoodLogTx(tx *sqlx.Tx, food *TblUserFoodLog) (int, error)
 
+	// FillUserFoodLogs works out the amounts of the foodlogs logged in another unit than the user's food,
+	// like AddUserFoodLogTx does when they are saved, so their totals can be worked out first.
+	// Foodlogs of a recipe, in the unit of their food, or without a food in a unit they convert into are not changed.
+	FillUserFoodLogs(ctx context.Context, userID int, foodlogs []TblUserFoodLog) error
+
 	///
 	/// Bodylog Functions
 	///
@@ -212,6 +302,118 @@ type DB interface {
 	// Delete the given bodylog with the user ID and row ID.
 	DeleteUserBodyLog(ctx context.Context, userID int, bodyLogID int) error
 
+	///
+	/// Medication Functions
+	///
+
+	// Add the given medication and return it's ID, or an error.
+	// Does not edit the given struct.
+	AddUserMedication(ctx context.Context, med *TblUserMedication) (int, error)
+
+	// Read all the users medications into the given array, or returns an error.
+	LoadUserMedications(ctx context.Context, userID int, out *[]TblUserMedication) error
+
+	// Update the given medication, scoped to the owning user.
+	// Returns ErrUserDoesNotHaveThisID if the medication does not belong to the user.
+	UpdateUserMedication(ctx context.Context, med *TblUserMedication) error
+
+	// Delete the medication with the given ID, along with all of it's schedules and logs.
+	// Returns ErrUserDoesNotHaveThisID if the medication does not belong to the user.
+	DeleteUserMedication(ctx context.Context, userID int, medicationID int) error
+
+	// Add the given schedule and return it's ID.
+	// Returns ErrUserDoesNotHaveThisID if the schedule's medication does not belong to the user.
+	AddUserMedicationSchedule(ctx context.Context, userID int, schedule *TblUserMedicationSchedule) (int, error)
+
+	// Read the schedules of all the users medications into the given array.
+	LoadUserMedicationSchedules(ctx context.Context, userID int, out *[]TblUserMedicationSchedule) error
+
+	// Update the given schedule.
+	// Returns ErrUserDoesNotHaveThisID if the schedule or it's medication does not belong to the user.
+	UpdateUserMedicationSchedule(ctx context.Context, userID int, schedule *TblUserMedicationSchedule) error
+
+	// Delete the schedule with the given ID, along with all of it's logs.
+	// Returns ErrUserDoesNotHaveThisID if the schedule does not belong to the user.
+	DeleteUserMedicationSchedule(ctx context.Context, userID int, scheduleID int) error
+
+	// Add the given medication log and return it's ID.
+	// Returns ErrUserDoesNotHaveThisID if the log's schedule does not belong to the user.
+	AddUserMedicationLog(ctx context.Context, userID int, medlog *TblUserMedicationLog) (int, error)
+
+	// Read all the users medication logs into the given array, newest first.
+	LoadUserMedicationLogs(ctx context.Context, userID int, out *[]TblUserMedicationLog) error
+
+	// Read the users medication logs with a TakenTime in [start, end) into the given array, oldest first.
+	LoadUserMedicationLogsBetween(
+		ctx context.Context,
+		userID int,
+		start time.Time,
+		end time.Time,
+		out *[]TblUserMedicationLog,
+	) error
+
+	// Update the given medication log.
+	// Returns ErrUserDoesNotHaveThisID if the log or it's schedule does not belong to the user.
+	UpdateUserMedicationLog(ctx context.Context, userID int, medlog *TblUserMedicationLog) error
+
+	// Delete the medication log with the given ID.
+	// Returns ErrUserDoesNotHaveThisID if the log does not belong to the user.
+	DeleteUserMedicationLog(ctx context.Context, userID int, medlogID int) error
+
+	///
+	/// Insulin Profile Functions
+	///
+
+	// Add the given insulin profile block and return it's ID.
+	AddUserInsulinProfileBlock(ctx context.Context, block *TblUserInsulinProfileBlock) (int, error)
+
+	// Read the users insulin profile blocks into the given array, ordered by StartMinute.
+	LoadUserInsulinProfile(ctx context.Context, userID int, out *[]TblUserInsulinProfileBlock) error
+
+	// Update the given insulin profile block, scoped to the owning user.
+	UpdateUserInsulinProfileBlock(ctx context.Context, block *TblUserInsulinProfileBlock) error
+
+	// Delete the insulin profile block with the given ID.
+	DeleteUserInsulinProfileBlock(ctx context.Context, userID int, blockID int) error
+
+	///
+	/// Glucose Functions
+	///
+
+	// Add the given readings for the user in one transaction.
+	// Readings at the same time as an existing reading are skipped.
+	// Returns how many readings were added.
+	AddUserGlucoseReadings(ctx context.Context, userID int, readings []TblUserGlucose) (int, error)
+
+	// Read the users glucose readings with start <= UserTime < end, oldest first.
+	LoadUserGlucoseBetween(ctx context.Context, userID int, start time.Time, end time.Time, out *[]TblUserGlucose) error
+
+	// Read at most n of the users glucose readings with start <= UserTime < end, newest first.
+	LoadUserGlucoseBetweenN(
+		ctx context.Context,
+		userID int,
+		start time.Time,
+		end time.Time,
+		n int,
+		out *[]TblUserGlucose,
+	) error
+
+	// Delete the glucose reading with the given ID.
+	DeleteUserGlucose(ctx context.Context, userID int, glucoseID int) error
+
+	///
+	/// Nightscout Functions
+	///
+
+	// Set the hash of the users Nightscout API secret, the SHA-256 of its SHA1 hex digest, replacing any previous one.
+	SetUserNightscoutSecret(ctx context.Context, userID int, secretHash string) error
+
+	// Remove the users Nightscout API secret, so Nightscout clients can no longer authenticate as them.
+	DeleteUserNightscoutSecret(ctx context.Context, userID int) error
+
+	// Read the user owning the Nightscout API secret with the given hash into the output, or returns an error.
+	LoadUserByNightscoutSecret(ctx context.Context, secretHash string, user *TblUser) error
+
 	///
 	/// Data Source Functions
 	///
@@ -241,6 +443,14 @@ type DB interface {
 		out *[]TblDataSourceFood,
 	) error
 
+	// Loads the food with the barcode in every data source, the barcode must be from NormalizeBarcode.
+	LoadDataSourceFoodByBarcode(ctx context.Context, barcode string, out *[]TblDataSourceFood) error
+
+	AddDataSourceFoodServing(ctx context.Context, serving *TblDataSourceFoodServing) (int, error)
+
+	// Loads the servings of all the given data source foods.
+	LoadDataSourceFoodServings(ctx context.Context, dataSourceFoodIDs []int, out *[]TblDataSourceFoodServing) error
+
 	///
 	/// User Goals
 	///
@@ -329,8 +539,45 @@ type DB interface {
 	AddUserPhoto(ctx context.Context, photo *TblUserPhoto) (int, error)
 
 	// AddUserEventLogPhotos creates mappings between an event log and a list of photo IDs.
-	AddUserEventLogPhotos(ctx context.Context, eventlogID int, photoIDs []int) error
+	// Returns ErrUserDoesNotHaveThisID if the eventlog or any of the photos do not belong to the user.
+	AddUserEventLogPhotos(ctx context.Context, userID int, eventlogID int, photoIDs []int) error
+
+	// AddUserEventLogPhotosTx is AddUserEventLogPhotos using the given transaction.
+	// Every path that links photos to an eventlog should go through this so ownership is always checked.
+	AddUserEventLogPhotosTx(tx *sqlx.Tx, userID int, eventlogID int, photoIDs []int) error
+
+	// LoadUserPhoto reads the photo with the given ID into the given struct.
+	// Returns sql.ErrNoRows if the photo does not exist or belongs to another user.
+	LoadUserPhoto(ctx context.Context, userID int, photoID int, out *TblUserPhoto) error
 
+	// DeleteUserPhoto removes the photo, and unlinks it from every eventlog.
+	// Returns ErrUserDoesNotHaveThisID if the photo does not exist or belongs to another user.
+	DeleteUserPhoto(ctx context.Context, userID int, photoID int) error
+
+	// DeleteUserEventLogPhoto unlinks the photo from the eventlog, without deleting the photo.
+	// Returns ErrUserDoesNotHaveThisID if the photo is not linked to one of the user's eventlogs.
+	DeleteUserEventLogPhoto(ctx context.Context, userID int, eventlogID int, photoID int) error
+
+	// DeleteUnlinkedUserPhotos removes every photo created before the given time that is not linked to any eventlog.
+	// Returns the deleted photos without their Data, so the caller can clean up storage outside the database.
+	DeleteUnlinkedUserPhotos(ctx context.Context, createdBefore time.Time) ([]TblUserPhoto, error)
+
+	// LoadPhotoByID reads the photo with the given ID regardless of the user, for maintenance commands.
+	LoadPhotoByID(ctx context.Context, photoID int, out *TblUserPhoto) error
+
+	// LoadPhotoIDsByStorage reads the ID of every photo kept in the given storage.
+	LoadPhotoIDsByStorage(ctx context.Context, storage string, out *[]int) error
+
+	// UpdatePhotoStorage sets the Storage, Hash, Data, and Thumbnail of the photo with the given ID.
+	UpdatePhotoStorage(ctx context.Context, photo *TblUserPhoto) error
+
+	// CountPhotosByHash returns how many photos in the given storage have the given hash.
+	CountPhotosByHash(ctx context.Context, storage string, hash string) (int, error)
+
+	// LoadUserTimeData sums how long the timespans with each tag lasted within the start and end time.
+	// Spans are clipped to the range and split across every bucket they overlap, see SumTimespanDurations.
+	// Buckets follow the user's timezone and day offset, see TruncateToBucket.
+	// Returns ErrTooManyBuckets when the range has more than MaxBuckets buckets.
 	LoadUserTimeData(
 		ctx context.Context,
 		userID int,
@@ -338,8 +585,26 @@ type DB interface {
 		endTime time.Time,
 		tags []string,
 		groupby GroupBy,
+		loc *time.Location,
+		shift time.Duration,
 		out *[]TimespanTagDurationPoint,
 	) error
+
+	// LoadUserChartData aggregates each column over buckets of the given size between the start and end time.
+	// Buckets follow the user's timezone and day offset, see TruncateToBucket.
+	// Points are ordered by the given columns, then by bucket. Buckets without any data are left out.
+	LoadUserChartData(
+		ctx context.Context,
+		userID int,
+		cols []ChartColumn,
+		aggregation AggregationFunc,
+		groupby GroupBy,
+		loc *time.Location,
+		shift time.Duration,
+		startTime time.Time,
+		endTime time.Time,
+		out *[]ChartPoint,
+	) error
 }
 
 type SQLxDB struct {
@@ -373,6 +638,28 @@ func (db *SQLxDB) CountOneTx(tx *sqlx.Tx, query string, arg ...any) (bool, error
 	return rowCount == 1, nil
 }
 
+// ExpectRowsAffected returns ErrUserDoesNotHaveThisID if the update or delete of a user's row changed nothing,
+// because the ID does not exist or belongs to another user.
+// Takes the results of ExecContext directly.
+func (db *SQLxDB) ExpectRowsAffected(res sql.Result, err error) error {
+
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrUserDoesNotHaveThisID
+	}
+
+	return nil
+}
+
 func (db *SQLxDB) InsertReturningID(ctx context.Context, query string, arg ...any) (int, error) {
 
 	rows, err := db.QueryContext(ctx, query, arg...)
@@ -502,7 +789,13 @@ func (db *SQLxDB) WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error
 	return err
 }
 
-func (db *SQLxDB) ExportQueryRowsAsCsv(ctx context.Context, query string, w io.Writer) error {
+// ExportTimezoneCol is the column export queries select the timezone of each row's user into.
+const ExportTimezoneCol = "EXPORT_TIMEZONE"
+
+// ExportTimeLayout is the layout times are exported in, which keeps the offset of the timezone.
+const ExportTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+func (db *SQLxDB) ExportQueryRowsAsCsv(ctx context.Context, query string, tz Timezone, w io.Writer) error {
 
 	rows, err := db.QueryxContext(ctx, query)
 
@@ -511,10 +804,13 @@ func (db *SQLxDB) ExportQueryRowsAsCsv(ctx context.Context, query string, w io.W
 	}
 	defer rows.Close()
 
-	return db.WriteRowsAsCsv(rows, w)
+	return db.WriteRowsAsCsv(rows, tz, w)
 }
 
-func (db *SQLxDB) WriteRowsAsCsv(rows *sqlx.Rows, w io.Writer) error {
+// WriteRowsAsCsv writes the rows as csv, with times in the timezone from the ExportTimezoneCol column.
+// If tz is set it is used for every row instead, and rows without the column are written in UTC.
+// The ExportTimezoneCol column is written as the timezone that was used.
+func (db *SQLxDB) WriteRowsAsCsv(rows *sqlx.Rows, tz Timezone, w io.Writer) error {
 
 	csvWriter := csv.NewWriter(w)
 	defer csvWriter.Flush()
@@ -529,6 +825,15 @@ func (db *SQLxDB) WriteRowsAsCsv(rows *sqlx.Rows, w io.Writer) error {
 		return err
 	}
 
+	tzCol := ""
+
+	for _, col := range cols {
+		if strings.EqualFold(col, ExportTimezoneCol) {
+			tzCol = col
+		}
+	}
+
+	zones := map[string]Timezone{}
 	csvRow := make([]string, len(cols))
 
 	for rows.Next() {
@@ -539,8 +844,41 @@ func (db *SQLxDB) WriteRowsAsCsv(rows *sqlx.Rows, w io.Writer) error {
 			return err
 		}
 
+		zone := tz
+
+		if zone.Name == "" && tzCol == "" {
+			zone = UTC
+		}
+
+		if zone.Name == "" {
+
+			name := ValueToString(row[tzCol])
+
+			var ok bool
+
+			if zone, ok = zones[name]; !ok {
+
+				if zone, err = NewTimezone(name); err != nil || name == "" {
+					zone = UTC
+				}
+
+				zones[name] = zone
+			}
+		}
+
 		for i, col := range cols {
-			csvRow[i] = ValueToString(row[col])
+
+			switch v := row[col].(type) {
+			case time.Time:
+				csvRow[i] = v.In(zone.Loc()).Format(ExportTimeLayout)
+
+			default:
+				if col == tzCol {
+					csvRow[i] = zone.Name
+				} else {
+					csvRow[i] = ValueToString(v)
+				}
+			}
 		}
 
 		if err := csvWriter.Write(csvRow); err != nil {
@@ -87,6 +87,8 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 			SessionExpireTimeSeconds: 500,
 			TimeFormat:               "auto",
 			DateFormat:               "auto",
+			Timezone:                 database.UTC,
+			GlucoseUnit:              database.GlucoseUnitMmol,
 		}
 
 		// test the username is not taken
@@ -124,6 +126,9 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 		loaded.SessionExpireTimeSeconds = 100
 		loaded.TimeFormat = "auto2"
 		loaded.DateFormat = "auto2"
+		loaded.Timezone, err = database.NewTimezone("America/Toronto")
+		require.NoError(t, err)
+		loaded.GlucoseUnit = database.GlucoseUnitMgdl
 		require.NoError(t, db.UpdateUser(ctx, &loaded))
 
 		// check the new username was taken
@@ -385,7 +390,7 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 			DataSourceID: dsID,
 			Name:         "Banana",
 		}
-		_, err = db.AddDataSourceFood(ctx, food)
+		foodID, err := db.AddDataSourceFood(ctx, food)
 		require.NoError(t, err)
 
 		var results []database.TblDataSourceFood
@@ -393,7 +398,11 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 			db.LoadDataSourceFoodBySimilarName(ctx, dsID, "Ban", &results),
 		)
 
-		assert.NotEmpty(t, results)
+		require.NotEmpty(t, results)
+
+		// Servings are saved under the returned ID.
+		assert.NotZero(t, foodID)
+		assert.Equal(t, foodID, results[0].ID)
 	})
 
 	t.Run("exports_do_not_error", func(t *testing.T) {
@@ -406,7 +415,7 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 
 		var buf bytes.Buffer
 
-		exports := []func(context.Context, io.Writer) error{
+		exports := []func(context.Context, database.Timezone, io.Writer) error{
 			db.ExportUserCSV,
 			db.ExportUserEventsCSV,
 			db.ExportUserEventLogsCSV,
@@ -418,11 +427,43 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 
 		for _, fn := range exports {
 			buf.Reset()
-			assert.NoError(t, fn(ctx, &buf))
+			assert.NoError(t, fn(ctx, database.Timezone{}, &buf))
 			assert.NotEmpty(t, buf)
 		}
 	})
 
+	t.Run("exports_use_user_timezone", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		toronto, err := database.NewTimezone("America/Toronto")
+		require.NoError(t, err)
+
+		userID, err := db.AddUser(ctx, &database.TblUser{Name: "alice", Timezone: toronto})
+		require.NoError(t, err)
+
+		_, err = db.AddUserGlucoseReadings(ctx, userID, []database.TblUserGlucose{{
+			UserTime: database.TimeMillis(time.Date(2026, 1, 15, 2, 30, 0, 0, time.UTC)),
+			Glucose:  6.2,
+		}})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+
+		require.NoError(t, db.ExportUserGlucoseCSV(ctx, database.Timezone{}, &buf))
+		assert.Contains(t, buf.String(), "2026-01-14T21:30:00.000-05:00")
+		assert.Contains(t, buf.String(), "America/Toronto")
+
+		buf.Reset()
+		require.NoError(t, db.ExportUserGlucoseCSV(ctx, database.UTC, &buf))
+		assert.Contains(t, buf.String(), "2026-01-15T02:30:00.000Z")
+		assert.NotContains(t, buf.String(), "America/Toronto")
+	})
+
 	t.Run("with_tx_commits", func(t *testing.T) {
 
 		lock.Lock()
@@ -1067,6 +1108,80 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 		assert.Len(t, eflogs, 2)
 	})
 
+	t.Run("LoadUserEventFoodLogsBetween", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Lunch"})
+		require.NoError(t, err)
+
+		day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+		for _, hour := range []int{6, 12, 8, 18} {
+			_, err = db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+				UserID:   userID,
+				EventID:  eventID,
+				Event:    "Lunch",
+				UserTime: database.TimeMillis(day.Add(time.Duration(hour) * time.Hour)),
+			}, []database.TblUserFoodLog{{Name: "Rice", Portion: 1, Unit: "g", Carb: float64(hour)}})
+			require.NoError(t, err)
+		}
+
+		var eflogs []database.UserEventFoodLog
+		require.NoError(t, db.LoadUserEventFoodLogsBetween(
+			ctx, userID, day.Add(8*time.Hour), day.Add(18*time.Hour), &eflogs,
+		))
+
+		// The range includes its start and excludes its end, oldest first.
+		require.Len(t, eflogs, 2)
+		assert.InDelta(t, 8, eflogs[0].TotalCarb, 1e-9)
+		assert.InDelta(t, 12, eflogs[1].TotalCarb, 1e-9)
+		require.Len(t, eflogs[0].Foodlogs, 1)
+		assert.Equal(t, "Rice", eflogs[0].Foodlogs[0].Name)
+		assert.NotNil(t, eflogs[0].PhotoIDs)
+	})
+
+	t.Run("UpdateUserEventFoodLog_net_carbs", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Breakfast"})
+		require.NoError(t, err)
+
+		eventlog := &database.TblUserEventLog{UserID: userID, EventID: eventID, Event: "Breakfast"}
+		logID, err := db.AddUserEventLogWith(ctx, eventlog, []database.TblUserFoodLog{
+			{UserID: userID, Name: "Toast", Unit: "slice", Portion: 2, Carb: 30, Fibre: 4},
+		})
+		require.NoError(t, err)
+
+		// The net carbs are for every foodlog, not only the last one.
+		eventlog.ID = logID
+		require.NoError(t, db.UpdateUserEventFoodLog(ctx, &database.UpdateUserEventLog{
+			Eventlog: *eventlog,
+			Foodlogs: []database.TblUserFoodLog{
+				{UserID: userID, Name: "Toast", Unit: "slice", Portion: 2, Carb: 30, Fibre: 4},
+				{UserID: userID, Name: "Jam", Unit: "tbsp", Portion: 1, Carb: 13},
+			},
+		}))
+
+		var eflog database.UserEventFoodLog
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID, logID, &eflog))
+		require.Len(t, eflog.Foodlogs, 2)
+		assert.InDelta(t, 39, eflog.Eventlog.NetCarbs, 1e-9)
+	})
+
 	t.Run("UpdateUserEventFoodLog", func(t *testing.T) {
 
 		lock.Lock()
@@ -1114,6 +1229,54 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 		assert.Equal(t, "Milk", eflog.Foodlogs[0].Name)
 	})
 
+	t.Run("eventlog_extended_bolus", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Pizza"})
+		require.NoError(t, err)
+
+		pizza := database.TblUserFoodLog{UserID: userID, Name: "Pizza", Portion: 1, Carb: 60, Fibre: 4, Fat: 20, Protein: 25}
+		eventlog := &database.TblUserEventLog{
+			UserID:                           userID,
+			EventID:                          eventID,
+			Event:                            "Pizza",
+			RecommendedExtendedInsulinAmount: 2.8,
+			RecommendedExtendedMinutes:       240,
+			ActualExtendedInsulinTaken:       2.5,
+			ActualExtendedMinutes:            180,
+		}
+		logID, err := db.AddUserEventLogWith(ctx, eventlog, []database.TblUserFoodLog{pizza})
+		require.NoError(t, err)
+
+		var eflog database.UserEventFoodLog
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID, logID, &eflog))
+		assert.InDelta(t, 2.8, eflog.Eventlog.FatProteinUnits, 1e-9)
+		assert.InDelta(t, 2.8, eflog.Eventlog.RecommendedExtendedInsulinAmount, 1e-9)
+		assert.Equal(t, 240, eflog.Eventlog.RecommendedExtendedMinutes)
+		assert.InDelta(t, 2.5, eflog.Eventlog.ActualExtendedInsulinTaken, 1e-9)
+		assert.Equal(t, 180, eflog.Eventlog.ActualExtendedMinutes)
+
+		// The units and net carbs are the sum of every food.
+		eventlog.ID = logID
+		eventlog.ActualExtendedMinutes = 240
+		require.NoError(t, db.UpdateUserEventFoodLog(ctx, &database.UpdateUserEventLog{
+			Eventlog: *eventlog,
+			Foodlogs: []database.TblUserFoodLog{pizza, {UserID: userID, Name: "Cheese", Portion: 1, Fat: 10, Protein: 2}},
+		}))
+
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID, logID, &eflog))
+		assert.InDelta(t, 2.8+0.98, eflog.Eventlog.FatProteinUnits, 1e-9)
+		assert.InDelta(t, 56, eflog.Eventlog.NetCarbs, 1e-9)
+		assert.Equal(t, 240, eflog.Eventlog.ActualExtendedMinutes)
+	})
+
 	t.Run("bodylog_crud", func(t *testing.T) {
 
 		lock.Lock()
@@ -1482,6 +1645,62 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 		assert.Equal(t, "food", tagged[0].Tags[0].Namespace)
 	})
 
+	t.Run("LoadUserInsulinEventLogsBetween", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Lunch"})
+		require.NoError(t, err)
+
+		day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+		for _, el := range []struct {
+			at       time.Time
+			insulin  float64
+			extended float64
+		}{
+			{day.Add(7 * time.Hour), 2, 0},
+			{day.Add(8 * time.Hour), 0, 0}, // no insulin
+			{day.Add(9 * time.Hour), 4, 0},
+			{day.Add(10 * time.Hour), 0, 3}, // only an extended bolus
+			{day.Add(12 * time.Hour), 6, 0}, // at the end, left out
+		} {
+			_, err := db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+				UserID:                     userID,
+				EventID:                    eventID,
+				Event:                      "Lunch",
+				UserTime:                   database.TimeMillis(el.at),
+				ActualInsulinTaken:         el.insulin,
+				ActualExtendedInsulinTaken: el.extended,
+				ActualExtendedMinutes:      120,
+			}, nil)
+			require.NoError(t, err)
+		}
+
+		var eventlogs []database.TblUserEventLog
+		require.NoError(t, db.LoadUserInsulinEventLogsBetween(
+			ctx, userID, day.Add(7*time.Hour), day.Add(12*time.Hour), &eventlogs,
+		))
+
+		require.Len(t, eventlogs, 3)
+		assert.InDelta(t, 2, eventlogs[0].ActualInsulinTaken, 1e-9)
+		assert.InDelta(t, 4, eventlogs[1].ActualInsulinTaken, 1e-9)
+		assert.InDelta(t, 3, eventlogs[2].ActualExtendedInsulinTaken, 1e-9)
+
+		// Other users can't see them.
+		eventlogs = nil
+		require.NoError(t, db.LoadUserInsulinEventLogsBetween(
+			ctx, userID+1, day, day.AddDate(0, 0, 1), &eventlogs,
+		))
+		assert.Empty(t, eventlogs)
+	})
+
 	t.Run("LoadUserTimeData", func(t *testing.T) {
 
 		lock.Lock()
@@ -1524,6 +1743,8 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 			stop.Add(48*time.Hour),
 			[]string{"activity:sleep"},
 			database.GroupByDay,
+			time.UTC,
+			0,
 			&points,
 		))
 
@@ -1535,6 +1756,83 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 		assert.True(t, points[0].Bucket.Time().Equal(expectedBucket))
 	})
 
+	t.Run("LoadUserTimeData_split_spans", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		// Monday 2024-01-15.
+		monday := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+		for _, span := range []struct {
+			start time.Time
+			stop  time.Time
+		}{
+			// Started before the window, only the last hour counts.
+			{monday.Add(-3 * time.Hour), monday.Add(time.Hour)},
+			// Monday 23:00 to Tuesday 07:00.
+			{monday.Add(23 * time.Hour), monday.Add(31 * time.Hour)},
+			// Sunday 22:00 to the next Monday 02:00, runs past the end of the window.
+			{monday.AddDate(0, 0, 6).Add(22 * time.Hour), monday.AddDate(0, 0, 7).Add(2 * time.Hour)},
+		} {
+			_, err := db.AddUserTimespan(ctx, &database.TblUserTimespan{
+				UserID:    userID,
+				StartTime: database.TimeMillis(span.start),
+				StopTime:  database.TimeMillis(span.stop),
+			}, []database.TblUserTag{
+				{UserID: userID, Namespace: "activity", Name: "sleep"},
+			})
+			require.NoError(t, err)
+		}
+
+		start := monday
+		end := monday.AddDate(0, 0, 7).Add(time.Hour)
+
+		var points []database.TimespanTagDurationPoint
+		require.NoError(t, db.LoadUserTimeData(
+			ctx, userID, start, end, []string{"activity:sleep"}, database.GroupByDay, time.UTC, 0, &points,
+		))
+
+		got := make(map[time.Time]int64)
+		for _, p := range points {
+			got[p.Bucket.Time().UTC()] = p.DurationMilli
+		}
+
+		hours := func(n int) int64 { return (time.Duration(n) * time.Hour).Milliseconds() }
+
+		assert.Equal(t, map[time.Time]int64{
+			monday:                  hours(2),
+			monday.AddDate(0, 0, 1): hours(7),
+			monday.AddDate(0, 0, 6): hours(2),
+			monday.AddDate(0, 0, 7): hours(1),
+		}, got)
+
+		points = nil
+		require.NoError(t, db.LoadUserTimeData(
+			ctx, userID, start, end, []string{"activity:sleep"}, database.GroupByWeek, time.UTC, 0, &points,
+		))
+
+		require.Len(t, points, 2)
+		assert.True(t, points[0].Bucket.Time().Equal(monday))
+		assert.Equal(t, hours(11), points[0].DurationMilli)
+		assert.True(t, points[1].Bucket.Time().Equal(monday.AddDate(0, 0, 7)))
+		assert.Equal(t, hours(1), points[1].DurationMilli)
+
+		points = nil
+		require.NoError(t, db.LoadUserTimeData(
+			ctx, userID, start, end, []string{"activity:sleep"}, database.GroupByOne, time.UTC, 0, &points,
+		))
+
+		require.Len(t, points, 1)
+		assert.True(t, points[0].Bucket.Time().Equal(start))
+		assert.Equal(t, hours(12), points[0].DurationMilli)
+	})
+
 	t.Run("LoadUserTimeData_no_tags", func(t *testing.T) {
 
 		lock.Lock()
@@ -1553,13 +1851,15 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 			time.Now().Add(time.Hour),
 			nil,
 			database.GroupByDay,
+			time.UTC,
+			0,
 			&points,
 		))
 
 		assert.Empty(t, points)
 	})
 
-	t.Run("SetUserTimespanTags", func(t *testing.T) {
+	t.Run("LoadUserTimeData_too_many_buckets", func(t *testing.T) {
 
 		lock.Lock()
 		t.Cleanup(lock.Unlock)
@@ -1569,28 +1869,24 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 
 		userID := getTestUser(t, db)
 
-		note := "timespan"
-		tsID, err := db.AddUserTimespan(ctx, &database.TblUserTimespan{
-			UserID:    userID,
-			StartTime: database.TimeMillis(time.Now()),
-			StopTime:  database.TimeMillis(time.Now().Add(time.Hour)),
-			Note:      &note,
-		}, nil)
-		require.NoError(t, err)
-
-		newTags := []database.TblUserTag{
-			{UserID: userID, Namespace: "food", Name: "Egg"},
-			{UserID: userID, Namespace: "food", Name: "Milk"},
-		}
-		require.NoError(t, db.SetUserTimespanTags(ctx, userID, tsID, newTags))
+		var points []database.TimespanTagDurationPoint
+		err := db.LoadUserTimeData(
+			ctx,
+			userID,
+			time.Now().AddDate(-1, 0, 0),
+			time.Now(),
+			[]string{"work:office"},
+			database.GroupBySecond,
+			time.UTC,
+			0,
+			&points,
+		)
 
-		var tagged []database.TaggedTimespan
-		require.NoError(t, db.LoadUserTimespansWithTags(ctx, userID, &tagged))
-		require.Len(t, tagged, 1)
-		assert.Len(t, tagged[0].Tags, 2)
+		require.ErrorIs(t, err, database.ErrTooManyBuckets)
+		assert.Empty(t, points)
 	})
 
-	t.Run("dashboard_crud", func(t *testing.T) {
+	t.Run("LoadUserChartData", func(t *testing.T) {
 
 		lock.Lock()
 		t.Cleanup(lock.Unlock)
@@ -1600,60 +1896,134 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 
 		userID := getTestUser(t, db)
 
-		// Load on empty database returns an empty slice without error.
-		var dashboards []database.TblUserDashboard
-		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
-		assert.Empty(t, dashboards)
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Lunch"})
+		require.NoError(t, err)
 
-		// Add a dashboard.
-		d := &database.TblUserDashboard{
-			UserID: userID,
-			Name:   "Default",
-			Data:   `[{"id":"card-1"}]`,
+		day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+		// Two meals on the first day, one on the next.
+		for _, meal := range []struct {
+			at      time.Time
+			carb    float64
+			glucose float64
+			insulin float64
+		}{
+			{day.Add(8 * time.Hour), 30, 6, 3},
+			{day.Add(12 * time.Hour), 50, 9, 5},
+			{day.Add(32 * time.Hour), 20, 0, 2},
+		} {
+			_, err := db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+				UserID:             userID,
+				EventID:            eventID,
+				Event:              "Lunch",
+				UserTime:           database.TimeMillis(meal.at),
+				BloodGlucose:       meal.glucose,
+				ActualInsulinTaken: meal.insulin,
+			}, []database.TblUserFoodLog{
+				{Name: "Rice", Portion: 1, Carb: meal.carb, Fibre: 5},
+			})
+			require.NoError(t, err)
 		}
-		id, err := db.AddUserDashboard(ctx, d)
+
+		_, err = db.AddUserBodyLogs(ctx, &database.TblUserBodyLog{
+			UserID:   userID,
+			UserTime: database.TimeMillis(day.Add(7 * time.Hour)),
+			WeightKg: 80,
+		})
 		require.NoError(t, err)
-		require.NotZero(t, id)
-		d.ID = id
 
-		// Load it back.
-		dashboards = dashboards[:0]
-		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
-		require.Len(t, dashboards, 1)
-		assert.Equal(t, id, dashboards[0].ID)
-		assert.Equal(t, userID, dashboards[0].UserID)
-		assert.Equal(t, "Default", dashboards[0].Name)
-		assert.Equal(t, `[{"id":"card-1"}]`, dashboards[0].Data)
+		// Outside of the range.
+		_, err = db.AddUserBodyLogs(ctx, &database.TblUserBodyLog{
+			UserID:   userID,
+			UserTime: database.TimeMillis(day.AddDate(0, 0, -10)),
+			WeightKg: 90,
+		})
+		require.NoError(t, err)
 
-		// Update name and data.
-		d.Name = "My Board"
-		d.Data = `[{"id":"card-2"}]`
-		require.NoError(t, db.UpdateUserDashboard(ctx, d))
+		// Another user's data is never included.
+		otherID := getTestUser2(t, db)
+		_, err = db.AddUserBodyLogs(ctx, &database.TblUserBodyLog{
+			UserID:   otherID,
+			UserTime: database.TimeMillis(day.Add(9 * time.Hour)),
+			WeightKg: 50,
+		})
+		require.NoError(t, err)
 
-		dashboards = dashboards[:0]
-		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
-		require.Len(t, dashboards, 1)
-		assert.Equal(t, "My Board", dashboards[0].Name)
-		assert.Equal(t, `[{"id":"card-2"}]`, dashboards[0].Data)
+		start := day.AddDate(0, 0, -1)
+		end := day.AddDate(0, 0, 3)
 
-		// Update with a wrong user_id must not affect the row.
-		bogus := &database.TblUserDashboard{ID: id, UserID: userID + 99, Name: "Hijacked", Data: "[]"}
-		require.NoError(t, db.UpdateUserDashboard(ctx, bogus))
+		var points []database.ChartPoint
+		require.NoError(t, db.LoadUserChartData(
+			ctx,
+			userID,
+			[]database.ChartColumn{database.ChartColumnNetCarbs, database.ChartColumnBodyWeightKg},
+			database.AggregationSum,
+			database.GroupByDay,
+			time.UTC,
+			0,
+			start,
+			end,
+			&points,
+		))
 
-		dashboards = dashboards[:0]
-		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
-		require.Len(t, dashboards, 1)
-		assert.Equal(t, "My Board", dashboards[0].Name, "row must be unchanged after wrong-user update")
+		require.Len(t, points, 3)
 
-		// Delete it.
-		require.NoError(t, db.DeleteUserDashboard(ctx, userID, id))
+		assert.Equal(t, database.ChartColumnNetCarbs, points[0].Column)
+		assert.True(t, points[0].Bucket.Time().Equal(day))
+		assert.InDelta(t, 70, points[0].Value, 0.001)
 
-		dashboards = dashboards[:0]
-		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
-		assert.Empty(t, dashboards)
+		assert.Equal(t, database.ChartColumnNetCarbs, points[1].Column)
+		assert.True(t, points[1].Bucket.Time().Equal(day.AddDate(0, 0, 1)))
+		assert.InDelta(t, 15, points[1].Value, 0.001)
+
+		assert.Equal(t, database.ChartColumnBodyWeightKg, points[2].Column)
+		assert.True(t, points[2].Bucket.Time().Equal(day))
+		assert.InDelta(t, 80, points[2].Value, 0.001)
+
+		// Glucose readings of 0 are not readings.
+		points = nil
+		require.NoError(t, db.LoadUserChartData(
+			ctx,
+			userID,
+			[]database.ChartColumn{database.ChartColumnEventBloodSugar, database.ChartColumnEventInsulin},
+			database.AggregationAvg,
+			database.GroupByOne,
+			time.UTC,
+			0,
+			start,
+			end,
+			&points,
+		))
+
+		require.Len(t, points, 2)
+		assert.True(t, points[0].Bucket.Time().Equal(start))
+		assert.InDelta(t, 7.5, points[0].Value, 0.001)
+		assert.InDelta(t, 10.0/3, points[1].Value, 0.001)
+
+		for _, agg := range []database.AggregationFunc{database.AggregationMin, database.AggregationMax} {
+
+			points = nil
+			require.NoError(t, db.LoadUserChartData(
+				ctx, userID, []database.ChartColumn{database.ChartColumnCarbs}, agg, database.GroupByOne, time.UTC, 0, start, end, &points,
+			))
+			require.Len(t, points, 1)
+
+			if agg == database.AggregationMin {
+				assert.InDelta(t, 20, points[0].Value, 0.001)
+			} else {
+				assert.InDelta(t, 50, points[0].Value, 0.001)
+			}
+		}
+
+		require.ErrorIs(t, db.LoadUserChartData(
+			ctx, userID, []database.ChartColumn{"DATA"}, database.AggregationSum, database.GroupByDay, time.UTC, 0, start, end, &points,
+		), database.ErrInvalidChartColumn)
+		require.ErrorIs(t, db.LoadUserChartData(
+			ctx, userID, nil, "MEDIAN", database.GroupByDay, time.UTC, 0, start, end, &points,
+		), database.ErrInvalidAggregation)
 	})
 
-	t.Run("dashboard_multiple_per_user", func(t *testing.T) {
+	t.Run("nutrients", func(t *testing.T) {
 
 		lock.Lock()
 		t.Cleanup(lock.Unlock)
@@ -1662,16 +2032,671 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 		db := newTestDB(t)
 
 		userID := getTestUser(t, db)
-		userID2 := getTestUser2(t, db)
 
-		for _, name := range []string{"Board A", "Board B"} {
-			_, err := db.AddUserDashboard(ctx, &database.TblUserDashboard{
-				UserID: userID,
-				Name:   name,
-				Data:   "[]",
-			})
-			require.NoError(t, err)
-		}
+		unit := "g"
+		_, err := db.AddUserFood(ctx, &database.TblUserFood{
+			UserID:    userID,
+			Name:      "Crackers",
+			Unit:      unit,
+			Portion:   1,
+			Carb:      0.7,
+			Nutrients: database.Nutrients{database.NutrientSodium: 4},
+		})
+		require.NoError(t, err)
+
+		var foods []database.TblUserFood
+		require.NoError(t, db.LoadUserFoods(ctx, userID, &foods))
+		require.Len(t, foods, 1)
+		assert.Equal(t, database.Nutrients{database.NutrientSodium: 4}, foods[0].Nutrients)
+
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Snack"})
+		require.NoError(t, err)
+
+		day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+		// The crackers were logged without nutrients, they come from the food.
+		// The apple has none, it must not break the sums.
+		_, err = db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+			UserID:   userID,
+			EventID:  eventID,
+			Event:    "Snack",
+			UserTime: database.TimeMillis(day.Add(10 * time.Hour)),
+		}, []database.TblUserFoodLog{
+			{Name: "Crackers", Unit: unit, Portion: 100, Carb: 70},
+			{Name: "Apple", Unit: "g", Portion: 100, Carb: 12},
+		})
+		require.NoError(t, err)
+
+		var eflogs []database.UserEventFoodLog
+		require.NoError(t, db.LoadUserEventFoodLogs(ctx, userID, &eflogs))
+		require.Len(t, eflogs, 1)
+		require.Len(t, eflogs[0].Foodlogs, 2)
+
+		for _, f := range eflogs[0].Foodlogs {
+			if f.Name == "Crackers" {
+				assert.InDelta(t, 400, f.Nutrients[database.NutrientSodium], 0.001)
+			} else {
+				assert.Nil(t, f.Nutrients)
+			}
+		}
+
+		goal := database.TblUserGoal{
+			UserID:          userID,
+			Name:            "Sodium",
+			TargetValue:     2300,
+			TargetCol:       "NUTRIENT_SODIUM",
+			AggregationType: string(database.AggregationSum),
+			ValueComparison: string(database.ComparisonLessThan),
+			TimeExpr:        "DAILY",
+		}
+		var progress database.UserGoalProgress
+		require.NoError(t, db.LoadUserGoalProgress(ctx, day.Add(20*time.Hour), 0, &goal, &progress))
+		assert.InDelta(t, 400, progress.CurrentValue, 0.001)
+
+		var points []database.ChartPoint
+		require.NoError(t, db.LoadUserChartData(
+			ctx,
+			userID,
+			[]database.ChartColumn{"NUTRIENT_SODIUM", "NUTRIENT_CAFFEINE"},
+			database.AggregationSum,
+			database.GroupByDay,
+			time.UTC,
+			0,
+			day.AddDate(0, 0, -1),
+			day.AddDate(0, 0, 1),
+			&points,
+		))
+
+		require.Len(t, points, 1)
+		assert.Equal(t, database.ChartColumn("NUTRIENT_SODIUM"), points[0].Column)
+		assert.InDelta(t, 400, points[0].Value, 0.001)
+
+		// A food created from a foodlog keeps the nutrients per unit.
+		_, err = db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+			UserID:   userID,
+			EventID:  eventID,
+			Event:    "Snack",
+			UserTime: database.TimeMillis(day.AddDate(0, 0, 2)),
+		}, []database.TblUserFoodLog{
+			{Name: "Pretzels", Unit: "g", Portion: 50, Carb: 40, Nutrients: database.Nutrients{database.NutrientSodium: 600}},
+		})
+		require.NoError(t, err)
+
+		foods = nil
+		require.NoError(t, db.LoadUserFoods(ctx, userID, &foods))
+		require.Len(t, foods, 3)
+		assert.Equal(t, "Pretzels", foods[2].Name)
+		assert.InDelta(t, 12, foods[2].Nutrients[database.NutrientSodium], 0.001)
+	})
+
+	t.Run("recipe_crud", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+		otherID := getTestUser2(t, db)
+
+		riceID, err := db.AddUserFood(ctx, &database.TblUserFood{
+			UserID: userID, Name: "Rice", Unit: "g", Portion: 1, Carb: 0.28,
+		})
+		require.NoError(t, err)
+
+		otherFoodID, err := db.AddUserFood(ctx, &database.TblUserFood{
+			UserID: otherID, Name: "Rice", Unit: "g", Portion: 1, Carb: 0.3,
+		})
+		require.NoError(t, err)
+
+		dsID, err := db.AddDataSource(ctx, &database.TblDataSource{Name: "USDA"})
+		require.NoError(t, err)
+
+		_, err = db.AddDataSourceFood(ctx, &database.TblDataSourceFood{
+			DataSourceID: dsID, Name: "Chicken", Unit: "g", Portion: 100, Protein: 30,
+		})
+		require.NoError(t, err)
+
+		var chicken []database.TblDataSourceFood
+		require.NoError(t, db.LoadDataSourceFoodBySimilarName(ctx, dsID, "Chicken", &chicken))
+		require.Len(t, chicken, 1)
+		chickenID := chicken[0].ID
+
+		recipe := database.TblUserRecipe{UserID: userID, Name: "Chicken Rice", Unit: "bowl", Yield: 4}
+		recipeID, err := db.AddUserRecipe(ctx, &recipe, []database.TblUserRecipeIngredient{
+			{FoodID: &riceID, Portion: 400},
+			{DataSourceFoodID: &chickenID, Portion: 600},
+		})
+		require.NoError(t, err)
+
+		// Another user's food can't be used.
+		_, err = db.AddUserRecipe(ctx, &recipe, []database.TblUserRecipeIngredient{{FoodID: &otherFoodID, Portion: 1}})
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+
+		var recipes []database.UserRecipe
+		require.NoError(t, db.LoadUserRecipes(ctx, userID, &recipes))
+		require.Len(t, recipes, 1)
+		assert.Equal(t, recipeID, recipes[0].Recipe.ID)
+		require.Len(t, recipes[0].Ingredients, 2)
+		assert.InDelta(t, 28, recipes[0].Serving.Carb, 0.001)
+		assert.InDelta(t, 45, recipes[0].Serving.Protein, 0.001)
+
+		// Editing an ingredient changes the recipe.
+		require.NoError(t, db.UpdateUserFood(ctx, &database.TblUserFood{
+			ID: riceID, UserID: userID, Name: "Rice", Unit: "g", Portion: 1, Carb: 0.3,
+		}))
+
+		var loaded database.UserRecipe
+		require.NoError(t, db.LoadUserRecipe(ctx, userID, recipeID, &loaded))
+		assert.InDelta(t, 30, loaded.Serving.Carb, 0.001)
+
+		require.ErrorIs(t, db.LoadUserRecipe(ctx, otherID, recipeID, &loaded), sql.ErrNoRows)
+
+		// Logging a recipe keeps its nutrition as it was.
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Dinner"})
+		require.NoError(t, err)
+
+		foodlog := database.TblUserFoodLog{Portion: 2}
+		loaded.FillFoodLog(&foodlog)
+
+		_, err = db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+			UserID:   userID,
+			EventID:  eventID,
+			Event:    "Dinner",
+			UserTime: database.TimeMillis(time.Now()),
+		}, []database.TblUserFoodLog{foodlog})
+		require.NoError(t, err)
+
+		// An ingredient's food can't be deleted, the recipe would lose its nutrition.
+		require.ErrorIs(t, db.DeleteUserFood(ctx, userID, riceID), database.ErrFoodUsedInRecipe)
+
+		var check database.UserRecipe
+		require.NoError(t, db.LoadUserRecipe(ctx, userID, recipeID, &check))
+		assert.Len(t, check.Ingredients, 2)
+		assert.InDelta(t, 30, check.Serving.Carb, 0.001)
+
+		// The recipe is replaced and the rice removed.
+		recipe.ID = recipeID
+		recipe.Yield = 2
+		require.NoError(t, db.UpdateUserRecipe(ctx, &recipe, []database.TblUserRecipeIngredient{
+			{DataSourceFoodID: &chickenID, Portion: 600},
+		}))
+
+		require.NoError(t, db.LoadUserRecipe(ctx, userID, recipeID, &loaded))
+		require.Len(t, loaded.Ingredients, 1)
+		assert.InDelta(t, 90, loaded.Serving.Protein, 0.001)
+		assert.Zero(t, loaded.Serving.Carb)
+
+		var eflogs []database.UserEventFoodLog
+		require.NoError(t, db.LoadUserEventFoodLogs(ctx, userID, &eflogs))
+		require.Len(t, eflogs, 1)
+		require.Len(t, eflogs[0].Foodlogs, 1)
+		assert.Equal(t, "Chicken Rice", eflogs[0].Foodlogs[0].Name)
+		assert.Nil(t, eflogs[0].Foodlogs[0].FoodID)
+		require.NotNil(t, eflogs[0].Foodlogs[0].RecipeID)
+		assert.Equal(t, recipeID, *eflogs[0].Foodlogs[0].RecipeID)
+		assert.InDelta(t, 60, eflogs[0].Foodlogs[0].Carb, 0.001)
+		assert.InDelta(t, 60, eflogs[0].Eventlog.NetCarbs, 0.001)
+
+		// No food was created for the recipe.
+		var foods []database.TblUserFood
+		require.NoError(t, db.LoadUserFoods(ctx, userID, &foods))
+		assert.Len(t, foods, 1)
+
+		// Another user can't log or change it.
+		require.ErrorIs(t, db.UpdateUserRecipe(ctx, &database.TblUserRecipe{ID: recipeID, UserID: otherID}, nil),
+			database.ErrUserDoesNotHaveThisID)
+
+		otherEventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: otherID, Name: "Dinner"})
+		require.NoError(t, err)
+
+		_, err = db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+			UserID:   otherID,
+			EventID:  otherEventID,
+			Event:    "Dinner",
+			UserTime: database.TimeMillis(time.Now()),
+		}, []database.TblUserFoodLog{foodlog})
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+
+		// Deleting the recipe keeps the foodlog.
+		require.NoError(t, db.DeleteUserRecipe(ctx, userID, recipeID))
+		recipes = nil
+		require.NoError(t, db.LoadUserRecipes(ctx, userID, &recipes))
+		assert.Empty(t, recipes)
+
+		eflogs = nil
+		require.NoError(t, db.LoadUserEventFoodLogs(ctx, userID, &eflogs))
+		require.Len(t, eflogs[0].Foodlogs, 1)
+		assert.Nil(t, eflogs[0].Foodlogs[0].RecipeID)
+		assert.InDelta(t, 60, eflogs[0].Foodlogs[0].Carb, 0.001)
+
+		// Once no recipe uses it the food can be deleted.
+		require.NoError(t, db.DeleteUserFood(ctx, userID, riceID))
+		foods = nil
+		require.NoError(t, db.LoadUserFoods(ctx, userID, &foods))
+		assert.Empty(t, foods)
+	})
+
+	t.Run("food_units", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		riceID, err := db.AddUserFood(ctx, &database.TblUserFood{
+			UserID:         userID,
+			Name:           "Rice",
+			Unit:           "g",
+			Portion:        1,
+			Carb:           0.28,
+			Nutrients:      database.Nutrients{database.NutrientSodium: 0.01},
+			Density:        0.8,
+			HouseholdUnits: database.HouseholdUnits{"bowl": {Amount: 185, Unit: "g"}},
+		})
+		require.NoError(t, err)
+
+		var foods []database.TblUserFood
+		require.NoError(t, db.LoadUserFoods(ctx, userID, &foods))
+		require.Len(t, foods, 1)
+		assert.InDelta(t, 0.8, foods[0].Density, 1e-9)
+		assert.Equal(t, database.HouseholdUnits{"bowl": {Amount: 185, Unit: "g"}}, foods[0].HouseholdUnits)
+
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Lunch"})
+		require.NoError(t, err)
+
+		// Logged in other units without macros, they come from the food.
+		_, err = db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+			UserID:   userID,
+			EventID:  eventID,
+			Event:    "Lunch",
+			UserTime: database.TimeMillis(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)),
+		}, []database.TblUserFoodLog{
+			{Name: "Rice", Unit: "oz", Portion: 3},
+			{Name: "Rice", Unit: "bowls", Portion: 2},
+			{Name: "Rice", Unit: "cup", Portion: 1},
+		})
+		require.NoError(t, err)
+
+		foods = nil
+		require.NoError(t, db.LoadUserFoods(ctx, userID, &foods))
+		require.Len(t, foods, 1)
+
+		var eflogs []database.UserEventFoodLog
+		require.NoError(t, db.LoadUserEventFoodLogs(ctx, userID, &eflogs))
+		require.Len(t, eflogs, 1)
+		require.Len(t, eflogs[0].Foodlogs, 3)
+
+		want := map[string]float64{
+			"oz":    3 * 28.349523125,
+			"bowls": 2 * 185,
+			"cup":   236.5882365 * 0.8,
+		}
+
+		for _, f := range eflogs[0].Foodlogs {
+			grams, ok := want[f.Unit]
+			require.True(t, ok, f.Unit)
+			require.NotNil(t, f.FoodID)
+			assert.Equal(t, riceID, *f.FoodID)
+			assert.InDelta(t, 0.28*grams, f.Carb, 0.001, f.Unit)
+			assert.InDelta(t, 0.01*grams, f.Nutrients[database.NutrientSodium], 0.001, f.Unit)
+		}
+
+		// The eventlog's totals are of the converted foodlogs.
+		totalGrams := want["oz"] + want["bowls"] + want["cup"]
+		assert.InDelta(t, 0.28*totalGrams, eflogs[0].Eventlog.NetCarbs, 0.001)
+
+		// They can be converted before saving, to work out the totals first.
+		foodlogs := []database.TblUserFoodLog{
+			{Name: "Rice", Unit: "oz", Portion: 3},
+			{Name: "Rice", Unit: "g", Portion: 100, Carb: 30},
+			{Name: "Bread", Unit: "slice", Portion: 1, Carb: 15},
+		}
+		require.NoError(t, db.FillUserFoodLogs(ctx, userID, foodlogs))
+		assert.InDelta(t, 0.28*want["oz"], foodlogs[0].Carb, 0.001)
+		assert.InDelta(t, 30, foodlogs[1].Carb, 1e-9)
+		assert.InDelta(t, 15, foodlogs[2].Carb, 1e-9)
+
+		// Updating the eventlog converts them the same way.
+		require.NoError(t, db.UpdateUserEventFoodLog(ctx, &database.UpdateUserEventLog{
+			Eventlog: eflogs[0].Eventlog,
+			Foodlogs: []database.TblUserFoodLog{{Name: "Rice", Unit: "oz", Portion: 3}},
+		}))
+
+		var eflog database.UserEventFoodLog
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID, eflogs[0].Eventlog.ID, &eflog))
+		require.Len(t, eflog.Foodlogs, 1)
+		assert.InDelta(t, 0.28*want["oz"], eflog.Foodlogs[0].Carb, 0.001)
+		assert.InDelta(t, 0.28*want["oz"], eflog.Eventlog.NetCarbs, 0.001)
+
+		// Units that can't be converted make a new food.
+		_, err = db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+			UserID:   userID,
+			EventID:  eventID,
+			Event:    "Lunch",
+			UserTime: database.TimeMillis(time.Date(2024, 1, 16, 12, 0, 0, 0, time.UTC)),
+		}, []database.TblUserFoodLog{
+			{Name: "Rice", Unit: "serving", Portion: 1, Carb: 45},
+		})
+		require.NoError(t, err)
+
+		foods = nil
+		require.NoError(t, db.LoadUserFoods(ctx, userID, &foods))
+		require.Len(t, foods, 2)
+	})
+
+	t.Run("data_source_food_servings", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		dsID, err := db.AddDataSource(ctx, &database.TblDataSource{Name: "FDC"})
+		require.NoError(t, err)
+
+		carrotID, err := db.AddDataSourceFood(ctx, &database.TblDataSourceFood{
+			DataSourceID: dsID, Name: "Carrots, raw", Unit: "g", Portion: 100, Carb: 9.6,
+		})
+		require.NoError(t, err)
+
+		saltID, err := db.AddDataSourceFood(ctx, &database.TblDataSourceFood{
+			DataSourceID: dsID, Name: "Salt", Unit: "g", Portion: 100,
+		})
+		require.NoError(t, err)
+
+		for _, s := range []database.TblDataSourceFoodServing{
+			{DataSourceFoodID: carrotID, Name: "1 cup, chopped", Amount: 1, Unit: "cup, chopped", Portion: 128},
+			{DataSourceFoodID: carrotID, Name: "1 medium", Amount: 1, Unit: "medium", Portion: 61},
+		} {
+			_, err := db.AddDataSourceFoodServing(ctx, &s)
+			require.NoError(t, err)
+		}
+
+		var foods []database.TblDataSourceFood
+		require.NoError(t, db.LoadDataSourceFoodBySimilarName(ctx, dsID, "a", &foods))
+		require.Len(t, foods, 2)
+
+		var servings []database.TblDataSourceFoodServing
+		require.NoError(t, db.LoadDataSourceFoodServings(ctx, []int{carrotID, saltID}, &servings))
+		require.Len(t, servings, 2)
+
+		withServings := database.NewDataSourceFoods(foods, servings)
+		require.Len(t, withServings, 2)
+
+		for _, f := range withServings {
+			if f.ID == carrotID {
+				require.Len(t, f.Servings, 2)
+				assert.Equal(t, "1 cup, chopped", f.Servings[0].Name)
+				assert.InDelta(t, 128, f.Servings[0].Portion, 1e-9)
+				assert.Equal(t, "medium", f.Servings[1].Unit)
+			} else {
+				assert.NotNil(t, f.Servings)
+				assert.Empty(t, f.Servings)
+			}
+		}
+
+		servings = nil
+		require.NoError(t, db.LoadDataSourceFoodServings(ctx, nil, &servings))
+		assert.Empty(t, servings)
+	})
+
+	t.Run("data_source_food_barcode", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		fdcID, err := db.AddDataSource(ctx, &database.TblDataSource{Name: "FDC"})
+		require.NoError(t, err)
+
+		otherID, err := db.AddDataSource(ctx, &database.TblDataSource{Name: "Other"})
+		require.NoError(t, err)
+
+		barcode, ok := database.NormalizeBarcode("016000275287")
+		require.True(t, ok)
+
+		for _, f := range []database.TblDataSourceFood{
+			{DataSourceID: fdcID, Name: "Cereal", Unit: "g", Portion: 100, Carb: 80, Barcode: barcode},
+			{DataSourceID: fdcID, Name: "Apple", Unit: "g", Portion: 100, Carb: 14},
+			{DataSourceID: otherID, Name: "Cereal, other source", Unit: "g", Portion: 100, Carb: 79, Barcode: barcode},
+		} {
+			_, err := db.AddDataSourceFood(ctx, &f)
+			require.NoError(t, err)
+		}
+
+		var foods []database.TblDataSourceFood
+		require.NoError(t, db.LoadDataSourceFoodByBarcode(ctx, barcode, &foods))
+		require.Len(t, foods, 2)
+		assert.Equal(t, "Cereal", foods[0].Name)
+		assert.Equal(t, barcode, foods[0].Barcode)
+		assert.Equal(t, otherID, foods[1].DataSourceID)
+
+		// Foods without a barcode are never found.
+		foods = nil
+		require.NoError(t, db.LoadDataSourceFoodByBarcode(ctx, "", &foods))
+		assert.Empty(t, foods)
+	})
+
+	t.Run("LoadUserChartData_timezone", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		loc, err := time.LoadLocation("America/Toronto")
+		require.NoError(t, err)
+
+		// The user's day starts at 3am.
+		shift := 3 * time.Hour
+
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Snack"})
+		require.NoError(t, err)
+
+		// Dinner and a late snack are the same day for the user, but different days in UTC.
+		// Breakfast is the next day.
+		for _, meal := range []struct {
+			at   time.Time
+			carb float64
+		}{
+			{time.Date(2024, 1, 15, 18, 0, 0, 0, loc), 40},
+			{time.Date(2024, 1, 16, 1, 0, 0, 0, loc), 10},
+			{time.Date(2024, 1, 16, 8, 0, 0, 0, loc), 25},
+		} {
+			_, err := db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+				UserID:   userID,
+				EventID:  eventID,
+				Event:    "Snack",
+				UserTime: database.TimeMillis(meal.at),
+			}, []database.TblUserFoodLog{
+				{Name: "Crackers", Portion: 1, Carb: meal.carb},
+			})
+			require.NoError(t, err)
+		}
+
+		start := time.Date(2024, 1, 14, 3, 0, 0, 0, loc)
+		end := time.Date(2024, 1, 18, 3, 0, 0, 0, loc)
+
+		var points []database.ChartPoint
+		require.NoError(t, db.LoadUserChartData(
+			ctx,
+			userID,
+			[]database.ChartColumn{database.ChartColumnCarbs},
+			database.AggregationSum,
+			database.GroupByDay,
+			loc,
+			shift,
+			start,
+			end,
+			&points,
+		))
+
+		require.Len(t, points, 2)
+
+		day := time.Date(2024, 1, 15, 3, 0, 0, 0, loc)
+		assert.True(t, points[0].Bucket.Time().Equal(day), "got %s", points[0].Bucket.Time())
+		assert.InDelta(t, 50, points[0].Value, 0.001)
+		assert.True(t, points[1].Bucket.Time().Equal(day.AddDate(0, 0, 1)), "got %s", points[1].Bucket.Time())
+		assert.InDelta(t, 25, points[1].Value, 0.001)
+
+		// Goal progress for the same day agrees.
+		goal := database.TblUserGoal{
+			UserID:          userID,
+			TargetCol:       string(database.TargetColumnCarbs),
+			AggregationType: string(database.AggregationSum),
+			ValueComparison: string(database.ComparisonGreaterThan),
+			TimeExpr:        "DAILY",
+		}
+
+		asOf := time.Date(2024, 1, 16, 2, 0, 0, 0, loc)
+
+		var progress database.UserGoalProgress
+		require.NoError(t, db.LoadUserGoalProgress(ctx, asOf.Add(-shift).In(loc), shift, &goal, &progress))
+		assert.InDelta(t, points[0].Value, progress.CurrentValue, 0.001)
+
+		// Timespans are bucketed the same way.
+		_, err = db.AddUserTimespan(ctx, &database.TblUserTimespan{
+			UserID:    userID,
+			StartTime: database.TimeMillis(time.Date(2024, 1, 16, 1, 0, 0, 0, loc)),
+			StopTime:  database.TimeMillis(time.Date(2024, 1, 16, 2, 0, 0, 0, loc)),
+		}, []database.TblUserTag{
+			{UserID: userID, Namespace: "activity", Name: "walk"},
+		})
+		require.NoError(t, err)
+
+		var durations []database.TimespanTagDurationPoint
+		require.NoError(t, db.LoadUserTimeData(
+			ctx, userID, start, end, []string{"activity:walk"}, database.GroupByDay, loc, shift, &durations,
+		))
+
+		require.Len(t, durations, 1)
+		assert.True(t, durations[0].Bucket.Time().Equal(day), "got %s", durations[0].Bucket.Time())
+	})
+
+	t.Run("SetUserTimespanTags", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		note := "timespan"
+		tsID, err := db.AddUserTimespan(ctx, &database.TblUserTimespan{
+			UserID:    userID,
+			StartTime: database.TimeMillis(time.Now()),
+			StopTime:  database.TimeMillis(time.Now().Add(time.Hour)),
+			Note:      &note,
+		}, nil)
+		require.NoError(t, err)
+
+		newTags := []database.TblUserTag{
+			{UserID: userID, Namespace: "food", Name: "Egg"},
+			{UserID: userID, Namespace: "food", Name: "Milk"},
+		}
+		require.NoError(t, db.SetUserTimespanTags(ctx, userID, tsID, newTags))
+
+		var tagged []database.TaggedTimespan
+		require.NoError(t, db.LoadUserTimespansWithTags(ctx, userID, &tagged))
+		require.Len(t, tagged, 1)
+		assert.Len(t, tagged[0].Tags, 2)
+	})
+
+	t.Run("dashboard_crud", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		// Load on empty database returns an empty slice without error.
+		var dashboards []database.TblUserDashboard
+		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
+		assert.Empty(t, dashboards)
+
+		// Add a dashboard.
+		d := &database.TblUserDashboard{
+			UserID: userID,
+			Name:   "Default",
+			Data:   `[{"id":"card-1"}]`,
+		}
+		id, err := db.AddUserDashboard(ctx, d)
+		require.NoError(t, err)
+		require.NotZero(t, id)
+		d.ID = id
+
+		// Load it back.
+		dashboards = dashboards[:0]
+		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
+		require.Len(t, dashboards, 1)
+		assert.Equal(t, id, dashboards[0].ID)
+		assert.Equal(t, userID, dashboards[0].UserID)
+		assert.Equal(t, "Default", dashboards[0].Name)
+		assert.Equal(t, `[{"id":"card-1"}]`, dashboards[0].Data)
+
+		// Update name and data.
+		d.Name = "My Board"
+		d.Data = `[{"id":"card-2"}]`
+		require.NoError(t, db.UpdateUserDashboard(ctx, d))
+
+		dashboards = dashboards[:0]
+		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
+		require.Len(t, dashboards, 1)
+		assert.Equal(t, "My Board", dashboards[0].Name)
+		assert.Equal(t, `[{"id":"card-2"}]`, dashboards[0].Data)
+
+		// Update with a wrong user_id must not affect the row.
+		bogus := &database.TblUserDashboard{ID: id, UserID: userID + 99, Name: "Hijacked", Data: "[]"}
+		require.NoError(t, db.UpdateUserDashboard(ctx, bogus))
+
+		dashboards = dashboards[:0]
+		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
+		require.Len(t, dashboards, 1)
+		assert.Equal(t, "My Board", dashboards[0].Name, "row must be unchanged after wrong-user update")
+
+		// Delete it.
+		require.NoError(t, db.DeleteUserDashboard(ctx, userID, id))
+
+		dashboards = dashboards[:0]
+		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
+		assert.Empty(t, dashboards)
+	})
+
+	t.Run("dashboard_multiple_per_user", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+		userID2 := getTestUser2(t, db)
+
+		for _, name := range []string{"Board A", "Board B"} {
+			_, err := db.AddUserDashboard(ctx, &database.TblUserDashboard{
+				UserID: userID,
+				Name:   name,
+				Data:   "[]",
+			})
+			require.NoError(t, err)
+		}
 
 		// A different user's dashboard must not appear in userID's list.
 		_, err := db.AddUserDashboard(ctx, &database.TblUserDashboard{
@@ -1681,14 +2706,516 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 		})
 		require.NoError(t, err)
 
-		var dashboards []database.TblUserDashboard
-		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
-		require.Len(t, dashboards, 2)
-		assert.Equal(t, "Board A", dashboards[0].Name)
-		assert.Equal(t, "Board B", dashboards[1].Name)
+		var dashboards []database.TblUserDashboard
+		require.NoError(t, db.LoadUserDashboards(ctx, userID, &dashboards))
+		require.Len(t, dashboards, 2)
+		assert.Equal(t, "Board A", dashboards[0].Name)
+		assert.Equal(t, "Board B", dashboards[1].Name)
+	})
+
+	t.Run("tag_color_crud", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		// Load on empty database returns an empty slice without error.
+		var colors []database.TblUserTagColor
+		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors))
+		assert.Empty(t, colors)
+
+		// Set a color.
+		c := []database.TblUserTagColor{{
+			UserID:    userID,
+			Namespace: "food",
+			Color:     "#ff0000",
+		}}
+		require.NoError(t, db.SetUserTagColors(ctx, c))
+
+		// Load it back.
+		colors = colors[:0]
+		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors))
+		require.Len(t, colors, 1)
+		assert.Equal(t, userID, colors[0].UserID)
+		assert.Equal(t, "food", colors[0].Namespace)
+		assert.Equal(t, "#ff0000", colors[0].Color)
+
+		// Upsert the same namespace with a new color.
+		c[0].Color = "var(--c-yellow)"
+		require.NoError(t, db.SetUserTagColors(ctx, c))
+
+		colors = colors[:0]
+		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors))
+		require.Len(t, colors, 1, "upsert must not create a duplicate row")
+		assert.Equal(t, "var(--c-yellow)", colors[0].Color)
+
+		// Set a second namespace.
+		require.NoError(t, db.SetUserTagColors(ctx, []database.TblUserTagColor{{
+			UserID:    userID,
+			Namespace: "exercise",
+			Color:     "#00ff00",
+		}}))
+
+		colors = colors[:0]
+		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors))
+		assert.Len(t, colors, 2)
+
+		// Delete one mapping.
+		require.NoError(t, db.DeleteUserTagColors(ctx, userID, []string{"food"}))
+
+		colors = colors[:0]
+		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors))
+		require.Len(t, colors, 1)
+		assert.Equal(t, "exercise", colors[0].Namespace)
+
+		// Deleting a non-existent namespace is a no-op (no error).
+		require.NoError(t, db.DeleteUserTagColors(ctx, userID, []string{"does_not_exist"}))
+	})
+
+	t.Run("tag_color_user_isolation", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+		userID2 := getTestUser2(t, db)
+
+		// Both users set a color for the same namespace.
+		require.NoError(t, db.SetUserTagColors(ctx, []database.TblUserTagColor{{
+			UserID:    userID,
+			Namespace: "food",
+			Color:     "#aaaaaa",
+		}}))
+		require.NoError(t, db.SetUserTagColors(ctx, []database.TblUserTagColor{{
+			UserID:    userID2,
+			Namespace: "food",
+			Color:     "#bbbbbb",
+		}}))
+
+		// Each user's load must only return their own row.
+		var colors1 []database.TblUserTagColor
+		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors1))
+		require.Len(t, colors1, 1)
+		assert.Equal(t, "#aaaaaa", colors1[0].Color)
+
+		var colors2 []database.TblUserTagColor
+		require.NoError(t, db.LoadUserTagColors(ctx, userID2, &colors2))
+		require.Len(t, colors2, 1)
+		assert.Equal(t, "#bbbbbb", colors2[0].Color)
+
+		// Deleting with wrong user_id must not remove the other user's row.
+		require.NoError(t, db.DeleteUserTagColors(ctx, userID2, []string{"food"}))
+
+		colors1 = colors1[:0]
+		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors1))
+		require.Len(t, colors1, 1, "user1's row must survive user2's delete")
+	})
+
+	t.Run("user_photo_add", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		photo := &database.TblUserPhoto{
+			UserID: userID,
+			Data:   []byte{0x89, 0x50, 0x4E, 0x47},
+		}
+
+		id, err := db.AddUserPhoto(ctx, photo)
+		require.NoError(t, err)
+		require.NotZero(t, id)
+	})
+
+	t.Run("user_eventlog_photo_mapping", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		event := &database.TblUserEvent{UserID: userID, Name: "Lunch"}
+		eventID, err := db.AddUserEvent(ctx, event)
+		require.NoError(t, err)
+		event.ID = eventID
+
+		eventlog := &database.TblUserEventLog{
+			UserID:  userID,
+			EventID: eventID,
+			Event:   event.Name,
+		}
+		logID, err := db.AddUserEventLogWith(ctx, eventlog, nil)
+		require.NoError(t, err)
+		require.NotZero(t, logID)
+
+		photo1 := &database.TblUserPhoto{UserID: userID, Data: []byte{0x01}}
+		photo2 := &database.TblUserPhoto{UserID: userID, Data: []byte{0x02}}
+
+		id1, err := db.AddUserPhoto(ctx, photo1)
+		require.NoError(t, err)
+		id2, err := db.AddUserPhoto(ctx, photo2)
+		require.NoError(t, err)
+
+		require.NoError(t, db.AddUserEventLogPhotos(ctx, userID, logID, []int{id1, id2}))
+
+		// Duplicate insert must fail (primary key violation).
+		err = db.AddUserEventLogPhotos(ctx, userID, logID, []int{id1})
+		require.Error(t, err)
+	})
+
+	t.Run("user_eventlog_photo_ownership", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+		userID2 := getTestUser2(t, db)
+
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Lunch"})
+		require.NoError(t, err)
+		logID, err := db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+			UserID:  userID,
+			EventID: eventID,
+			Event:   "Lunch",
+		}, nil)
+		require.NoError(t, err)
+
+		eventID2, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID2, Name: "Lunch"})
+		require.NoError(t, err)
+		logID2, err := db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+			UserID:  userID2,
+			EventID: eventID2,
+			Event:   "Lunch",
+		}, nil)
+		require.NoError(t, err)
+
+		photoID, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{UserID: userID, Data: []byte{0x01}})
+		require.NoError(t, err)
+		photoID2, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{UserID: userID2, Data: []byte{0x02}})
+		require.NoError(t, err)
+
+		// Another user's photo onto your own eventlog.
+		err = db.AddUserEventLogPhotos(ctx, userID, logID, []int{photoID2})
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+
+		// Your own photo onto another user's eventlog.
+		err = db.AddUserEventLogPhotos(ctx, userID, logID2, []int{photoID})
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+
+		// A photo that doesn't exist.
+		err = db.AddUserEventLogPhotos(ctx, userID, logID, []int{photoID + photoID2 + 100})
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+
+		// One bad ID rolls back the whole link.
+		err = db.AddUserEventLogPhotos(ctx, userID, logID, []int{photoID, photoID2})
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+
+		var eflog database.UserEventFoodLog
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID, logID, &eflog))
+		assert.Empty(t, eflog.PhotoIDs)
+
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID2, logID2, &eflog))
+		assert.Empty(t, eflog.PhotoIDs)
+
+		// Unlinking another user's mapping does nothing.
+		require.NoError(t, db.AddUserEventLogPhotos(ctx, userID, logID, []int{photoID}))
+		err = db.DeleteUserEventLogPhoto(ctx, userID2, logID, photoID)
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID, logID, &eflog))
+		assert.Equal(t, []int{photoID}, eflog.PhotoIDs)
+	})
+
+	t.Run("AddUserEventLogWithPhotos", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+		userID2 := getTestUser2(t, db)
+
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Lunch"})
+		require.NoError(t, err)
+
+		photoID, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{UserID: userID, Data: []byte{0x01}})
+		require.NoError(t, err)
+		photoID2, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{UserID: userID2, Data: []byte{0x02}})
+		require.NoError(t, err)
+
+		newEventLog := func() *database.TblUserEventLog {
+			return &database.TblUserEventLog{UserID: userID, EventID: eventID, Event: "Lunch"}
+		}
+
+		// Another user's photo saves neither the eventlog nor its foodlogs.
+		_, err = db.AddUserEventLogWithPhotos(ctx, newEventLog(), []database.TblUserFoodLog{
+			{Name: "Toast", Unit: "slice", Portion: 1, Carb: 15},
+		}, []int{photoID, photoID2})
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+
+		var eventlogs []database.TblUserEventLog
+		require.NoError(t, db.LoadUserEventLogs(ctx, userID, &eventlogs))
+		assert.Empty(t, eventlogs)
+
+		logID, err := db.AddUserEventLogWithPhotos(ctx, newEventLog(), nil, []int{photoID})
+		require.NoError(t, err)
+
+		var eflog database.UserEventFoodLog
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID, logID, &eflog))
+		assert.Equal(t, []int{photoID}, eflog.PhotoIDs)
+	})
+
+	t.Run("AddUserEventLogsOnce", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+		at := database.TimeMillis(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+
+		eventlogs := []database.TblUserEventLog{
+			{UserTime: at, Event: "Lunch", ActualInsulinTaken: 4},
+			{UserTime: at, Event: "Correction", ActualInsulinTaken: 1},
+			{UserTime: at, Event: "Lunch", ActualInsulinTaken: 4},
+		}
+		foodlogs := [][]database.TblUserFoodLog{
+			{{Name: "Carbs", Unit: "g", Portion: 45, Carb: 45}},
+			nil,
+			{{Name: "Carbs", Unit: "g", Portion: 45, Carb: 45}},
+		}
+
+		// The same eventlog twice in one batch is saved once.
+		saved, err := db.AddUserEventLogsOnce(ctx, userID, eventlogs, foodlogs)
+		require.NoError(t, err)
+		require.Len(t, saved, 2)
+		assert.NotZero(t, saved[0].ID)
+		assert.InDelta(t, 45, saved[0].NetCarbs, 1e-9)
+
+		// Sending the batch again saves nothing.
+		saved, err = db.AddUserEventLogsOnce(ctx, userID, eventlogs, foodlogs)
+		require.NoError(t, err)
+		assert.Empty(t, saved)
+
+		var loaded []database.TblUserEventLog
+		require.NoError(t, db.LoadUserEventLogs(ctx, userID, &loaded))
+		assert.Len(t, loaded, 2)
+
+		var events []database.TblUserEvent
+		require.NoError(t, db.LoadUserEvents(ctx, userID, &events))
+		assert.Len(t, events, 2)
+	})
+
+	t.Run("user_photo_load_and_delete", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+		userID2 := getTestUser2(t, db)
+
+		data := []byte{0xFF, 0xD8, 0xFF, 0xE0}
+
+		photoID, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{UserID: userID, Data: data})
+		require.NoError(t, err)
+
+		var photo database.TblUserPhoto
+		require.NoError(t, db.LoadUserPhoto(ctx, userID, photoID, &photo))
+		assert.Equal(t, photoID, photo.ID)
+		assert.Equal(t, userID, photo.UserID)
+		assert.Equal(t, data, photo.Data)
+		assert.False(t, photo.Created.Time().IsZero())
+
+		// Another user cannot read or delete it.
+		err = db.LoadUserPhoto(ctx, userID2, photoID, &photo)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = db.DeleteUserPhoto(ctx, userID2, photoID)
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+		require.NoError(t, db.LoadUserPhoto(ctx, userID, photoID, &photo))
+
+		require.NoError(t, db.DeleteUserPhoto(ctx, userID, photoID))
+		err = db.LoadUserPhoto(ctx, userID, photoID, &photo)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		// Already gone.
+		err = db.DeleteUserPhoto(ctx, userID, photoID)
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+	})
+
+	t.Run("user_eventfoodlog_photo_ids", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Dinner"})
+		require.NoError(t, err)
+
+		eventlog := &database.TblUserEventLog{UserID: userID, EventID: eventID, Event: "Dinner"}
+		logID, err := db.AddUserEventLogWith(ctx, eventlog, nil)
+		require.NoError(t, err)
+
+		id1, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{UserID: userID, Data: []byte{0x01}})
+		require.NoError(t, err)
+		id2, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{UserID: userID, Data: []byte{0x02}})
+		require.NoError(t, err)
+
+		var eflog database.UserEventFoodLog
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID, logID, &eflog))
+		assert.NotNil(t, eflog.PhotoIDs)
+		assert.Empty(t, eflog.PhotoIDs)
+
+		require.NoError(t, db.AddUserEventLogPhotos(ctx, userID, logID, []int{id2, id1}))
+
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID, logID, &eflog))
+		assert.Equal(t, []int{id1, id2}, eflog.PhotoIDs)
+
+		var eflogs []database.UserEventFoodLog
+		require.NoError(t, db.LoadUserEventFoodLogs(ctx, userID, &eflogs))
+		require.Len(t, eflogs, 1)
+		assert.Equal(t, []int{id1, id2}, eflogs[0].PhotoIDs)
+
+		// Unlinking keeps the photo around.
+		require.NoError(t, db.DeleteUserEventLogPhoto(ctx, userID, logID, id1))
+
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID, logID, &eflog))
+		assert.Equal(t, []int{id2}, eflog.PhotoIDs)
+
+		var photo database.TblUserPhoto
+		require.NoError(t, db.LoadUserPhoto(ctx, userID, id1, &photo))
+
+		// Deleting the photo unlinks it.
+		require.NoError(t, db.DeleteUserPhoto(ctx, userID, id2))
+
+		require.NoError(t, db.LoadUserEventFoodLog(ctx, userID, logID, &eflog))
+		assert.Empty(t, eflog.PhotoIDs)
+	})
+
+	t.Run("DeleteUnlinkedUserPhotos", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		eventID, err := db.AddUserEvent(ctx, &database.TblUserEvent{UserID: userID, Name: "Lunch"})
+		require.NoError(t, err)
+
+		eventlog := &database.TblUserEventLog{UserID: userID, EventID: eventID, Event: "Lunch"}
+		logID, err := db.AddUserEventLogWith(ctx, eventlog, nil)
+		require.NoError(t, err)
+
+		linked, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{UserID: userID, Data: []byte{0x01}})
+		require.NoError(t, err)
+		unlinked, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{UserID: userID, Data: []byte{0x02}})
+		require.NoError(t, err)
+
+		require.NoError(t, db.AddUserEventLogPhotos(ctx, userID, logID, []int{linked}))
+
+		// Nothing is old enough yet.
+		deleted, err := db.DeleteUnlinkedUserPhotos(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, deleted)
+
+		deleted, err = db.DeleteUnlinkedUserPhotos(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, deleted, 1)
+		assert.Equal(t, unlinked, deleted[0].ID)
+		assert.Equal(t, "db", deleted[0].Storage)
+
+		var photo database.TblUserPhoto
+		require.NoError(t, db.LoadUserPhoto(ctx, userID, linked, &photo))
+		require.ErrorIs(t, db.LoadUserPhoto(ctx, userID, unlinked, &photo), sql.ErrNoRows)
+	})
+
+	t.Run("user_photo_storage", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		// Two rows sharing the same bytes outside the database.
+		first, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{
+			UserID: userID, Data: []byte{}, Storage: "fs", Hash: "abc",
+		})
+		require.NoError(t, err)
+		second, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{
+			UserID: userID, Data: []byte{}, Storage: "fs", Hash: "abc",
+		})
+		require.NoError(t, err)
+		inDB, err := db.AddUserPhoto(ctx, &database.TblUserPhoto{UserID: userID, Data: []byte{0x01}})
+		require.NoError(t, err)
+
+		count, err := db.CountPhotosByHash(ctx, "fs", "abc")
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		var ids []int
+		require.NoError(t, db.LoadPhotoIDsByStorage(ctx, "fs", &ids))
+		assert.Equal(t, []int{first, second}, ids)
+
+		ids = nil
+		require.NoError(t, db.LoadPhotoIDsByStorage(ctx, "db", &ids))
+		assert.Equal(t, []int{inDB}, ids)
+
+		var photo database.TblUserPhoto
+		require.NoError(t, db.LoadPhotoByID(ctx, second, &photo))
+
+		photo.Storage = "db"
+		photo.Hash = "def"
+		photo.Data = []byte{0x02}
+		photo.Thumbnail = []byte{0x03}
+		require.NoError(t, db.UpdatePhotoStorage(ctx, &photo))
+
+		count, err = db.CountPhotosByHash(ctx, "fs", "abc")
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		photo = database.TblUserPhoto{}
+		require.NoError(t, db.LoadUserPhoto(ctx, userID, second, &photo))
+		assert.Equal(t, "db", photo.Storage)
+		assert.Equal(t, "def", photo.Hash)
+		assert.Equal(t, []byte{0x02}, photo.Data)
+		assert.Equal(t, []byte{0x03}, photo.Thumbnail)
 	})
 
-	t.Run("tag_color_crud", func(t *testing.T) {
+	t.Run("medication_crud", func(t *testing.T) {
 
 		lock.Lock()
 		t.Cleanup(lock.Unlock)
@@ -1698,60 +3225,129 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 
 		userID := getTestUser(t, db)
 
-		// Load on empty database returns an empty slice without error.
-		var colors []database.TblUserTagColor
-		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors))
-		assert.Empty(t, colors)
+		var meds []database.TblUserMedication
+		require.NoError(t, db.LoadUserMedications(ctx, userID, &meds))
+		assert.Empty(t, meds)
 
-		// Set a color.
-		c := []database.TblUserTagColor{{
-			UserID:    userID,
-			Namespace: "food",
-			Color:     "#ff0000",
-		}}
-		require.NoError(t, db.SetUserTagColors(ctx, c))
+		start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
 
-		// Load it back.
-		colors = colors[:0]
-		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors))
-		require.Len(t, colors, 1)
-		assert.Equal(t, userID, colors[0].UserID)
-		assert.Equal(t, "food", colors[0].Namespace)
-		assert.Equal(t, "#ff0000", colors[0].Color)
+		med := &database.TblUserMedication{
+			UserID:       userID,
+			Name:         "Metformin",
+			Category:     "diabetes",
+			DosageAmount: 500,
+			DosageUnit:   "mg",
+			Form:         "tablet",
+			StartDate:    database.TimeMillis(start),
+		}
+		medID, err := db.AddUserMedication(ctx, med)
+		require.NoError(t, err)
+		require.NotZero(t, medID)
+		med.ID = medID
+
+		require.NoError(t, db.LoadUserMedications(ctx, userID, &meds))
+		require.Len(t, meds, 1)
+		assert.Equal(t, "Metformin", meds[0].Name)
+		assert.Equal(t, 500.0, meds[0].DosageAmount)
+		assert.Equal(t, start.UnixMilli(), meds[0].StartDate.Time().UnixMilli())
+		assert.True(t, meds[0].EndDate.Time().IsZero())
+
+		med.DosageAmount = 1000
+		med.Notes = "with dinner"
+		require.NoError(t, db.UpdateUserMedication(ctx, med))
+
+		meds = meds[:0]
+		require.NoError(t, db.LoadUserMedications(ctx, userID, &meds))
+		require.Len(t, meds, 1)
+		assert.Equal(t, 1000.0, meds[0].DosageAmount)
+		assert.Equal(t, "with dinner", meds[0].Notes)
+
+		schedule := &database.TblUserMedicationSchedule{
+			MedicationID:      medID,
+			MinutesOfHourMask: 1 << 30,
+			HoursOfDayMask:    1<<8 | 1<<20,
+			DaysOfWeekMask:    0b1111111,
+			DaysOfMonthMask:   1 << 30,
+			MonthsOfYearMask:  1 << 11,
+			WithFood:          true,
+			ReminderEnabled:   true,
+		}
+		scheduleID, err := db.AddUserMedicationSchedule(ctx, userID, schedule)
+		require.NoError(t, err)
+		require.NotZero(t, scheduleID)
+		schedule.ID = scheduleID
+
+		var schedules []database.TblUserMedicationSchedule
+		require.NoError(t, db.LoadUserMedicationSchedules(ctx, userID, &schedules))
+		require.Len(t, schedules, 1)
+		assert.Equal(t, medID, schedules[0].MedicationID)
+		assert.Equal(t, int64(1<<30), schedules[0].MinutesOfHourMask)
+		assert.Equal(t, int32(1<<8|1<<20), schedules[0].HoursOfDayMask)
+		assert.Equal(t, int8(0b1111111), schedules[0].DaysOfWeekMask)
+		assert.Equal(t, int32(1<<30), schedules[0].DaysOfMonthMask)
+		assert.Equal(t, int16(1<<11), schedules[0].MonthsOfYearMask)
+		assert.True(t, schedules[0].WithFood)
+		assert.False(t, schedules[0].Fasting)
+
+		schedule.HoursOfDayMask = 1 << 9
+		schedule.Fasting = true
+		require.NoError(t, db.UpdateUserMedicationSchedule(ctx, userID, schedule))
+
+		schedules = schedules[:0]
+		require.NoError(t, db.LoadUserMedicationSchedules(ctx, userID, &schedules))
+		require.Len(t, schedules, 1)
+		assert.Equal(t, int32(1<<9), schedules[0].HoursOfDayMask)
+		assert.True(t, schedules[0].Fasting)
+
+		taken := time.Date(2025, 3, 2, 9, 31, 0, 0, time.UTC)
+
+		medlog := &database.TblUserMedicationLog{
+			ScheduleID: scheduleID,
+			TakenTime:  database.TimeMillis(taken),
+			Taken:      true,
+		}
+		logID, err := db.AddUserMedicationLog(ctx, userID, medlog)
+		require.NoError(t, err)
+		require.NotZero(t, logID)
+		medlog.ID = logID
 
-		// Upsert the same namespace with a new color.
-		c[0].Color = "var(--c-yellow)"
-		require.NoError(t, db.SetUserTagColors(ctx, c))
+		var logs []database.TblUserMedicationLog
+		require.NoError(t, db.LoadUserMedicationLogs(ctx, userID, &logs))
+		require.Len(t, logs, 1)
+		assert.Equal(t, scheduleID, logs[0].ScheduleID)
+		assert.Equal(t, taken.UnixMilli(), logs[0].TakenTime.Time().UnixMilli())
+		assert.True(t, logs[0].Taken)
 
-		colors = colors[:0]
-		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors))
-		require.Len(t, colors, 1, "upsert must not create a duplicate row")
-		assert.Equal(t, "var(--c-yellow)", colors[0].Color)
+		medlog.Taken = false
+		medlog.Notes = "skipped"
+		require.NoError(t, db.UpdateUserMedicationLog(ctx, userID, medlog))
 
-		// Set a second namespace.
-		require.NoError(t, db.SetUserTagColors(ctx, []database.TblUserTagColor{{
-			UserID:    userID,
-			Namespace: "exercise",
-			Color:     "#00ff00",
-		}}))
+		logs = logs[:0]
+		require.NoError(t, db.LoadUserMedicationLogs(ctx, userID, &logs))
+		require.Len(t, logs, 1)
+		assert.False(t, logs[0].Taken)
+		assert.Equal(t, "skipped", logs[0].Notes)
 
-		colors = colors[:0]
-		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors))
-		assert.Len(t, colors, 2)
+		require.NoError(t, db.DeleteUserMedicationLog(ctx, userID, logID))
 
-		// Delete one mapping.
-		require.NoError(t, db.DeleteUserTagColors(ctx, userID, []string{"food"}))
+		logs = logs[:0]
+		require.NoError(t, db.LoadUserMedicationLogs(ctx, userID, &logs))
+		assert.Empty(t, logs)
 
-		colors = colors[:0]
-		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors))
-		require.Len(t, colors, 1)
-		assert.Equal(t, "exercise", colors[0].Namespace)
+		require.NoError(t, db.DeleteUserMedicationSchedule(ctx, userID, scheduleID))
 
-		// Deleting a non-existent namespace is a no-op (no error).
-		require.NoError(t, db.DeleteUserTagColors(ctx, userID, []string{"does_not_exist"}))
+		schedules = schedules[:0]
+		require.NoError(t, db.LoadUserMedicationSchedules(ctx, userID, &schedules))
+		assert.Empty(t, schedules)
+
+		require.NoError(t, db.DeleteUserMedication(ctx, userID, medID))
+
+		meds = meds[:0]
+		require.NoError(t, db.LoadUserMedications(ctx, userID, &meds))
+		assert.Empty(t, meds)
 	})
 
-	t.Run("tag_color_user_isolation", func(t *testing.T) {
+	t.Run("medication_delete_cascades", func(t *testing.T) {
 
 		lock.Lock()
 		t.Cleanup(lock.Unlock)
@@ -1760,40 +3356,36 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 		db := newTestDB(t)
 
 		userID := getTestUser(t, db)
-		userID2 := getTestUser2(t, db)
 
-		// Both users set a color for the same namespace.
-		require.NoError(t, db.SetUserTagColors(ctx, []database.TblUserTagColor{{
-			UserID:    userID,
-			Namespace: "food",
-			Color:     "#aaaaaa",
-		}}))
-		require.NoError(t, db.SetUserTagColors(ctx, []database.TblUserTagColor{{
-			UserID:    userID2,
-			Namespace: "food",
-			Color:     "#bbbbbb",
-		}}))
+		medID, err := db.AddUserMedication(ctx, &database.TblUserMedication{UserID: userID, Name: "Vitamin D"})
+		require.NoError(t, err)
 
-		// Each user's load must only return their own row.
-		var colors1 []database.TblUserTagColor
-		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors1))
-		require.Len(t, colors1, 1)
-		assert.Equal(t, "#aaaaaa", colors1[0].Color)
+		scheduleID, err := db.AddUserMedicationSchedule(ctx, userID, &database.TblUserMedicationSchedule{
+			MedicationID:      medID,
+			MinutesOfHourMask: 1,
+			HoursOfDayMask:    1 << 8,
+		})
+		require.NoError(t, err)
 
-		var colors2 []database.TblUserTagColor
-		require.NoError(t, db.LoadUserTagColors(ctx, userID2, &colors2))
-		require.Len(t, colors2, 1)
-		assert.Equal(t, "#bbbbbb", colors2[0].Color)
+		_, err = db.AddUserMedicationLog(ctx, userID, &database.TblUserMedicationLog{
+			ScheduleID: scheduleID,
+			TakenTime:  database.TimeMillis(time.Now().UTC()),
+			Taken:      true,
+		})
+		require.NoError(t, err)
 
-		// Deleting with wrong user_id must not remove the other user's row.
-		require.NoError(t, db.DeleteUserTagColors(ctx, userID2, []string{"food"}))
+		require.NoError(t, db.DeleteUserMedication(ctx, userID, medID))
 
-		colors1 = colors1[:0]
-		require.NoError(t, db.LoadUserTagColors(ctx, userID, &colors1))
-		require.Len(t, colors1, 1, "user1's row must survive user2's delete")
+		var schedules []database.TblUserMedicationSchedule
+		require.NoError(t, db.LoadUserMedicationSchedules(ctx, userID, &schedules))
+		assert.Empty(t, schedules)
+
+		var logs []database.TblUserMedicationLog
+		require.NoError(t, db.LoadUserMedicationLogs(ctx, userID, &logs))
+		assert.Empty(t, logs)
 	})
 
-	t.Run("user_photo_add", func(t *testing.T) {
+	t.Run("LoadUserMedicationLogsBetween", func(t *testing.T) {
 
 		lock.Lock()
 		t.Cleanup(lock.Unlock)
@@ -1803,17 +3395,40 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 
 		userID := getTestUser(t, db)
 
-		photo := &database.TblUserPhoto{
-			UserID: userID,
-			Data:   []byte{0x89, 0x50, 0x4E, 0x47},
-		}
+		medID, err := db.AddUserMedication(ctx, &database.TblUserMedication{UserID: userID, Name: "Metformin"})
+		require.NoError(t, err)
 
-		id, err := db.AddUserPhoto(ctx, photo)
+		scheduleID, err := db.AddUserMedicationSchedule(ctx, userID, &database.TblUserMedicationSchedule{
+			MedicationID:      medID,
+			MinutesOfHourMask: 1,
+			HoursOfDayMask:    1<<8 | 1<<20,
+		})
 		require.NoError(t, err)
-		require.NotZero(t, id)
+
+		day := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+
+		for _, tt := range []time.Time{
+			day.Add(-time.Minute),
+			day.Add(20 * time.Hour),
+			day.Add(8 * time.Hour),
+			day.Add(24 * time.Hour),
+		} {
+			_, err = db.AddUserMedicationLog(ctx, userID, &database.TblUserMedicationLog{
+				ScheduleID: scheduleID,
+				TakenTime:  database.TimeMillis(tt),
+				Taken:      true,
+			})
+			require.NoError(t, err)
+		}
+
+		var logs []database.TblUserMedicationLog
+		require.NoError(t, db.LoadUserMedicationLogsBetween(ctx, userID, day, day.Add(24*time.Hour), &logs))
+		require.Len(t, logs, 2)
+		assert.Equal(t, day.Add(8*time.Hour).UnixMilli(), logs[0].TakenTime.Time().UnixMilli())
+		assert.Equal(t, day.Add(20*time.Hour).UnixMilli(), logs[1].TakenTime.Time().UnixMilli())
 	})
 
-	t.Run("user_eventlog_photo_mapping", func(t *testing.T) {
+	t.Run("medication_user_isolation", func(t *testing.T) {
 
 		lock.Lock()
 		t.Cleanup(lock.Unlock)
@@ -1822,34 +3437,315 @@ func runDbTests(t *testing.T, newTestDB NewTestDB) {
 		db := newTestDB(t)
 
 		userID := getTestUser(t, db)
+		userID2 := getTestUser2(t, db)
 
-		event := &database.TblUserEvent{UserID: userID, Name: "Lunch"}
-		eventID, err := db.AddUserEvent(ctx, event)
+		medID, err := db.AddUserMedication(ctx, &database.TblUserMedication{UserID: userID, Name: "Aspirin"})
 		require.NoError(t, err)
-		event.ID = eventID
 
-		eventlog := &database.TblUserEventLog{
-			UserID:  userID,
-			EventID: eventID,
-			Event:   event.Name,
+		schedule := &database.TblUserMedicationSchedule{
+			MedicationID:      medID,
+			MinutesOfHourMask: 1,
+			HoursOfDayMask:    1 << 8,
 		}
-		logID, err := db.AddUserEventLogWith(ctx, eventlog, nil)
+		scheduleID, err := db.AddUserMedicationSchedule(ctx, userID, schedule)
 		require.NoError(t, err)
-		require.NotZero(t, logID)
+		schedule.ID = scheduleID
 
-		photo1 := &database.TblUserPhoto{UserID: userID, Data: []byte{0x01}}
-		photo2 := &database.TblUserPhoto{UserID: userID, Data: []byte{0x02}}
+		medlog := &database.TblUserMedicationLog{
+			ScheduleID: scheduleID,
+			TakenTime:  database.TimeMillis(time.Now().UTC()),
+			Taken:      true,
+		}
+		logID, err := db.AddUserMedicationLog(ctx, userID, medlog)
+		require.NoError(t, err)
+		medlog.ID = logID
 
-		id1, err := db.AddUserPhoto(ctx, photo1)
+		// The second user cannot attach anything to the first user's rows.
+		_, err = db.AddUserMedicationSchedule(ctx, userID2, &database.TblUserMedicationSchedule{MedicationID: medID})
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+
+		_, err = db.AddUserMedicationLog(ctx, userID2, &database.TblUserMedicationLog{
+			ScheduleID: scheduleID,
+			TakenTime:  database.TimeMillis(time.Now().UTC()),
+		})
+		require.ErrorIs(t, err, database.ErrUserDoesNotHaveThisID)
+
+		// Or update them.
+		require.ErrorIs(t, db.UpdateUserMedicationSchedule(ctx, userID2, schedule), database.ErrUserDoesNotHaveThisID)
+		require.ErrorIs(t, db.UpdateUserMedicationLog(ctx, userID2, medlog), database.ErrUserDoesNotHaveThisID)
+		require.ErrorIs(t,
+			db.UpdateUserMedication(ctx, &database.TblUserMedication{ID: medID, UserID: userID2, Name: "Mine"}),
+			database.ErrUserDoesNotHaveThisID,
+		)
+
+		// Or see them.
+		var meds []database.TblUserMedication
+		require.NoError(t, db.LoadUserMedications(ctx, userID2, &meds))
+		assert.Empty(t, meds)
+
+		var schedules []database.TblUserMedicationSchedule
+		require.NoError(t, db.LoadUserMedicationSchedules(ctx, userID2, &schedules))
+		assert.Empty(t, schedules)
+
+		var logs []database.TblUserMedicationLog
+		require.NoError(t, db.LoadUserMedicationLogs(ctx, userID2, &logs))
+		assert.Empty(t, logs)
+
+		// Or delete them.
+		require.ErrorIs(t, db.DeleteUserMedicationLog(ctx, userID2, logID), database.ErrUserDoesNotHaveThisID)
+		require.ErrorIs(t, db.DeleteUserMedicationSchedule(ctx, userID2, scheduleID), database.ErrUserDoesNotHaveThisID)
+		require.ErrorIs(t, db.DeleteUserMedication(ctx, userID2, medID), database.ErrUserDoesNotHaveThisID)
+
+		require.NoError(t, db.LoadUserMedicationLogs(ctx, userID, &logs))
+		require.Len(t, logs, 1, "user1's log must survive user2's delete")
+	})
+
+	t.Run("insulin_profile_crud", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+		userID2 := getTestUser2(t, db)
+
+		var blocks []database.TblUserInsulinProfileBlock
+		require.NoError(t, db.LoadUserInsulinProfile(ctx, userID, &blocks))
+		assert.Empty(t, blocks)
+
+		dinner := &database.TblUserInsulinProfileBlock{
+			UserID:                   userID,
+			StartMinute:              17 * 60,
+			InsulinToCarbRatio:       1.5,
+			InsulinSensitivityFactor: 2.5,
+			TargetBloodSugar:         6,
+		}
+		dinnerID, err := db.AddUserInsulinProfileBlock(ctx, dinner)
 		require.NoError(t, err)
-		id2, err := db.AddUserPhoto(ctx, photo2)
+		require.NotZero(t, dinnerID)
+		dinner.ID = dinnerID
+
+		breakfastID, err := db.AddUserInsulinProfileBlock(ctx, &database.TblUserInsulinProfileBlock{
+			UserID:                   userID,
+			StartMinute:              6 * 60,
+			InsulinToCarbRatio:       2,
+			InsulinSensitivityFactor: 2,
+			TargetBloodSugar:         5.5,
+		})
 		require.NoError(t, err)
 
-		require.NoError(t, db.AddUserEventLogPhotos(ctx, logID, []int{id1, id2}))
-
-		// Duplicate insert must fail (primary key violation).
-		err = db.AddUserEventLogPhotos(ctx, logID, []int{id1})
+		// Two blocks cannot start at the same minute.
+		_, err = db.AddUserInsulinProfileBlock(ctx, &database.TblUserInsulinProfileBlock{
+			UserID:      userID,
+			StartMinute: 6 * 60,
+		})
 		require.Error(t, err)
+
+		require.NoError(t, db.LoadUserInsulinProfile(ctx, userID, &blocks))
+		require.Len(t, blocks, 2)
+		assert.Equal(t, breakfastID, blocks[0].ID)
+		assert.Equal(t, dinnerID, blocks[1].ID)
+		assert.Equal(t, 1.5, blocks[1].InsulinToCarbRatio)
+		assert.Equal(t, 2.5, blocks[1].InsulinSensitivityFactor)
+		assert.Equal(t, 6.0, blocks[1].TargetBloodSugar)
+
+		dinner.StartMinute = 18 * 60
+		dinner.InsulinToCarbRatio = 1.2
+		require.NoError(t, db.UpdateUserInsulinProfileBlock(ctx, dinner))
+
+		// Updates and deletes with the wrong user are a no-op.
+		moved := *dinner
+		moved.UserID = userID2
+		moved.StartMinute = 0
+		require.NoError(t, db.UpdateUserInsulinProfileBlock(ctx, &moved))
+		require.NoError(t, db.DeleteUserInsulinProfileBlock(ctx, userID2, breakfastID))
+
+		blocks = blocks[:0]
+		require.NoError(t, db.LoadUserInsulinProfile(ctx, userID, &blocks))
+		require.Len(t, blocks, 2)
+		assert.Equal(t, 18*60, blocks[1].StartMinute)
+		assert.Equal(t, 1.2, blocks[1].InsulinToCarbRatio)
+
+		var other []database.TblUserInsulinProfileBlock
+		require.NoError(t, db.LoadUserInsulinProfile(ctx, userID2, &other))
+		assert.Empty(t, other)
+
+		require.NoError(t, db.DeleteUserInsulinProfileBlock(ctx, userID, breakfastID))
+
+		blocks = blocks[:0]
+		require.NoError(t, db.LoadUserInsulinProfile(ctx, userID, &blocks))
+		require.Len(t, blocks, 1)
+		assert.Equal(t, dinnerID, blocks[0].ID)
+	})
+
+	t.Run("glucose_crud", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+		userID2 := getTestUser2(t, db)
+
+		start := time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC)
+
+		readings := make([]database.TblUserGlucose, 0, 12)
+		for i := range 12 {
+			readings = append(readings, database.TblUserGlucose{
+				UserTime: database.TimeMillis(start.Add(time.Duration(i) * 5 * time.Minute)),
+				Glucose:  5 + float64(i)/2,
+				Source:   "Dexcom G7",
+			})
+		}
+
+		added, err := db.AddUserGlucoseReadings(ctx, userID, readings[:8])
+		require.NoError(t, err)
+		assert.Equal(t, 8, added)
+
+		// Overlapping readings are skipped.
+		added, err = db.AddUserGlucoseReadings(ctx, userID, readings[4:])
+		require.NoError(t, err)
+		assert.Equal(t, 4, added)
+
+		var out []database.TblUserGlucose
+		require.NoError(t, db.LoadUserGlucoseBetween(ctx, userID, start, start.Add(time.Hour), &out))
+		require.Len(t, out, 12)
+		assert.Equal(t, start.UnixMilli(), out[0].UserTime.Time().UnixMilli())
+		assert.InDelta(t, 5, out[0].Glucose, 1e-9)
+		assert.InDelta(t, 10.5, out[11].Glucose, 1e-9)
+		assert.Equal(t, "Dexcom G7", out[11].Source)
+		assert.Equal(t, userID, out[11].UserID)
+
+		out = out[:0]
+		require.NoError(t, db.LoadUserGlucoseBetween(ctx, userID, start.Add(10*time.Minute), start.Add(20*time.Minute), &out))
+		require.Len(t, out, 2)
+
+		// Readings are per user.
+		var other []database.TblUserGlucose
+		require.NoError(t, db.LoadUserGlucoseBetween(ctx, userID2, start, start.Add(time.Hour), &other))
+		assert.Empty(t, other)
+
+		added, err = db.AddUserGlucoseReadings(ctx, userID2, readings[:1])
+		require.NoError(t, err)
+		assert.Equal(t, 1, added)
+
+		// Glucose can be a goal.
+		goal := database.TblUserGoal{
+			UserID:          userID,
+			TargetCol:       string(database.TargetColumnGlucose),
+			AggregationType: string(database.AggregationAvg),
+			ValueComparison: string(database.ComparisonLessThan),
+			TimeExpr:        "DAILY",
+		}
+
+		var progress database.UserGoalProgress
+		require.NoError(t, db.LoadUserGoalProgress(ctx, start.Add(2*time.Hour), 0, &goal, &progress))
+		assert.InDelta(t, 7.75, progress.CurrentValue, 1e-9)
+
+		require.NoError(t, db.DeleteUserGlucose(ctx, userID2, out[0].ID))
+		require.NoError(t, db.DeleteUserGlucose(ctx, userID, out[1].ID))
+
+		out = out[:0]
+		require.NoError(t, db.LoadUserGlucoseBetween(ctx, userID, start.Add(10*time.Minute), start.Add(20*time.Minute), &out))
+		require.Len(t, out, 1, "user2's delete must not remove user1's reading")
+
+		// Newest first, at most n.
+		out = out[:0]
+		require.NoError(t, db.LoadUserGlucoseBetweenN(ctx, userID, start, start.Add(time.Hour), 3, &out))
+		require.Len(t, out, 3)
+		assert.InDelta(t, 10.5, out[0].Glucose, 1e-9)
+		assert.InDelta(t, 9.5, out[2].Glucose, 1e-9)
+	})
+
+	t.Run("nightscout_secret", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+		userID2 := getTestUser2(t, db)
+
+		var user database.TblUser
+		err := db.LoadUserByNightscoutSecret(ctx, "secret-a", &user)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		require.NoError(t, db.SetUserNightscoutSecret(ctx, userID, "secret-a"))
+		require.NoError(t, db.SetUserNightscoutSecret(ctx, userID2, "secret-b"))
+
+		require.NoError(t, db.LoadUserByNightscoutSecret(ctx, "secret-a", &user))
+		assert.Equal(t, userID, user.ID)
+
+		require.NoError(t, db.LoadUserByNightscoutSecret(ctx, "secret-b", &user))
+		assert.Equal(t, userID2, user.ID)
+
+		// A new secret replaces the old one.
+		require.NoError(t, db.SetUserNightscoutSecret(ctx, userID, "secret-c"))
+		err = db.LoadUserByNightscoutSecret(ctx, "secret-a", &user)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		require.NoError(t, db.LoadUserByNightscoutSecret(ctx, "secret-c", &user))
+		assert.Equal(t, userID, user.ID)
+
+		require.NoError(t, db.DeleteUserNightscoutSecret(ctx, userID))
+		err = db.LoadUserByNightscoutSecret(ctx, "secret-c", &user)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		require.NoError(t, db.LoadUserByNightscoutSecret(ctx, "secret-b", &user))
+		assert.Equal(t, userID2, user.ID)
+	})
+
+	t.Run("eventlogs_between_n", func(t *testing.T) {
+
+		lock.Lock()
+		t.Cleanup(lock.Unlock)
+
+		ctx := t.Context()
+		db := newTestDB(t)
+
+		userID := getTestUser(t, db)
+
+		var event database.TblUserEvent
+		event.UserID = userID
+		event.Name = "Snack"
+		eventID, err := db.AddUserEvent(ctx, &event)
+		require.NoError(t, err)
+
+		start := time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC)
+
+		for i := range 4 {
+			_, err := db.AddUserEventLogWith(ctx, &database.TblUserEventLog{
+				UserID:             userID,
+				EventID:            eventID,
+				Event:              event.Name,
+				UserTime:           database.TimeMillis(start.Add(time.Duration(i) * time.Hour)),
+				ActualInsulinTaken: float64(i),
+			}, nil)
+			require.NoError(t, err)
+		}
+
+		var out []database.TblUserEventLog
+		require.NoError(t, db.LoadUserEventLogsBetweenN(ctx, userID, start, start.Add(3*time.Hour), 10, &out))
+		require.Len(t, out, 3)
+		assert.InDelta(t, 2, out[0].ActualInsulinTaken, 1e-9)
+		assert.InDelta(t, 0, out[2].ActualInsulinTaken, 1e-9)
+
+		out = out[:0]
+		require.NoError(t, db.LoadUserEventLogsBetweenN(ctx, userID, start, start.Add(time.Hour*24), 2, &out))
+		require.Len(t, out, 2)
+		assert.InDelta(t, 3, out[0].ActualInsulinTaken, 1e-9)
+
+		out = out[:0]
+		require.NoError(t, db.LoadUserEventLogsBetween(ctx, userID, start.Add(time.Hour), start.Add(time.Hour*24), &out))
+		require.Len(t, out, 3)
+		assert.InDelta(t, 1, out[0].ActualInsulinTaken, 1e-9)
+		assert.InDelta(t, 3, out[2].ActualInsulinTaken, 1e-9)
 	})
 }
 
@@ -1932,10 +3828,13 @@ func TestDB_Postgres(t *testing.T) {
 			"pon.user_eventlog_photo",
 			"pon.user_food",
 			"pon.user_foodlog",
+			"pon.user_glucose",
 			"pon.user_goal",
+			"pon.user_insulin_profile",
 			"pon.user_medication",
 			"pon.user_medication_schedule",
 			"pon.user_medicationlog",
+			"pon.user_nightscout",
 			"pon.user_photo",
 			"pon.user_session",
 			"pon.user_tag",
@@ -1976,10 +3875,13 @@ func TestDB_Sqlite(t *testing.T) {
 			"PON_USER_EVENTLOG_PHOTO",
 			"PON_USER_FOOD",
 			"PON_USER_FOODLOG",
+			"PON_USER_GLUCOSE",
 			"PON_USER_GOAL",
-			// "PON_USER_MEDICATION",
-			// "PON_USER_MEDICATION_SCHEDULE",
-			// "PON_USER_MEDICATIONLOG",
+			"PON_USER_INSULIN_PROFILE",
+			"PON_USER_MEDICATION",
+			"PON_USER_MEDICATION_SCHEDULE",
+			"PON_USER_MEDICATIONLOG",
+			"PON_USER_NIGHTSCOUT",
 			"PON_USER_PHOTO",
 			"PON_USER_SESSION",
 			"PON_USER_TAG",
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"database/sql/driver"
+	"karopon/src/units"
+)
+
+// HouseholdUnits are a food's own units, like a slice or a scoop, by name as an amount of a standard unit.
+// It is stored as a JSON object.
+type HouseholdUnits map[string]units.Quantity
+
+// MarshalJSON writes nil as an empty object.
+func (h HouseholdUnits) MarshalJSON() ([]byte, error) {
+	return marshalJSONObject(h)
+}
+
+func (h HouseholdUnits) Value() (driver.Value, error) {
+
+	b, err := h.MarshalJSON()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan reads the JSON object, without any units it is nil.
+func (h *HouseholdUnits) Scan(src any) error {
+	return scanJSONObject(src, h)
+}
+
+// Converter converts amounts of the food between the standard units and its household units.
+func (f *TblUserFood) Converter() units.Converter {
+	return units.Converter{Density: f.Density, Household: f.HouseholdUnits}
+}
+
+// FillFoodLog sets the foodlog's food and its amounts for the foodlog's portion,
+// which can be in any unit the food converts into.
+func (f *TblUserFood) FillFoodLog(food *TblUserFoodLog) error {
+
+	amount, err := f.Converter().Convert(food.Portion, food.Unit, f.Unit)
+
+	if err != nil {
+		return err
+	}
+
+	scale := amount
+
+	if f.Portion > 0 {
+		scale /= f.Portion
+	}
+
+	food.FoodID = &f.ID
+	food.Protein = f.Protein * scale
+	food.Carb = f.Carb * scale
+	food.Fibre = f.Fibre * scale
+	food.Fat = f.Fat * scale
+	food.Nutrients = f.Nutrients.Scaled(scale)
+
+	return nil
+}
+
+// MatchFoodUnit returns the food in the given unit,
+// or otherwise the first food the unit converts into.
+func MatchFoodUnit(foods []TblUserFood, unit string) (*TblUserFood, bool) {
+
+	for i := range foods {
+		if foods[i].Unit == unit {
+			return &foods[i], true
+		}
+	}
+
+	for i := range foods {
+		if _, err := foods[i].Converter().Convert(1, unit, foods[i].Unit); err == nil {
+			return &foods[i], true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"errors"
+	"math"
+)
+
+// GlucoseUnit is the unit a user enters and reads blood glucose in.
+// Glucose is always stored in mmol/L, the API converts to and from the user's unit.
+type GlucoseUnit string
+
+const (
+	GlucoseUnitMmol GlucoseUnit = "mmol/L"
+	GlucoseUnitMgdl GlucoseUnit = "mg/dL"
+)
+
+// MaxMmolGlucose is higher than any real reading in mmol/L, so a larger value must be in mg/dL.
+const MaxMmolGlucose = 35
+
+var ErrInvalidGlucoseUnit = errors.New("invalid glucose unit")
+
+func (u GlucoseUnit) IsValid() bool {
+	switch u {
+	case GlucoseUnitMmol, GlucoseUnitMgdl:
+		return true
+	default:
+		return false
+	}
+}
+
+// FromMmol converts the stored glucose value into this unit.
+// mg/dL is rounded to 4 decimals, so that values entered in mg/dL read back the same.
+func (u GlucoseUnit) FromMmol(glucose float64) float64 {
+
+	if u != GlucoseUnitMgdl {
+		return glucose
+	}
+
+	return math.Round(glucose*MgdlPerMmol*1e4) / 1e4
+}
+
+// ToMmol converts the glucose value in this unit into mmol/L for storing.
+func (u GlucoseUnit) ToMmol(glucose float64) float64 {
+
+	if u != GlucoseUnitMgdl {
+		return glucose
+	}
+
+	return glucose / MgdlPerMmol
+}
+
+// GlucoseFromMmol converts the glucose values of the eventlog into the unit, the insulin sensitivity is glucose per unit.
+func (e *TblUserEventLog) GlucoseFromMmol(u GlucoseUnit) {
+	e.BloodGlucose = u.FromMmol(e.BloodGlucose)
+	e.BloodGlucoseTarget = u.FromMmol(e.BloodGlucoseTarget)
+	e.InsulinSensitivityFactor = u.FromMmol(e.InsulinSensitivityFactor)
+}
+
+// GlucoseToMmol is the inverse of GlucoseFromMmol.
+func (e *TblUserEventLog) GlucoseToMmol(u GlucoseUnit) {
+	e.BloodGlucose = u.ToMmol(e.BloodGlucose)
+	e.BloodGlucoseTarget = u.ToMmol(e.BloodGlucoseTarget)
+	e.InsulinSensitivityFactor = u.ToMmol(e.InsulinSensitivityFactor)
+}
+
+// GlucoseFromMmol converts the user's glucose settings into their glucose unit.
+func (u *TblUser) GlucoseFromMmol() {
+	u.TargetBloodSugar = u.GlucoseUnit.FromMmol(u.TargetBloodSugar)
+	u.InsulinSensitivityFactor = u.GlucoseUnit.FromMmol(u.InsulinSensitivityFactor)
+}
+
+// GlucoseToMmol is the inverse of GlucoseFromMmol, the settings are in the given unit,
+// which is not their glucose unit while it is being changed.
+func (u *TblUser) GlucoseToMmol(unit GlucoseUnit) {
+	u.TargetBloodSugar = unit.ToMmol(u.TargetBloodSugar)
+	u.InsulinSensitivityFactor = unit.ToMmol(u.InsulinSensitivityFactor)
+}
+
+// GlucoseFromMmol converts the target of a goal on glucose into the unit, other goals are left alone.
+func (g *TblUserGoal) GlucoseFromMmol(u GlucoseUnit) {
+	if g.TargetColumn().IsGlucose() {
+		g.TargetValue = u.FromMmol(g.TargetValue)
+	}
+}
+
+// GlucoseToMmol is the inverse of GlucoseFromMmol.
+func (g *TblUserGoal) GlucoseToMmol(u GlucoseUnit) {
+	if g.TargetColumn().IsGlucose() {
+		g.TargetValue = u.ToMmol(g.TargetValue)
+	}
+}
+
+// GlucoseFromMmol converts the block's glucose values into the unit.
+func (b *TblUserInsulinProfileBlock) GlucoseFromMmol(u GlucoseUnit) {
+	b.InsulinSensitivityFactor = u.FromMmol(b.InsulinSensitivityFactor)
+	b.TargetBloodSugar = u.FromMmol(b.TargetBloodSugar)
+}
+
+// GlucoseToMmol is the inverse of GlucoseFromMmol.
+func (b *TblUserInsulinProfileBlock) GlucoseToMmol(u GlucoseUnit) {
+	b.InsulinSensitivityFactor = u.ToMmol(b.InsulinSensitivityFactor)
+	b.TargetBloodSugar = u.ToMmol(b.TargetBloodSugar)
+}
+
+// GlucoseFromMmol converts the glucose values of the metrics into the unit, the percentages stay the same.
+func (m *GlucoseMetrics) GlucoseFromMmol(u GlucoseUnit) {
+	m.RangeLow = u.FromMmol(m.RangeLow)
+	m.RangeHigh = u.FromMmol(m.RangeHigh)
+	m.Mean = u.FromMmol(m.Mean)
+	m.StdDev = u.FromMmol(m.StdDev)
+}
+
+// GlucoseFromMmol converts the percentiles into the unit.
+func (p *GlucoseHourPercentiles) GlucoseFromMmol(u GlucoseUnit) {
+	p.P5 = u.FromMmol(p.P5)
+	p.P25 = u.FromMmol(p.P25)
+	p.P50 = u.FromMmol(p.P50)
+	p.P75 = u.FromMmol(p.P75)
+	p.P95 = u.FromMmol(p.P95)
+}
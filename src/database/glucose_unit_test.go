@@ -0,0 +1,63 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlucoseUnit(t *testing.T) {
+	assert.True(t, GlucoseUnitMmol.IsValid())
+	assert.True(t, GlucoseUnitMgdl.IsValid())
+	assert.False(t, GlucoseUnit("mg/dl").IsValid())
+
+	assert.Equal(t, 5.5, GlucoseUnitMmol.FromMmol(5.5))
+	assert.Equal(t, 5.5, GlucoseUnitMmol.ToMmol(5.5))
+
+	// Users created before the setting have no unit, which is mmol/L.
+	assert.Equal(t, 5.5, GlucoseUnit("").FromMmol(5.5))
+
+	assert.InDelta(t, 99.1001, GlucoseUnitMgdl.FromMmol(5.5), 1e-9)
+	assert.InDelta(t, 5.5, GlucoseUnitMgdl.ToMmol(99.1001), 1e-4)
+
+	// What is entered in mg/dL reads back the same.
+	for _, mgdl := range []float64{40, 99, 120, 180, 250.5, 400} {
+		assert.Equal(t, mgdl, GlucoseUnitMgdl.FromMmol(GlucoseUnitMgdl.ToMmol(mgdl)))
+	}
+}
+
+func TestGlucoseUnitGoal(t *testing.T) {
+	goal := TblUserGoal{TargetCol: string(TargetColumnEventBloodSugar), TargetValue: 126}
+	goal.GlucoseToMmol(GlucoseUnitMgdl)
+	assert.InDelta(t, 126/MgdlPerMmol, goal.TargetValue, 1e-9)
+
+	goal.GlucoseFromMmol(GlucoseUnitMgdl)
+	assert.Equal(t, 126.0, goal.TargetValue)
+
+	// Only goals on glucose are converted.
+	goal = TblUserGoal{TargetCol: string(TargetColumnCarbs), TargetValue: 126}
+	goal.GlucoseToMmol(GlucoseUnitMgdl)
+	assert.Equal(t, 126.0, goal.TargetValue)
+}
+
+func TestGlucoseUnitEventLog(t *testing.T) {
+	eventlog := TblUserEventLog{
+		BloodGlucose:             180,
+		BloodGlucoseTarget:       100,
+		InsulinSensitivityFactor: 50,
+		InsulinToCarbRatio:       10,
+		NetCarbs:                 40,
+	}
+
+	eventlog.GlucoseToMmol(GlucoseUnitMgdl)
+	assert.InDelta(t, 180/MgdlPerMmol, eventlog.BloodGlucose, 1e-9)
+	assert.InDelta(t, 100/MgdlPerMmol, eventlog.BloodGlucoseTarget, 1e-9)
+	assert.InDelta(t, 50/MgdlPerMmol, eventlog.InsulinSensitivityFactor, 1e-9)
+	assert.Equal(t, 10.0, eventlog.InsulinToCarbRatio)
+	assert.Equal(t, 40.0, eventlog.NetCarbs)
+
+	eventlog.GlucoseFromMmol(GlucoseUnitMgdl)
+	assert.Equal(t, 180.0, eventlog.BloodGlucose)
+	assert.Equal(t, 100.0, eventlog.BloodGlucoseTarget)
+	assert.Equal(t, 50.0, eventlog.InsulinSensitivityFactor)
+}
@@ -0,0 +1,41 @@
+package database
+
+import "time"
+
+// MinutesPerDay is one past the last StartMinute of an insulin profile block.
+const MinutesPerDay = 24 * 60
+
+// ActiveInsulinProfileBlock returns the block of the profile in effect at the given time.
+//
+// The time must already be in the user's timezone, blocks follow the wall clock and ignore the day offset.
+// Before the first block of the day, the last block from the day before is still in effect.
+// Returns false if the profile has no blocks.
+func ActiveInsulinProfileBlock(blocks []TblUserInsulinProfileBlock, at time.Time) (TblUserInsulinProfileBlock, bool) {
+
+	if len(blocks) == 0 {
+		return TblUserInsulinProfileBlock{}, false
+	}
+
+	minute := at.Hour()*60 + at.Minute()
+
+	var today, last *TblUserInsulinProfileBlock
+
+	for i := range blocks {
+
+		b := &blocks[i]
+
+		if b.StartMinute <= minute && (today == nil || b.StartMinute > today.StartMinute) {
+			today = b
+		}
+
+		if last == nil || b.StartMinute > last.StartMinute {
+			last = b
+		}
+	}
+
+	if today != nil {
+		return *today, true
+	}
+
+	return *last, true
+}
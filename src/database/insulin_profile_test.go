@@ -0,0 +1,64 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActiveInsulinProfileBlock(t *testing.T) {
+	_, ok := ActiveInsulinProfileBlock(nil, time.Now())
+	assert.False(t, ok)
+
+	// Out of order on purpose.
+	blocks := []TblUserInsulinProfileBlock{
+		{ID: 3, StartMinute: 17 * 60, InsulinToCarbRatio: 12},    // dinner
+		{ID: 1, StartMinute: 6 * 60, InsulinToCarbRatio: 8},      // breakfast
+		{ID: 2, StartMinute: 11*60 + 30, InsulinToCarbRatio: 10}, // lunch
+	}
+
+	day := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+
+	cases := map[time.Duration]int{
+		6 * time.Hour:                 1,
+		6*time.Hour - time.Minute:     3, // still dinner from last night
+		0:                             3,
+		7 * time.Hour:                 1,
+		11*time.Hour + 29*time.Minute: 1,
+		11*time.Hour + 30*time.Minute: 2,
+		23 * time.Hour:                3,
+	}
+
+	for offset, want := range cases {
+		got, ok := ActiveInsulinProfileBlock(blocks, day.Add(offset))
+		require.True(t, ok)
+		assert.Equal(t, want, got.ID, "at %s", offset)
+	}
+}
+
+func TestActiveInsulinProfileBlock_WallClock(t *testing.T) {
+	loc, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	blocks := []TblUserInsulinProfileBlock{
+		{ID: 1, StartMinute: 6 * 60},
+		{ID: 2, StartMinute: 12 * 60},
+	}
+
+	// 15:00 UTC is 11:00 in Toronto during DST, and 10:00 outside of it.
+	summer := time.Date(2026, 7, 1, 15, 0, 0, 0, time.UTC).In(loc)
+
+	got, ok := ActiveInsulinProfileBlock(blocks, summer)
+	require.True(t, ok)
+	assert.Equal(t, 1, got.ID)
+
+	// 16:00 UTC is noon in the summer.
+	got, _ = ActiveInsulinProfileBlock(blocks, summer.Add(time.Hour))
+	assert.Equal(t, 2, got.ID)
+
+	// But only 11:00 in the winter.
+	got, _ = ActiveInsulinProfileBlock(blocks, time.Date(2026, 1, 15, 16, 0, 0, 0, time.UTC).In(loc))
+	assert.Equal(t, 1, got.ID)
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// scanJSONObject reads a JSON object column into the map, an empty object is nil.
+func scanJSONObject[T ~map[K]V, K comparable, V any](src any, out *T) error {
+
+	var b []byte
+
+	switch v := src.(type) {
+	case nil:
+		*out = nil
+		return nil
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, *out) //nolint:err113
+	}
+
+	var m T
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	if len(m) == 0 {
+		m = nil
+	}
+
+	*out = m
+
+	return nil
+}
+
+// marshalJSONObject writes the map as a JSON object, nil is an empty object.
+func marshalJSONObject[T ~map[K]V, K comparable, V any](m T) ([]byte, error) {
+
+	if m == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(map[K]V(m))
+}
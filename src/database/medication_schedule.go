@@ -0,0 +1,244 @@
+package database
+
+import (
+	"slices"
+	"time"
+)
+
+// MedicationDoseStatus is the state of a single scheduled dose.
+type MedicationDoseStatus string
+
+const (
+	// The dose has a log marked as taken.
+	DoseStatusTaken MedicationDoseStatus = "taken"
+	// The dose has a log marked as not taken.
+	DoseStatusSkipped MedicationDoseStatus = "skipped"
+	// The dose has no log and is more than MedicationDoseGracePeriod past due.
+	DoseStatusMissed MedicationDoseStatus = "missed"
+	// The dose has no log and is not yet past the grace period.
+	DoseStatusPending MedicationDoseStatus = "pending"
+)
+
+// MedicationDoseGracePeriod is how long after the due time a dose without a log is still pending.
+const MedicationDoseGracePeriod = time.Hour
+
+// MedicationDose is a single due time for a schedule, joined with the log that covers it (if any).
+type MedicationDose struct {
+	MedicationID int                  `json:"medication_id"`
+	ScheduleID   int                  `json:"schedule_id"`
+	DueTime      TimeMillis           `json:"due_time"`
+	Status       MedicationDoseStatus `json:"status"`
+	LogID        int                  `json:"log_id"` // 0 when there is no log for this dose
+	TakenTime    TimeMillis           `json:"taken_time"`
+}
+
+// HasTimes returns true if the schedule has at least one minute and hour set, without those it never fires.
+func (s *TblUserMedicationSchedule) HasTimes() bool {
+	return s.MinutesOfHourMask&(1<<60-1) != 0 && s.HoursOfDayMask&(1<<24-1) != 0
+}
+
+// MatchesDay returns true if the schedule fires on the given day.
+//
+// The day, month, and weekday are read directly from the given time,
+// so for users with a day offset the time should already have the offset subtracted.
+//
+// A day-of-week, day-of-month, or month mask of 0 matches every value,
+// and unlike cron all of the day masks must match (they are AND'd, not OR'd).
+func (s *TblUserMedicationSchedule) MatchesDay(day time.Time) bool {
+
+	// time.Weekday is 0 = sunday, the mask is 0 = monday
+	weekday := (int(day.Weekday()) + 6) % 7
+
+	if s.DaysOfWeekMask != 0 && s.DaysOfWeekMask&(1<<weekday) == 0 {
+		return false
+	}
+
+	if s.DaysOfMonthMask != 0 && s.DaysOfMonthMask&(1<<(day.Day()-1)) == 0 {
+		return false
+	}
+
+	if s.MonthsOfYearMask != 0 && s.MonthsOfYearMask&(1<<(int(day.Month())-1)) == 0 {
+		return false
+	}
+
+	return true
+}
+
+// ClipToDates clips [start, end) to the days the medication is taken, from its StartDate to its EndDate inclusive.
+//
+// The dates are calendar days in loc, and like every user day they begin shift after midnight,
+// so a medication ending on the 15th is still due late on the 15th for a user far from UTC.
+// A zero StartDate or EndDate leaves that side open.
+func (m *TblUserMedication) ClipToDates(
+	start, end time.Time,
+	loc *time.Location,
+	shift time.Duration,
+) (time.Time, time.Time) {
+
+	if d := m.StartDate.Time(); !d.IsZero() {
+
+		d = d.In(loc)
+
+		if first := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc).Add(shift); first.After(start) {
+			start = first
+		}
+	}
+
+	if d := m.EndDate.Time(); !d.IsZero() {
+
+		d = d.In(loc)
+
+		if last := time.Date(d.Year(), d.Month(), d.Day()+1, 0, 0, 0, 0, loc).Add(shift); last.Before(end) {
+			end = last
+		}
+	}
+
+	return start, end
+}
+
+// DueTimes returns every time in [start, end) the schedule fires at, in ascending order.
+//
+// The hour and minute masks are wall-clock times in loc.
+// shift is the user's DayTimeOffsetSeconds as a time.Duration, and decides which day a time belongs to.
+// With a 2am day start, a dose at 01:00 belongs to the day before,
+// so a monday only schedule at 01:00 fires at 01:00 on tuesday.
+func (s *TblUserMedicationSchedule) DueTimes(
+	start, end time.Time,
+	loc *time.Location,
+	shift time.Duration,
+) []time.Time {
+
+	if !s.HasTimes() || !start.Before(end) {
+		return nil
+	}
+
+	var times []time.Time
+
+	// Start a day early, a user day can begin on the calendar day before start.
+	first := start.In(loc).Add(-shift)
+	day := time.Date(first.Year(), first.Month(), first.Day()-1, 0, 0, 0, 0, loc)
+	last := end.In(loc).Add(-shift)
+	lastDay := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, loc)
+
+	for ; !day.After(lastDay); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+
+		if !s.MatchesDay(day) {
+			continue
+		}
+
+		for h := range 24 {
+
+			if s.HoursOfDayMask&(1<<h) == 0 {
+				continue
+			}
+
+			for m := range 60 {
+
+				if s.MinutesOfHourMask&(1<<m) == 0 {
+					continue
+				}
+
+				// Move the time of day into the user's day, which is [shift, shift+24h).
+				ofDay := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+				dayAdj := 0
+
+				for ofDay < shift {
+					ofDay += 24 * time.Hour
+					dayAdj++
+				}
+				for ofDay >= shift+24*time.Hour {
+					ofDay -= 24 * time.Hour
+					dayAdj--
+				}
+
+				due := time.Date(day.Year(), day.Month(), day.Day()+dayAdj, h, m, 0, 0, loc)
+
+				if !due.Before(start) && due.Before(end) {
+					times = append(times, due)
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+
+	return times
+}
+
+// MatchMedicationDoses pairs each due time with the log closest to it.
+//
+// The logs must belong to the given schedule, logs are taken in time order and
+// each one claims the nearest due time that no earlier log has claimed.
+// Logs left over after every due time is claimed are ignored.
+// Due times without a log are missed once they are more than MedicationDoseGracePeriod older than now.
+func MatchMedicationDoses(
+	medicationID int,
+	scheduleID int,
+	dueTimes []time.Time,
+	logs []TblUserMedicationLog,
+	now time.Time,
+) []MedicationDose {
+
+	doses := make([]MedicationDose, len(dueTimes))
+
+	for i, due := range dueTimes {
+		doses[i] = MedicationDose{
+			MedicationID: medicationID,
+			ScheduleID:   scheduleID,
+			DueTime:      TimeMillis(due),
+		}
+	}
+
+	sorted := slices.Clone(logs)
+	slices.SortFunc(sorted, func(a, b TblUserMedicationLog) int {
+		return a.TakenTime.Time().Compare(b.TakenTime.Time())
+	})
+
+	for _, log := range sorted {
+
+		best := -1
+		var bestDist time.Duration
+
+		for i := range doses {
+
+			if doses[i].LogID != 0 {
+				continue
+			}
+
+			dist := doses[i].DueTime.Time().Sub(log.TakenTime.Time()).Abs()
+
+			if best == -1 || dist < bestDist {
+				best = i
+				bestDist = dist
+			}
+		}
+
+		if best == -1 {
+			break
+		}
+
+		doses[best].LogID = log.ID
+		doses[best].TakenTime = log.TakenTime
+
+		if log.Taken {
+			doses[best].Status = DoseStatusTaken
+		} else {
+			doses[best].Status = DoseStatusSkipped
+		}
+	}
+
+	for i := range doses {
+
+		if doses[i].LogID != 0 {
+			continue
+		}
+
+		if now.Sub(doses[i].DueTime.Time()) > MedicationDoseGracePeriod {
+			doses[i].Status = DoseStatusMissed
+		} else {
+			doses[i].Status = DoseStatusPending
+		}
+	}
+
+	return doses
+}
@@ -0,0 +1,236 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// everyDayAt returns a schedule firing every day at each of the given hours, on the given minute.
+func everyDayAt(minute int, hours ...int) TblUserMedicationSchedule {
+
+	s := TblUserMedicationSchedule{MinutesOfHourMask: 1 << minute}
+
+	for _, h := range hours {
+		s.HoursOfDayMask |= 1 << h
+	}
+
+	return s
+}
+
+func TestMedicationSchedule_NoTimes(t *testing.T) {
+	start := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+
+	// Without a minute or an hour the schedule never fires.
+	s := TblUserMedicationSchedule{HoursOfDayMask: 1 << 8}
+	assert.Empty(t, s.DueTimes(start, end, time.UTC, 0))
+
+	s = TblUserMedicationSchedule{MinutesOfHourMask: 1}
+	assert.Empty(t, s.DueTimes(start, end, time.UTC, 0))
+}
+
+func TestMedicationSchedule_EveryDay(t *testing.T) {
+	s := everyDayAt(30, 8, 20)
+
+	start := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+	got := s.DueTimes(start, start.AddDate(0, 0, 2), time.UTC, 0)
+
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 4, 13, 8, 30, 0, 0, time.UTC),
+		time.Date(2026, 4, 13, 20, 30, 0, 0, time.UTC),
+		time.Date(2026, 4, 14, 8, 30, 0, 0, time.UTC),
+		time.Date(2026, 4, 14, 20, 30, 0, 0, time.UTC),
+	}, got)
+}
+
+func TestMedicationSchedule_WindowIsHalfOpen(t *testing.T) {
+	s := everyDayAt(0, 8)
+
+	start := time.Date(2026, 4, 13, 8, 0, 0, 0, time.UTC)
+	got := s.DueTimes(start, start.AddDate(0, 0, 1), time.UTC, 0)
+
+	// The start is included, the end is not.
+	assert.Equal(t, []time.Time{start}, got)
+}
+
+func TestMedicationSchedule_DayMasks(t *testing.T) {
+	// 2026-04-13 is a monday.
+	start := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+
+	// Monday and Wednesday.
+	s := everyDayAt(0, 9)
+	s.DaysOfWeekMask = 1<<0 | 1<<2
+
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 4, 13, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 15, 9, 0, 0, 0, time.UTC),
+	}, s.DueTimes(start, start.AddDate(0, 0, 7), time.UTC, 0))
+
+	// The 1st and the 15th.
+	s = everyDayAt(0, 9)
+	s.DaysOfMonthMask = 1<<0 | 1<<14
+
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 4, 15, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC),
+	}, s.DueTimes(start, start.AddDate(0, 0, 30), time.UTC, 0))
+
+	// The 1st, but only in june.
+	s.DaysOfMonthMask = 1 << 0
+	s.MonthsOfYearMask = 1 << 5
+
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC),
+	}, s.DueTimes(start, start.AddDate(0, 3, 0), time.UTC, 0))
+
+	// Day masks are AND'd, the 15th is a wednesday but the 1st of may is a friday.
+	s = everyDayAt(0, 9)
+	s.DaysOfWeekMask = 1 << 2
+	s.DaysOfMonthMask = 1<<0 | 1<<14
+
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 4, 15, 9, 0, 0, 0, time.UTC),
+	}, s.DueTimes(start, start.AddDate(0, 0, 30), time.UTC, 0))
+}
+
+func TestMedicationSchedule_DayOffset(t *testing.T) {
+	// The user's day starts at 2am.
+	shift := 2 * time.Hour
+
+	// Monday only, at 01:00 and 23:00.
+	s := everyDayAt(0, 1, 23)
+	s.DaysOfWeekMask = 1 << 0
+
+	// The user's monday is 2026-04-13 02:00 to 2026-04-14 02:00.
+	start := time.Date(2026, 4, 13, 2, 0, 0, 0, time.UTC)
+	got := s.DueTimes(start.AddDate(0, 0, -1), start.AddDate(0, 0, 2), time.UTC, shift)
+
+	// 01:00 is the end of the user's monday, so it fires on tuesday's calendar day.
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 4, 13, 23, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 14, 1, 0, 0, 0, time.UTC),
+	}, got)
+}
+
+func TestMedicationSchedule_Timezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	s := everyDayAt(0, 8)
+
+	// DST starts 2026-03-08 in Toronto, the dose stays at 08:00 wall-clock on both sides.
+	start := time.Date(2026, 3, 7, 0, 0, 0, 0, loc)
+	got := s.DueTimes(start, start.AddDate(0, 0, 2), loc, 0)
+
+	require.Len(t, got, 2)
+	assert.Equal(t, time.Date(2026, 3, 7, 13, 0, 0, 0, time.UTC), got[0].UTC())
+	assert.Equal(t, time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC), got[1].UTC())
+}
+
+func TestMedicationClipToDates(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+
+	morning := everyDayAt(0, 8)
+	twice := everyDayAt(0, 8, 22)
+	night := everyDayAt(0, 2)
+
+	// Taken on the 13th and the 14th, the dates are the user's midnight of those days.
+	med := TblUserMedication{
+		StartDate: TimeMillis(time.Date(2026, 4, 13, 0, 0, 0, 0, sydney)),
+		EndDate:   TimeMillis(time.Date(2026, 4, 14, 0, 0, 0, 0, sydney)),
+	}
+
+	// The first day in Sydney starts before the 13th in UTC, its morning dose is due.
+	day := time.Date(2026, 4, 13, 0, 0, 0, 0, sydney)
+	start, end := med.ClipToDates(day, day.AddDate(0, 0, 1), sydney, 0)
+	assert.Equal(t, day, start)
+	assert.Equal(t, day.AddDate(0, 0, 1), end)
+	assert.Len(t, morning.DueTimes(start, end, sydney, 0), 1)
+
+	// The day after the end date has nothing due.
+	day = time.Date(2026, 4, 15, 0, 0, 0, 0, sydney)
+	start, end = med.ClipToDates(day, day.AddDate(0, 0, 1), sydney, 0)
+	assert.Empty(t, morning.DueTimes(start, end, sydney, 0))
+
+	med = TblUserMedication{
+		StartDate: TimeMillis(time.Date(2026, 4, 13, 0, 0, 0, 0, toronto)),
+		EndDate:   TimeMillis(time.Date(2026, 4, 14, 0, 0, 0, 0, toronto)),
+	}
+
+	// The last day in Toronto ends after the 14th in UTC, its late evening dose is due.
+	day = time.Date(2026, 4, 14, 0, 0, 0, 0, toronto)
+	start, end = med.ClipToDates(day, day.AddDate(0, 0, 1), toronto, 0)
+	assert.Equal(t, day.AddDate(0, 0, 1), end)
+	assert.Len(t, twice.DueTimes(start, end, toronto, 0), 2)
+
+	// The user's day starts at 04:00, the dose at 02:00 on the 15th is still the 14th.
+	shift := 4 * time.Hour
+	start, end = med.ClipToDates(day.Add(shift), day.AddDate(0, 0, 1).Add(shift), toronto, shift)
+	assert.Equal(t, day.AddDate(0, 0, 1).Add(shift), end)
+	assert.Len(t, night.DueTimes(start, end, toronto, shift), 1)
+
+	// Without dates the window is left as is.
+	med = TblUserMedication{}
+	start, end = med.ClipToDates(day, day.AddDate(0, 0, 1), toronto, 0)
+	assert.Equal(t, day, start)
+	assert.Equal(t, day.AddDate(0, 0, 1), end)
+}
+
+func TestMatchMedicationDoses(t *testing.T) {
+	day := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+	due := []time.Time{
+		day.Add(8 * time.Hour),
+		day.Add(14 * time.Hour),
+		day.Add(20 * time.Hour),
+	}
+
+	logs := []TblUserMedicationLog{
+		// Late dose, still closest to 14:00.
+		{ID: 2, ScheduleID: 5, TakenTime: TimeMillis(day.Add(15 * time.Hour)), Taken: false},
+		// Early dose, closest to 08:00.
+		{ID: 1, ScheduleID: 5, TakenTime: TimeMillis(day.Add(7*time.Hour + 50*time.Minute)), Taken: true},
+	}
+
+	now := day.Add(20*time.Hour + 30*time.Minute)
+	doses := MatchMedicationDoses(3, 5, due, logs, now)
+
+	require.Len(t, doses, 3)
+
+	assert.Equal(t, DoseStatusTaken, doses[0].Status)
+	assert.Equal(t, 1, doses[0].LogID)
+	assert.Equal(t, 3, doses[0].MedicationID)
+	assert.Equal(t, 5, doses[0].ScheduleID)
+
+	assert.Equal(t, DoseStatusSkipped, doses[1].Status)
+	assert.Equal(t, 2, doses[1].LogID)
+
+	// 20:00 is only 30 minutes ago.
+	assert.Equal(t, DoseStatusPending, doses[2].Status)
+	assert.Zero(t, doses[2].LogID)
+
+	doses = MatchMedicationDoses(3, 5, due, logs, now.Add(time.Hour))
+	assert.Equal(t, DoseStatusMissed, doses[2].Status)
+}
+
+func TestMatchMedicationDoses_ExtraLogs(t *testing.T) {
+	day := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+	due := []time.Time{day.Add(8 * time.Hour)}
+
+	logs := []TblUserMedicationLog{
+		{ID: 1, TakenTime: TimeMillis(day.Add(8 * time.Hour)), Taken: true},
+		{ID: 2, TakenTime: TimeMillis(day.Add(9 * time.Hour)), Taken: true},
+	}
+
+	doses := MatchMedicationDoses(1, 1, due, logs, day.Add(12*time.Hour))
+
+	require.Len(t, doses, 1)
+	assert.Equal(t, 1, doses[0].LogID)
+	assert.Equal(t, DoseStatusTaken, doses[0].Status)
+}
@@ -0,0 +1,87 @@
+/*
+The medication tables were created back in 0006_add_more_tables but nothing ever wrote to them.
+
+The BIT(n) masks can't be scanned into Go integers, and almost every column allowed NULL,
+so before anything uses these tables the masks become plain integers and the columns get NOT NULL defaults.
+*/
+ALTER TABLE PON.USER_MEDICATION
+ALTER COLUMN START_DATE TYPE TIMESTAMP USING START_DATE::TIMESTAMP,
+ALTER COLUMN END_DATE   TYPE TIMESTAMP USING END_DATE::TIMESTAMP;
+
+UPDATE PON.USER_MEDICATION SET CATEGORY      = '' WHERE CATEGORY      IS NULL;
+UPDATE PON.USER_MEDICATION SET DOSAGE_AMOUNT = 0  WHERE DOSAGE_AMOUNT IS NULL;
+UPDATE PON.USER_MEDICATION SET DOSAGE_UNIT   = '' WHERE DOSAGE_UNIT   IS NULL;
+UPDATE PON.USER_MEDICATION SET FORM          = '' WHERE FORM          IS NULL;
+UPDATE PON.USER_MEDICATION SET NOTES         = '' WHERE NOTES         IS NULL;
+
+ALTER TABLE PON.USER_MEDICATION
+ALTER COLUMN CATEGORY      SET DEFAULT '',
+ALTER COLUMN CATEGORY      SET NOT NULL,
+ALTER COLUMN DOSAGE_AMOUNT SET DEFAULT 0,
+ALTER COLUMN DOSAGE_AMOUNT SET NOT NULL,
+ALTER COLUMN DOSAGE_UNIT   SET DEFAULT '',
+ALTER COLUMN DOSAGE_UNIT   SET NOT NULL,
+ALTER COLUMN FORM          SET DEFAULT '',
+ALTER COLUMN FORM          SET NOT NULL,
+ALTER COLUMN NOTES         SET DEFAULT '',
+ALTER COLUMN NOTES         SET NOT NULL;
+
+
+/*
+bit 0 of each mask is the first value (minute 0, hour 0, monday, the 1st, january).
+*/
+ALTER TABLE PON.USER_MEDICATION_SCHEDULE
+ALTER COLUMN MINUTES_OF_HOUR_MASK TYPE BIGINT   USING COALESCE(MINUTES_OF_HOUR_MASK::BIGINT, 0),
+ALTER COLUMN HOURS_OF_DAY_MASK    TYPE INTEGER  USING COALESCE(HOURS_OF_DAY_MASK::INTEGER, 0),
+ALTER COLUMN DAYS_OF_WEEK_MASK    TYPE SMALLINT USING COALESCE(DAYS_OF_WEEK_MASK::INTEGER, 0),
+ALTER COLUMN DAYS_OF_MONTH_MASK   TYPE INTEGER  USING COALESCE(DAYS_OF_MONTH_MASK::INTEGER, 0),
+ALTER COLUMN MONTH_OF_YEAR_MASK   TYPE SMALLINT USING COALESCE(MONTH_OF_YEAR_MASK::INTEGER, 0);
+
+UPDATE PON.USER_MEDICATION_SCHEDULE SET WITH_FOOD        = FALSE WHERE WITH_FOOD        IS NULL;
+UPDATE PON.USER_MEDICATION_SCHEDULE SET FASTING          = FALSE WHERE FASTING          IS NULL;
+UPDATE PON.USER_MEDICATION_SCHEDULE SET REMINDER_ENABLED = TRUE  WHERE REMINDER_ENABLED IS NULL;
+UPDATE PON.USER_MEDICATION_SCHEDULE SET NOTES            = ''    WHERE NOTES            IS NULL;
+
+ALTER TABLE PON.USER_MEDICATION_SCHEDULE
+ALTER COLUMN MINUTES_OF_HOUR_MASK SET DEFAULT 0,
+ALTER COLUMN MINUTES_OF_HOUR_MASK SET NOT NULL,
+ALTER COLUMN HOURS_OF_DAY_MASK    SET DEFAULT 0,
+ALTER COLUMN HOURS_OF_DAY_MASK    SET NOT NULL,
+ALTER COLUMN DAYS_OF_WEEK_MASK    SET DEFAULT 0,
+ALTER COLUMN DAYS_OF_WEEK_MASK    SET NOT NULL,
+ALTER COLUMN DAYS_OF_MONTH_MASK   SET DEFAULT 0,
+ALTER COLUMN DAYS_OF_MONTH_MASK   SET NOT NULL,
+ALTER COLUMN MONTH_OF_YEAR_MASK   SET DEFAULT 0,
+ALTER COLUMN MONTH_OF_YEAR_MASK   SET NOT NULL,
+ALTER COLUMN WITH_FOOD            SET DEFAULT FALSE,
+ALTER COLUMN WITH_FOOD            SET NOT NULL,
+ALTER COLUMN FASTING              SET DEFAULT FALSE,
+ALTER COLUMN FASTING              SET NOT NULL,
+ALTER COLUMN REMINDER_ENABLED     SET NOT NULL,
+ALTER COLUMN NOTES                SET DEFAULT '',
+ALTER COLUMN NOTES                SET NOT NULL;
+
+
+/*
+A log without a schedule can't be traced back to a user, and deleting a medication should take its history with it.
+*/
+DELETE FROM PON.USER_MEDICATIONLOG
+WHERE SCHEDULE_ID IS NULL;
+
+UPDATE PON.USER_MEDICATIONLOG SET NOTES = '' WHERE NOTES IS NULL;
+
+ALTER TABLE PON.USER_MEDICATIONLOG
+DROP CONSTRAINT IF EXISTS user_medicationlog_schedule_id_fkey;
+
+ALTER TABLE PON.USER_MEDICATIONLOG
+ADD CONSTRAINT user_medicationlog_schedule_id_fkey
+FOREIGN KEY (SCHEDULE_ID) REFERENCES PON.USER_MEDICATION_SCHEDULE(ID)
+ON DELETE CASCADE;
+
+ALTER TABLE PON.USER_MEDICATIONLOG
+ALTER COLUMN SCHEDULE_ID SET NOT NULL,
+ALTER COLUMN NOTES       SET DEFAULT '',
+ALTER COLUMN NOTES       SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_usermedicationlog_scheduletakentime
+ON PON.USER_MEDICATIONLOG (SCHEDULE_ID, TAKEN_TIME);
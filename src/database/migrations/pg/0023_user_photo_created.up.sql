@@ -0,0 +1,9 @@
+/*
+Photos are uploaded before the eventlog they belong to is created,
+so a photo that never gets linked needs a created time to know when it's safe to clean up.
+*/
+ALTER TABLE PON.USER_PHOTO
+ADD COLUMN IF NOT EXISTS created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_usereventlogphoto_photoid
+ON PON.USER_EVENTLOG_PHOTO (photo_id);
@@ -0,0 +1,16 @@
+/*
+Photo bytes can now live outside the database.
+STORAGE says where the bytes are ('db' keeps them in DATA, 'fs' keeps them in a directory named by HASH),
+and HASH is the hex SHA-256 of the bytes.
+*/
+ALTER TABLE PON.USER_PHOTO
+ADD COLUMN IF NOT EXISTS storage TEXT NOT NULL DEFAULT 'db';
+
+ALTER TABLE PON.USER_PHOTO
+ADD COLUMN IF NOT EXISTS hash TEXT NOT NULL DEFAULT '';
+
+UPDATE PON.USER_PHOTO
+SET hash = encode(sha256(data), 'hex');
+
+CREATE INDEX IF NOT EXISTS idx_userphoto_storagehash
+ON PON.USER_PHOTO (storage, hash);
@@ -0,0 +1,16 @@
+/*
+Uploaded photos are re-encoded and get a small thumbnail.
+THUMBNAIL follows DATA, it's empty unless STORAGE is 'db'.
+Photos uploaded before this have no thumbnail, content type or size.
+*/
+ALTER TABLE PON.USER_PHOTO
+ADD COLUMN IF NOT EXISTS thumbnail BYTEA NOT NULL DEFAULT '';
+
+ALTER TABLE PON.USER_PHOTO
+ADD COLUMN IF NOT EXISTS content_type TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE PON.USER_PHOTO
+ADD COLUMN IF NOT EXISTS width INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE PON.USER_PHOTO
+ADD COLUMN IF NOT EXISTS height INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+/*
+The user's IANA timezone, used with DAY_TIME_OFFSET_SECONDS to decide where days, weeks and months start.
+Existing users keep the old behaviour of UTC.
+*/
+ALTER TABLE PON.USER
+ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,13 @@
+/*
+How the user's insulin is used up, for insulin on board.
+INSULIN_ACTION_CURVE is 'exponential', 'linear', or 'none'.
+INSULIN_PEAK_MINUTES is only used by the exponential curve.
+*/
+ALTER TABLE PON.USER
+ADD COLUMN IF NOT EXISTS insulin_action_curve TEXT NOT NULL DEFAULT 'exponential';
+
+ALTER TABLE PON.USER
+ADD COLUMN IF NOT EXISTS insulin_duration_minutes INTEGER NOT NULL DEFAULT 300;
+
+ALTER TABLE PON.USER
+ADD COLUMN IF NOT EXISTS insulin_peak_minutes INTEGER NOT NULL DEFAULT 75;
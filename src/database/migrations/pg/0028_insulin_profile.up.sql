@@ -0,0 +1,14 @@
+/*
+The user's insulin ratios by time of day.
+A block starts at START_MINUTE (minutes after local midnight) and lasts until the next block starts,
+the last block of the day carries on past midnight until the first one.
+*/
+CREATE TABLE IF NOT EXISTS PON.USER_INSULIN_PROFILE (
+    id                         SERIAL  PRIMARY KEY NOT NULL,
+    user_id                    INTEGER NOT NULL REFERENCES PON.USER(id) ON DELETE CASCADE,
+    start_minute               INTEGER NOT NULL,
+    insulin_to_carb_ratio      FLOAT   NOT NULL,
+    insulin_sensitivity_factor FLOAT   NOT NULL,
+    target_blood_sugar         FLOAT   NOT NULL,
+    UNIQUE (user_id, start_minute)
+);
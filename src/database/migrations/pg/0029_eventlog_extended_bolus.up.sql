@@ -0,0 +1,18 @@
+/*
+Insulin for the fat and protein in a meal (Warsaw method), given as an extended or split bolus.
+FAT_PROTEIN_UNITS is calculated from the foodlogs, 1 FPU is 100 kcal of fat and protein.
+*/
+ALTER TABLE PON.USER_EVENTLOG
+ADD COLUMN IF NOT EXISTS fat_protein_units FLOAT NOT NULL DEFAULT 0;
+
+ALTER TABLE PON.USER_EVENTLOG
+ADD COLUMN IF NOT EXISTS recommended_extended_insulin_amount FLOAT NOT NULL DEFAULT 0;
+
+ALTER TABLE PON.USER_EVENTLOG
+ADD COLUMN IF NOT EXISTS recommended_extended_minutes INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE PON.USER_EVENTLOG
+ADD COLUMN IF NOT EXISTS actual_extended_insulin_taken FLOAT NOT NULL DEFAULT 0;
+
+ALTER TABLE PON.USER_EVENTLOG
+ADD COLUMN IF NOT EXISTS actual_extended_minutes INTEGER NOT NULL DEFAULT 0;
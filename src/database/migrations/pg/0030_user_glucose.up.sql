@@ -0,0 +1,13 @@
+/*
+The user's blood glucose readings, from a meter or a CGM sensor, in mmol/L.
+A reading is unique by its time, so importing the same readings twice does not duplicate them.
+*/
+CREATE TABLE IF NOT EXISTS PON.USER_GLUCOSE (
+    id                         SERIAL    PRIMARY KEY NOT NULL,
+    user_id                    INTEGER   NOT NULL REFERENCES PON.USER(id) ON DELETE CASCADE,
+    created                    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_time                  TIMESTAMP NOT NULL,
+    glucose                    FLOAT     NOT NULL,
+    source                     TEXT      NOT NULL DEFAULT '',
+    UNIQUE (user_id, user_time)
+);
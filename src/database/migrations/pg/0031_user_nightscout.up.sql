@@ -0,0 +1,9 @@
+/*
+The secret a user's Nightscout uploaders and followers authenticate with.
+Nightscout clients send the SHA1 of the secret in the api-secret header, only the SHA-256 of that SHA1 is stored.
+*/
+CREATE TABLE IF NOT EXISTS PON.USER_NIGHTSCOUT (
+    user_id                    INTEGER   PRIMARY KEY NOT NULL REFERENCES PON.USER(id) ON DELETE CASCADE,
+    created                    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    api_secret_hash            TEXT      NOT NULL UNIQUE
+);
@@ -0,0 +1,31 @@
+/*
+Recipes are foods made of other foods, like a batch cooked meal.
+The ingredients reference a user food or a data source food, so editing the food changes the recipe.
+A food can't be deleted while a recipe uses it, the recipe would quietly lose the ingredient.
+yield is how many unit the ingredients make, the nutrition of a recipe is always worked out from them.
+A foodlog of a recipe keeps a copy of the recipe's nutrition when it was logged and the recipe_id it came from.
+*/
+CREATE TABLE IF NOT EXISTS PON.USER_RECIPE (
+    id                  SERIAL    PRIMARY KEY NOT NULL,
+    user_id             INTEGER   NOT NULL REFERENCES PON.USER(id) ON DELETE CASCADE,
+    created             TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    name                TEXT      NOT NULL,
+    unit                TEXT      NOT NULL,
+    yield               FLOAT     NOT NULL,
+    notes               TEXT      NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS PON.USER_RECIPE_INGREDIENT (
+    id                  SERIAL  PRIMARY KEY NOT NULL,
+    recipe_id           INTEGER NOT NULL REFERENCES PON.USER_RECIPE(id) ON DELETE CASCADE,
+    food_id             INTEGER REFERENCES PON.USER_FOOD(id) ON DELETE RESTRICT,
+    data_source_food_id INTEGER REFERENCES PON.DATA_SOURCE_FOOD(id) ON DELETE RESTRICT,
+    portion             FLOAT   NOT NULL, -- in the unit of the food
+    CHECK ((food_id IS NULL) <> (data_source_food_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_userrecipeingredient_recipe
+ON PON.USER_RECIPE_INGREDIENT (recipe_id);
+
+ALTER TABLE PON.USER_FOODLOG
+ADD COLUMN IF NOT EXISTS recipe_id INTEGER REFERENCES PON.USER_RECIPE(id) ON DELETE SET NULL;
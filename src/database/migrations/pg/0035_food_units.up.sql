@@ -0,0 +1,10 @@
+/*
+How to convert a food's portion into other units, see the units package.
+density is grams per millilitre to convert between mass and volume, 0 if unknown.
+household_units is a JSON object of the food's own units, like {"slice": {"amount": 28, "unit": "g"}}.
+*/
+ALTER TABLE PON.USER_FOOD
+ADD COLUMN IF NOT EXISTS density FLOAT NOT NULL DEFAULT 0;
+
+ALTER TABLE PON.USER_FOOD
+ADD COLUMN IF NOT EXISTS household_units JSONB NOT NULL DEFAULT '{}';
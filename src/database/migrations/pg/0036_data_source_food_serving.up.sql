@@ -0,0 +1,15 @@
+/*
+Servings of a data source food besides its portion, like "1 cup, chopped" being 128 g.
+amount of unit is the serving, portion is how much of the food's unit it weighs.
+*/
+CREATE TABLE IF NOT EXISTS PON.DATA_SOURCE_FOOD_SERVING (
+    id                  SERIAL  PRIMARY KEY NOT NULL,
+    data_source_food_id INTEGER NOT NULL REFERENCES PON.DATA_SOURCE_FOOD(id) ON DELETE CASCADE,
+    name                TEXT    NOT NULL,
+    amount              FLOAT   NOT NULL,
+    unit                TEXT    NOT NULL,
+    portion             FLOAT   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_datasourcefoodserving_food
+ON PON.DATA_SOURCE_FOOD_SERVING (data_source_food_id);
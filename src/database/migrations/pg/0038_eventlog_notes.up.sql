@@ -0,0 +1,6 @@
+/*
+Free text about an eventlog, such as the notes of a Nightscout treatment.
+The event name stays the kind of eventlog, so the notes don't each make a new event.
+*/
+ALTER TABLE PON.USER_EVENTLOG
+ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
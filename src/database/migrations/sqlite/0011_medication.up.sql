@@ -0,0 +1,60 @@
+/*
+These tables have existed on postgres since 0006_add_more_tables,
+this brings sqlite up to the same shape as postgres after 0022_medication.
+
+bit 0 of each mask is the first value (minute 0, hour 0, monday, the 1st, january).
+*/
+CREATE TABLE IF NOT EXISTS PON_USER_MEDICATION (
+    ID                  INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    USER_ID             INTEGER NOT NULL,
+    CREATED             TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    NAME                TEXT NOT NULL,
+    CATEGORY            TEXT NOT NULL DEFAULT '',
+    DOSAGE_AMOUNT       REAL NOT NULL DEFAULT 0,
+    DOSAGE_UNIT         TEXT NOT NULL DEFAULT '',
+    FORM                TEXT NOT NULL DEFAULT '', -- tablet, capsule, liquid
+
+    START_DATE          TIMESTAMP, -- day the medication started
+    END_DATE            TIMESTAMP, -- day the medication end
+
+    NOTES               TEXT NOT NULL DEFAULT '',
+
+    FOREIGN KEY (USER_ID) REFERENCES PON_USER(ID)
+);
+
+CREATE TABLE IF NOT EXISTS PON_USER_MEDICATION_SCHEDULE (
+    ID                  INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    MEDICATION_ID       INTEGER NOT NULL,
+    CREATED             TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- basically describing cron
+    MINUTES_OF_HOUR_MASK  INTEGER NOT NULL DEFAULT 0,
+    HOURS_OF_DAY_MASK     INTEGER NOT NULL DEFAULT 0,
+    DAYS_OF_WEEK_MASK     INTEGER NOT NULL DEFAULT 0,
+    DAYS_OF_MONTH_MASK    INTEGER NOT NULL DEFAULT 0,
+    MONTH_OF_YEAR_MASK    INTEGER NOT NULL DEFAULT 0,
+
+    WITH_FOOD           INTEGER NOT NULL DEFAULT FALSE,
+    FASTING             INTEGER NOT NULL DEFAULT FALSE,
+
+    REMINDER_ENABLED    INTEGER NOT NULL DEFAULT TRUE,
+    NOTES               TEXT NOT NULL DEFAULT '',
+
+    FOREIGN KEY (MEDICATION_ID) REFERENCES PON_USER_MEDICATION(ID) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS PON_USER_MEDICATIONLOG (
+    ID                  INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    SCHEDULE_ID         INTEGER NOT NULL,
+
+    TAKEN_TIME          TIMESTAMP NOT NULL,
+    TAKEN               INTEGER NOT NULL,
+
+    NOTES               TEXT NOT NULL DEFAULT '',
+
+    FOREIGN KEY (SCHEDULE_ID) REFERENCES PON_USER_MEDICATION_SCHEDULE(ID) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_usermedicationlog_scheduletakentime
+ON PON_USER_MEDICATIONLOG (SCHEDULE_ID, TAKEN_TIME);
@@ -0,0 +1,16 @@
+/*
+Photos are uploaded before the eventlog they belong to is created,
+so a photo that never gets linked needs a created time to know when it's safe to clean up.
+
+SQLite won't add a column with a CURRENT_TIMESTAMP default, and rebuilding the table would cascade delete the eventlog mappings,
+so the default is a constant and the insert sets the real time.
+Existing photos are given the migration time so unlinked ones get the same grace period as new uploads.
+*/
+ALTER TABLE PON_USER_PHOTO
+ADD COLUMN CREATED TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00';
+
+UPDATE PON_USER_PHOTO
+SET CREATED = CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_usereventlogphoto_photoid
+ON PON_USER_EVENTLOG_PHOTO (PHOTO_ID);
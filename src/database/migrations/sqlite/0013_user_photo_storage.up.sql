@@ -0,0 +1,15 @@
+/*
+Photo bytes can now live outside the database.
+STORAGE says where the bytes are ('db' keeps them in DATA, 'fs' keeps them in a directory named by HASH),
+and HASH is the hex SHA-256 of the bytes.
+
+SQLite has no sha256, existing photos get their hash when they are moved with 'db migrate-photos'.
+*/
+ALTER TABLE PON_USER_PHOTO
+ADD COLUMN STORAGE TEXT NOT NULL DEFAULT 'db';
+
+ALTER TABLE PON_USER_PHOTO
+ADD COLUMN HASH TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_userphoto_storagehash
+ON PON_USER_PHOTO (STORAGE, HASH);
@@ -0,0 +1,16 @@
+/*
+Uploaded photos are re-encoded and get a small thumbnail.
+THUMBNAIL follows DATA, it's empty unless STORAGE is 'db'.
+Photos uploaded before this have no thumbnail, content type or size.
+*/
+ALTER TABLE PON_USER_PHOTO
+ADD COLUMN THUMBNAIL BLOB NOT NULL DEFAULT X'';
+
+ALTER TABLE PON_USER_PHOTO
+ADD COLUMN CONTENT_TYPE TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE PON_USER_PHOTO
+ADD COLUMN WIDTH INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE PON_USER_PHOTO
+ADD COLUMN HEIGHT INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+/*
+How the user's insulin is used up, for insulin on board.
+INSULIN_ACTION_CURVE is 'exponential', 'linear', or 'none'.
+INSULIN_PEAK_MINUTES is only used by the exponential curve.
+*/
+ALTER TABLE PON_USER
+ADD COLUMN INSULIN_ACTION_CURVE TEXT NOT NULL DEFAULT 'exponential';
+
+ALTER TABLE PON_USER
+ADD COLUMN INSULIN_DURATION_MINUTES INTEGER NOT NULL DEFAULT 300;
+
+ALTER TABLE PON_USER
+ADD COLUMN INSULIN_PEAK_MINUTES INTEGER NOT NULL DEFAULT 75;
@@ -0,0 +1,15 @@
+/*
+The user's insulin ratios by time of day.
+A block starts at START_MINUTE (minutes after local midnight) and lasts until the next block starts,
+the last block of the day carries on past midnight until the first one.
+*/
+CREATE TABLE IF NOT EXISTS PON_USER_INSULIN_PROFILE (
+    ID                         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    USER_ID                    INTEGER NOT NULL,
+    START_MINUTE               INTEGER NOT NULL,
+    INSULIN_TO_CARB_RATIO      REAL    NOT NULL,
+    INSULIN_SENSITIVITY_FACTOR REAL    NOT NULL,
+    TARGET_BLOOD_SUGAR         REAL    NOT NULL,
+    UNIQUE (USER_ID, START_MINUTE),
+    FOREIGN KEY (USER_ID) REFERENCES PON_USER(ID) ON DELETE CASCADE
+);
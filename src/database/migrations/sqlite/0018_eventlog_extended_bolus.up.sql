@@ -0,0 +1,18 @@
+/*
+Insulin for the fat and protein in a meal (Warsaw method), given as an extended or split bolus.
+FAT_PROTEIN_UNITS is calculated from the foodlogs, 1 FPU is 100 kcal of fat and protein.
+*/
+ALTER TABLE PON_USER_EVENTLOG
+ADD COLUMN FAT_PROTEIN_UNITS REAL NOT NULL DEFAULT 0;
+
+ALTER TABLE PON_USER_EVENTLOG
+ADD COLUMN RECOMMENDED_EXTENDED_INSULIN_AMOUNT REAL NOT NULL DEFAULT 0;
+
+ALTER TABLE PON_USER_EVENTLOG
+ADD COLUMN RECOMMENDED_EXTENDED_MINUTES INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE PON_USER_EVENTLOG
+ADD COLUMN ACTUAL_EXTENDED_INSULIN_TAKEN REAL NOT NULL DEFAULT 0;
+
+ALTER TABLE PON_USER_EVENTLOG
+ADD COLUMN ACTUAL_EXTENDED_MINUTES INTEGER NOT NULL DEFAULT 0;
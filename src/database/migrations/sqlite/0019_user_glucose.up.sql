@@ -0,0 +1,14 @@
+/*
+The user's blood glucose readings, from a meter or a CGM sensor, in mmol/L.
+A reading is unique by its time, so importing the same readings twice does not duplicate them.
+*/
+CREATE TABLE IF NOT EXISTS PON_USER_GLUCOSE (
+    ID                         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    USER_ID                    INTEGER NOT NULL,
+    CREATED                    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    USER_TIME                  TIMESTAMP NOT NULL,
+    GLUCOSE                    REAL    NOT NULL,
+    SOURCE                     TEXT    NOT NULL DEFAULT '',
+    UNIQUE (USER_ID, USER_TIME),
+    FOREIGN KEY (USER_ID) REFERENCES PON_USER(ID) ON DELETE CASCADE
+);
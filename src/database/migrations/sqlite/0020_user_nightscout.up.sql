@@ -0,0 +1,10 @@
+/*
+The secret a user's Nightscout uploaders and followers authenticate with.
+Nightscout clients send the SHA1 of the secret in the api-secret header, only the SHA-256 of that SHA1 is stored.
+*/
+CREATE TABLE IF NOT EXISTS PON_USER_NIGHTSCOUT (
+    USER_ID                    INTEGER PRIMARY KEY NOT NULL,
+    CREATED                    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    API_SECRET_HASH            TEXT    NOT NULL UNIQUE,
+    FOREIGN KEY (USER_ID) REFERENCES PON_USER(ID) ON DELETE CASCADE
+);
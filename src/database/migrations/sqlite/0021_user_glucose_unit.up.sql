@@ -0,0 +1,40 @@
+/*
+The unit the user enters and reads blood glucose in, 'mmol/L' or 'mg/dL'.
+Glucose is stored in mmol/L, the API converts to and from the user's unit.
+
+Nothing recorded which unit glucose was in before this, so it is worked out for each user.
+No blood glucose is over 35 mmol/L, so a user whose target or average eventlog glucose
+is over 35 gets the mg/dL setting.
+All the glucose values of those users are then converted, including low readings and insulin sensitivity,
+and the values of mmol/L users are left alone.
+Glucose readings were always stored in mmol/L, so they are neither used to pick the unit nor converted.
+*/
+ALTER TABLE PON_USER
+ADD COLUMN GLUCOSE_UNIT TEXT NOT NULL DEFAULT 'mmol/L';
+
+UPDATE PON_USER SET GLUCOSE_UNIT = 'mg/dL'
+WHERE TARGET_BLOOD_SUGAR > 35
+   OR (
+       SELECT AVG(el.BLOOD_GLUCOSE) FROM PON_USER_EVENTLOG el
+       WHERE el.USER_ID = PON_USER.ID AND el.BLOOD_GLUCOSE > 0
+   ) > 35;
+
+UPDATE PON_USER
+SET TARGET_BLOOD_SUGAR = TARGET_BLOOD_SUGAR / 18.0182,
+    INSULIN_SENSITIVITY_FACTOR = INSULIN_SENSITIVITY_FACTOR / 18.0182
+WHERE GLUCOSE_UNIT = 'mg/dL';
+
+UPDATE PON_USER_EVENTLOG
+SET BLOOD_GLUCOSE = BLOOD_GLUCOSE / 18.0182,
+    BLOOD_GLUCOSE_TARGET = BLOOD_GLUCOSE_TARGET / 18.0182,
+    INSULIN_SENSITIVITY_FACTOR = INSULIN_SENSITIVITY_FACTOR / 18.0182
+WHERE USER_ID IN (SELECT ID FROM PON_USER WHERE GLUCOSE_UNIT = 'mg/dL');
+
+UPDATE PON_USER_INSULIN_PROFILE
+SET TARGET_BLOOD_SUGAR = TARGET_BLOOD_SUGAR / 18.0182,
+    INSULIN_SENSITIVITY_FACTOR = INSULIN_SENSITIVITY_FACTOR / 18.0182
+WHERE USER_ID IN (SELECT ID FROM PON_USER WHERE GLUCOSE_UNIT = 'mg/dL');
+
+UPDATE PON_USER_GOAL SET TARGET_VALUE = TARGET_VALUE / 18.0182
+WHERE TARGET_COL IN ('BLOOD_SUGAR', 'GLUCOSE')
+  AND USER_ID IN (SELECT ID FROM PON_USER WHERE GLUCOSE_UNIT = 'mg/dL');
@@ -0,0 +1,13 @@
+/*
+Nutrients beyond the macros, like sodium and vitamins, as a JSON object of the amounts by nutrient.
+The supported nutrients and their units are listed in database.NutrientInfos.
+Foods store the amounts per unit like the macros, foodlogs store the amounts for the portion eaten.
+*/
+ALTER TABLE PON_USER_FOOD
+ADD COLUMN NUTRIENTS TEXT NOT NULL DEFAULT '{}';
+
+ALTER TABLE PON_USER_FOODLOG
+ADD COLUMN NUTRIENTS TEXT NOT NULL DEFAULT '{}';
+
+ALTER TABLE PON_DATA_SOURCE_FOOD
+ADD COLUMN NUTRIENTS TEXT NOT NULL DEFAULT '{}';
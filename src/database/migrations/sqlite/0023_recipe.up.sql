@@ -0,0 +1,35 @@
+/*
+Recipes are foods made of other foods, like a batch cooked meal.
+The ingredients reference a user food or a data source food, so editing the food changes the recipe.
+A food can't be deleted while a recipe uses it, the recipe would quietly lose the ingredient.
+YIELD is how many UNIT the ingredients make, the nutrition of a recipe is always worked out from them.
+A foodlog of a recipe keeps a copy of the recipe's nutrition when it was logged and the RECIPE_ID it came from.
+*/
+CREATE TABLE IF NOT EXISTS PON_USER_RECIPE (
+    ID                  INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    USER_ID             INTEGER NOT NULL,
+    CREATED             TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    NAME                TEXT NOT NULL,
+    UNIT                TEXT NOT NULL,
+    YIELD               REAL NOT NULL,
+    NOTES               TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (USER_ID) REFERENCES PON_USER(ID) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS PON_USER_RECIPE_INGREDIENT (
+    ID                  INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    RECIPE_ID           INTEGER NOT NULL,
+    FOOD_ID             INTEGER,
+    DATA_SOURCE_FOOD_ID INTEGER,
+    PORTION             REAL NOT NULL, -- in the unit of the food
+    CHECK ((FOOD_ID IS NULL) <> (DATA_SOURCE_FOOD_ID IS NULL)),
+    FOREIGN KEY (RECIPE_ID) REFERENCES PON_USER_RECIPE(ID) ON DELETE CASCADE,
+    FOREIGN KEY (FOOD_ID) REFERENCES PON_USER_FOOD(ID) ON DELETE RESTRICT,
+    FOREIGN KEY (DATA_SOURCE_FOOD_ID) REFERENCES PON_DATA_SOURCE_FOOD(ID) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_userrecipeingredient_recipe
+ON PON_USER_RECIPE_INGREDIENT (RECIPE_ID);
+
+ALTER TABLE PON_USER_FOODLOG
+ADD COLUMN RECIPE_ID INTEGER REFERENCES PON_USER_RECIPE(ID) ON DELETE SET NULL;
@@ -0,0 +1,10 @@
+/*
+How to convert a food's portion into other units, see the units package.
+DENSITY is grams per millilitre to convert between mass and volume, 0 if unknown.
+HOUSEHOLD_UNITS is a JSON object of the food's own units, like {"slice": {"amount": 28, "unit": "g"}}.
+*/
+ALTER TABLE PON_USER_FOOD
+ADD COLUMN DENSITY REAL NOT NULL DEFAULT 0;
+
+ALTER TABLE PON_USER_FOOD
+ADD COLUMN HOUSEHOLD_UNITS TEXT NOT NULL DEFAULT '{}';
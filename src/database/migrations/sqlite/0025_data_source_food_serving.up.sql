@@ -0,0 +1,16 @@
+/*
+Servings of a data source food besides its portion, like "1 cup, chopped" being 128 g.
+AMOUNT of UNIT is the serving, PORTION is how much of the food's unit it weighs.
+*/
+CREATE TABLE IF NOT EXISTS PON_DATA_SOURCE_FOOD_SERVING (
+    ID                  INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    DATA_SOURCE_FOOD_ID INTEGER NOT NULL,
+    NAME                TEXT NOT NULL,
+    AMOUNT              REAL NOT NULL,
+    UNIT                TEXT NOT NULL,
+    PORTION             REAL NOT NULL,
+    FOREIGN KEY (DATA_SOURCE_FOOD_ID) REFERENCES PON_DATA_SOURCE_FOOD(ID) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_datasourcefoodserving_food
+ON PON_DATA_SOURCE_FOOD_SERVING (DATA_SOURCE_FOOD_ID);
@@ -0,0 +1,8 @@
+/*
+The GTIN/UPC barcode of a data source food, as 14 digits with leading zeros, empty if it has none.
+*/
+ALTER TABLE PON_DATA_SOURCE_FOOD
+ADD COLUMN BARCODE TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_datasourcefood_barcode
+ON PON_DATA_SOURCE_FOOD (BARCODE) WHERE BARCODE <> '';
@@ -25,31 +25,35 @@ func (p *BaseMockDB) WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) err
 	panic("not implemented")
 }
 
-func (p *BaseMockDB) ExportUserCSV(ctx context.Context, w io.Writer) error {
+func (p *BaseMockDB) ExportUserCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 	panic("not implemented")
 }
 
-func (p *BaseMockDB) ExportUserEventsCSV(ctx context.Context, w io.Writer) error {
+func (p *BaseMockDB) ExportUserEventsCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 	panic("not implemented")
 }
 
-func (p *BaseMockDB) ExportUserEventLogsCSV(ctx context.Context, w io.Writer) error {
+func (p *BaseMockDB) ExportUserEventLogsCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 	panic("not implemented")
 }
 
-func (p *BaseMockDB) ExportUserFoodsCSV(ctx context.Context, w io.Writer) error {
+func (p *BaseMockDB) ExportUserFoodsCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 	panic("not implemented")
 }
 
-func (p *BaseMockDB) ExportUserFoodLogsCSV(ctx context.Context, w io.Writer) error {
+func (p *BaseMockDB) ExportUserFoodLogsCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 	panic("not implemented")
 }
 
-func (p *BaseMockDB) ExportBodyLogCSV(ctx context.Context, w io.Writer) error {
+func (p *BaseMockDB) ExportBodyLogCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 	panic("not implemented")
 }
 
-func (p *BaseMockDB) ExportVersionCSV(ctx context.Context, w io.Writer) error {
+func (p *BaseMockDB) ExportUserGlucoseCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) ExportVersionCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 	panic("not implemented")
 }
 
@@ -145,6 +149,34 @@ func (p *BaseMockDB) DeleteUserFood(ctx context.Context, userID int, foodID int)
 	panic("not implemented")
 }
 
+func (p *BaseMockDB) AddUserRecipe(
+	ctx context.Context,
+	recipe *database.TblUserRecipe,
+	ingredients []database.TblUserRecipeIngredient,
+) (int, error) {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserRecipes(ctx context.Context, userID int, out *[]database.UserRecipe) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserRecipe(ctx context.Context, userID int, recipeID int, out *database.UserRecipe) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) UpdateUserRecipe(
+	ctx context.Context,
+	recipe *database.TblUserRecipe,
+	ingredients []database.TblUserRecipeIngredient,
+) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) DeleteUserRecipe(ctx context.Context, userID int, recipeID int) error {
+	panic("not implemented")
+}
+
 func (p *BaseMockDB) AddUserEvent(ctx context.Context, event *database.TblUserEvent) (int, error) {
 	panic("not implemented")
 }
@@ -187,10 +219,71 @@ func (p *BaseMockDB) AddUserEventLogWith(
 	panic("not implemented")
 }
 
+func (p *BaseMockDB) AddUserEventLogWithTx(
+	tx *sqlx.Tx,
+	event *database.TblUserEventLog,
+	foodlogs []database.TblUserFoodLog,
+) (int, error) {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) AddUserEventLogWithPhotos(
+	ctx context.Context,
+	event *database.TblUserEventLog,
+	foodlogs []database.TblUserFoodLog,
+	photoIDs []int,
+) (int, error) {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) AddUserEventLogsOnce(
+	ctx context.Context,
+	userID int,
+	eventlogs []database.TblUserEventLog,
+	foodlogs [][]database.TblUserFoodLog,
+) ([]database.TblUserEventLog, error) {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) FillUserFoodLogs(ctx context.Context, userID int, foodlogs []database.TblUserFoodLog) error {
+	panic("not implemented")
+}
+
 func (p *BaseMockDB) LoadUserEventLogs(ctx context.Context, userID int, events *[]database.TblUserEventLog) error {
 	panic("not implemented")
 }
 
+func (p *BaseMockDB) LoadUserInsulinEventLogsBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserEventLog,
+) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserEventLogsBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserEventLog,
+) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserEventLogsBetweenN(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	n int,
+	out *[]database.TblUserEventLog,
+) error {
+	panic("not implemented")
+}
+
 func (p *BaseMockDB) LoadUserEventLogsTx(tx *sqlx.Tx, userID int, events *[]database.TblUserEventLog) error {
 	panic("not implemented")
 }
@@ -212,6 +305,15 @@ func (p *BaseMockDB) LoadUserEventFoodLogs(ctx context.Context, userID int, eflo
 	panic("not implemented")
 }
 
+func (p *BaseMockDB) LoadUserEventFoodLogsBetween(
+	ctx context.Context,
+	userID int,
+	start, end time.Time,
+	eflogs *[]database.UserEventFoodLog,
+) error {
+	panic("not implemented")
+}
+
 func (p *BaseMockDB) LoadUserEventFoodLogsN(
 	ctx context.Context,
 	userID int,
@@ -249,6 +351,87 @@ func (p *BaseMockDB) DeleteUserBodyLog(ctx context.Context, userID int, bodyLogI
 	panic("not implemented")
 }
 
+func (p *BaseMockDB) AddUserMedication(ctx context.Context, med *database.TblUserMedication) (int, error) {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserMedications(ctx context.Context, userID int, out *[]database.TblUserMedication) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) UpdateUserMedication(ctx context.Context, med *database.TblUserMedication) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) DeleteUserMedication(ctx context.Context, userID int, medicationID int) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) AddUserMedicationSchedule(ctx context.Context, userID int, schedule *database.TblUserMedicationSchedule) (int, error) {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserMedicationSchedules(ctx context.Context, userID int, out *[]database.TblUserMedicationSchedule) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) UpdateUserMedicationSchedule(ctx context.Context, userID int, schedule *database.TblUserMedicationSchedule) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) DeleteUserMedicationSchedule(ctx context.Context, userID int, scheduleID int) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) AddUserMedicationLog(ctx context.Context, userID int, medlog *database.TblUserMedicationLog) (int, error) {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserMedicationLogs(ctx context.Context, userID int, out *[]database.TblUserMedicationLog) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserMedicationLogsBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserMedicationLog,
+) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) UpdateUserMedicationLog(ctx context.Context, userID int, medlog *database.TblUserMedicationLog) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) DeleteUserMedicationLog(ctx context.Context, userID int, medlogID int) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) AddUserInsulinProfileBlock(
+	ctx context.Context,
+	block *database.TblUserInsulinProfileBlock,
+) (int, error) {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserInsulinProfile(
+	ctx context.Context,
+	userID int,
+	out *[]database.TblUserInsulinProfileBlock,
+) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) UpdateUserInsulinProfileBlock(ctx context.Context, block *database.TblUserInsulinProfileBlock) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) DeleteUserInsulinProfileBlock(ctx context.Context, userID int, blockID int) error {
+	panic("not implemented")
+}
+
 func (p *BaseMockDB) AddDataSource(ctx context.Context, ds *database.TblDataSource) (int, error) {
 	panic("not implemented")
 }
@@ -284,6 +467,29 @@ func (p *BaseMockDB) LoadDataSourceFoodBySimilarNameN(
 	panic("not implemented")
 }
 
+func (p *BaseMockDB) LoadDataSourceFoodByBarcode(
+	ctx context.Context,
+	barcode string,
+	out *[]database.TblDataSourceFood,
+) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) AddDataSourceFoodServing(
+	ctx context.Context,
+	serving *database.TblDataSourceFoodServing,
+) (int, error) {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadDataSourceFoodServings(
+	ctx context.Context,
+	dataSourceFoodIDs []int,
+	out *[]database.TblDataSourceFoodServing,
+) error {
+	panic("not implemented")
+}
+
 func (p *BaseMockDB) DeleteUserGoal(ctx context.Context, userID int, goalID int) error {
 	panic("not implemented")
 }
@@ -434,7 +640,46 @@ func (p *BaseMockDB) AddUserPhoto(ctx context.Context, photo *database.TblUserPh
 	panic("not implemented")
 }
 
-func (p *BaseMockDB) AddUserEventLogPhotos(ctx context.Context, eventlogID int, photoIDs []int) error {
+func (p *BaseMockDB) AddUserEventLogPhotos(ctx context.Context, userID int, eventlogID int, photoIDs []int) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) AddUserEventLogPhotosTx(tx *sqlx.Tx, userID int, eventlogID int, photoIDs []int) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserPhoto(ctx context.Context, userID int, photoID int, out *database.TblUserPhoto) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) DeleteUserPhoto(ctx context.Context, userID int, photoID int) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) DeleteUserEventLogPhoto(ctx context.Context, userID int, eventlogID int, photoID int) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) DeleteUnlinkedUserPhotos(
+	ctx context.Context,
+	createdBefore time.Time,
+) ([]database.TblUserPhoto, error) {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadPhotoByID(ctx context.Context, photoID int, out *database.TblUserPhoto) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadPhotoIDsByStorage(ctx context.Context, storage string, out *[]int) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) UpdatePhotoStorage(ctx context.Context, photo *database.TblUserPhoto) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) CountPhotosByHash(ctx context.Context, storage string, hash string) (int, error) {
 	panic("not implemented")
 }
 
@@ -445,7 +690,69 @@ func (p *BaseMockDB) LoadUserTimeData(
 	endTime time.Time,
 	tags []string,
 	groupby database.GroupBy,
+	loc *time.Location,
+	shift time.Duration,
 	out *[]database.TimespanTagDurationPoint,
 ) error {
 	panic("not implemented")
 }
+
+func (p *BaseMockDB) LoadUserChartData(
+	ctx context.Context,
+	userID int,
+	cols []database.ChartColumn,
+	aggregation database.AggregationFunc,
+	groupby database.GroupBy,
+	loc *time.Location,
+	shift time.Duration,
+	startTime time.Time,
+	endTime time.Time,
+	out *[]database.ChartPoint,
+) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) AddUserGlucoseReadings(
+	ctx context.Context,
+	userID int,
+	readings []database.TblUserGlucose,
+) (int, error) {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserGlucoseBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserGlucose,
+) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserGlucoseBetweenN(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	n int,
+	out *[]database.TblUserGlucose,
+) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) DeleteUserGlucose(ctx context.Context, userID int, glucoseID int) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) SetUserNightscoutSecret(ctx context.Context, userID int, secretHash string) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) DeleteUserNightscoutSecret(ctx context.Context, userID int) error {
+	panic("not implemented")
+}
+
+func (p *BaseMockDB) LoadUserByNightscoutSecret(ctx context.Context, secretHash string, user *database.TblUser) error {
+	panic("not implemented")
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Nutrient is a nutrient tracked beyond the macros, the key it is stored under in Nutrients.
+type Nutrient string
+
+const (
+	NutrientSugars       Nutrient = "sugars"
+	NutrientSaturatedFat Nutrient = "saturated_fat"
+	NutrientTransFat     Nutrient = "trans_fat"
+	NutrientCholesterol  Nutrient = "cholesterol"
+	NutrientSodium       Nutrient = "sodium"
+	NutrientPotassium    Nutrient = "potassium"
+	NutrientCalcium      Nutrient = "calcium"
+	NutrientIron         Nutrient = "iron"
+	NutrientMagnesium    Nutrient = "magnesium"
+	NutrientVitaminA     Nutrient = "vitamin_a"
+	NutrientVitaminB12   Nutrient = "vitamin_b12"
+	NutrientVitaminC     Nutrient = "vitamin_c"
+	NutrientVitaminD     Nutrient = "vitamin_d"
+	NutrientCaffeine     Nutrient = "caffeine"
+)
+
+var (
+	ErrInvalidNutrient       = errors.New("invalid nutrient")
+	ErrNegativeNutrientValue = errors.New("nutrient amount cannot be negative")
+)
+
+// NutrientInfo describes a nutrient, its amounts are always in Unit.
+type NutrientInfo struct {
+	Nutrient Nutrient `json:"nutrient"`
+	Name     string   `json:"name"`
+	Unit     string   `json:"unit"`
+
+	// The names of the nutrient in FDC foodNutrients, the first is preferred when a food has more than one.
+	FDCNames []string `json:"-"`
+}
+
+// NutrientInfos are all the supported nutrients, adding one here makes it usable everywhere.
+var NutrientInfos = []NutrientInfo{
+	{NutrientSugars, "Sugars", "g", []string{"Sugars, total including NLEA", "Total Sugars", "Sugars, Total"}},
+	{NutrientSaturatedFat, "Saturated Fat", "g", []string{"Fatty acids, total saturated"}},
+	{NutrientTransFat, "Trans Fat", "g", []string{"Fatty acids, total trans"}},
+	{NutrientCholesterol, "Cholesterol", "mg", []string{"Cholesterol"}},
+	{NutrientSodium, "Sodium", "mg", []string{"Sodium, Na"}},
+	{NutrientPotassium, "Potassium", "mg", []string{"Potassium, K"}},
+	{NutrientCalcium, "Calcium", "mg", []string{"Calcium, Ca"}},
+	{NutrientIron, "Iron", "mg", []string{"Iron, Fe"}},
+	{NutrientMagnesium, "Magnesium", "mg", []string{"Magnesium, Mg"}},
+	{NutrientVitaminA, "Vitamin A", "µg", []string{"Vitamin A, RAE"}},
+	{NutrientVitaminB12, "Vitamin B12", "µg", []string{"Vitamin B-12"}},
+	{NutrientVitaminC, "Vitamin C", "mg", []string{"Vitamin C, total ascorbic acid"}},
+	{NutrientVitaminD, "Vitamin D", "µg", []string{"Vitamin D (D2 + D3)"}},
+	{NutrientCaffeine, "Caffeine", "mg", []string{"Caffeine"}},
+}
+
+func (n Nutrient) Info() (NutrientInfo, bool) {
+
+	for _, info := range NutrientInfos {
+		if info.Nutrient == n {
+			return info, true
+		}
+	}
+
+	return NutrientInfo{}, false
+}
+
+func (n Nutrient) IsValid() bool {
+	_, ok := n.Info()
+	return ok
+}
+
+// nutrientColumnPrefix is the prefix of the goal target and chart columns for a nutrient.
+const nutrientColumnPrefix = "NUTRIENT_"
+
+// nutrientFromColumn returns the nutrient of a goal target or chart column like NUTRIENT_SODIUM.
+func nutrientFromColumn(col string) (Nutrient, bool) {
+
+	name, ok := strings.CutPrefix(col, nutrientColumnPrefix)
+
+	if !ok {
+		return "", false
+	}
+
+	n := Nutrient(strings.ToLower(name))
+
+	return n, n.IsValid()
+}
+
+// Nutrients is the amount of each nutrient, nutrients not in the map are unknown rather than zero.
+// It is stored as a JSON object.
+type Nutrients map[Nutrient]float64
+
+// Validate checks every nutrient is supported and not negative.
+func (n Nutrients) Validate() error {
+
+	for k, v := range n {
+
+		if !k.IsValid() {
+			return fmt.Errorf("%w: %s", ErrInvalidNutrient, k)
+		}
+
+		if v < 0 {
+			return fmt.Errorf("%w: %s", ErrNegativeNutrientValue, k)
+		}
+	}
+
+	return nil
+}
+
+// Scaled returns a copy with every amount multiplied by the factor.
+func (n Nutrients) Scaled(factor float64) Nutrients {
+
+	if len(n) == 0 {
+		return nil
+	}
+
+	out := make(Nutrients, len(n))
+
+	for k, v := range n {
+		out[k] = v * factor
+	}
+
+	return out
+}
+
+// MarshalJSON writes nil as an empty object.
+func (n Nutrients) MarshalJSON() ([]byte, error) {
+	return marshalJSONObject(n)
+}
+
+func (n Nutrients) Value() (driver.Value, error) {
+
+	b, err := n.MarshalJSON()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan reads the JSON object, without any nutrients it is nil.
+func (n *Nutrients) Scan(src any) error {
+	return scanJSONObject(src, n)
+}
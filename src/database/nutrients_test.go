@@ -0,0 +1,57 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNutrients(t *testing.T) {
+
+	n := Nutrients{NutrientSodium: 400, NutrientVitaminC: 12}
+	assert.NoError(t, n.Validate())
+	assert.ErrorIs(t, Nutrients{"unobtanium": 1}.Validate(), ErrInvalidNutrient)
+	assert.ErrorIs(t, Nutrients{NutrientIron: -1}.Validate(), ErrNegativeNutrientValue)
+
+	assert.Equal(t, Nutrients{NutrientSodium: 100, NutrientVitaminC: 3}, n.Scaled(0.25))
+	assert.Nil(t, Nutrients(nil).Scaled(2))
+
+	b, err := json.Marshal(Nutrients(nil))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(b))
+
+	v, err := n.Value()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"sodium": 400, "vitamin_c": 12}`, v.(string))
+
+	var scanned Nutrients
+	require.NoError(t, scanned.Scan([]byte(v.(string))))
+	assert.Equal(t, n, scanned)
+
+	require.NoError(t, scanned.Scan("{}"))
+	assert.Nil(t, scanned)
+	assert.Error(t, scanned.Scan(12))
+}
+
+func TestNutrientColumns(t *testing.T) {
+
+	assert.True(t, GoalTargetColumn("NUTRIENT_SODIUM").IsValid())
+	assert.True(t, ChartColumn("NUTRIENT_VITAMIN_B12").IsValid())
+	assert.False(t, GoalTargetColumn("NUTRIENT_UNOBTANIUM").IsValid())
+	assert.False(t, ChartColumn("SODIUM").IsValid())
+
+	n, ok := ChartColumn("NUTRIENT_SATURATED_FAT").Nutrient()
+	assert.True(t, ok)
+	assert.Equal(t, NutrientSaturatedFat, n)
+
+	_, ok = TargetColumnFat.Nutrient()
+	assert.False(t, ok)
+
+	// Every nutrient has a unit the FDC importer can convert into.
+	for _, info := range NutrientInfos {
+		assert.Contains(t, []string{"g", "mg", "µg"}, info.Unit, info.Nutrient)
+		assert.NotEmpty(t, info.FDCNames, info.Nutrient)
+	}
+}
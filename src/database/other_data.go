@@ -14,6 +14,7 @@ type UpdateUserEventLog struct {
 type UserEventFoodLog struct {
 	Eventlog     TblUserEventLog  `json:"eventlog"`
 	Foodlogs     []TblUserFoodLog `json:"foodlogs"`
+	PhotoIDs     []int            `json:"photo_ids"`
 	TotalProtein float64          `json:"total_protein"`
 	TotalCarb    float64          `json:"total_carb"`
 	TotalFibre   float64          `json:"total_fibre"`
@@ -24,14 +25,16 @@ type CreateUserEventLog struct {
 	Event TblUserEvent     `json:"event"`
 	Foods []TblUserFoodLog `json:"foods"`
 
-	BloodGlucose             float64    `json:"blood_glucose"`
-	BloodGlucoseTarget       float64    `json:"blood_glucose_target"`
-	InsulinSensitivityFactor float64    `json:"insulin_sensitivity_factor"`
-	InsulinToCarbRatio       float64    `json:"insulin_to_carb_ratio"`
-	RecommendedInsulinAmount float64    `json:"recommended_insulin_amount"`
-	ActualInsulinTaken       float64    `json:"actual_insulin_taken"`
-	CreatedTime              TimeMillis `json:"created_time"`
-	PhotoIDs                 []int      `json:"photo_ids"`
+	BloodGlucose               float64    `json:"blood_glucose"`
+	BloodGlucoseTarget         float64    `json:"blood_glucose_target"`
+	InsulinSensitivityFactor   float64    `json:"insulin_sensitivity_factor"`
+	InsulinToCarbRatio         float64    `json:"insulin_to_carb_ratio"`
+	RecommendedInsulinAmount   float64    `json:"recommended_insulin_amount"`
+	ActualInsulinTaken         float64    `json:"actual_insulin_taken"`
+	ActualExtendedInsulinTaken float64    `json:"actual_extended_insulin_taken"`
+	ActualExtendedMinutes      int        `json:"actual_extended_minutes"`
+	CreatedTime                TimeMillis `json:"created_time"`
+	PhotoIDs                   []int      `json:"photo_ids"`
 }
 
 type UserGoalProgress struct {
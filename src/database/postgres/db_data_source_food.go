@@ -12,9 +12,9 @@ func (db *PGDatabase) AddDataSourceFood(ctx context.Context, ds *database.TblDat
 
 	query := `
 		INSERT INTO PON.DATA_SOURCE_FOOD(
-			DATA_SOURCE_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT, DATA_SOURCE_ROW_INT_ID
+			DATA_SOURCE_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT, DATA_SOURCE_ROW_INT_ID, NUTRIENTS, BARCODE
 		) VALUES (
-			:data_source_id, :name, :unit, :portion, :protein, :carb, :fibre, :fat, :data_source_row_int_id
+			:data_source_id, :name, :unit, :portion, :protein, :carb, :fibre, :fat, :data_source_row_int_id, :nutrients, :barcode
 		)
         RETURNING ID;
     `
@@ -61,3 +61,58 @@ func (db *PGDatabase) LoadDataSourceFoodBySimilarNameN(
 
 	})
 }
+
+func (db *PGDatabase) AddDataSourceFoodServing(
+	ctx context.Context,
+	serving *database.TblDataSourceFoodServing,
+) (int, error) {
+
+	query := `
+		INSERT INTO PON.DATA_SOURCE_FOOD_SERVING(
+			DATA_SOURCE_FOOD_ID, NAME, AMOUNT, UNIT, PORTION
+		) VALUES (
+			:data_source_food_id, :name, :amount, :unit, :portion
+		)
+        RETURNING ID;
+    `
+
+	return db.NamedInsertReturningID(ctx, query, serving)
+}
+
+func (db *PGDatabase) LoadDataSourceFoodServings(
+	ctx context.Context,
+	dataSourceFoodIDs []int,
+	out *[]database.TblDataSourceFoodServing,
+) error {
+
+	if len(dataSourceFoodIDs) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT * FROM PON.DATA_SOURCE_FOOD_SERVING
+		WHERE DATA_SOURCE_FOOD_ID IN (?)
+		ORDER BY DATA_SOURCE_FOOD_ID ASC, ID ASC
+	`, dataSourceFoodIDs)
+
+	if err != nil {
+		return err
+	}
+
+	return db.SelectContext(ctx, out, db.Rebind(query), args...)
+}
+
+func (db *PGDatabase) LoadDataSourceFoodByBarcode(
+	ctx context.Context,
+	barcode string,
+	out *[]database.TblDataSourceFood,
+) error {
+
+	query := `
+		SELECT * FROM PON.DATA_SOURCE_FOOD
+		WHERE BARCODE = $1 AND BARCODE <> ''
+		ORDER BY DATA_SOURCE_ID ASC, ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, barcode)
+}
@@ -34,6 +34,15 @@ func (db *PGDatabase) HasAnyUser(ctx context.Context) (bool, error) {
 	return result.Count != 0, err
 }
 
+// lockUserTx locks the user's row until the transaction ends,
+// so a transaction reading then adding the user's data can't interleave with another doing the same.
+func (db *PGDatabase) lockUserTx(tx *sqlx.Tx, userID int) error {
+
+	_, err := tx.Exec(`SELECT ID FROM PON.USER WHERE ID = $1 FOR UPDATE`, userID)
+
+	return err
+}
+
 func (db *PGDatabase) AddUser(ctx context.Context, user *database.TblUser) (int, error) {
 
 	var retUserID int = -1
@@ -50,7 +59,12 @@ func (db *PGDatabase) AddUser(ctx context.Context, user *database.TblUser) (int,
 				EVENT_LOG_TRAILING_ROWS,
 				DAY_TIME_OFFSET_SECONDS,
 				FILL_EVENTLOG_FROM_LAST,
-				TIMESPAN_HISTORY_FETCH_LIMIT
+				TIMESPAN_HISTORY_FETCH_LIMIT,
+				TIMEZONE,
+				INSULIN_ACTION_CURVE,
+				INSULIN_DURATION_MINUTES,
+				INSULIN_PEAK_MINUTES,
+				GLUCOSE_UNIT
 			) VALUES (
 				:name, :password,
 				:theme, :show_diabetes, :caloric_calc_method,
@@ -61,7 +75,12 @@ func (db *PGDatabase) AddUser(ctx context.Context, user *database.TblUser) (int,
 				:event_log_trailing_rows,
 				:day_time_offset_seconds,
 				:fill_eventlog_from_last,
-				:timespan_history_fetch_limit
+				:timespan_history_fetch_limit,
+				:timezone,
+				:insulin_action_curve,
+				:insulin_duration_minutes,
+				:insulin_peak_minutes,
+				:glucose_unit
 			)
     	    RETURNING ID;
     	`
@@ -97,7 +116,12 @@ func (db *PGDatabase) UpdateUser(ctx context.Context, user *database.TblUser) er
 	EVENT_LOG_TRAILING_ROWS=:event_log_trailing_rows,
 	DAY_TIME_OFFSET_SECONDS=:day_time_offset_seconds,
 	FILL_EVENTLOG_FROM_LAST=:fill_eventlog_from_last,
-	TIMESPAN_HISTORY_FETCH_LIMIT=:timespan_history_fetch_limit
+	TIMESPAN_HISTORY_FETCH_LIMIT=:timespan_history_fetch_limit,
+	TIMEZONE=:timezone,
+	INSULIN_ACTION_CURVE=:insulin_action_curve,
+	INSULIN_DURATION_MINUTES=:insulin_duration_minutes,
+	INSULIN_PEAK_MINUTES=:insulin_peak_minutes,
+	GLUCOSE_UNIT=:glucose_unit
 	WHERE ID=:id
 	`
 
@@ -132,9 +156,9 @@ func (db *PGDatabase) LoadUser(ctx context.Context, username string, user *datab
 	return nil
 }
 
-func (db *PGDatabase) ExportUserCSV(ctx context.Context, w io.Writer) error {
+func (db *PGDatabase) ExportUserCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 
-	query := `SELECT * FROM PON.USER`
+	query := `SELECT u.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON.USER u`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
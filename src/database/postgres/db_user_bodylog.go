@@ -86,9 +86,9 @@ func (db *PGDatabase) DeleteUserBodyLog(ctx context.Context, userID int, bodyLog
 	return err
 }
 
-func (db *PGDatabase) ExportBodyLogCSV(ctx context.Context, w io.Writer) error {
+func (db *PGDatabase) ExportBodyLogCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 
-	query := `SELECT * FROM PON.USER_BODYLOG`
+	query := `SELECT t.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON.USER_BODYLOG t LEFT JOIN PON.USER u ON u.ID = t.USER_ID`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
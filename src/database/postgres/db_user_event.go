@@ -74,9 +74,9 @@ func (db *PGDatabase) LoadAndOrCreateUserEventByNameTx(
 	return nil
 }
 
-func (db *PGDatabase) ExportUserEventsCSV(ctx context.Context, w io.Writer) error {
+func (db *PGDatabase) ExportUserEventsCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 
-	query := `SELECT * FROM PON.USER_EVENT`
+	query := `SELECT t.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON.USER_EVENT t LEFT JOIN PON.USER u ON u.ID = t.USER_ID`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
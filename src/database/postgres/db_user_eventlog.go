@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"karopon/src/database"
+	"karopon/src/insulin"
 	"math"
 	"time"
 
@@ -20,45 +21,169 @@ func (db *PGDatabase) AddUserEventLogWith(
 
 	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
 
-		if time.Time(event.UserTime).IsZero() {
-			event.UserTime = database.TimeMillis(time.Now())
+		eventLogID, err := db.AddUserEventLogWithTx(tx, event, foodlogs)
+
+		if err != nil {
+			return err
 		}
 
-		event.NetCarbs = 0
+		retEventLogID = eventLogID
+
+		return nil
+	})
+
+	return retEventLogID, err
+
+}
+
+func (db *PGDatabase) AddUserEventLogWithTx(
+	tx *sqlx.Tx,
+	event *database.TblUserEventLog,
+	foodlogs []database.TblUserFoodLog,
+) (int, error) {
+
+	if time.Time(event.UserTime).IsZero() {
+		event.UserTime = database.TimeMillis(time.Now())
+	}
+
+	// The totals are of the amounts the foodlogs are saved with.
+	if err := db.fillUserFoodLogsTx(tx, event.UserID, foodlogs); err != nil {
+		return -1, err
+	}
+
+	event.NetCarbs = 0
+	event.FatProteinUnits = 0
+
+	for _, food := range foodlogs {
+		event.NetCarbs += food.Carb - food.Fibre
+		event.FatProteinUnits += insulin.FatProteinUnits(food.Fat, food.Protein)
+	}
+
+	eventLogID, err := db.AddUserEventLogTx(tx, event)
 
-		for _, food := range foodlogs {
-			event.NetCarbs += food.Carb - food.Fibre
+	if err != nil {
+		return -1, err
+	}
+
+	for _, food := range foodlogs {
+
+		food.UserID = event.UserID
+		food.Event = event.Event
+		food.EventLogID = eventLogID
+		food.UserTime = event.UserTime
+
+		id, err := db.AddUserFoodLogTx(tx, &food)
+
+		if err != nil {
+			return -1, err
 		}
 
-		eventLogID, err := db.AddUserEventLogTx(tx, event)
+		food.ID = id
+	}
+
+	return eventLogID, nil
+}
+
+func (db *PGDatabase) AddUserEventLogWithPhotos(
+	ctx context.Context,
+	event *database.TblUserEventLog,
+	foodlogs []database.TblUserFoodLog,
+	photoIDs []int,
+) (int, error) {
+
+	var retEventLogID int = -1
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		eventLogID, err := db.AddUserEventLogWithTx(tx, event, foodlogs)
 
 		if err != nil {
 			return err
 		}
 
-		for _, food := range foodlogs {
+		if len(photoIDs) > 0 {
+			if err := db.AddUserEventLogPhotosTx(tx, event.UserID, eventLogID, photoIDs); err != nil {
+				return err
+			}
+		}
 
-			food.UserID = event.UserID
-			food.Event = event.Event
-			food.EventLogID = eventLogID
-			food.UserTime = event.UserTime
+		retEventLogID = eventLogID
 
-			id, err := db.AddUserFoodLogTx(tx, &food)
+		return nil
+	})
+
+	return retEventLogID, err
+}
+
+func (db *PGDatabase) AddUserEventLogsOnce(
+	ctx context.Context,
+	userID int,
+	eventlogs []database.TblUserEventLog,
+	foodlogs [][]database.TblUserFoodLog,
+) ([]database.TblUserEventLog, error) {
+
+	saved := make([]database.TblUserEventLog, 0, len(eventlogs))
+
+	if len(eventlogs) == 0 {
+		return saved, nil
+	}
+
+	query := `
+		SELECT COUNT(*) FROM PON.USER_EVENTLOG el
+		WHERE el.USER_ID = $1 AND el.EVENT = $2 AND el.NOTES = $3 AND el.USER_TIME >= $4 AND el.USER_TIME < $5
+	`
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		// A batch sent again while the first is still saving waits here, then sees what it saved.
+		if err := db.lockUserTx(tx, userID); err != nil {
+			return err
+		}
+
+		for i := range eventlogs {
+
+			eventlog := eventlogs[i]
+			eventlog.UserID = userID
+
+			at := eventlog.UserTime.Time().UTC()
+
+			// Also finds the eventlogs saved earlier in the batch.
+			var count int
+
+			if err := tx.Get(&count, query, userID, eventlog.Event, eventlog.Notes, at, at.Add(time.Millisecond)); err != nil {
+				return err
+			}
+
+			if count > 0 {
+				continue
+			}
+
+			var event database.TblUserEvent
+
+			if err := db.LoadAndOrCreateUserEventByNameTx(tx, userID, eventlog.Event, &event); err != nil {
+				return err
+			}
+
+			eventlog.EventID = event.ID
+
+			id, err := db.AddUserEventLogWithTx(tx, &eventlog, foodlogs[i])
 
 			if err != nil {
 				return err
 			}
 
-			food.ID = id
+			eventlog.ID = id
+			saved = append(saved, eventlog)
 		}
 
-		retEventLogID = eventLogID
-
 		return nil
 	})
 
-	return retEventLogID, err
+	if err != nil {
+		return nil, err
+	}
 
+	return saved, nil
 }
 
 func (db *PGDatabase) AddUserEventLogTx(tx *sqlx.Tx, event *database.TblUserEventLog) (int, error) {
@@ -75,7 +200,13 @@ func (db *PGDatabase) AddUserEventLogTx(tx *sqlx.Tx, event *database.TblUserEven
 			INSULIN_TO_CARB_RATIO,
 			BLOOD_GLUCOSE_TARGET,
 			RECOMMENDED_INSULIN_AMOUNT,
-			ACTUAL_INSULIN_TAKEN
+			ACTUAL_INSULIN_TAKEN,
+			FAT_PROTEIN_UNITS,
+			RECOMMENDED_EXTENDED_INSULIN_AMOUNT,
+			RECOMMENDED_EXTENDED_MINUTES,
+			ACTUAL_EXTENDED_INSULIN_TAKEN,
+			ACTUAL_EXTENDED_MINUTES,
+			NOTES
 		)
 		VALUES(:user_id, :event_id, :user_time, :event, :net_carbs, 
 			:blood_glucose,
@@ -83,7 +214,13 @@ func (db *PGDatabase) AddUserEventLogTx(tx *sqlx.Tx, event *database.TblUserEven
 			:insulin_to_carb_ratio,
 			:blood_glucose_target,
 			:recommended_insulin_amount,
-			:actual_insulin_taken
+			:actual_insulin_taken,
+			:fat_protein_units,
+			:recommended_extended_insulin_amount,
+			:recommended_extended_minutes,
+			:actual_extended_insulin_taken,
+			:actual_extended_minutes,
+			:notes
 		)
 		RETURNING ID;
 	`
@@ -114,6 +251,10 @@ func (db *PGDatabase) LoadUserEventFoodLog(
 			return err
 		}
 
+		if err := db.LoadUserEventLogPhotoIDsTx(tx, userID, eventlogID, &eventlogWithFood.PhotoIDs); err != nil {
+			return err
+		}
+
 		eventlogWithFood.Eventlog = eventlog
 
 		for _, foodlog := range eventlogWithFood.Foodlogs {
@@ -125,6 +266,9 @@ func (db *PGDatabase) LoadUserEventFoodLog(
 		if eventlogWithFood.Foodlogs == nil {
 			eventlogWithFood.Foodlogs = make([]database.TblUserFoodLog, 0)
 		}
+		if eventlogWithFood.PhotoIDs == nil {
+			eventlogWithFood.PhotoIDs = make([]int, 0)
+		}
 
 		*eventWithFood = eventlogWithFood
 
@@ -147,35 +291,78 @@ func (db *PGDatabase) LoadUserEventFoodLogsN(
 			return err
 		}
 
-		eventlogsWithFood := make([]database.UserEventFoodLog, len(eventlogs))
+		return db.loadUserEventFoodLogsTx(tx, userID, eventlogs, eventWithFood)
+	})
+}
 
-		for i, eventlog := range eventlogs {
+func (db *PGDatabase) LoadUserEventFoodLogsBetween(
+	ctx context.Context,
+	userID int,
+	start, end time.Time,
+	eventWithFood *[]database.UserEventFoodLog,
+) error {
 
-			ewfood := &eventlogsWithFood[i]
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
 
-			if err := db.LoadUserFoodLogByEventLogTx(tx, userID, eventlog.ID, &ewfood.Foodlogs); err != nil {
-				return err
-			}
+		query := `
+			SELECT * FROM PON.USER_EVENTLOG el
+			WHERE el.USER_ID = $1 AND el.USER_TIME >= $2 AND el.USER_TIME < $3
+			ORDER BY el.USER_TIME ASC, el.ID ASC
+		`
 
-			ewfood.Eventlog = eventlog
+		var eventlogs []database.TblUserEventLog
 
-			for _, foodlog := range ewfood.Foodlogs {
-				ewfood.TotalCarb += foodlog.Carb
-				ewfood.TotalProtein += foodlog.Protein
-				ewfood.TotalFat += foodlog.Fat
-				ewfood.TotalFibre += foodlog.Fibre
-			}
-			if ewfood.Foodlogs == nil {
-				ewfood.Foodlogs = make([]database.TblUserFoodLog, 0)
-			}
+		if err := tx.Select(&eventlogs, query, userID, start.UTC(), end.UTC()); err != nil {
+			return err
 		}
 
-		*eventWithFood = eventlogsWithFood
-
-		return nil
+		return db.loadUserEventFoodLogsTx(tx, userID, eventlogs, eventWithFood)
 	})
 }
 
+// loadUserEventFoodLogsTx reads the food and photos of each eventlog into the output.
+func (db *PGDatabase) loadUserEventFoodLogsTx(
+	tx *sqlx.Tx,
+	userID int,
+	eventlogs []database.TblUserEventLog,
+	eventWithFood *[]database.UserEventFoodLog,
+) error {
+
+	eventlogsWithFood := make([]database.UserEventFoodLog, len(eventlogs))
+
+	for i, eventlog := range eventlogs {
+
+		ewfood := &eventlogsWithFood[i]
+
+		if err := db.LoadUserFoodLogByEventLogTx(tx, userID, eventlog.ID, &ewfood.Foodlogs); err != nil {
+			return err
+		}
+
+		if err := db.LoadUserEventLogPhotoIDsTx(tx, userID, eventlog.ID, &ewfood.PhotoIDs); err != nil {
+			return err
+		}
+
+		ewfood.Eventlog = eventlog
+
+		for _, foodlog := range ewfood.Foodlogs {
+			ewfood.TotalCarb += foodlog.Carb
+			ewfood.TotalProtein += foodlog.Protein
+			ewfood.TotalFat += foodlog.Fat
+			ewfood.TotalFibre += foodlog.Fibre
+		}
+		if ewfood.Foodlogs == nil {
+			ewfood.Foodlogs = make([]database.TblUserFoodLog, 0)
+		}
+		if ewfood.PhotoIDs == nil {
+			ewfood.PhotoIDs = make([]int, 0)
+		}
+	}
+
+	*eventWithFood = eventlogsWithFood
+
+	return nil
+}
+
 func (db *PGDatabase) LoadUserEventFoodLogs(
 	ctx context.Context,
 	userID int,
@@ -192,6 +379,61 @@ func (db *PGDatabase) LoadUserEventLogs(ctx context.Context, userID int, out *[]
 	})
 }
 
+func (db *PGDatabase) LoadUserInsulinEventLogsBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserEventLog,
+) error {
+
+	query := `
+		SELECT * FROM PON.USER_EVENTLOG el
+		WHERE el.USER_ID = $1
+		  AND (el.ACTUAL_INSULIN_TAKEN > 0 OR el.ACTUAL_EXTENDED_INSULIN_TAKEN > 0)
+		  AND el.USER_TIME >= $2 AND el.USER_TIME < $3
+		ORDER BY el.USER_TIME ASC, el.ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID, start.UTC(), end.UTC())
+}
+
+func (db *PGDatabase) LoadUserEventLogsBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserEventLog,
+) error {
+
+	query := `
+		SELECT * FROM PON.USER_EVENTLOG el
+		WHERE el.USER_ID = $1 AND el.USER_TIME >= $2 AND el.USER_TIME < $3
+		ORDER BY el.USER_TIME ASC, el.ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID, start.UTC(), end.UTC())
+}
+
+func (db *PGDatabase) LoadUserEventLogsBetweenN(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	n int,
+	out *[]database.TblUserEventLog,
+) error {
+
+	query := `
+		SELECT * FROM PON.USER_EVENTLOG el
+		WHERE el.USER_ID = $1 AND el.USER_TIME >= $2 AND el.USER_TIME < $3
+		ORDER BY el.USER_TIME DESC, el.ID DESC
+		LIMIT $4
+	`
+
+	return db.SelectContext(ctx, out, query, userID, start.UTC(), end.UTC(), n)
+}
+
 func (db *PGDatabase) LoadUserEventLogsNTx(tx *sqlx.Tx, userID int, n int, out *[]database.TblUserEventLog) error {
 
 	query := `
@@ -261,11 +503,10 @@ func (db *PGDatabase) UpdateUserEventFoodLog(ctx context.Context, eventlog *data
 		}
 
 		eventlog.Eventlog.NetCarbs = 0
+		eventlog.Eventlog.FatProteinUnits = 0
 
 		for _, food := range eventlog.Foodlogs {
 
-			eventlog.Eventlog.NetCarbs = food.Carb - food.Fibre
-
 			food.UserID = eventlog.Eventlog.UserID
 			food.UserTime = eventlog.Eventlog.UserTime
 			food.Event = eventlog.Eventlog.Event
@@ -278,6 +519,10 @@ func (db *PGDatabase) UpdateUserEventFoodLog(ctx context.Context, eventlog *data
 			}
 
 			food.ID = id
+
+			// The totals are of the amounts the foodlog was saved with.
+			eventlog.Eventlog.NetCarbs += food.Carb - food.Fibre
+			eventlog.Eventlog.FatProteinUnits += insulin.FatProteinUnits(food.Fat, food.Protein)
 		}
 
 		query := `UPDATE PON.USER_EVENTLOG SET ` +
@@ -290,7 +535,12 @@ func (db *PGDatabase) UpdateUserEventFoodLog(ctx context.Context, eventlog *data
 			`INSULIN_TO_CARB_RATIO		= :insulin_to_carb_ratio,` +
 			`BLOOD_GLUCOSE_TARGET		= :blood_glucose_target,` +
 			`RECOMMENDED_INSULIN_AMOUNT	= :recommended_insulin_amount,` +
-			`ACTUAL_INSULIN_TAKEN		= :actual_insulin_taken ` +
+			`ACTUAL_INSULIN_TAKEN		= :actual_insulin_taken,` +
+			`FAT_PROTEIN_UNITS			= :fat_protein_units,` +
+			`RECOMMENDED_EXTENDED_INSULIN_AMOUNT	= :recommended_extended_insulin_amount,` +
+			`RECOMMENDED_EXTENDED_MINUTES		= :recommended_extended_minutes,` +
+			`ACTUAL_EXTENDED_INSULIN_TAKEN		= :actual_extended_insulin_taken,` +
+			`ACTUAL_EXTENDED_MINUTES			= :actual_extended_minutes ` +
 			`WHERE USER_ID = :user_id AND ID = :id`
 
 		_, err = tx.NamedExecContext(ctx, query, eventlog.Eventlog)
@@ -307,9 +557,9 @@ func (db *PGDatabase) DeleteUserEventLog(ctx context.Context, userID int, eventl
 	return err
 }
 
-func (db *PGDatabase) ExportUserEventLogsCSV(ctx context.Context, w io.Writer) error {
+func (db *PGDatabase) ExportUserEventLogsCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 
-	query := `SELECT * FROM PON.USER_EVENTLOG`
+	query := `SELECT t.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON.USER_EVENTLOG t LEFT JOIN PON.USER u ON u.ID = t.USER_ID`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
@@ -11,8 +11,8 @@ import (
 func (db *PGDatabase) AddUserFood(ctx context.Context, food *database.TblUserFood) (int, error) {
 
 	query := `
-        INSERT INTO PON.USER_FOOD (USER_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT)
-		VALUES (:user_id, :name, :unit, :portion, :protein, :carb, :fibre, :fat)
+        INSERT INTO PON.USER_FOOD (USER_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT, NUTRIENTS, DENSITY, HOUSEHOLD_UNITS)
+		VALUES (:user_id, :name, :unit, :portion, :protein, :carb, :fibre, :fat, :nutrients, :density, :household_units)
         RETURNING ID;
     `
 
@@ -26,8 +26,8 @@ func (db *PGDatabase) AddUserFoods(ctx context.Context, foods []database.TblUser
 	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
 
 		query := `
-			INSERT INTO PON.USER_FOOD (USER_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT)
-			VALUES (:user_id, :name, :unit, :portion, :protein, :carb, :fibre, :fat)
+			INSERT INTO PON.USER_FOOD (USER_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT, NUTRIENTS, DENSITY, HOUSEHOLD_UNITS)
+			VALUES (:user_id, :name, :unit, :portion, :protein, :carb, :fibre, :fat, :nutrients, :density, :household_units)
     	`
 		for _, food := range foods {
 
@@ -47,13 +47,16 @@ func (db *PGDatabase) UpdateUserFood(ctx context.Context, food *database.TblUser
 	query := `
 		UPDATE PON.USER_FOOD
 		SET
-			NAME    = :name,
-			UNIT    = :unit,
-			PORTION = :portion,
-			PROTEIN = :protein,
-			CARB    = :carb,
-			FIBRE   = :fibre,
-			FAT     = :fat
+			NAME            = :name,
+			UNIT            = :unit,
+			PORTION         = :portion,
+			PROTEIN         = :protein,
+			CARB            = :carb,
+			FIBRE           = :fibre,
+			FAT             = :fat,
+			NUTRIENTS       = :nutrients,
+			DENSITY         = :density,
+			HOUSEHOLD_UNITS = :household_units
 		WHERE USER_ID = :user_id AND ID = :id 
     `
 
@@ -64,14 +67,27 @@ func (db *PGDatabase) UpdateUserFood(ctx context.Context, food *database.TblUser
 
 func (db *PGDatabase) DeleteUserFood(ctx context.Context, userID int, foodID int) error {
 
-	query := `
-	DELETE FROM PON.USER_FOOD f
-	WHERE f.USER_ID = $1 AND f.ID = $2
-	`
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
 
-	_, err := db.ExecContext(ctx, query, userID, foodID)
+		var used int
 
-	return err
+		if err := tx.Get(&used, `SELECT COUNT(*) FROM PON.USER_RECIPE_INGREDIENT WHERE FOOD_ID = $1`, foodID); err != nil {
+			return err
+		}
+
+		if used > 0 {
+			return database.ErrFoodUsedInRecipe
+		}
+
+		query := `
+		DELETE FROM PON.USER_FOOD f
+		WHERE f.USER_ID = $1 AND f.ID = $2
+		`
+
+		_, err := tx.Exec(query, userID, foodID)
+
+		return err
+	})
 }
 
 func (db *PGDatabase) LoadUserFoods(ctx context.Context, userID int, out *[]database.TblUserFood) error {
@@ -84,8 +100,8 @@ func (db *PGDatabase) LoadUserFoods(ctx context.Context, userID int, out *[]data
 	return db.SelectContext(ctx, out, query, userID)
 }
 
-func (db *PGDatabase) ExportUserFoodsCSV(ctx context.Context, w io.Writer) error {
-	query := `SELECT * FROM PON.USER_FOOD`
+func (db *PGDatabase) ExportUserFoodsCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
+	query := `SELECT t.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON.USER_FOOD t LEFT JOIN PON.USER u ON u.ID = t.USER_ID`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
@@ -2,8 +2,6 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"io"
 	"karopon/src/database"
 
@@ -11,25 +9,79 @@ import (
 	"github.com/vinovest/sqlx"
 )
 
+func (db *PGDatabase) FillUserFoodLogs(ctx context.Context, userID int, foodlogs []database.TblUserFoodLog) error {
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		return db.fillUserFoodLogsTx(tx, userID, foodlogs)
+	})
+}
+
+func (db *PGDatabase) fillUserFoodLogsTx(tx *sqlx.Tx, userID int, foodlogs []database.TblUserFoodLog) error {
+
+	query := `SELECT * FROM PON.USER_FOOD f ` +
+		`WHERE f.USER_ID = $1 AND f.NAME = $2 ` +
+		`ORDER BY f.ID ASC`
+
+	for i := range foodlogs {
+
+		food := &foodlogs[i]
+
+		if food.RecipeID != nil {
+			continue
+		}
+
+		var foods []database.TblUserFood
+
+		if err := tx.Select(&foods, query, userID, food.Name); err != nil {
+			return err
+		}
+
+		// The same food AddUserFoodLogTx picks.
+		if match, ok := database.MatchFoodUnit(foods, food.Unit); ok && match.Unit != food.Unit {
+			if err := match.FillFoodLog(food); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (db *PGDatabase) AddUserFoodLogTx(tx *sqlx.Tx, food *database.TblUserFoodLog) (int, error) {
 
 	var query string
 
-	{ // USER_FOOD table stuff
-		query = `SELECT ID FROM PON.USER_FOOD f ` +
-			`WHERE f.USER_ID = $1 AND f.NAME = $2 AND f.UNIT = $3 ` +
-			`LIMIT 1`
+	if food.RecipeID != nil {
+
+		// Recipes are logged with the amounts already filled in, they have no food.
+		if err := db.userHasRecipeTx(tx, food.UserID, *food.RecipeID); err != nil {
+			return -1, err
+		}
+
+		food.FoodID = nil
+
+	} else { // USER_FOOD table stuff
+		query = `SELECT * FROM PON.USER_FOOD f ` +
+			`WHERE f.USER_ID = $1 AND f.NAME = $2 ` +
+			`ORDER BY f.ID ASC`
+
+		var foods []database.TblUserFood
+
+		if err := tx.Select(&foods, query, food.UserID, food.Name); err != nil {
+			return -1, err
+		}
 
-		err := tx.QueryRow(query, food.UserID, food.Name, food.Unit).Scan(&food.FoodID)
+		// The food in the foodlog's unit, or one the unit converts into.
+		match, ok := database.MatchFoodUnit(foods, food.Unit)
 
 		switch {
 
-		case errors.Is(err, sql.ErrNoRows):
-			log.Debug().Msg("got error no rows")
+		case !ok:
+			log.Debug().Msg("no food in a matching unit")
 
 			query = `INSERT INTO PON.USER_FOOD ` +
-				`(USER_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT) VALUES ` +
-				`(:user_id, :name, :unit, :portion, :protein, :carb, :fibre, :fat) ` +
+				`(USER_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT, NUTRIENTS) VALUES ` +
+				`(:user_id, :name, :unit, :portion, :protein, :carb, :fibre, :fat, :nutrients) ` +
 				`RETURNING ID;`
 
 			if food.Portion == 0 {
@@ -45,6 +97,8 @@ func (db *PGDatabase) AddUserFoodLogTx(tx *sqlx.Tx, food *database.TblUserFoodLo
 				Carb:    food.Carb,
 				Fibre:   food.Fibre,
 				Fat:     food.Fat,
+
+				Nutrients: food.Nutrients,
 			}
 			newFood.Scale()
 
@@ -55,25 +109,32 @@ func (db *PGDatabase) AddUserFoodLogTx(tx *sqlx.Tx, food *database.TblUserFoodLo
 				log.Debug().Int("id", *food.FoodID).Msg("created new food")
 			}
 
-		case err != nil:
-			return -1, err
+		case match.Unit != food.Unit:
+
+			// Logged in another unit, so the amounts are worked out from the food.
+			if err := match.FillFoodLog(food); err != nil {
+				return -1, err
+			}
+			log.Debug().Int("id", match.ID).Str("unit", food.Unit).Msg("converted existing food")
 
 		default:
-			id := -1
-			if food.FoodID != nil {
-				id = *food.FoodID
+			food.FoodID = &match.ID
+			log.Debug().Int("id", match.ID).Msg("found existing food")
+
+			// Foods are per unit, so the amounts for the portion eaten.
+			if len(food.Nutrients) == 0 {
+				food.Nutrients = match.Nutrients.Scaled(food.Portion)
 			}
-			log.Debug().Int("id", id).Msg("found existing food")
 		}
 	}
 
 	query = `INSERT INTO PON.USER_FOODLOG ` +
 		`(` +
 		`USER_ID, FOOD_ID, USER_TIME, NAME, EVENT, UNIT, PORTION, PROTEIN, ` +
-		`CARB, FIBRE, FAT, EVENTLOG_ID` +
+		`CARB, FIBRE, FAT, EVENTLOG_ID, NUTRIENTS, RECIPE_ID` +
 		`) VALUES (` +
 		`:user_id, :food_id, :user_time, :name, :event, :unit, :portion, :protein, ` +
-		`:carb, :fibre, :fat, :eventlog_id` +
+		`:carb, :fibre, :fat, :eventlog_id, :nutrients, :recipe_id` +
 		`) RETURNING ID;`
 
 	id, err := db.NamedInsertReturningIDTx(tx, query, food)
@@ -99,9 +160,9 @@ func (db *PGDatabase) LoadUserFoodLogByEventLogTx(
 	return tx.Select(out, query, userID, eventLogID)
 }
 
-func (db *PGDatabase) ExportUserFoodLogsCSV(ctx context.Context, w io.Writer) error {
+func (db *PGDatabase) ExportUserFoodLogsCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 
-	query := `SELECT * FROM PON.USER_FOODLOG`
+	query := `SELECT t.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON.USER_FOODLOG t LEFT JOIN PON.USER u ON u.ID = t.USER_ID`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
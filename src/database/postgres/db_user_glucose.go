@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"io"
+	"karopon/src/database"
+	"time"
+
+	"github.com/vinovest/sqlx"
+)
+
+func (db *PGDatabase) AddUserGlucoseReadings(
+	ctx context.Context,
+	userID int,
+	readings []database.TblUserGlucose,
+) (int, error) {
+
+	query := `
+		INSERT INTO PON.USER_GLUCOSE (USER_ID, USER_TIME, GLUCOSE, SOURCE)
+		VALUES (:user_id, :user_time, :glucose, :source)
+		ON CONFLICT (USER_ID, USER_TIME) DO NOTHING
+	`
+
+	added := 0
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		for _, reading := range readings {
+
+			reading.UserID = userID
+
+			res, err := tx.NamedExecContext(ctx, query, reading)
+
+			if err != nil {
+				return err
+			}
+
+			n, err := res.RowsAffected()
+
+			if err != nil {
+				return err
+			}
+
+			added += int(n)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return added, nil
+}
+
+func (db *PGDatabase) LoadUserGlucoseBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserGlucose,
+) error {
+
+	query := `
+		SELECT * FROM PON.USER_GLUCOSE g
+		WHERE g.USER_ID = $1 AND g.USER_TIME >= $2 AND g.USER_TIME < $3
+		ORDER BY g.USER_TIME ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID, start.UTC(), end.UTC())
+}
+
+func (db *PGDatabase) LoadUserGlucoseBetweenN(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	n int,
+	out *[]database.TblUserGlucose,
+) error {
+
+	query := `
+		SELECT * FROM PON.USER_GLUCOSE g
+		WHERE g.USER_ID = $1 AND g.USER_TIME >= $2 AND g.USER_TIME < $3
+		ORDER BY g.USER_TIME DESC
+		LIMIT $4
+	`
+
+	return db.SelectContext(ctx, out, query, userID, start.UTC(), end.UTC(), n)
+}
+
+func (db *PGDatabase) DeleteUserGlucose(ctx context.Context, userID int, glucoseID int) error {
+
+	query := `DELETE FROM PON.USER_GLUCOSE WHERE USER_ID = $1 AND ID = $2`
+
+	_, err := db.ExecContext(ctx, query, userID, glucoseID)
+
+	return err
+}
+
+func (db *PGDatabase) ExportUserGlucoseCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
+
+	query := `SELECT t.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON.USER_GLUCOSE t LEFT JOIN PON.USER u ON u.ID = t.USER_ID`
+
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
+}
@@ -114,7 +114,14 @@ func (db *PGDatabase) LoadUserGoalProgress(
 
 	switch userGoal.TargetColumn() {
 	default:
-		return database.ErrInvalidGoalTargetColumn
+		n, ok := userGoal.TargetColumn().Nutrient()
+
+		if !ok {
+			return database.ErrInvalidGoalTargetColumn
+		}
+
+		tableSQL = "PON.USER_FOODLOG"
+		colSQL, whereSQL = nutrientToPG(n)
 
 	case database.TargetColumnCalories:
 		// TODO: don't hard code this and make it use the user's setting
@@ -175,6 +182,10 @@ func (db *PGDatabase) LoadUserGoalProgress(
 		tableSQL = "PON.USER_EVENTLOG"
 		colSQL = "BLOOD_GLUCOSE"
 		whereSQL = " AND BLOOD_GLUCOSE > 0"
+	case database.TargetColumnGlucose:
+		tableSQL = "PON.USER_GLUCOSE"
+		colSQL = "GLUCOSE"
+		whereSQL = ""
 	}
 
 	query := `
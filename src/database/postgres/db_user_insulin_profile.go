@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"karopon/src/database"
+)
+
+func (db *PGDatabase) AddUserInsulinProfileBlock(
+	ctx context.Context,
+	block *database.TblUserInsulinProfileBlock,
+) (int, error) {
+
+	query := `
+		INSERT INTO PON.USER_INSULIN_PROFILE (
+			USER_ID, START_MINUTE, INSULIN_TO_CARB_RATIO, INSULIN_SENSITIVITY_FACTOR, TARGET_BLOOD_SUGAR
+		) VALUES (
+			:user_id, :start_minute, :insulin_to_carb_ratio, :insulin_sensitivity_factor, :target_blood_sugar
+		)
+		RETURNING ID
+	`
+
+	return db.NamedInsertReturningID(ctx, query, block)
+}
+
+func (db *PGDatabase) LoadUserInsulinProfile(
+	ctx context.Context,
+	userID int,
+	out *[]database.TblUserInsulinProfileBlock,
+) error {
+
+	query := `
+		SELECT * FROM PON.USER_INSULIN_PROFILE
+		WHERE USER_ID = $1
+		ORDER BY START_MINUTE ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID)
+}
+
+func (db *PGDatabase) UpdateUserInsulinProfileBlock(ctx context.Context, block *database.TblUserInsulinProfileBlock) error {
+
+	query := `
+		UPDATE PON.USER_INSULIN_PROFILE
+		SET
+			START_MINUTE               = :start_minute,
+			INSULIN_TO_CARB_RATIO      = :insulin_to_carb_ratio,
+			INSULIN_SENSITIVITY_FACTOR = :insulin_sensitivity_factor,
+			TARGET_BLOOD_SUGAR         = :target_blood_sugar
+		WHERE ID = :id AND USER_ID = :user_id
+	`
+
+	_, err := db.NamedExecContext(ctx, query, block)
+
+	return err
+}
+
+func (db *PGDatabase) DeleteUserInsulinProfileBlock(ctx context.Context, userID int, blockID int) error {
+
+	query := `DELETE FROM PON.USER_INSULIN_PROFILE WHERE ID = $1 AND USER_ID = $2`
+
+	_, err := db.ExecContext(ctx, query, blockID, userID)
+
+	return err
+}
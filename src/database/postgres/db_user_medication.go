@@ -0,0 +1,325 @@
+package postgres
+
+import (
+	"context"
+	"karopon/src/database"
+	"time"
+
+	"github.com/vinovest/sqlx"
+)
+
+func (db *PGDatabase) AddUserMedication(ctx context.Context, med *database.TblUserMedication) (int, error) {
+
+	query := `
+		INSERT INTO PON.USER_MEDICATION (
+			USER_ID, NAME, CATEGORY, DOSAGE_AMOUNT, DOSAGE_UNIT, FORM, START_DATE, END_DATE, NOTES
+		) VALUES (
+			:user_id, :name, :category, :dosage_amount, :dosage_unit, :form, :start_date, :end_date, :notes
+		)
+		RETURNING ID
+	`
+
+	return db.NamedInsertReturningID(ctx, query, med)
+}
+
+func (db *PGDatabase) LoadUserMedications(ctx context.Context, userID int, out *[]database.TblUserMedication) error {
+
+	query := `
+		SELECT * FROM PON.USER_MEDICATION
+		WHERE USER_ID = $1
+		ORDER BY ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID)
+}
+
+func (db *PGDatabase) UpdateUserMedication(ctx context.Context, med *database.TblUserMedication) error {
+
+	query := `
+		UPDATE PON.USER_MEDICATION
+		SET
+			NAME          = :name,
+			CATEGORY      = :category,
+			DOSAGE_AMOUNT = :dosage_amount,
+			DOSAGE_UNIT   = :dosage_unit,
+			FORM          = :form,
+			START_DATE    = :start_date,
+			END_DATE      = :end_date,
+			NOTES         = :notes
+		WHERE ID = :id AND USER_ID = :user_id
+	`
+
+	return db.ExpectRowsAffected(db.NamedExecContext(ctx, query, med))
+}
+
+func (db *PGDatabase) DeleteUserMedication(ctx context.Context, userID int, medicationID int) error {
+
+	query := `DELETE FROM PON.USER_MEDICATION WHERE ID = $1 AND USER_ID = $2`
+
+	return db.ExpectRowsAffected(db.ExecContext(ctx, query, medicationID, userID))
+}
+
+func (db *PGDatabase) userHasMedicationTx(tx *sqlx.Tx, userID int, medicationID int) error {
+
+	query := `SELECT COUNT(ID) FROM PON.USER_MEDICATION WHERE USER_ID = $1 AND ID = $2 LIMIT 1`
+
+	if ok, err := db.CountOneTx(tx, query, userID, medicationID); err != nil {
+		return err
+	} else if !ok {
+		return database.ErrUserDoesNotHaveThisID
+	}
+
+	return nil
+}
+
+func (db *PGDatabase) userHasMedicationScheduleTx(tx *sqlx.Tx, userID int, scheduleID int) error {
+
+	query := `
+		SELECT COUNT(s.ID) FROM PON.USER_MEDICATION_SCHEDULE s
+		JOIN PON.USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+		WHERE m.USER_ID = $1 AND s.ID = $2
+		LIMIT 1
+	`
+
+	if ok, err := db.CountOneTx(tx, query, userID, scheduleID); err != nil {
+		return err
+	} else if !ok {
+		return database.ErrUserDoesNotHaveThisID
+	}
+
+	return nil
+}
+
+func (db *PGDatabase) userHasMedicationLogTx(tx *sqlx.Tx, userID int, medlogID int) error {
+
+	query := `
+		SELECT COUNT(l.ID) FROM PON.USER_MEDICATIONLOG l
+		JOIN PON.USER_MEDICATION_SCHEDULE s ON s.ID = l.SCHEDULE_ID
+		JOIN PON.USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+		WHERE m.USER_ID = $1 AND l.ID = $2
+		LIMIT 1
+	`
+
+	if ok, err := db.CountOneTx(tx, query, userID, medlogID); err != nil {
+		return err
+	} else if !ok {
+		return database.ErrUserDoesNotHaveThisID
+	}
+
+	return nil
+}
+
+func (db *PGDatabase) AddUserMedicationSchedule(
+	ctx context.Context,
+	userID int,
+	schedule *database.TblUserMedicationSchedule,
+) (int, error) {
+
+	var id int
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		// Make sure the UserID has the MedicationID, since the caller can't verify this.
+		if err := db.userHasMedicationTx(tx, userID, schedule.MedicationID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO PON.USER_MEDICATION_SCHEDULE (
+				MEDICATION_ID,
+				MINUTES_OF_HOUR_MASK, HOURS_OF_DAY_MASK, DAYS_OF_WEEK_MASK, DAYS_OF_MONTH_MASK, MONTH_OF_YEAR_MASK,
+				WITH_FOOD, FASTING, REMINDER_ENABLED, NOTES
+			) VALUES (
+				:medication_id,
+				:minutes_of_hour_mask, :hours_of_day_mask, :days_of_week_mask, :days_of_month_mask, :month_of_year_mask,
+				:with_food, :fasting, :reminder_enabled, :notes
+			)
+			RETURNING ID
+		`
+
+		var err error
+		id, err = db.NamedInsertReturningIDTx(tx, query, schedule)
+
+		return err
+	})
+
+	return id, err
+}
+
+func (db *PGDatabase) LoadUserMedicationSchedules(
+	ctx context.Context,
+	userID int,
+	out *[]database.TblUserMedicationSchedule,
+) error {
+
+	query := `
+		SELECT s.* FROM PON.USER_MEDICATION_SCHEDULE s
+		JOIN PON.USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+		WHERE m.USER_ID = $1
+		ORDER BY s.ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID)
+}
+
+func (db *PGDatabase) UpdateUserMedicationSchedule(
+	ctx context.Context,
+	userID int,
+	schedule *database.TblUserMedicationSchedule,
+) error {
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		if err := db.userHasMedicationScheduleTx(tx, userID, schedule.ID); err != nil {
+			return err
+		}
+
+		// The schedule can be moved onto another medication, which must also belong to the user.
+		if err := db.userHasMedicationTx(tx, userID, schedule.MedicationID); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE PON.USER_MEDICATION_SCHEDULE
+			SET
+				MEDICATION_ID        = :medication_id,
+				MINUTES_OF_HOUR_MASK = :minutes_of_hour_mask,
+				HOURS_OF_DAY_MASK    = :hours_of_day_mask,
+				DAYS_OF_WEEK_MASK    = :days_of_week_mask,
+				DAYS_OF_MONTH_MASK   = :days_of_month_mask,
+				MONTH_OF_YEAR_MASK   = :month_of_year_mask,
+				WITH_FOOD            = :with_food,
+				FASTING              = :fasting,
+				REMINDER_ENABLED     = :reminder_enabled,
+				NOTES                = :notes
+			WHERE ID = :id
+		`
+
+		return db.ExpectRowsAffected(tx.NamedExec(query, schedule))
+	})
+}
+
+func (db *PGDatabase) DeleteUserMedicationSchedule(ctx context.Context, userID int, scheduleID int) error {
+
+	query := `
+		DELETE FROM PON.USER_MEDICATION_SCHEDULE
+		WHERE ID = $1 AND MEDICATION_ID IN (
+			SELECT ID FROM PON.USER_MEDICATION WHERE USER_ID = $2
+		)
+	`
+
+	return db.ExpectRowsAffected(db.ExecContext(ctx, query, scheduleID, userID))
+}
+
+func (db *PGDatabase) AddUserMedicationLog(
+	ctx context.Context,
+	userID int,
+	medlog *database.TblUserMedicationLog,
+) (int, error) {
+
+	var id int
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		// Make sure the UserID has the ScheduleID, since the caller can't verify this.
+		if err := db.userHasMedicationScheduleTx(tx, userID, medlog.ScheduleID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO PON.USER_MEDICATIONLOG (
+				SCHEDULE_ID, TAKEN_TIME, TAKEN, NOTES
+			) VALUES (
+				:schedule_id, :taken_time, :taken, :notes
+			)
+			RETURNING ID
+		`
+
+		var err error
+		id, err = db.NamedInsertReturningIDTx(tx, query, medlog)
+
+		return err
+	})
+
+	return id, err
+}
+
+func (db *PGDatabase) LoadUserMedicationLogs(
+	ctx context.Context,
+	userID int,
+	out *[]database.TblUserMedicationLog,
+) error {
+
+	query := `
+		SELECT l.* FROM PON.USER_MEDICATIONLOG l
+		JOIN PON.USER_MEDICATION_SCHEDULE s ON s.ID = l.SCHEDULE_ID
+		JOIN PON.USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+		WHERE m.USER_ID = $1
+		ORDER BY l.TAKEN_TIME DESC, l.ID DESC
+	`
+
+	return db.SelectContext(ctx, out, query, userID)
+}
+
+func (db *PGDatabase) LoadUserMedicationLogsBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserMedicationLog,
+) error {
+
+	query := `
+		SELECT l.* FROM PON.USER_MEDICATIONLOG l
+		JOIN PON.USER_MEDICATION_SCHEDULE s ON s.ID = l.SCHEDULE_ID
+		JOIN PON.USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+		WHERE m.USER_ID = $1 AND l.TAKEN_TIME >= $2 AND l.TAKEN_TIME < $3
+		ORDER BY l.TAKEN_TIME ASC, l.ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID, start.UTC(), end.UTC())
+}
+
+func (db *PGDatabase) UpdateUserMedicationLog(
+	ctx context.Context,
+	userID int,
+	medlog *database.TblUserMedicationLog,
+) error {
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		if err := db.userHasMedicationLogTx(tx, userID, medlog.ID); err != nil {
+			return err
+		}
+
+		if err := db.userHasMedicationScheduleTx(tx, userID, medlog.ScheduleID); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE PON.USER_MEDICATIONLOG
+			SET
+				SCHEDULE_ID = :schedule_id,
+				TAKEN_TIME  = :taken_time,
+				TAKEN       = :taken,
+				NOTES       = :notes
+			WHERE ID = :id
+		`
+
+		return db.ExpectRowsAffected(tx.NamedExec(query, medlog))
+	})
+}
+
+func (db *PGDatabase) DeleteUserMedicationLog(ctx context.Context, userID int, medlogID int) error {
+
+	query := `
+		DELETE FROM PON.USER_MEDICATIONLOG
+		WHERE ID = $1 AND SCHEDULE_ID IN (
+			SELECT s.ID FROM PON.USER_MEDICATION_SCHEDULE s
+			JOIN PON.USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+			WHERE m.USER_ID = $2
+		)
+	`
+
+	return db.ExpectRowsAffected(db.ExecContext(ctx, query, medlogID, userID))
+}
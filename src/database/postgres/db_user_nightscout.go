@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"context"
+	"karopon/src/database"
+)
+
+func (db *PGDatabase) SetUserNightscoutSecret(ctx context.Context, userID int, secretHash string) error {
+
+	query := `
+		INSERT INTO PON.USER_NIGHTSCOUT (USER_ID, API_SECRET_HASH)
+		VALUES ($1, $2)
+		ON CONFLICT (USER_ID) DO UPDATE SET API_SECRET_HASH = EXCLUDED.API_SECRET_HASH, CREATED = CURRENT_TIMESTAMP
+	`
+
+	_, err := db.ExecContext(ctx, query, userID, secretHash)
+
+	return err
+}
+
+func (db *PGDatabase) DeleteUserNightscoutSecret(ctx context.Context, userID int) error {
+
+	query := `DELETE FROM PON.USER_NIGHTSCOUT WHERE USER_ID = $1`
+
+	_, err := db.ExecContext(ctx, query, userID)
+
+	return err
+}
+
+func (db *PGDatabase) LoadUserByNightscoutSecret(ctx context.Context, secretHash string, user *database.TblUser) error {
+
+	query := `
+		SELECT u.* FROM PON.USER u
+		JOIN PON.USER_NIGHTSCOUT ns ON ns.USER_ID = u.ID
+		WHERE ns.API_SECRET_HASH = $1
+		LIMIT 1
+	`
+
+	return db.GetContext(ctx, user, query, secretHash)
+}
@@ -3,34 +3,159 @@ package postgres
 import (
 	"context"
 	"karopon/src/database"
+	"time"
 
 	"github.com/vinovest/sqlx"
 )
 
 func (db *PGDatabase) AddUserPhoto(ctx context.Context, photo *database.TblUserPhoto) (int, error) {
 
-	query := `INSERT INTO PON.USER_PHOTO (user_id, data) VALUES (:user_id, :data) RETURNING id`
+	query := `
+		INSERT INTO PON.USER_PHOTO (
+			user_id, data, thumbnail, storage, hash, content_type, width, height
+		) VALUES (
+			:user_id, :data, COALESCE(:thumbnail, decode('', 'hex')), COALESCE(NULLIF(:storage, ''), 'db'), :hash,
+			:content_type, :width, :height
+		)
+		RETURNING id
+	`
 
 	return db.NamedInsertReturningID(ctx, query, photo)
 }
 
-func (db *PGDatabase) AddUserEventLogPhotos(ctx context.Context, eventlogID int, photoIDs []int) error {
+func (db *PGDatabase) AddUserEventLogPhotos(ctx context.Context, userID int, eventlogID int, photoIDs []int) error {
 
 	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		return db.AddUserEventLogPhotosTx(tx, userID, eventlogID, photoIDs)
+	})
+}
+
+func (db *PGDatabase) AddUserEventLogPhotosTx(tx *sqlx.Tx, userID int, eventlogID int, photoIDs []int) error {
+
+	// Make sure the UserID has the EventlogID and every PhotoID, since the caller can't verify this.
+	query := `SELECT COUNT(id) FROM PON.USER_EVENTLOG WHERE user_id = $1 AND id = $2 LIMIT 1`
 
-		for _, photoID := range photoIDs {
+	if ok, err := db.CountOneTx(tx, query, userID, eventlogID); err != nil {
+		return err
+	} else if !ok {
+		return database.ErrUserDoesNotHaveThisID
+	}
 
-			_, err := tx.Exec(
-				`INSERT INTO PON.USER_EVENTLOG_PHOTO (eventlog_id, photo_id) VALUES ($1, $2)`,
-				eventlogID,
-				photoID,
-			)
+	for _, photoID := range photoIDs {
 
-			if err != nil {
-				return err
-			}
+		query = `SELECT COUNT(id) FROM PON.USER_PHOTO WHERE user_id = $1 AND id = $2 LIMIT 1`
+
+		if ok, err := db.CountOneTx(tx, query, userID, photoID); err != nil {
+			return err
+		} else if !ok {
+			return database.ErrUserDoesNotHaveThisID
 		}
 
-		return nil
-	})
+		_, err := tx.Exec(
+			`INSERT INTO PON.USER_EVENTLOG_PHOTO (eventlog_id, photo_id) VALUES ($1, $2)`,
+			eventlogID,
+			photoID,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *PGDatabase) LoadUserPhoto(ctx context.Context, userID int, photoID int, out *database.TblUserPhoto) error {
+
+	query := `SELECT * FROM PON.USER_PHOTO WHERE id = $1 AND user_id = $2`
+
+	return db.GetContext(ctx, out, query, photoID, userID)
+}
+
+func (db *PGDatabase) LoadUserEventLogPhotoIDsTx(tx *sqlx.Tx, userID int, eventlogID int, out *[]int) error {
+
+	query := `
+		SELECT p.id FROM PON.USER_EVENTLOG_PHOTO ep
+		JOIN PON.USER_PHOTO p ON p.id = ep.photo_id
+		WHERE p.user_id = $1 AND ep.eventlog_id = $2
+		ORDER BY p.id ASC
+	`
+
+	return tx.Select(out, query, userID, eventlogID)
+}
+
+func (db *PGDatabase) DeleteUserPhoto(ctx context.Context, userID int, photoID int) error {
+
+	query := `DELETE FROM PON.USER_PHOTO WHERE id = $1 AND user_id = $2`
+
+	return db.ExpectRowsAffected(db.ExecContext(ctx, query, photoID, userID))
+}
+
+func (db *PGDatabase) DeleteUserEventLogPhoto(ctx context.Context, userID int, eventlogID int, photoID int) error {
+
+	query := `
+		DELETE FROM PON.USER_EVENTLOG_PHOTO ep
+		USING PON.USER_EVENTLOG el
+		WHERE el.id = ep.eventlog_id AND el.user_id = $3
+		AND ep.eventlog_id = $1 AND ep.photo_id = $2
+	`
+
+	return db.ExpectRowsAffected(db.ExecContext(ctx, query, eventlogID, photoID, userID))
+}
+
+func (db *PGDatabase) DeleteUnlinkedUserPhotos(
+	ctx context.Context,
+	createdBefore time.Time,
+) ([]database.TblUserPhoto, error) {
+
+	query := `
+		DELETE FROM PON.USER_PHOTO p
+		WHERE p.created < $1
+		AND NOT EXISTS (SELECT 1 FROM PON.USER_EVENTLOG_PHOTO ep WHERE ep.photo_id = p.id)
+		RETURNING p.id, p.user_id, p.created, p.storage, p.hash
+	`
+
+	var photos []database.TblUserPhoto
+
+	err := db.SelectContext(ctx, &photos, query, createdBefore.UTC())
+
+	return photos, err
+}
+
+func (db *PGDatabase) LoadPhotoByID(ctx context.Context, photoID int, out *database.TblUserPhoto) error {
+
+	query := `SELECT * FROM PON.USER_PHOTO WHERE id = $1`
+
+	return db.GetContext(ctx, out, query, photoID)
+}
+
+func (db *PGDatabase) LoadPhotoIDsByStorage(ctx context.Context, storage string, out *[]int) error {
+
+	query := `SELECT id FROM PON.USER_PHOTO WHERE storage = $1 ORDER BY id ASC`
+
+	return db.SelectContext(ctx, out, query, storage)
+}
+
+func (db *PGDatabase) UpdatePhotoStorage(ctx context.Context, photo *database.TblUserPhoto) error {
+
+	query := `
+		UPDATE PON.USER_PHOTO
+		SET storage = :storage, hash = :hash, data = :data, thumbnail = COALESCE(:thumbnail, decode('', 'hex'))
+		WHERE id = :id
+	`
+
+	_, err := db.NamedExecContext(ctx, query, photo)
+
+	return err
+}
+
+func (db *PGDatabase) CountPhotosByHash(ctx context.Context, storage string, hash string) (int, error) {
+
+	query := `SELECT COUNT(id) FROM PON.USER_PHOTO WHERE storage = $1 AND hash = $2`
+
+	var count int
+
+	err := db.GetContext(ctx, &count, query, storage, hash)
+
+	return count, err
 }
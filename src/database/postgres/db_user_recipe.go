@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"karopon/src/database"
+
+	"github.com/vinovest/sqlx"
+)
+
+func (db *PGDatabase) userHasRecipeTx(tx *sqlx.Tx, userID int, recipeID int) error {
+
+	query := `SELECT COUNT(ID) FROM PON.USER_RECIPE WHERE USER_ID = $1 AND ID = $2 LIMIT 1`
+
+	if ok, err := db.CountOneTx(tx, query, userID, recipeID); err != nil {
+		return err
+	} else if !ok {
+		return database.ErrUserDoesNotHaveThisID
+	}
+
+	return nil
+}
+
+// addUserRecipeIngredientsTx adds the ingredients to the recipe,
+// making sure the user has each user food and each data source food exists.
+func (db *PGDatabase) addUserRecipeIngredientsTx(
+	tx *sqlx.Tx,
+	userID int,
+	recipeID int,
+	ingredients []database.TblUserRecipeIngredient,
+) error {
+
+	query := `
+		INSERT INTO PON.USER_RECIPE_INGREDIENT (
+			RECIPE_ID, FOOD_ID, DATA_SOURCE_FOOD_ID, PORTION
+		) VALUES (
+			:recipe_id, :food_id, :data_source_food_id, :portion
+		)
+	`
+
+	for _, ing := range ingredients {
+
+		var ok bool
+		var err error
+
+		switch {
+		case ing.FoodID != nil && ing.DataSourceFoodID == nil:
+			ok, err = db.CountOneTx(tx,
+				`SELECT COUNT(ID) FROM PON.USER_FOOD WHERE USER_ID = $1 AND ID = $2 LIMIT 1`, userID, *ing.FoodID)
+		case ing.DataSourceFoodID != nil && ing.FoodID == nil:
+			ok, err = db.CountOneTx(tx,
+				`SELECT COUNT(ID) FROM PON.DATA_SOURCE_FOOD WHERE ID = $1 LIMIT 1`, *ing.DataSourceFoodID)
+		}
+
+		if err != nil {
+			return err
+		} else if !ok {
+			return database.ErrUserDoesNotHaveThisID
+		}
+
+		ing.RecipeID = recipeID
+
+		if _, err := tx.NamedExec(query, ing); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *PGDatabase) AddUserRecipe(
+	ctx context.Context,
+	recipe *database.TblUserRecipe,
+	ingredients []database.TblUserRecipeIngredient,
+) (int, error) {
+
+	var id int
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		query := `
+			INSERT INTO PON.USER_RECIPE (
+				USER_ID, NAME, UNIT, YIELD, NOTES
+			) VALUES (
+				:user_id, :name, :unit, :yield, :notes
+			)
+			RETURNING ID
+		`
+
+		var err error
+		id, err = db.NamedInsertReturningIDTx(tx, query, recipe)
+
+		if err != nil {
+			return err
+		}
+
+		return db.addUserRecipeIngredientsTx(tx, recipe.UserID, id, ingredients)
+	})
+
+	return id, err
+}
+
+// loadUserRecipesTx reads the users recipes, or only the one with the recipeID when it is not 0.
+func (db *PGDatabase) loadUserRecipesTx(tx *sqlx.Tx, userID int, recipeID int, out *[]database.UserRecipe) error {
+
+	var recipes []database.TblUserRecipe
+	var ingredients []database.TblUserRecipeIngredient
+	var foods []database.TblUserFood
+	var dataSourceFoods []database.TblDataSourceFood
+
+	query := `
+		SELECT * FROM PON.USER_RECIPE
+		WHERE USER_ID = $1 AND ($2 = 0 OR ID = $2)
+		ORDER BY NAME ASC, ID ASC
+	`
+
+	if err := tx.Select(&recipes, query, userID, recipeID); err != nil {
+		return err
+	}
+
+	ingredientsQuery := `
+		SELECT i.* FROM PON.USER_RECIPE_INGREDIENT i
+		JOIN PON.USER_RECIPE r ON r.ID = i.RECIPE_ID
+		WHERE r.USER_ID = $1 AND ($2 = 0 OR r.ID = $2)
+	`
+
+	if err := tx.Select(&ingredients, ingredientsQuery+` ORDER BY i.ID ASC`, userID, recipeID); err != nil {
+		return err
+	}
+
+	query = `SELECT * FROM PON.USER_FOOD WHERE ID IN (SELECT FOOD_ID FROM (` + ingredientsQuery + `) i)`
+
+	if err := tx.Select(&foods, query, userID, recipeID); err != nil {
+		return err
+	}
+
+	query = `SELECT * FROM PON.DATA_SOURCE_FOOD WHERE ID IN (SELECT DATA_SOURCE_FOOD_ID FROM (` + ingredientsQuery + `) i)`
+
+	if err := tx.Select(&dataSourceFoods, query, userID, recipeID); err != nil {
+		return err
+	}
+
+	*out = database.NewUserRecipes(recipes, ingredients, foods, dataSourceFoods)
+
+	return nil
+}
+
+func (db *PGDatabase) LoadUserRecipes(ctx context.Context, userID int, out *[]database.UserRecipe) error {
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		return db.loadUserRecipesTx(tx, userID, 0, out)
+	})
+}
+
+func (db *PGDatabase) LoadUserRecipe(ctx context.Context, userID int, recipeID int, out *database.UserRecipe) error {
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		var recipes []database.UserRecipe
+
+		if err := db.loadUserRecipesTx(tx, userID, recipeID, &recipes); err != nil {
+			return err
+		}
+
+		if len(recipes) == 0 || recipeID == 0 {
+			return sql.ErrNoRows
+		}
+
+		*out = recipes[0]
+
+		return nil
+	})
+}
+
+func (db *PGDatabase) UpdateUserRecipe(
+	ctx context.Context,
+	recipe *database.TblUserRecipe,
+	ingredients []database.TblUserRecipeIngredient,
+) error {
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		if err := db.userHasRecipeTx(tx, recipe.UserID, recipe.ID); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE PON.USER_RECIPE
+			SET
+				NAME  = :name,
+				UNIT  = :unit,
+				YIELD = :yield,
+				NOTES = :notes
+			WHERE ID = :id AND USER_ID = :user_id
+		`
+
+		if _, err := tx.NamedExec(query, recipe); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM PON.USER_RECIPE_INGREDIENT WHERE RECIPE_ID = $1`, recipe.ID); err != nil {
+			return err
+		}
+
+		return db.addUserRecipeIngredientsTx(tx, recipe.UserID, recipe.ID, ingredients)
+	})
+}
+
+func (db *PGDatabase) DeleteUserRecipe(ctx context.Context, userID int, recipeID int) error {
+
+	query := `DELETE FROM PON.USER_RECIPE WHERE ID = $1 AND USER_ID = $2`
+
+	_, err := db.ExecContext(ctx, query, recipeID, userID)
+
+	return err
+}
@@ -16,7 +16,7 @@ func aggregateToPG(fun database.AggregationFunc) string {
 	case database.AggregationSum:
 		return "SUM"
 	case database.AggregationAvg:
-		return "AVERAGE" // or "AVG" if Postgres expects it
+		return "AVG"
 	case database.AggregationMin:
 		return "MIN"
 	case database.AggregationMax:
@@ -24,6 +24,28 @@ func aggregateToPG(fun database.AggregationFunc) string {
 	}
 }
 
+// bucketToPG returns SQL truncating the given UTC timestamp column into its bucket, matching database.TruncateToBucket,
+// and the arguments for it's placeholders.
+//
+// The column is shifted back by the day offset, converted to the user's local time and truncated,
+// then converted back to UTC and shifted forward again.
+func bucketToPG(col string, groupby database.GroupBy, loc *time.Location, shift time.Duration) (string, []any) {
+
+	tz := "UTC"
+
+	if loc != nil && loc != time.Local {
+		tz = loc.String()
+	}
+
+	sql := `(
+		date_trunc(?, (` + col + ` - ? * INTERVAL '1 second') AT TIME ZONE 'UTC' AT TIME ZONE ?)
+		AT TIME ZONE ? AT TIME ZONE 'UTC'
+		+ ? * INTERVAL '1 second'
+	)`
+
+	return sql, []any{groupbyToPG(groupby), shift.Seconds(), tz, tz, shift.Seconds()}
+}
+
 // groupbyToPG returns the first parameter that should be passed into the postgres date_trunc(field, source [, time_zone
 // ]) function.
 // See https://www.postgresql.org/docs/current/functions-datetime.html#FUNCTIONS-DATETIME-TRUNC
@@ -58,18 +80,24 @@ func (db *PGDatabase) LoadUserTimeData(
 	endTime time.Time,
 	tags []string,
 	groupby database.GroupBy,
+	loc *time.Location,
+	shift time.Duration,
 	out *[]database.TimespanTagDurationPoint,
 ) error {
 
+	if err := database.ValidateBucketCount(startTime, endTime, groupby); err != nil {
+		return err
+	}
+
 	if len(tags) == 0 {
 		return nil
 	}
 
 	sql := `
 		SELECT
-			t.NAMESPACE || ':' || t.NAME                                           AS TAG,
-			date_trunc(?, ts.START_TIME)                                           AS BUCKET,
-			EXTRACT(EPOCH FROM (SUM(ts.STOP_TIME - ts.START_TIME) * 1000))::bigint AS DURATION_MILLI
+			t.NAMESPACE || ':' || t.NAME AS TAG,
+			ts.START_TIME                AS START_TIME,
+			ts.STOP_TIME                 AS STOP_TIME
 
 		FROM PON.USER_TAG t
 
@@ -79,19 +107,16 @@ func (db *PGDatabase) LoadUserTimeData(
 		JOIN PON.USER_TIMESPAN ts
 		ON ts.ID = tt.TIMESPAN_ID
 
-		WHERE 
+		WHERE
 			ts.STOP_TIME > ts.START_TIME
 			AND (t.NAMESPACE || ':' || t.NAME) IN (?)
 			AND t.USER_ID = ?
 			AND ts.USER_ID = ?
-			AND ts.START_TIME >= ? 
-			AND ts.START_TIME <= ?
-
-		GROUP BY t.NAMESPACE, t.NAME, BUCKET
-		ORDER BY BUCKET ASC
+			AND ts.STOP_TIME > ?
+			AND ts.START_TIME < ?
 	`
 
-	query, args, err := sqlx.In(sql, groupbyToPG(groupby), tags, userID, userID, startTime.UTC(), endTime.UTC())
+	query, args, err := sqlx.In(sql, tags, userID, userID, startTime.UTC(), endTime.UTC())
 
 	if err != nil {
 		return err
@@ -99,25 +124,136 @@ func (db *PGDatabase) LoadUserTimeData(
 
 	query = db.Rebind(query)
 
-	return db.SelectContext(ctx, out, query, args...)
+	var rows []database.TagTimespan
+
+	if err := db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return err
+	}
+
+	// Spans are split across buckets in Go, so both databases bucket them the same way.
+	*out = database.SumTimespanDurations(rows, startTime, endTime, groupby, loc, shift)
+
+	return nil
+}
+
+// nutrientToPG returns the value expression and where clause for a nutrient of the foodlogs,
+// foodlogs without the nutrient are left out rather than counted as zero.
+func nutrientToPG(n database.Nutrient) (string, string) {
+	return "(NUTRIENTS->>'" + string(n) + "')::DOUBLE PRECISION", " AND NUTRIENTS->>'" + string(n) + "' IS NOT NULL"
+}
+
+// chartColumnToPG returns the table, value expression, and extra where clause for the chart column.
+func chartColumnToPG(col database.ChartColumn) (string, string, string) {
+
+	if n, ok := col.Nutrient(); ok {
+		colSQL, whereSQL := nutrientToPG(n)
+		return "PON.USER_FOODLOG", colSQL, whereSQL
+	}
+
+	switch col {
+	default:
+		panic("impossible chart column")
+
+	case database.ChartColumnCalories:
+		// TODO: don't hard code this and make it use the user's setting
+		return "PON.USER_FOODLOG", "PROTEIN * 4 + (CARB - FIBRE) * 4 + FAT * 9", ""
+	case database.ChartColumnNetCarbs:
+		return "PON.USER_FOODLOG", "CARB - FIBRE", ""
+	case database.ChartColumnFat:
+		return "PON.USER_FOODLOG", "FAT", ""
+	case database.ChartColumnCarbs:
+		return "PON.USER_FOODLOG", "CARB", ""
+	case database.ChartColumnFibre:
+		return "PON.USER_FOODLOG", "FIBRE", ""
+	case database.ChartColumnProtein:
+		return "PON.USER_FOODLOG", "PROTEIN", ""
+
+	case database.ChartColumnBodyWeightKg:
+		return "PON.USER_BODYLOG", "WEIGHT_KG", " AND WEIGHT_KG > 0"
+	case database.ChartColumnBodyWeightLbs:
+		return "PON.USER_BODYLOG", "WEIGHT_KG * 2.2046226218", " AND WEIGHT_KG > 0"
+	case database.ChartColumnBodyFatPercent:
+		return "PON.USER_BODYLOG", "BODY_FAT_PERCENT", " AND BODY_FAT_PERCENT > 0"
+	case database.ChartColumnBodyHeartRate:
+		return "PON.USER_BODYLOG", "HEART_RATE_BPM", " AND HEART_RATE_BPM > 0"
+	case database.ChartColumnBodySteps:
+		return "PON.USER_BODYLOG", "STEPS_COUNT", " AND STEPS_COUNT > 0"
+	case database.ChartColumnBodyBloodPressureSys:
+		return "PON.USER_BODYLOG", "BP_SYSTOLIC", " AND BP_SYSTOLIC > 0"
+	case database.ChartColumnBodyBloodPressureDia:
+		return "PON.USER_BODYLOG", "BP_DIASTOLIC", " AND BP_DIASTOLIC > 0"
+
+	case database.ChartColumnEventBloodSugar:
+		return "PON.USER_EVENTLOG", "BLOOD_GLUCOSE", " AND BLOOD_GLUCOSE > 0"
+	case database.ChartColumnEventInsulin:
+		return "PON.USER_EVENTLOG", "ACTUAL_INSULIN_TAKEN", " AND ACTUAL_INSULIN_TAKEN > 0"
+	case database.ChartColumnGlucose:
+		return "PON.USER_GLUCOSE", "GLUCOSE", ""
+	}
 }
 
 func (db *PGDatabase) LoadUserChartData(
 	ctx context.Context,
-	cols []string,
+	userID int,
+	cols []database.ChartColumn,
 	aggregation database.AggregationFunc,
 	groupby database.GroupBy,
-	startTime, stopTime time.Time,
+	loc *time.Location,
+	shift time.Duration,
+	startTime time.Time,
+	endTime time.Time,
+	out *[]database.ChartPoint,
 ) error {
 
-	// aggFunc := aggregateToPG(aggregation)
+	if err := database.ValidateChartQuery(cols, aggregation, groupby); err != nil {
+		return err
+	}
+
+	points := []database.ChartPoint{}
 
-	// sql := `
-	// SELECT
-	//
+	for _, col := range cols {
 
-	// `
+		tableSQL, colSQL, whereSQL := chartColumnToPG(col)
+		bucketSQL, args := bucketToPG("USER_TIME", groupby, loc, shift)
 
-	return nil
+		query := db.Rebind(`
+			SELECT
+				` + bucketSQL + ` AS BUCKET,
+				` + aggregateToPG(aggregation) + `(` + colSQL + `) AS VALUE
+			FROM ` + tableSQL + `
+			WHERE
+				USER_ID = ?
+				AND USER_TIME >= ?
+				AND USER_TIME <= ?
+			` + whereSQL + `
+			GROUP BY BUCKET
+			ORDER BY BUCKET ASC
+		`)
 
+		args = append(args, userID, startTime.UTC(), endTime.UTC())
+
+		var colPoints []database.ChartPoint
+
+		err := db.SelectContext(ctx, &colPoints, query, args...)
+
+		if err != nil {
+			return err
+		}
+
+		for _, p := range colPoints {
+
+			p.Column = col
+
+			// Everything is one bucket, which starts with the range.
+			if groupby == database.GroupByOne {
+				p.Bucket = database.TimeMillis(startTime)
+			}
+
+			points = append(points, p)
+		}
+	}
+
+	*out = points
+
+	return nil
 }
@@ -44,9 +44,9 @@ func (db *PGDatabase) SetVersion(ctx context.Context, version database.Version)
 	return err
 }
 
-func (db *PGDatabase) ExportVersionCSV(ctx context.Context, w io.Writer) error {
+func (db *PGDatabase) ExportVersionCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 
 	query := `SELECT * FROM PON.CONFIG`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
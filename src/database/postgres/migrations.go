@@ -28,6 +28,23 @@ var postgresUpMigrations = []database.Migration{
 	database.NewFileMigration(17, 18, "pg/0019_user_photo"),
 	database.NewFileMigration(18, 19, "pg/0020_user_setting"),
 	database.NewFileMigration(19, 20, "pg/0021_user_setting"),
+	database.NewFileMigration(20, 21, "pg/0022_medication"),
+	database.NewFileMigration(21, 22, "pg/0023_user_photo_created"),
+	database.NewFileMigration(22, 23, "pg/0024_user_photo_storage"),
+	database.NewFileMigration(23, 24, "pg/0025_user_photo_thumbnail"),
+	database.NewFileMigration(24, 25, "pg/0026_user_timezone"),
+	database.NewFileMigration(25, 26, "pg/0027_user_insulin_action"),
+	database.NewFileMigration(26, 27, "pg/0028_insulin_profile"),
+	database.NewFileMigration(27, 28, "pg/0029_eventlog_extended_bolus"),
+	database.NewFileMigration(28, 29, "pg/0030_user_glucose"),
+	database.NewFileMigration(29, 30, "pg/0031_user_nightscout"),
+	database.NewFileMigration(30, 31, "pg/0032_user_glucose_unit"),
+	database.NewFileMigration(31, 32, "pg/0033_nutrients"),
+	database.NewFileMigration(32, 33, "pg/0034_recipe"),
+	database.NewFileMigration(33, 34, "pg/0035_food_units"),
+	database.NewFileMigration(34, 35, "pg/0036_data_source_food_serving"),
+	database.NewFileMigration(35, 36, "pg/0037_data_source_food_barcode"),
+	database.NewFileMigration(36, 37, "pg/0038_eventlog_notes"),
 }
 
 func (db *PGDatabase) GetMigrationMaxVersion() database.Version {
@@ -0,0 +1,163 @@
+package database
+
+// UserRecipeIngredient is an ingredient with the name, unit and amounts of its food for the ingredient's portion.
+type UserRecipeIngredient struct {
+	TblUserRecipeIngredient
+
+	Name      string    `json:"name"`
+	Unit      string    `json:"unit"`
+	Protein   float64   `json:"protein"`
+	Carb      float64   `json:"carb"`
+	Fibre     float64   `json:"fibre"`
+	Fat       float64   `json:"fat"`
+	Nutrients Nutrients `json:"nutrients"`
+}
+
+// UserRecipe is a recipe with its ingredients.
+// Serving is one Unit of the recipe, worked out from the ingredients every time it is loaded.
+type UserRecipe struct {
+	Recipe      TblUserRecipe          `json:"recipe"`
+	Ingredients []UserRecipeIngredient `json:"ingredients"`
+	Serving     TblUserFood            `json:"serving"`
+}
+
+// NewUserRecipes puts the recipes together from their ingredients and the foods the ingredients use.
+// Ingredients whose food is missing are left out.
+func NewUserRecipes(
+	recipes []TblUserRecipe,
+	ingredients []TblUserRecipeIngredient,
+	foods []TblUserFood,
+	dataSourceFoods []TblDataSourceFood,
+) []UserRecipe {
+
+	foodByID := make(map[int]*TblUserFood, len(foods))
+	for i := range foods {
+		foodByID[foods[i].ID] = &foods[i]
+	}
+
+	dataSourceFoodByID := make(map[int]*TblDataSourceFood, len(dataSourceFoods))
+	for i := range dataSourceFoods {
+		dataSourceFoodByID[dataSourceFoods[i].ID] = &dataSourceFoods[i]
+	}
+
+	out := make([]UserRecipe, len(recipes))
+	index := make(map[int]int, len(recipes))
+
+	for i, r := range recipes {
+		out[i].Recipe = r
+		index[r.ID] = i
+	}
+
+	for _, ing := range ingredients {
+
+		i, ok := index[ing.RecipeID]
+
+		if !ok {
+			continue
+		}
+
+		item := UserRecipeIngredient{TblUserRecipeIngredient: ing}
+
+		// The foods' amounts are for their portion.
+		var portion float64
+
+		switch {
+
+		case ing.FoodID != nil:
+
+			f, ok := foodByID[*ing.FoodID]
+
+			if !ok {
+				continue
+			}
+
+			portion = f.Portion
+			item.Name, item.Unit = f.Name, f.Unit
+			item.Protein, item.Carb, item.Fibre, item.Fat = f.Protein, f.Carb, f.Fibre, f.Fat
+			item.Nutrients = f.Nutrients
+
+		case ing.DataSourceFoodID != nil:
+
+			f, ok := dataSourceFoodByID[*ing.DataSourceFoodID]
+
+			if !ok {
+				continue
+			}
+
+			portion = f.Portion
+			item.Name, item.Unit = f.Name, f.Unit
+			item.Protein, item.Carb, item.Fibre, item.Fat = f.Protein, f.Carb, f.Fibre, f.Fat
+			item.Nutrients = f.Nutrients
+
+		default:
+			continue
+		}
+
+		scale := ing.Portion
+
+		if portion > 0 {
+			scale /= portion
+		}
+
+		item.Protein *= scale
+		item.Carb *= scale
+		item.Fibre *= scale
+		item.Fat *= scale
+		item.Nutrients = item.Nutrients.Scaled(scale)
+
+		out[i].Ingredients = append(out[i].Ingredients, item)
+	}
+
+	for i := range out {
+		out[i].calculateServing()
+	}
+
+	return out
+}
+
+// calculateServing sets the Serving to the sum of the ingredients divided by the yield.
+// A nutrient only known for some of the ingredients is the sum of the ones it is known for.
+func (r *UserRecipe) calculateServing() {
+
+	r.Serving = TblUserFood{
+		UserID:  r.Recipe.UserID,
+		Name:    r.Recipe.Name,
+		Unit:    r.Recipe.Unit,
+		Portion: r.Recipe.Yield,
+	}
+
+	for _, ing := range r.Ingredients {
+
+		r.Serving.Protein += ing.Protein
+		r.Serving.Carb += ing.Carb
+		r.Serving.Fibre += ing.Fibre
+		r.Serving.Fat += ing.Fat
+
+		for k, v := range ing.Nutrients {
+
+			if r.Serving.Nutrients == nil {
+				r.Serving.Nutrients = make(Nutrients)
+			}
+
+			r.Serving.Nutrients[k] += v
+		}
+	}
+
+	if r.Serving.Portion > 0 {
+		r.Serving.Scale()
+	}
+}
+
+// FillFoodLog sets the foodlog's name, unit and amounts to the foodlog's portion of the recipe.
+func (r *UserRecipe) FillFoodLog(food *TblUserFoodLog) {
+
+	food.RecipeID = &r.Recipe.ID
+	food.FoodID = nil
+	food.Name = r.Serving.Name
+	food.Unit = r.Serving.Unit
+	food.Protein = r.Serving.Protein * food.Portion
+	food.Carb = r.Serving.Carb * food.Portion
+	food.Fibre = r.Serving.Fibre * food.Portion
+	food.Fat = r.Serving.Fat * food.Portion
+	food.Nutrients = r.Serving.Nutrients.Scaled(food.Portion)
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUserRecipes(t *testing.T) {
+
+	rice, oil, gone, dsChicken := 1, 2, 3, 10
+
+	recipes := []TblUserRecipe{
+		{ID: 1, Name: "Chicken Rice", Unit: "bowl", Yield: 4},
+		{ID: 2, Name: "Empty", Unit: "g", Yield: 100},
+	}
+
+	ingredients := []TblUserRecipeIngredient{
+		{ID: 1, RecipeID: 1, FoodID: &rice, Portion: 400},
+		{ID: 2, RecipeID: 1, FoodID: &oil, Portion: 20},
+		{ID: 3, RecipeID: 1, DataSourceFoodID: &dsChicken, Portion: 600},
+		{ID: 4, RecipeID: 1, FoodID: &gone, Portion: 50}, // the food was not loaded
+		{ID: 5, RecipeID: 99, FoodID: &rice, Portion: 1}, // not one of the recipes
+	}
+
+	foods := []TblUserFood{
+		{ID: rice, Name: "Rice", Unit: "g", Portion: 1, Protein: 0.03, Carb: 0.28, Fibre: 0.01},
+		{ID: oil, Name: "Oil", Unit: "ml", Portion: 1, Fat: 0.9, Nutrients: Nutrients{NutrientSaturatedFat: 0.1}},
+	}
+
+	// Data source foods are per 100g.
+	dataSourceFoods := []TblDataSourceFood{
+		{ID: dsChicken, Name: "Chicken", Unit: "g", Portion: 100, Protein: 30, Fat: 4, Nutrients: Nutrients{NutrientSodium: 70}},
+	}
+
+	got := NewUserRecipes(recipes, ingredients, foods, dataSourceFoods)
+	require.Len(t, got, 2)
+
+	r := got[0]
+	require.Len(t, r.Ingredients, 3)
+	assert.Equal(t, "Chicken", r.Ingredients[2].Name)
+	assert.InDelta(t, 180, r.Ingredients[2].Protein, 1e-9)
+	assert.InDelta(t, 420, r.Ingredients[2].Nutrients[NutrientSodium], 1e-9)
+
+	// Per bowl.
+	assert.Equal(t, "Chicken Rice", r.Serving.Name)
+	assert.Equal(t, "bowl", r.Serving.Unit)
+	assert.InDelta(t, 1, r.Serving.Portion, 1e-9)
+	assert.InDelta(t, (12+180)/4.0, r.Serving.Protein, 1e-9)
+	assert.InDelta(t, 112/4.0, r.Serving.Carb, 1e-9)
+	assert.InDelta(t, 4/4.0, r.Serving.Fibre, 1e-9)
+	assert.InDelta(t, (18+24)/4.0, r.Serving.Fat, 1e-9)
+	assert.InDelta(t, 105, r.Serving.Nutrients[NutrientSodium], 1e-9)
+	assert.InDelta(t, 0.5, r.Serving.Nutrients[NutrientSaturatedFat], 1e-9)
+
+	assert.Empty(t, got[1].Ingredients)
+	assert.Zero(t, got[1].Serving.Carb)
+	assert.Nil(t, got[1].Serving.Nutrients)
+
+	// Logging one and a half bowls.
+	food := TblUserFoodLog{Name: "typed by the user", FoodID: &rice, Portion: 1.5}
+	r.FillFoodLog(&food)
+
+	assert.Equal(t, &r.Recipe.ID, food.RecipeID)
+	assert.Nil(t, food.FoodID)
+	assert.Equal(t, "Chicken Rice", food.Name)
+	assert.Equal(t, "bowl", food.Unit)
+	assert.InDelta(t, 1.5*112/4.0, food.Carb, 1e-9)
+	assert.InDelta(t, 1.5*105, food.Nutrients[NutrientSodium], 1e-9)
+}
@@ -12,13 +12,13 @@ func (db *SqliteDatabase) AddDataSourceFood(ctx context.Context, ds *database.Tb
 
 	query := `
 		INSERT INTO PON_DATA_SOURCE_FOOD(
-			DATA_SOURCE_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT, DATA_SOURCE_ROW_INT_ID
+			DATA_SOURCE_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT, DATA_SOURCE_ROW_INT_ID, NUTRIENTS, BARCODE
 		) VALUES (
-			:DATA_SOURCE_ID, :NAME, :UNIT, :PORTION, :PROTEIN, :CARB, :FIBRE, :FAT, :DATA_SOURCE_ROW_INT_ID
+			:DATA_SOURCE_ID, :NAME, :UNIT, :PORTION, :PROTEIN, :CARB, :FIBRE, :FAT, :DATA_SOURCE_ROW_INT_ID, :NUTRIENTS, :BARCODE
 		)
     `
 
-	return db.NamedInsertReturningID(ctx, query, ds)
+	return db.NamedInsertGetLastRowID(ctx, query, ds)
 }
 
 func (db *SqliteDatabase) LoadDataSourceFoodBySimilarName(
@@ -52,3 +52,57 @@ func (db *SqliteDatabase) LoadDataSourceFoodBySimilarNameN(
 
 	})
 }
+
+func (db *SqliteDatabase) AddDataSourceFoodServing(
+	ctx context.Context,
+	serving *database.TblDataSourceFoodServing,
+) (int, error) {
+
+	query := `
+		INSERT INTO PON_DATA_SOURCE_FOOD_SERVING(
+			DATA_SOURCE_FOOD_ID, NAME, AMOUNT, UNIT, PORTION
+		) VALUES (
+			:DATA_SOURCE_FOOD_ID, :NAME, :AMOUNT, :UNIT, :PORTION
+		)
+    `
+
+	return db.NamedInsertGetLastRowID(ctx, query, serving)
+}
+
+func (db *SqliteDatabase) LoadDataSourceFoodServings(
+	ctx context.Context,
+	dataSourceFoodIDs []int,
+	out *[]database.TblDataSourceFoodServing,
+) error {
+
+	if len(dataSourceFoodIDs) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT * FROM PON_DATA_SOURCE_FOOD_SERVING
+		WHERE DATA_SOURCE_FOOD_ID IN (?)
+		ORDER BY DATA_SOURCE_FOOD_ID ASC, ID ASC
+	`, dataSourceFoodIDs)
+
+	if err != nil {
+		return err
+	}
+
+	return db.SelectContext(ctx, out, db.Rebind(query), args...)
+}
+
+func (db *SqliteDatabase) LoadDataSourceFoodByBarcode(
+	ctx context.Context,
+	barcode string,
+	out *[]database.TblDataSourceFood,
+) error {
+
+	query := `
+		SELECT * FROM PON_DATA_SOURCE_FOOD
+		WHERE BARCODE = $1 AND BARCODE <> ''
+		ORDER BY DATA_SOURCE_ID ASC, ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, barcode)
+}
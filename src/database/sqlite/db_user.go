@@ -4,6 +4,8 @@ import (
 	"context"
 	"io"
 	"karopon/src/database"
+
+	"github.com/vinovest/sqlx"
 )
 
 func (db *SqliteDatabase) UsernameTaken(ctx context.Context, userID int, username string) (bool, error) {
@@ -32,6 +34,16 @@ func (db *SqliteDatabase) HasAnyUser(ctx context.Context) (bool, error) {
 	return result.Count != 0, err
 }
 
+// lockUserTx locks the user's row until the transaction ends,
+// so a transaction reading then adding the user's data can't interleave with another doing the same.
+func (db *SqliteDatabase) lockUserTx(tx *sqlx.Tx, userID int) error {
+
+	// Writing takes the database write lock now, instead of at the transaction's first insert.
+	_, err := tx.Exec(`UPDATE PON_USER SET ID = ID WHERE ID = $1`, userID)
+
+	return err
+}
+
 func (db *SqliteDatabase) AddUser(ctx context.Context, user *database.TblUser) (int, error) {
 
 	query := `
@@ -45,7 +57,12 @@ func (db *SqliteDatabase) AddUser(ctx context.Context, user *database.TblUser) (
 			EVENT_LOG_TRAILING_ROWS,
 			DAY_TIME_OFFSET_SECONDS,
 			FILL_EVENTLOG_FROM_LAST,
-			TIMESPAN_HISTORY_FETCH_LIMIT
+			TIMESPAN_HISTORY_FETCH_LIMIT,
+			TIMEZONE,
+			INSULIN_ACTION_CURVE,
+			INSULIN_DURATION_MINUTES,
+			INSULIN_PEAK_MINUTES,
+			GLUCOSE_UNIT
 		) VALUES (
 			:NAME, :PASSWORD,
 			:THEME, :SHOW_DIABETES, :CALORIC_CALC_METHOD,
@@ -56,7 +73,12 @@ func (db *SqliteDatabase) AddUser(ctx context.Context, user *database.TblUser) (
 			:EVENT_LOG_TRAILING_ROWS,
 			:DAY_TIME_OFFSET_SECONDS,
 			:FILL_EVENTLOG_FROM_LAST,
-			:TIMESPAN_HISTORY_FETCH_LIMIT
+			:TIMESPAN_HISTORY_FETCH_LIMIT,
+			:TIMEZONE,
+			:INSULIN_ACTION_CURVE,
+			:INSULIN_DURATION_MINUTES,
+			:INSULIN_PEAK_MINUTES,
+			:GLUCOSE_UNIT
 		)
 	`
 
@@ -80,7 +102,12 @@ func (db *SqliteDatabase) UpdateUser(ctx context.Context, user *database.TblUser
 	EVENT_LOG_TRAILING_ROWS=:EVENT_LOG_TRAILING_ROWS,
 	DAY_TIME_OFFSET_SECONDS=:DAY_TIME_OFFSET_SECONDS,
 	FILL_EVENTLOG_FROM_LAST=:FILL_EVENTLOG_FROM_LAST,
-	TIMESPAN_HISTORY_FETCH_LIMIT=:TIMESPAN_HISTORY_FETCH_LIMIT
+	TIMESPAN_HISTORY_FETCH_LIMIT=:TIMESPAN_HISTORY_FETCH_LIMIT,
+	TIMEZONE=:TIMEZONE,
+	INSULIN_ACTION_CURVE=:INSULIN_ACTION_CURVE,
+	INSULIN_DURATION_MINUTES=:INSULIN_DURATION_MINUTES,
+	INSULIN_PEAK_MINUTES=:INSULIN_PEAK_MINUTES,
+	GLUCOSE_UNIT=:GLUCOSE_UNIT
 	WHERE ID=:ID
 	`
 
@@ -115,9 +142,9 @@ func (db *SqliteDatabase) LoadUser(ctx context.Context, username string, user *d
 	return nil
 }
 
-func (db *SqliteDatabase) ExportUserCSV(ctx context.Context, w io.Writer) error {
+func (db *SqliteDatabase) ExportUserCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 
-	query := `SELECT * FROM PON_USER`
+	query := `SELECT u.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON_USER u`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
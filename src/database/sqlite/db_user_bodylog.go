@@ -69,9 +69,9 @@ func (db *SqliteDatabase) DeleteUserBodyLog(ctx context.Context, userID int, bod
 	return err
 }
 
-func (db *SqliteDatabase) ExportBodyLogCSV(ctx context.Context, w io.Writer) error {
+func (db *SqliteDatabase) ExportBodyLogCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 
-	query := `SELECT * FROM PON_USER_BODYLOG`
+	query := `SELECT t.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON_USER_BODYLOG t LEFT JOIN PON_USER u ON u.ID = t.USER_ID`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
@@ -79,9 +79,9 @@ func (db *SqliteDatabase) LoadAndOrCreateUserEventByNameTx(
 	return nil
 }
 
-func (db *SqliteDatabase) ExportUserEventsCSV(ctx context.Context, w io.Writer) error {
+func (db *SqliteDatabase) ExportUserEventsCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 
-	query := `SELECT * FROM PON_USER_EVENT`
+	query := `SELECT t.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON_USER_EVENT t LEFT JOIN PON_USER u ON u.ID = t.USER_ID`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
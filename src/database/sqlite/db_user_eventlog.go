@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"karopon/src/database"
+	"karopon/src/insulin"
 	"time"
 
 	"github.com/vinovest/sqlx"
@@ -19,45 +20,169 @@ func (db *SqliteDatabase) AddUserEventLogWith(
 
 	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
 
-		if time.Time(event.UserTime).IsZero() {
-			event.UserTime = database.TimeMillis(time.Now())
+		eventLogID, err := db.AddUserEventLogWithTx(tx, event, foodlogs)
+
+		if err != nil {
+			return err
 		}
 
-		event.NetCarbs = 0
+		retEventLogID = eventLogID
+
+		return nil
+	})
+
+	return retEventLogID, err
+
+}
+
+func (db *SqliteDatabase) AddUserEventLogWithTx(
+	tx *sqlx.Tx,
+	event *database.TblUserEventLog,
+	foodlogs []database.TblUserFoodLog,
+) (int, error) {
+
+	if time.Time(event.UserTime).IsZero() {
+		event.UserTime = database.TimeMillis(time.Now())
+	}
+
+	// The totals are of the amounts the foodlogs are saved with.
+	if err := db.fillUserFoodLogsTx(tx, event.UserID, foodlogs); err != nil {
+		return -1, err
+	}
+
+	event.NetCarbs = 0
+	event.FatProteinUnits = 0
+
+	for _, food := range foodlogs {
+		event.NetCarbs += food.Carb - food.Fibre
+		event.FatProteinUnits += insulin.FatProteinUnits(food.Fat, food.Protein)
+	}
 
-		for _, food := range foodlogs {
-			event.NetCarbs += food.Carb - food.Fibre
+	eventLogID, err := db.AddUserEventLogTx(tx, event)
+
+	if err != nil {
+		return -1, err
+	}
+
+	for _, food := range foodlogs {
+
+		food.UserID = event.UserID
+		food.Event = event.Event
+		food.EventLogID = eventLogID
+		food.UserTime = event.UserTime
+
+		id, err := db.AddUserFoodLogTx(tx, &food)
+
+		if err != nil {
+			return -1, err
 		}
 
-		eventLogID, err := db.AddUserEventLogTx(tx, event)
+		food.ID = id
+	}
+
+	return eventLogID, nil
+}
+
+func (db *SqliteDatabase) AddUserEventLogWithPhotos(
+	ctx context.Context,
+	event *database.TblUserEventLog,
+	foodlogs []database.TblUserFoodLog,
+	photoIDs []int,
+) (int, error) {
+
+	var retEventLogID int = -1
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		eventLogID, err := db.AddUserEventLogWithTx(tx, event, foodlogs)
 
 		if err != nil {
 			return err
 		}
 
-		for _, food := range foodlogs {
+		if len(photoIDs) > 0 {
+			if err := db.AddUserEventLogPhotosTx(tx, event.UserID, eventLogID, photoIDs); err != nil {
+				return err
+			}
+		}
+
+		retEventLogID = eventLogID
+
+		return nil
+	})
+
+	return retEventLogID, err
+}
+
+func (db *SqliteDatabase) AddUserEventLogsOnce(
+	ctx context.Context,
+	userID int,
+	eventlogs []database.TblUserEventLog,
+	foodlogs [][]database.TblUserFoodLog,
+) ([]database.TblUserEventLog, error) {
+
+	saved := make([]database.TblUserEventLog, 0, len(eventlogs))
+
+	if len(eventlogs) == 0 {
+		return saved, nil
+	}
+
+	query := `
+		SELECT COUNT(*) FROM PON_USER_EVENTLOG el
+		WHERE el.USER_ID = $1 AND el.EVENT = $2 AND el.NOTES = $3 AND el.USER_TIME >= $4 AND el.USER_TIME < $5
+	`
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		// A batch sent again while the first is still saving waits here, then sees what it saved.
+		if err := db.lockUserTx(tx, userID); err != nil {
+			return err
+		}
+
+		for i := range eventlogs {
 
-			food.UserID = event.UserID
-			food.Event = event.Event
-			food.EventLogID = eventLogID
-			food.UserTime = event.UserTime
+			eventlog := eventlogs[i]
+			eventlog.UserID = userID
 
-			id, err := db.AddUserFoodLogTx(tx, &food)
+			at := eventlog.UserTime.Time().UTC()
+
+			// Also finds the eventlogs saved earlier in the batch.
+			var count int
+
+			if err := tx.Get(&count, query, userID, eventlog.Event, eventlog.Notes, at, at.Add(time.Millisecond)); err != nil {
+				return err
+			}
+
+			if count > 0 {
+				continue
+			}
+
+			var event database.TblUserEvent
+
+			if err := db.LoadAndOrCreateUserEventByNameTx(tx, userID, eventlog.Event, &event); err != nil {
+				return err
+			}
+
+			eventlog.EventID = event.ID
+
+			id, err := db.AddUserEventLogWithTx(tx, &eventlog, foodlogs[i])
 
 			if err != nil {
 				return err
 			}
 
-			food.ID = id
+			eventlog.ID = id
+			saved = append(saved, eventlog)
 		}
 
-		retEventLogID = eventLogID
-
 		return nil
 	})
 
-	return retEventLogID, err
+	if err != nil {
+		return nil, err
+	}
 
+	return saved, nil
 }
 
 func (db *SqliteDatabase) AddUserEventLogTx(tx *sqlx.Tx, event *database.TblUserEventLog) (int, error) {
@@ -74,7 +199,13 @@ func (db *SqliteDatabase) AddUserEventLogTx(tx *sqlx.Tx, event *database.TblUser
 			INSULIN_TO_CARB_RATIO,
 			BLOOD_GLUCOSE_TARGET,
 			RECOMMENDED_INSULIN_AMOUNT,
-			ACTUAL_INSULIN_TAKEN
+			ACTUAL_INSULIN_TAKEN,
+			FAT_PROTEIN_UNITS,
+			RECOMMENDED_EXTENDED_INSULIN_AMOUNT,
+			RECOMMENDED_EXTENDED_MINUTES,
+			ACTUAL_EXTENDED_INSULIN_TAKEN,
+			ACTUAL_EXTENDED_MINUTES,
+			NOTES
 		)
 		VALUES(:USER_ID, :EVENT_ID, :USER_TIME, :EVENT, :NET_CARBS, 
 			:BLOOD_GLUCOSE,
@@ -82,7 +213,13 @@ func (db *SqliteDatabase) AddUserEventLogTx(tx *sqlx.Tx, event *database.TblUser
 			:INSULIN_TO_CARB_RATIO,
 			:BLOOD_GLUCOSE_TARGET,
 			:RECOMMENDED_INSULIN_AMOUNT,
-			:ACTUAL_INSULIN_TAKEN
+			:ACTUAL_INSULIN_TAKEN,
+			:FAT_PROTEIN_UNITS,
+			:RECOMMENDED_EXTENDED_INSULIN_AMOUNT,
+			:RECOMMENDED_EXTENDED_MINUTES,
+			:ACTUAL_EXTENDED_INSULIN_TAKEN,
+			:ACTUAL_EXTENDED_MINUTES,
+			:NOTES
 		)
 	`
 
@@ -112,6 +249,10 @@ func (db *SqliteDatabase) LoadUserEventFoodLog(
 			return err
 		}
 
+		if err := db.LoadUserEventLogPhotoIDsTx(tx, userID, eventlogID, &eventlogWithFood.PhotoIDs); err != nil {
+			return err
+		}
+
 		eventlogWithFood.Eventlog = eventlog
 
 		for _, foodlog := range eventlogWithFood.Foodlogs {
@@ -123,6 +264,9 @@ func (db *SqliteDatabase) LoadUserEventFoodLog(
 		if eventlogWithFood.Foodlogs == nil {
 			eventlogWithFood.Foodlogs = make([]database.TblUserFoodLog, 0)
 		}
+		if eventlogWithFood.PhotoIDs == nil {
+			eventlogWithFood.PhotoIDs = make([]int, 0)
+		}
 
 		*eventWithFood = eventlogWithFood
 
@@ -145,35 +289,78 @@ func (db *SqliteDatabase) LoadUserEventFoodLogsN(
 			return err
 		}
 
-		eventlogsWithFood := make([]database.UserEventFoodLog, len(eventlogs))
+		return db.loadUserEventFoodLogsTx(tx, userID, eventlogs, eventWithFood)
+	})
+}
 
-		for i, eventlog := range eventlogs {
+func (db *SqliteDatabase) LoadUserEventFoodLogsBetween(
+	ctx context.Context,
+	userID int,
+	start, end time.Time,
+	eventWithFood *[]database.UserEventFoodLog,
+) error {
 
-			ewfood := &eventlogsWithFood[i]
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
 
-			if err := db.LoadUserFoodLogByEventLogTx(tx, userID, eventlog.ID, &ewfood.Foodlogs); err != nil {
-				return err
-			}
+		query := `
+			SELECT * FROM PON_USER_EVENTLOG el
+			WHERE el.USER_ID = $1 AND el.USER_TIME >= $2 AND el.USER_TIME < $3
+			ORDER BY el.USER_TIME ASC, el.ID ASC
+		`
 
-			ewfood.Eventlog = eventlog
+		var eventlogs []database.TblUserEventLog
 
-			for _, foodlog := range ewfood.Foodlogs {
-				ewfood.TotalCarb += foodlog.Carb
-				ewfood.TotalProtein += foodlog.Protein
-				ewfood.TotalFat += foodlog.Fat
-				ewfood.TotalFibre += foodlog.Fibre
-			}
-			if ewfood.Foodlogs == nil {
-				ewfood.Foodlogs = make([]database.TblUserFoodLog, 0)
-			}
+		if err := tx.Select(&eventlogs, query, userID, start.UTC(), end.UTC()); err != nil {
+			return err
 		}
 
-		*eventWithFood = eventlogsWithFood
-
-		return nil
+		return db.loadUserEventFoodLogsTx(tx, userID, eventlogs, eventWithFood)
 	})
 }
 
+// loadUserEventFoodLogsTx reads the food and photos of each eventlog into the output.
+func (db *SqliteDatabase) loadUserEventFoodLogsTx(
+	tx *sqlx.Tx,
+	userID int,
+	eventlogs []database.TblUserEventLog,
+	eventWithFood *[]database.UserEventFoodLog,
+) error {
+
+	eventlogsWithFood := make([]database.UserEventFoodLog, len(eventlogs))
+
+	for i, eventlog := range eventlogs {
+
+		ewfood := &eventlogsWithFood[i]
+
+		if err := db.LoadUserFoodLogByEventLogTx(tx, userID, eventlog.ID, &ewfood.Foodlogs); err != nil {
+			return err
+		}
+
+		if err := db.LoadUserEventLogPhotoIDsTx(tx, userID, eventlog.ID, &ewfood.PhotoIDs); err != nil {
+			return err
+		}
+
+		ewfood.Eventlog = eventlog
+
+		for _, foodlog := range ewfood.Foodlogs {
+			ewfood.TotalCarb += foodlog.Carb
+			ewfood.TotalProtein += foodlog.Protein
+			ewfood.TotalFat += foodlog.Fat
+			ewfood.TotalFibre += foodlog.Fibre
+		}
+		if ewfood.Foodlogs == nil {
+			ewfood.Foodlogs = make([]database.TblUserFoodLog, 0)
+		}
+		if ewfood.PhotoIDs == nil {
+			ewfood.PhotoIDs = make([]int, 0)
+		}
+	}
+
+	*eventWithFood = eventlogsWithFood
+
+	return nil
+}
+
 func (db *SqliteDatabase) LoadUserEventFoodLogs(
 	ctx context.Context,
 	userID int,
@@ -190,6 +377,61 @@ func (db *SqliteDatabase) LoadUserEventLogs(ctx context.Context, userID int, out
 	})
 }
 
+func (db *SqliteDatabase) LoadUserInsulinEventLogsBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserEventLog,
+) error {
+
+	query := `
+		SELECT * FROM PON_USER_EVENTLOG el
+		WHERE el.USER_ID = $1
+		  AND (el.ACTUAL_INSULIN_TAKEN > 0 OR el.ACTUAL_EXTENDED_INSULIN_TAKEN > 0)
+		  AND el.USER_TIME >= $2 AND el.USER_TIME < $3
+		ORDER BY el.USER_TIME ASC, el.ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID, start.UTC(), end.UTC())
+}
+
+func (db *SqliteDatabase) LoadUserEventLogsBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserEventLog,
+) error {
+
+	query := `
+		SELECT * FROM PON_USER_EVENTLOG el
+		WHERE el.USER_ID = $1 AND el.USER_TIME >= $2 AND el.USER_TIME < $3
+		ORDER BY el.USER_TIME ASC, el.ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID, start.UTC(), end.UTC())
+}
+
+func (db *SqliteDatabase) LoadUserEventLogsBetweenN(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	n int,
+	out *[]database.TblUserEventLog,
+) error {
+
+	query := `
+		SELECT * FROM PON_USER_EVENTLOG el
+		WHERE el.USER_ID = $1 AND el.USER_TIME >= $2 AND el.USER_TIME < $3
+		ORDER BY el.USER_TIME DESC, el.ID DESC
+		LIMIT $4
+	`
+
+	return db.SelectContext(ctx, out, query, userID, start.UTC(), end.UTC(), n)
+}
+
 func (db *SqliteDatabase) LoadUserEventLogsNTx(tx *sqlx.Tx, userID int, n int, out *[]database.TblUserEventLog) error {
 
 	query := `
@@ -264,11 +506,10 @@ func (db *SqliteDatabase) UpdateUserEventFoodLog(ctx context.Context, eventlog *
 		}
 
 		eventlog.Eventlog.NetCarbs = 0
+		eventlog.Eventlog.FatProteinUnits = 0
 
 		for _, food := range eventlog.Foodlogs {
 
-			eventlog.Eventlog.NetCarbs = food.Carb - food.Fibre
-
 			food.UserID = eventlog.Eventlog.UserID
 			food.UserTime = eventlog.Eventlog.UserTime
 			food.Event = eventlog.Eventlog.Event
@@ -281,6 +522,10 @@ func (db *SqliteDatabase) UpdateUserEventFoodLog(ctx context.Context, eventlog *
 			}
 
 			food.ID = id
+
+			// The totals are of the amounts the foodlog was saved with.
+			eventlog.Eventlog.NetCarbs += food.Carb - food.Fibre
+			eventlog.Eventlog.FatProteinUnits += insulin.FatProteinUnits(food.Fat, food.Protein)
 		}
 
 		query := `UPDATE PON_USER_EVENTLOG SET ` +
@@ -293,7 +538,12 @@ func (db *SqliteDatabase) UpdateUserEventFoodLog(ctx context.Context, eventlog *
 			`INSULIN_TO_CARB_RATIO		= :INSULIN_TO_CARB_RATIO,` +
 			`BLOOD_GLUCOSE_TARGET		= :BLOOD_GLUCOSE_TARGET,` +
 			`RECOMMENDED_INSULIN_AMOUNT	= :RECOMMENDED_INSULIN_AMOUNT,` +
-			`ACTUAL_INSULIN_TAKEN		= :ACTUAL_INSULIN_TAKEN ` +
+			`ACTUAL_INSULIN_TAKEN		= :ACTUAL_INSULIN_TAKEN,` +
+			`FAT_PROTEIN_UNITS			= :FAT_PROTEIN_UNITS,` +
+			`RECOMMENDED_EXTENDED_INSULIN_AMOUNT	= :RECOMMENDED_EXTENDED_INSULIN_AMOUNT,` +
+			`RECOMMENDED_EXTENDED_MINUTES		= :RECOMMENDED_EXTENDED_MINUTES,` +
+			`ACTUAL_EXTENDED_INSULIN_TAKEN		= :ACTUAL_EXTENDED_INSULIN_TAKEN,` +
+			`ACTUAL_EXTENDED_MINUTES			= :ACTUAL_EXTENDED_MINUTES ` +
 			`WHERE USER_ID = :USER_ID AND ID = :ID`
 
 		_, err = tx.NamedExec(query, eventlog.Eventlog)
@@ -314,9 +564,9 @@ func (db *SqliteDatabase) DeleteUserEventLog(
 	return err
 }
 
-func (db *SqliteDatabase) ExportUserEventLogsCSV(ctx context.Context, w io.Writer) error {
+func (db *SqliteDatabase) ExportUserEventLogsCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 
-	query := `SELECT * FROM PON_USER_EVENTLOG`
+	query := `SELECT t.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON_USER_EVENTLOG t LEFT JOIN PON_USER u ON u.ID = t.USER_ID`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
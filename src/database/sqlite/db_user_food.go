@@ -11,8 +11,8 @@ import (
 func (db *SqliteDatabase) AddUserFood(ctx context.Context, food *database.TblUserFood) (int, error) {
 
 	query := `
-        INSERT INTO PON_USER_FOOD (USER_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT)
-		VALUES (:USER_ID, :NAME, :UNIT, :PORTION, :PROTEIN, :CARB, :FIBRE, :FAT)
+        INSERT INTO PON_USER_FOOD (USER_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT, NUTRIENTS, DENSITY, HOUSEHOLD_UNITS)
+		VALUES (:USER_ID, :NAME, :UNIT, :PORTION, :PROTEIN, :CARB, :FIBRE, :FAT, :NUTRIENTS, :DENSITY, :HOUSEHOLD_UNITS)
     `
 
 	id, err := db.NamedInsertGetLastRowID(ctx, query, food)
@@ -25,8 +25,8 @@ func (db *SqliteDatabase) AddUserFoods(ctx context.Context, foods []database.Tbl
 	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
 
 		query := `
-			INSERT INTO PON_USER_FOOD (USER_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT)
-			VALUES (:USER_ID, :NAME, :UNIT, :PORTION, :PROTEIN, :CARB, :FIBRE, :FAT)
+			INSERT INTO PON_USER_FOOD (USER_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT, NUTRIENTS, DENSITY, HOUSEHOLD_UNITS)
+			VALUES (:USER_ID, :NAME, :UNIT, :PORTION, :PROTEIN, :CARB, :FIBRE, :FAT, :NUTRIENTS, :DENSITY, :HOUSEHOLD_UNITS)
     	`
 		for _, food := range foods {
 
@@ -46,13 +46,16 @@ func (db *SqliteDatabase) UpdateUserFood(ctx context.Context, food *database.Tbl
 	query := `
 		UPDATE PON_USER_FOOD
 		SET
-			NAME    = :NAME,
-			UNIT    = :UNIT,
-			PORTION = :PORTION,
-			PROTEIN = :PROTEIN,
-			CARB    = :CARB,
-			FIBRE   = :FIBRE,
-			FAT     = :FAT
+			NAME            = :NAME,
+			UNIT            = :UNIT,
+			PORTION         = :PORTION,
+			PROTEIN         = :PROTEIN,
+			CARB            = :CARB,
+			FIBRE           = :FIBRE,
+			FAT             = :FAT,
+			NUTRIENTS       = :NUTRIENTS,
+			DENSITY         = :DENSITY,
+			HOUSEHOLD_UNITS = :HOUSEHOLD_UNITS
 		WHERE USER_ID = :USER_ID AND ID = :ID 
     `
 
@@ -63,14 +66,27 @@ func (db *SqliteDatabase) UpdateUserFood(ctx context.Context, food *database.Tbl
 
 func (db *SqliteDatabase) DeleteUserFood(ctx context.Context, userID int, foodID int) error {
 
-	query := `
-	DELETE FROM PON_USER_FOOD
-	WHERE USER_ID = $1 AND ID = $2
-	`
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
 
-	_, err := db.ExecContext(ctx, query, userID, foodID)
+		var used int
 
-	return err
+		if err := tx.Get(&used, `SELECT COUNT(*) FROM PON_USER_RECIPE_INGREDIENT WHERE FOOD_ID = $1`, foodID); err != nil {
+			return err
+		}
+
+		if used > 0 {
+			return database.ErrFoodUsedInRecipe
+		}
+
+		query := `
+		DELETE FROM PON_USER_FOOD
+		WHERE USER_ID = $1 AND ID = $2
+		`
+
+		_, err := tx.Exec(query, userID, foodID)
+
+		return err
+	})
 }
 
 func (db *SqliteDatabase) LoadUserFoods(ctx context.Context, userID int, out *[]database.TblUserFood) error {
@@ -83,8 +99,8 @@ func (db *SqliteDatabase) LoadUserFoods(ctx context.Context, userID int, out *[]
 	return db.SelectContext(ctx, out, query, userID)
 }
 
-func (db *SqliteDatabase) ExportUserFoodsCSV(ctx context.Context, w io.Writer) error {
-	query := `SELECT * FROM PON_USER_FOOD`
+func (db *SqliteDatabase) ExportUserFoodsCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
+	query := `SELECT t.*, u.TIMEZONE AS EXPORT_TIMEZONE FROM PON_USER_FOOD t LEFT JOIN PON_USER u ON u.ID = t.USER_ID`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
@@ -113,7 +113,14 @@ func (db *SqliteDatabase) LoadUserGoalProgress(
 
 	switch userGoal.TargetColumn() {
 	default:
-		return database.ErrInvalidGoalTargetColumn
+		n, ok := userGoal.TargetColumn().Nutrient()
+
+		if !ok {
+			return database.ErrInvalidGoalTargetColumn
+		}
+
+		tableSQL = "PON_USER_FOODLOG"
+		colSQL, whereSQL = nutrientToSqlite(n)
 
 	case database.TargetColumnCalories:
 		// TODO: don't hard code this and make it use the user's setting
@@ -174,6 +181,10 @@ func (db *SqliteDatabase) LoadUserGoalProgress(
 		tableSQL = "PON_USER_EVENTLOG"
 		colSQL = "BLOOD_GLUCOSE"
 		whereSQL = " AND BLOOD_GLUCOSE > 0"
+	case database.TargetColumnGlucose:
+		tableSQL = "PON_USER_GLUCOSE"
+		colSQL = "GLUCOSE"
+		whereSQL = ""
 	}
 
 	query := `
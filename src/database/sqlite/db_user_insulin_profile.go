@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"karopon/src/database"
+)
+
+func (db *SqliteDatabase) AddUserInsulinProfileBlock(
+	ctx context.Context,
+	block *database.TblUserInsulinProfileBlock,
+) (int, error) {
+
+	query := `
+		INSERT INTO PON_USER_INSULIN_PROFILE (
+			USER_ID, START_MINUTE, INSULIN_TO_CARB_RATIO, INSULIN_SENSITIVITY_FACTOR, TARGET_BLOOD_SUGAR
+		) VALUES (
+			:USER_ID, :START_MINUTE, :INSULIN_TO_CARB_RATIO, :INSULIN_SENSITIVITY_FACTOR, :TARGET_BLOOD_SUGAR
+		)
+	`
+
+	return db.NamedInsertGetLastRowID(ctx, query, block)
+}
+
+func (db *SqliteDatabase) LoadUserInsulinProfile(
+	ctx context.Context,
+	userID int,
+	out *[]database.TblUserInsulinProfileBlock,
+) error {
+
+	query := `
+		SELECT * FROM PON_USER_INSULIN_PROFILE
+		WHERE USER_ID = $1
+		ORDER BY START_MINUTE ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID)
+}
+
+func (db *SqliteDatabase) UpdateUserInsulinProfileBlock(ctx context.Context, block *database.TblUserInsulinProfileBlock) error {
+
+	query := `
+		UPDATE PON_USER_INSULIN_PROFILE
+		SET
+			START_MINUTE               = :START_MINUTE,
+			INSULIN_TO_CARB_RATIO      = :INSULIN_TO_CARB_RATIO,
+			INSULIN_SENSITIVITY_FACTOR = :INSULIN_SENSITIVITY_FACTOR,
+			TARGET_BLOOD_SUGAR         = :TARGET_BLOOD_SUGAR
+		WHERE ID = :ID AND USER_ID = :USER_ID
+	`
+
+	_, err := db.NamedExecContext(ctx, query, block)
+
+	return err
+}
+
+func (db *SqliteDatabase) DeleteUserInsulinProfileBlock(ctx context.Context, userID int, blockID int) error {
+
+	query := `DELETE FROM PON_USER_INSULIN_PROFILE WHERE ID = $1 AND USER_ID = $2`
+
+	_, err := db.ExecContext(ctx, query, blockID, userID)
+
+	return err
+}
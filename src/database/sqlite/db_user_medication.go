@@ -0,0 +1,322 @@
+package sqlite
+
+import (
+	"context"
+	"karopon/src/database"
+	"time"
+
+	"github.com/vinovest/sqlx"
+)
+
+func (db *SqliteDatabase) AddUserMedication(ctx context.Context, med *database.TblUserMedication) (int, error) {
+
+	query := `
+		INSERT INTO PON_USER_MEDICATION (
+			USER_ID, NAME, CATEGORY, DOSAGE_AMOUNT, DOSAGE_UNIT, FORM, START_DATE, END_DATE, NOTES
+		) VALUES (
+			:USER_ID, :NAME, :CATEGORY, :DOSAGE_AMOUNT, :DOSAGE_UNIT, :FORM, :START_DATE, :END_DATE, :NOTES
+		)
+	`
+
+	return db.NamedInsertGetLastRowID(ctx, query, med)
+}
+
+func (db *SqliteDatabase) LoadUserMedications(ctx context.Context, userID int, out *[]database.TblUserMedication) error {
+
+	query := `
+		SELECT * FROM PON_USER_MEDICATION
+		WHERE USER_ID = $1
+		ORDER BY ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID)
+}
+
+func (db *SqliteDatabase) UpdateUserMedication(ctx context.Context, med *database.TblUserMedication) error {
+
+	query := `
+		UPDATE PON_USER_MEDICATION
+		SET
+			NAME          = :NAME,
+			CATEGORY      = :CATEGORY,
+			DOSAGE_AMOUNT = :DOSAGE_AMOUNT,
+			DOSAGE_UNIT   = :DOSAGE_UNIT,
+			FORM          = :FORM,
+			START_DATE    = :START_DATE,
+			END_DATE      = :END_DATE,
+			NOTES         = :NOTES
+		WHERE ID = :ID AND USER_ID = :USER_ID
+	`
+
+	return db.ExpectRowsAffected(db.NamedExecContext(ctx, query, med))
+}
+
+func (db *SqliteDatabase) DeleteUserMedication(ctx context.Context, userID int, medicationID int) error {
+
+	query := `DELETE FROM PON_USER_MEDICATION WHERE ID = $1 AND USER_ID = $2`
+
+	return db.ExpectRowsAffected(db.ExecContext(ctx, query, medicationID, userID))
+}
+
+func (db *SqliteDatabase) userHasMedicationTx(tx *sqlx.Tx, userID int, medicationID int) error {
+
+	query := `SELECT COUNT(ID) FROM PON_USER_MEDICATION WHERE USER_ID = $1 AND ID = $2 LIMIT 1`
+
+	if ok, err := db.CountOneTx(tx, query, userID, medicationID); err != nil {
+		return err
+	} else if !ok {
+		return database.ErrUserDoesNotHaveThisID
+	}
+
+	return nil
+}
+
+func (db *SqliteDatabase) userHasMedicationScheduleTx(tx *sqlx.Tx, userID int, scheduleID int) error {
+
+	query := `
+		SELECT COUNT(s.ID) FROM PON_USER_MEDICATION_SCHEDULE s
+		JOIN PON_USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+		WHERE m.USER_ID = $1 AND s.ID = $2
+		LIMIT 1
+	`
+
+	if ok, err := db.CountOneTx(tx, query, userID, scheduleID); err != nil {
+		return err
+	} else if !ok {
+		return database.ErrUserDoesNotHaveThisID
+	}
+
+	return nil
+}
+
+func (db *SqliteDatabase) userHasMedicationLogTx(tx *sqlx.Tx, userID int, medlogID int) error {
+
+	query := `
+		SELECT COUNT(l.ID) FROM PON_USER_MEDICATIONLOG l
+		JOIN PON_USER_MEDICATION_SCHEDULE s ON s.ID = l.SCHEDULE_ID
+		JOIN PON_USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+		WHERE m.USER_ID = $1 AND l.ID = $2
+		LIMIT 1
+	`
+
+	if ok, err := db.CountOneTx(tx, query, userID, medlogID); err != nil {
+		return err
+	} else if !ok {
+		return database.ErrUserDoesNotHaveThisID
+	}
+
+	return nil
+}
+
+func (db *SqliteDatabase) AddUserMedicationSchedule(
+	ctx context.Context,
+	userID int,
+	schedule *database.TblUserMedicationSchedule,
+) (int, error) {
+
+	var id int
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		// Make sure the UserID has the MedicationID, since the caller can't verify this.
+		if err := db.userHasMedicationTx(tx, userID, schedule.MedicationID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO PON_USER_MEDICATION_SCHEDULE (
+				MEDICATION_ID,
+				MINUTES_OF_HOUR_MASK, HOURS_OF_DAY_MASK, DAYS_OF_WEEK_MASK, DAYS_OF_MONTH_MASK, MONTH_OF_YEAR_MASK,
+				WITH_FOOD, FASTING, REMINDER_ENABLED, NOTES
+			) VALUES (
+				:MEDICATION_ID,
+				:MINUTES_OF_HOUR_MASK, :HOURS_OF_DAY_MASK, :DAYS_OF_WEEK_MASK, :DAYS_OF_MONTH_MASK, :MONTH_OF_YEAR_MASK,
+				:WITH_FOOD, :FASTING, :REMINDER_ENABLED, :NOTES
+			)
+		`
+
+		var err error
+		id, err = db.NamedInsertGetLastRowIDTx(tx, query, schedule)
+
+		return err
+	})
+
+	return id, err
+}
+
+func (db *SqliteDatabase) LoadUserMedicationSchedules(
+	ctx context.Context,
+	userID int,
+	out *[]database.TblUserMedicationSchedule,
+) error {
+
+	query := `
+		SELECT s.* FROM PON_USER_MEDICATION_SCHEDULE s
+		JOIN PON_USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+		WHERE m.USER_ID = $1
+		ORDER BY s.ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID)
+}
+
+func (db *SqliteDatabase) UpdateUserMedicationSchedule(
+	ctx context.Context,
+	userID int,
+	schedule *database.TblUserMedicationSchedule,
+) error {
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		if err := db.userHasMedicationScheduleTx(tx, userID, schedule.ID); err != nil {
+			return err
+		}
+
+		// The schedule can be moved onto another medication, which must also belong to the user.
+		if err := db.userHasMedicationTx(tx, userID, schedule.MedicationID); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE PON_USER_MEDICATION_SCHEDULE
+			SET
+				MEDICATION_ID        = :MEDICATION_ID,
+				MINUTES_OF_HOUR_MASK = :MINUTES_OF_HOUR_MASK,
+				HOURS_OF_DAY_MASK    = :HOURS_OF_DAY_MASK,
+				DAYS_OF_WEEK_MASK    = :DAYS_OF_WEEK_MASK,
+				DAYS_OF_MONTH_MASK   = :DAYS_OF_MONTH_MASK,
+				MONTH_OF_YEAR_MASK   = :MONTH_OF_YEAR_MASK,
+				WITH_FOOD            = :WITH_FOOD,
+				FASTING              = :FASTING,
+				REMINDER_ENABLED     = :REMINDER_ENABLED,
+				NOTES                = :NOTES
+			WHERE ID = :ID
+		`
+
+		return db.ExpectRowsAffected(tx.NamedExec(query, schedule))
+	})
+}
+
+func (db *SqliteDatabase) DeleteUserMedicationSchedule(ctx context.Context, userID int, scheduleID int) error {
+
+	query := `
+		DELETE FROM PON_USER_MEDICATION_SCHEDULE
+		WHERE ID = $1 AND MEDICATION_ID IN (
+			SELECT ID FROM PON_USER_MEDICATION WHERE USER_ID = $2
+		)
+	`
+
+	return db.ExpectRowsAffected(db.ExecContext(ctx, query, scheduleID, userID))
+}
+
+func (db *SqliteDatabase) AddUserMedicationLog(
+	ctx context.Context,
+	userID int,
+	medlog *database.TblUserMedicationLog,
+) (int, error) {
+
+	var id int
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		// Make sure the UserID has the ScheduleID, since the caller can't verify this.
+		if err := db.userHasMedicationScheduleTx(tx, userID, medlog.ScheduleID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO PON_USER_MEDICATIONLOG (
+				SCHEDULE_ID, TAKEN_TIME, TAKEN, NOTES
+			) VALUES (
+				:SCHEDULE_ID, :TAKEN_TIME, :TAKEN, :NOTES
+			)
+		`
+
+		var err error
+		id, err = db.NamedInsertGetLastRowIDTx(tx, query, medlog)
+
+		return err
+	})
+
+	return id, err
+}
+
+func (db *SqliteDatabase) LoadUserMedicationLogs(
+	ctx context.Context,
+	userID int,
+	out *[]database.TblUserMedicationLog,
+) error {
+
+	query := `
+		SELECT l.* FROM PON_USER_MEDICATIONLOG l
+		JOIN PON_USER_MEDICATION_SCHEDULE s ON s.ID = l.SCHEDULE_ID
+		JOIN PON_USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+		WHERE m.USER_ID = $1
+		ORDER BY l.TAKEN_TIME DESC, l.ID DESC
+	`
+
+	return db.SelectContext(ctx, out, query, userID)
+}
+
+func (db *SqliteDatabase) LoadUserMedicationLogsBetween(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	end time.Time,
+	out *[]database.TblUserMedicationLog,
+) error {
+
+	query := `
+		SELECT l.* FROM PON_USER_MEDICATIONLOG l
+		JOIN PON_USER_MEDICATION_SCHEDULE s ON s.ID = l.SCHEDULE_ID
+		JOIN PON_USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+		WHERE m.USER_ID = $1 AND l.TAKEN_TIME >= $2 AND l.TAKEN_TIME < $3
+		ORDER BY l.TAKEN_TIME ASC, l.ID ASC
+	`
+
+	return db.SelectContext(ctx, out, query, userID, start.UTC(), end.UTC())
+}
+
+func (db *SqliteDatabase) UpdateUserMedicationLog(
+	ctx context.Context,
+	userID int,
+	medlog *database.TblUserMedicationLog,
+) error {
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		if err := db.userHasMedicationLogTx(tx, userID, medlog.ID); err != nil {
+			return err
+		}
+
+		if err := db.userHasMedicationScheduleTx(tx, userID, medlog.ScheduleID); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE PON_USER_MEDICATIONLOG
+			SET
+				SCHEDULE_ID = :SCHEDULE_ID,
+				TAKEN_TIME  = :TAKEN_TIME,
+				TAKEN       = :TAKEN,
+				NOTES       = :NOTES
+			WHERE ID = :ID
+		`
+
+		return db.ExpectRowsAffected(tx.NamedExec(query, medlog))
+	})
+}
+
+func (db *SqliteDatabase) DeleteUserMedicationLog(ctx context.Context, userID int, medlogID int) error {
+
+	query := `
+		DELETE FROM PON_USER_MEDICATIONLOG
+		WHERE ID = $1 AND SCHEDULE_ID IN (
+			SELECT s.ID FROM PON_USER_MEDICATION_SCHEDULE s
+			JOIN PON_USER_MEDICATION m ON m.ID = s.MEDICATION_ID
+			WHERE m.USER_ID = $2
+		)
+	`
+
+	return db.ExpectRowsAffected(db.ExecContext(ctx, query, medlogID, userID))
+}
@@ -3,34 +3,162 @@ package sqlite
 import (
 	"context"
 	"karopon/src/database"
+	"time"
 
 	"github.com/vinovest/sqlx"
 )
 
 func (db *SqliteDatabase) AddUserPhoto(ctx context.Context, photo *database.TblUserPhoto) (int, error) {
 
-	query := `INSERT INTO PON_USER_PHOTO (USER_ID, DATA) VALUES (:USER_ID, :DATA)`
+	query := `
+		INSERT INTO PON_USER_PHOTO (
+			USER_ID, DATA, THUMBNAIL, STORAGE, HASH, CONTENT_TYPE, WIDTH, HEIGHT, CREATED
+		) VALUES (
+			:USER_ID, :DATA, COALESCE(:THUMBNAIL, X''), COALESCE(NULLIF(:STORAGE, ''), 'db'), :HASH,
+			:CONTENT_TYPE, :WIDTH, :HEIGHT, CURRENT_TIMESTAMP
+		)
+	`
 
 	return db.NamedInsertGetLastRowID(ctx, query, photo)
 }
 
-func (db *SqliteDatabase) AddUserEventLogPhotos(ctx context.Context, eventlogID int, photoIDs []int) error {
+func (db *SqliteDatabase) AddUserEventLogPhotos(ctx context.Context, userID int, eventlogID int, photoIDs []int) error {
 
 	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		return db.AddUserEventLogPhotosTx(tx, userID, eventlogID, photoIDs)
+	})
+}
+
+func (db *SqliteDatabase) AddUserEventLogPhotosTx(tx *sqlx.Tx, userID int, eventlogID int, photoIDs []int) error {
+
+	// Make sure the UserID has the EventlogID and every PhotoID, since the caller can't verify this.
+	query := `SELECT COUNT(ID) FROM PON_USER_EVENTLOG WHERE USER_ID = $1 AND ID = $2 LIMIT 1`
 
-		for _, photoID := range photoIDs {
+	if ok, err := db.CountOneTx(tx, query, userID, eventlogID); err != nil {
+		return err
+	} else if !ok {
+		return database.ErrUserDoesNotHaveThisID
+	}
 
-			_, err := tx.Exec(
-				`INSERT INTO PON_USER_EVENTLOG_PHOTO (EVENTLOG_ID, PHOTO_ID) VALUES ($1, $2)`,
-				eventlogID,
-				photoID,
-			)
+	for _, photoID := range photoIDs {
 
-			if err != nil {
-				return err
-			}
+		query = `SELECT COUNT(ID) FROM PON_USER_PHOTO WHERE USER_ID = $1 AND ID = $2 LIMIT 1`
+
+		if ok, err := db.CountOneTx(tx, query, userID, photoID); err != nil {
+			return err
+		} else if !ok {
+			return database.ErrUserDoesNotHaveThisID
 		}
 
-		return nil
-	})
+		_, err := tx.Exec(
+			`INSERT INTO PON_USER_EVENTLOG_PHOTO (EVENTLOG_ID, PHOTO_ID) VALUES ($1, $2)`,
+			eventlogID,
+			photoID,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *SqliteDatabase) LoadUserPhoto(
+	ctx context.Context,
+	userID int,
+	photoID int,
+	out *database.TblUserPhoto,
+) error {
+
+	query := `SELECT * FROM PON_USER_PHOTO WHERE ID = $1 AND USER_ID = $2`
+
+	return db.GetContext(ctx, out, query, photoID, userID)
+}
+
+func (db *SqliteDatabase) LoadUserEventLogPhotoIDsTx(tx *sqlx.Tx, userID int, eventlogID int, out *[]int) error {
+
+	query := `
+		SELECT p.ID FROM PON_USER_EVENTLOG_PHOTO ep
+		JOIN PON_USER_PHOTO p ON p.ID = ep.PHOTO_ID
+		WHERE p.USER_ID = $1 AND ep.EVENTLOG_ID = $2
+		ORDER BY p.ID ASC
+	`
+
+	return tx.Select(out, query, userID, eventlogID)
+}
+
+func (db *SqliteDatabase) DeleteUserPhoto(ctx context.Context, userID int, photoID int) error {
+
+	query := `DELETE FROM PON_USER_PHOTO WHERE ID = $1 AND USER_ID = $2`
+
+	return db.ExpectRowsAffected(db.ExecContext(ctx, query, photoID, userID))
+}
+
+func (db *SqliteDatabase) DeleteUserEventLogPhoto(ctx context.Context, userID int, eventlogID int, photoID int) error {
+
+	query := `
+		DELETE FROM PON_USER_EVENTLOG_PHOTO
+		WHERE EVENTLOG_ID = $1 AND PHOTO_ID = $2
+		AND EVENTLOG_ID IN (SELECT ID FROM PON_USER_EVENTLOG WHERE USER_ID = $3)
+	`
+
+	return db.ExpectRowsAffected(db.ExecContext(ctx, query, eventlogID, photoID, userID))
+}
+
+func (db *SqliteDatabase) DeleteUnlinkedUserPhotos(
+	ctx context.Context,
+	createdBefore time.Time,
+) ([]database.TblUserPhoto, error) {
+
+	query := `
+		DELETE FROM PON_USER_PHOTO
+		WHERE CREATED < $1
+		AND NOT EXISTS (SELECT 1 FROM PON_USER_EVENTLOG_PHOTO ep WHERE ep.PHOTO_ID = PON_USER_PHOTO.ID)
+		RETURNING ID, USER_ID, CREATED, STORAGE, HASH
+	`
+
+	var photos []database.TblUserPhoto
+
+	err := db.SelectContext(ctx, &photos, query, createdBefore.UTC())
+
+	return photos, err
+}
+
+func (db *SqliteDatabase) LoadPhotoByID(ctx context.Context, photoID int, out *database.TblUserPhoto) error {
+
+	query := `SELECT * FROM PON_USER_PHOTO WHERE ID = $1`
+
+	return db.GetContext(ctx, out, query, photoID)
+}
+
+func (db *SqliteDatabase) LoadPhotoIDsByStorage(ctx context.Context, storage string, out *[]int) error {
+
+	query := `SELECT ID FROM PON_USER_PHOTO WHERE STORAGE = $1 ORDER BY ID ASC`
+
+	return db.SelectContext(ctx, out, query, storage)
+}
+
+func (db *SqliteDatabase) UpdatePhotoStorage(ctx context.Context, photo *database.TblUserPhoto) error {
+
+	query := `
+		UPDATE PON_USER_PHOTO
+		SET STORAGE = :STORAGE, HASH = :HASH, DATA = :DATA, THUMBNAIL = COALESCE(:THUMBNAIL, X'')
+		WHERE ID = :ID
+	`
+
+	_, err := db.NamedExecContext(ctx, query, photo)
+
+	return err
+}
+
+func (db *SqliteDatabase) CountPhotosByHash(ctx context.Context, storage string, hash string) (int, error) {
+
+	query := `SELECT COUNT(ID) FROM PON_USER_PHOTO WHERE STORAGE = $1 AND HASH = $2`
+
+	var count int
+
+	err := db.GetContext(ctx, &count, query, storage, hash)
+
+	return count, err
 }
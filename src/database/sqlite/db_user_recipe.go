@@ -0,0 +1,215 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"karopon/src/database"
+
+	"github.com/vinovest/sqlx"
+)
+
+func (db *SqliteDatabase) userHasRecipeTx(tx *sqlx.Tx, userID int, recipeID int) error {
+
+	query := `SELECT COUNT(ID) FROM PON_USER_RECIPE WHERE USER_ID = $1 AND ID = $2 LIMIT 1`
+
+	if ok, err := db.CountOneTx(tx, query, userID, recipeID); err != nil {
+		return err
+	} else if !ok {
+		return database.ErrUserDoesNotHaveThisID
+	}
+
+	return nil
+}
+
+// addUserRecipeIngredientsTx adds the ingredients to the recipe,
+// making sure the user has each user food and each data source food exists.
+func (db *SqliteDatabase) addUserRecipeIngredientsTx(
+	tx *sqlx.Tx,
+	userID int,
+	recipeID int,
+	ingredients []database.TblUserRecipeIngredient,
+) error {
+
+	query := `
+		INSERT INTO PON_USER_RECIPE_INGREDIENT (
+			RECIPE_ID, FOOD_ID, DATA_SOURCE_FOOD_ID, PORTION
+		) VALUES (
+			:RECIPE_ID, :FOOD_ID, :DATA_SOURCE_FOOD_ID, :PORTION
+		)
+	`
+
+	for _, ing := range ingredients {
+
+		var ok bool
+		var err error
+
+		switch {
+		case ing.FoodID != nil && ing.DataSourceFoodID == nil:
+			ok, err = db.CountOneTx(tx,
+				`SELECT COUNT(ID) FROM PON_USER_FOOD WHERE USER_ID = $1 AND ID = $2 LIMIT 1`, userID, *ing.FoodID)
+		case ing.DataSourceFoodID != nil && ing.FoodID == nil:
+			ok, err = db.CountOneTx(tx,
+				`SELECT COUNT(ID) FROM PON_DATA_SOURCE_FOOD WHERE ID = $1 LIMIT 1`, *ing.DataSourceFoodID)
+		}
+
+		if err != nil {
+			return err
+		} else if !ok {
+			return database.ErrUserDoesNotHaveThisID
+		}
+
+		ing.RecipeID = recipeID
+
+		if _, err := tx.NamedExec(query, ing); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *SqliteDatabase) AddUserRecipe(
+	ctx context.Context,
+	recipe *database.TblUserRecipe,
+	ingredients []database.TblUserRecipeIngredient,
+) (int, error) {
+
+	var id int
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		query := `
+			INSERT INTO PON_USER_RECIPE (
+				USER_ID, NAME, UNIT, YIELD, NOTES
+			) VALUES (
+				:USER_ID, :NAME, :UNIT, :YIELD, :NOTES
+			)
+		`
+
+		var err error
+		id, err = db.NamedInsertGetLastRowIDTx(tx, query, recipe)
+
+		if err != nil {
+			return err
+		}
+
+		return db.addUserRecipeIngredientsTx(tx, recipe.UserID, id, ingredients)
+	})
+
+	return id, err
+}
+
+// loadUserRecipesTx reads the users recipes, or only the one with the recipeID when it is not 0.
+func (db *SqliteDatabase) loadUserRecipesTx(tx *sqlx.Tx, userID int, recipeID int, out *[]database.UserRecipe) error {
+
+	var recipes []database.TblUserRecipe
+	var ingredients []database.TblUserRecipeIngredient
+	var foods []database.TblUserFood
+	var dataSourceFoods []database.TblDataSourceFood
+
+	query := `
+		SELECT * FROM PON_USER_RECIPE
+		WHERE USER_ID = $1 AND ($2 = 0 OR ID = $2)
+		ORDER BY NAME ASC, ID ASC
+	`
+
+	if err := tx.Select(&recipes, query, userID, recipeID); err != nil {
+		return err
+	}
+
+	ingredientsQuery := `
+		SELECT i.* FROM PON_USER_RECIPE_INGREDIENT i
+		JOIN PON_USER_RECIPE r ON r.ID = i.RECIPE_ID
+		WHERE r.USER_ID = $1 AND ($2 = 0 OR r.ID = $2)
+	`
+
+	if err := tx.Select(&ingredients, ingredientsQuery+` ORDER BY i.ID ASC`, userID, recipeID); err != nil {
+		return err
+	}
+
+	query = `SELECT * FROM PON_USER_FOOD WHERE ID IN (SELECT FOOD_ID FROM (` + ingredientsQuery + `) i)`
+
+	if err := tx.Select(&foods, query, userID, recipeID); err != nil {
+		return err
+	}
+
+	query = `SELECT * FROM PON_DATA_SOURCE_FOOD WHERE ID IN (SELECT DATA_SOURCE_FOOD_ID FROM (` + ingredientsQuery + `) i)`
+
+	if err := tx.Select(&dataSourceFoods, query, userID, recipeID); err != nil {
+		return err
+	}
+
+	*out = database.NewUserRecipes(recipes, ingredients, foods, dataSourceFoods)
+
+	return nil
+}
+
+func (db *SqliteDatabase) LoadUserRecipes(ctx context.Context, userID int, out *[]database.UserRecipe) error {
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		return db.loadUserRecipesTx(tx, userID, 0, out)
+	})
+}
+
+func (db *SqliteDatabase) LoadUserRecipe(ctx context.Context, userID int, recipeID int, out *database.UserRecipe) error {
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		var recipes []database.UserRecipe
+
+		if err := db.loadUserRecipesTx(tx, userID, recipeID, &recipes); err != nil {
+			return err
+		}
+
+		if len(recipes) == 0 || recipeID == 0 {
+			return sql.ErrNoRows
+		}
+
+		*out = recipes[0]
+
+		return nil
+	})
+}
+
+func (db *SqliteDatabase) UpdateUserRecipe(
+	ctx context.Context,
+	recipe *database.TblUserRecipe,
+	ingredients []database.TblUserRecipeIngredient,
+) error {
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+
+		if err := db.userHasRecipeTx(tx, recipe.UserID, recipe.ID); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE PON_USER_RECIPE
+			SET
+				NAME  = :NAME,
+				UNIT  = :UNIT,
+				YIELD = :YIELD,
+				NOTES = :NOTES
+			WHERE ID = :ID AND USER_ID = :USER_ID
+		`
+
+		if _, err := tx.NamedExec(query, recipe); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM PON_USER_RECIPE_INGREDIENT WHERE RECIPE_ID = $1`, recipe.ID); err != nil {
+			return err
+		}
+
+		return db.addUserRecipeIngredientsTx(tx, recipe.UserID, recipe.ID, ingredients)
+	})
+}
+
+func (db *SqliteDatabase) DeleteUserRecipe(ctx context.Context, userID int, recipeID int) error {
+
+	query := `DELETE FROM PON_USER_RECIPE WHERE ID = $1 AND USER_ID = $2`
+
+	_, err := db.ExecContext(ctx, query, recipeID, userID)
+
+	return err
+}
@@ -9,36 +9,6 @@ import (
 	"github.com/vinovest/sqlx"
 )
 
-// truncateToBucket truncates t (in UTC) to the start of the bucket it falls into, mirroring
-// postgres' date_trunc(bucket, source) behaviour.
-func truncateToBucket(t time.Time, groupby database.GroupBy) time.Time {
-
-	t = t.UTC()
-
-	switch groupby {
-	case database.GroupByOne:
-		return time.Time{}
-	case database.GroupBySecond:
-		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
-	case database.GroupByMinute:
-		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
-	case database.GroupByHour:
-		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.UTC)
-	case database.GroupByDay:
-		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
-	case database.GroupByWeek:
-		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
-		offset := (int(d.Weekday()) + 6) % 7 // ISO week starts on Monday
-		return d.AddDate(0, 0, -offset)
-	case database.GroupByMonth:
-		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
-	case database.GroupByYear:
-		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
-	default:
-		panic("impossible group by")
-	}
-}
-
 func (db *SqliteDatabase) LoadUserTimeData(
 	ctx context.Context,
 	userID int,
@@ -46,9 +16,15 @@ func (db *SqliteDatabase) LoadUserTimeData(
 	endTime time.Time,
 	tags []string,
 	groupby database.GroupBy,
+	loc *time.Location,
+	shift time.Duration,
 	out *[]database.TimespanTagDurationPoint,
 ) error {
 
+	if err := database.ValidateBucketCount(startTime, endTime, groupby); err != nil {
+		return err
+	}
+
 	if len(tags) == 0 {
 		return nil
 	}
@@ -72,8 +48,8 @@ func (db *SqliteDatabase) LoadUserTimeData(
 			AND (t.NAMESPACE || ':' || t.NAME) IN (?)
 			AND t.USER_ID = ?
 			AND ts.USER_ID = ?
-			AND ts.START_TIME >= ?
-			AND ts.START_TIME <= ?
+			AND ts.STOP_TIME > ?
+			AND ts.START_TIME < ?
 	`
 
 	query, args, err := sqlx.In(sql, tags, userID, userID, startTime.UTC(), endTime.UTC())
@@ -84,43 +60,194 @@ func (db *SqliteDatabase) LoadUserTimeData(
 
 	query = db.Rebind(query)
 
-	var rows []struct {
-		Tag       string              `db:"tag"`
-		StartTime database.TimeMillis `db:"start_time"`
-		StopTime  database.TimeMillis `db:"stop_time"`
-	}
+	var rows []database.TagTimespan
 
 	if err := db.SelectContext(ctx, &rows, query, args...); err != nil {
 		return err
 	}
 
-	type bucketKey struct {
-		tag    string
-		bucket time.Time
+	*out = database.SumTimespanDurations(rows, startTime, endTime, groupby, loc, shift)
+
+	return nil
+}
+
+// nutrientToSqlite returns the value expression and where clause for a nutrient of the foodlogs,
+// foodlogs without the nutrient are left out rather than counted as zero.
+func nutrientToSqlite(n database.Nutrient) (string, string) {
+
+	expr := "json_extract(NUTRIENTS, '$." + string(n) + "')"
+
+	return expr, " AND " + expr + " IS NOT NULL"
+}
+
+// chartColumnToSqlite returns the table, value expression, and extra where clause for the chart column.
+func chartColumnToSqlite(col database.ChartColumn) (string, string, string) {
+
+	if n, ok := col.Nutrient(); ok {
+		colSQL, whereSQL := nutrientToSqlite(n)
+		return "PON_USER_FOODLOG", colSQL, whereSQL
+	}
+
+	switch col {
+	default:
+		panic("impossible chart column")
+
+	case database.ChartColumnCalories:
+		// TODO: don't hard code this and make it use the user's setting
+		return "PON_USER_FOODLOG", "PROTEIN * 4 + (CARB - FIBRE) * 4 + FAT * 9", ""
+	case database.ChartColumnNetCarbs:
+		return "PON_USER_FOODLOG", "CARB - FIBRE", ""
+	case database.ChartColumnFat:
+		return "PON_USER_FOODLOG", "FAT", ""
+	case database.ChartColumnCarbs:
+		return "PON_USER_FOODLOG", "CARB", ""
+	case database.ChartColumnFibre:
+		return "PON_USER_FOODLOG", "FIBRE", ""
+	case database.ChartColumnProtein:
+		return "PON_USER_FOODLOG", "PROTEIN", ""
+
+	case database.ChartColumnBodyWeightKg:
+		return "PON_USER_BODYLOG", "WEIGHT_KG", " AND WEIGHT_KG > 0"
+	case database.ChartColumnBodyWeightLbs:
+		return "PON_USER_BODYLOG", "WEIGHT_KG * 2.2046226218", " AND WEIGHT_KG > 0"
+	case database.ChartColumnBodyFatPercent:
+		return "PON_USER_BODYLOG", "BODY_FAT_PERCENT", " AND BODY_FAT_PERCENT > 0"
+	case database.ChartColumnBodyHeartRate:
+		return "PON_USER_BODYLOG", "HEART_RATE_BPM", " AND HEART_RATE_BPM > 0"
+	case database.ChartColumnBodySteps:
+		return "PON_USER_BODYLOG", "STEPS_COUNT", " AND STEPS_COUNT > 0"
+	case database.ChartColumnBodyBloodPressureSys:
+		return "PON_USER_BODYLOG", "BP_SYSTOLIC", " AND BP_SYSTOLIC > 0"
+	case database.ChartColumnBodyBloodPressureDia:
+		return "PON_USER_BODYLOG", "BP_DIASTOLIC", " AND BP_DIASTOLIC > 0"
+
+	case database.ChartColumnEventBloodSugar:
+		return "PON_USER_EVENTLOG", "BLOOD_GLUCOSE", " AND BLOOD_GLUCOSE > 0"
+	case database.ChartColumnEventInsulin:
+		return "PON_USER_EVENTLOG", "ACTUAL_INSULIN_TAKEN", " AND ACTUAL_INSULIN_TAKEN > 0"
+	case database.ChartColumnGlucose:
+		return "PON_USER_GLUCOSE", "GLUCOSE", ""
 	}
+}
 
-	sums := make(map[bucketKey]int64)
+// chartBucket accumulates the values of a single bucket for every aggregation function.
+type chartBucket struct {
+	sum   float64
+	min   float64
+	max   float64
+	count int
+}
 
-	for _, r := range rows {
+func (b *chartBucket) add(v float64) {
 
-		k := bucketKey{tag: r.Tag, bucket: truncateToBucket(r.StartTime.Time(), groupby)}
+	if b.count == 0 || v < b.min {
+		b.min = v
+	}
 
-		sums[k] += r.StopTime.Time().Sub(r.StartTime.Time()).Milliseconds()
+	if b.count == 0 || v > b.max {
+		b.max = v
 	}
 
-	points := make([]database.TimespanTagDurationPoint, 0, len(sums))
+	b.sum += v
+	b.count++
+}
 
-	for k, durationMilli := range sums {
-		points = append(points, database.TimespanTagDurationPoint{
-			Tag:           k.tag,
-			Bucket:        database.TimeMillis(k.bucket),
-			DurationMilli: durationMilli,
-		})
+func (b *chartBucket) value(aggregation database.AggregationFunc) float64 {
+
+	switch aggregation {
+	default:
+		panic("impossible aggregation function")
+	case database.AggregationSum:
+		return b.sum
+	case database.AggregationAvg:
+		return b.sum / float64(b.count)
+	case database.AggregationMin:
+		return b.min
+	case database.AggregationMax:
+		return b.max
+	}
+}
+
+func (db *SqliteDatabase) LoadUserChartData(
+	ctx context.Context,
+	userID int,
+	cols []database.ChartColumn,
+	aggregation database.AggregationFunc,
+	groupby database.GroupBy,
+	loc *time.Location,
+	shift time.Duration,
+	startTime time.Time,
+	endTime time.Time,
+	out *[]database.ChartPoint,
+) error {
+
+	if err := database.ValidateChartQuery(cols, aggregation, groupby); err != nil {
+		return err
 	}
 
-	sort.Slice(points, func(i, j int) bool {
-		return points[i].Bucket.Time().Before(points[j].Bucket.Time())
-	})
+	points := []database.ChartPoint{}
+
+	for _, col := range cols {
+
+		tableSQL, colSQL, whereSQL := chartColumnToSqlite(col)
+
+		query := `
+			SELECT
+				USER_TIME,
+				` + colSQL + ` AS VALUE
+			FROM ` + tableSQL + `
+			WHERE
+				USER_ID = $1
+				AND USER_TIME >= $2
+				AND USER_TIME <= $3
+			` + whereSQL
+
+		var rows []struct {
+			UserTime database.TimeMillis `db:"user_time"`
+			Value    float64             `db:"value"`
+		}
+
+		if err := db.SelectContext(ctx, &rows, query, userID, startTime.UTC(), endTime.UTC()); err != nil {
+			return err
+		}
+
+		buckets := make(map[time.Time]*chartBucket)
+
+		for _, r := range rows {
+
+			// Everything is one bucket, which starts with the range.
+			bucket := startTime
+
+			if groupby != database.GroupByOne {
+				bucket = database.TruncateToBucket(r.UserTime.Time(), groupby, loc, shift)
+			}
+
+			b, ok := buckets[bucket]
+
+			if !ok {
+				b = &chartBucket{}
+				buckets[bucket] = b
+			}
+
+			b.add(r.Value)
+		}
+
+		colPoints := make([]database.ChartPoint, 0, len(buckets))
+
+		for bucket, b := range buckets {
+			colPoints = append(colPoints, database.ChartPoint{
+				Column: col,
+				Bucket: database.TimeMillis(bucket),
+				Value:  b.value(aggregation),
+			})
+		}
+
+		sort.Slice(colPoints, func(i, j int) bool {
+			return colPoints[i].Bucket.Time().Before(colPoints[j].Bucket.Time())
+		})
+
+		points = append(points, colPoints...)
+	}
 
 	*out = points
 
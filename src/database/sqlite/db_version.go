@@ -44,9 +44,9 @@ func (db *SqliteDatabase) SetVersion(ctx context.Context, version database.Versi
 	return err
 }
 
-func (db *SqliteDatabase) ExportVersionCSV(ctx context.Context, w io.Writer) error {
+func (db *SqliteDatabase) ExportVersionCSV(ctx context.Context, tz database.Timezone, w io.Writer) error {
 
 	query := `SELECT * FROM PON_CONFIG`
 
-	return db.ExportQueryRowsAsCsv(ctx, query, w)
+	return db.ExportQueryRowsAsCsv(ctx, query, tz, w)
 }
@@ -17,6 +17,23 @@ var sqliteUpMigrations = []database.Migration{
 	database.NewFileMigration(6, 7, "sqlite/0008_user_photo"),
 	database.NewFileMigration(7, 8, "sqlite/0009_user_settings"),
 	database.NewFileMigration(8, 9, "sqlite/0010_user_settings"),
+	database.NewFileMigration(9, 10, "sqlite/0011_medication"),
+	database.NewFileMigration(10, 11, "sqlite/0012_user_photo_created"),
+	database.NewFileMigration(11, 12, "sqlite/0013_user_photo_storage"),
+	database.NewFileMigration(12, 13, "sqlite/0014_user_photo_thumbnail"),
+	database.NewFileMigration(13, 14, "sqlite/0015_user_timezone"),
+	database.NewFileMigration(14, 15, "sqlite/0016_user_insulin_action"),
+	database.NewFileMigration(15, 16, "sqlite/0017_insulin_profile"),
+	database.NewFileMigration(16, 17, "sqlite/0018_eventlog_extended_bolus"),
+	database.NewFileMigration(17, 18, "sqlite/0019_user_glucose"),
+	database.NewFileMigration(18, 19, "sqlite/0020_user_nightscout"),
+	database.NewFileMigration(19, 20, "sqlite/0021_user_glucose_unit"),
+	database.NewFileMigration(20, 21, "sqlite/0022_nutrients"),
+	database.NewFileMigration(21, 22, "sqlite/0023_recipe"),
+	database.NewFileMigration(22, 23, "sqlite/0024_food_units"),
+	database.NewFileMigration(23, 24, "sqlite/0025_data_source_food_serving"),
+	database.NewFileMigration(24, 25, "sqlite/0026_data_source_food_barcode"),
+	database.NewFileMigration(25, 26, "sqlite/0027_eventlog_notes"),
 }
 
 func (db *SqliteDatabase) GetMigrationMaxVersion() database.Version {
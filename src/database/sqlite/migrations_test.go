@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"testing"
+	"time"
 
 	"karopon/src/database"
 
@@ -301,4 +302,526 @@ func TestSqliteMigrations(t *testing.T) {
 		).Scan(&mappingCount))
 		assert.Equal(t, 0, mappingCount, "mapping rows must be cascade-deleted with their eventlog")
 	})
+
+	// 0009_user_settings, 0010_user_settings: 7 → 9
+	// User setting columns only, no schema changes the later tests depend on.
+	t.Run("0009_0010_user_settings", func(t *testing.T) {
+		ver, err := database.RunUpMigrations(ctx, conn, 7, sqliteUpMigrations[8:10])
+		require.NoError(t, err)
+		assert.Equal(t, database.Version(9), ver)
+	})
+
+	// 0011_medication: 9 → 10
+	// Creates PON_USER_MEDICATION, PON_USER_MEDICATION_SCHEDULE and PON_USER_MEDICATIONLOG,
+	// each cascading from the row above it.
+	t.Run("0011_medication", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 9, sqliteUpMigrations[10:11])
+		require.NoError(t, err)
+
+		res, err := conn.ExecContext(ctx,
+			`INSERT INTO PON_USER_MEDICATION (USER_ID, NAME) VALUES (?, 'Metformin')`, userID)
+		require.NoError(t, err)
+		medID, err := res.LastInsertId()
+		require.NoError(t, err)
+
+		// Unset columns must get their defaults rather than NULL.
+		var category, notes string
+		var dosage float64
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT CATEGORY, DOSAGE_AMOUNT, NOTES FROM PON_USER_MEDICATION WHERE ID = ?`, medID,
+		).Scan(&category, &dosage, &notes))
+		assert.Empty(t, category)
+		assert.Zero(t, dosage)
+		assert.Empty(t, notes)
+
+		// Masks must hold the full 60 bits of the minutes mask.
+		res, err = conn.ExecContext(ctx, `
+			INSERT INTO PON_USER_MEDICATION_SCHEDULE (MEDICATION_ID, MINUTES_OF_HOUR_MASK, MONTH_OF_YEAR_MASK)
+			VALUES (?, ?, 4095)`, medID, int64(1)<<59)
+		require.NoError(t, err)
+		scheduleID, err := res.LastInsertId()
+		require.NoError(t, err)
+
+		var minutes int64
+		var reminder bool
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT MINUTES_OF_HOUR_MASK, REMINDER_ENABLED FROM PON_USER_MEDICATION_SCHEDULE WHERE ID = ?`, scheduleID,
+		).Scan(&minutes, &reminder))
+		assert.Equal(t, int64(1)<<59, minutes)
+		assert.True(t, reminder, "REMINDER_ENABLED must default to true")
+
+		_, err = conn.ExecContext(ctx,
+			`INSERT INTO PON_USER_MEDICATIONLOG (SCHEDULE_ID, TAKEN_TIME, TAKEN) VALUES (?, datetime('now'), 1)`,
+			scheduleID)
+		require.NoError(t, err)
+
+		// FK on SCHEDULE_ID must reject a non-existent schedule.
+		_, err = conn.ExecContext(ctx,
+			`INSERT INTO PON_USER_MEDICATIONLOG (SCHEDULE_ID, TAKEN_TIME, TAKEN) VALUES (99999, datetime('now'), 1)`)
+		require.Error(t, err, "FK violation on SCHEDULE_ID should be rejected")
+
+		// Deleting the medication must remove its schedules and logs.
+		_, err = conn.ExecContext(ctx, `DELETE FROM PON_USER_MEDICATION WHERE ID = ?`, medID)
+		require.NoError(t, err)
+
+		var count int
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM PON_USER_MEDICATIONLOG WHERE SCHEDULE_ID = ?`, scheduleID,
+		).Scan(&count))
+		assert.Equal(t, 0, count, "medication logs must be cascade-deleted with their medication")
+	})
+
+	// 0012_user_photo_created: 10 → 11
+	// Adds CREATED to PON_USER_PHOTO, existing photos get the migration time.
+	t.Run("0012_user_photo_created", func(t *testing.T) {
+		res, err := conn.ExecContext(ctx,
+			`INSERT INTO PON_USER_PHOTO (USER_ID, DATA) VALUES (?, X'01')`, userID)
+		require.NoError(t, err)
+		photoID, err := res.LastInsertId()
+		require.NoError(t, err)
+
+		_, err = database.RunUpMigrations(ctx, conn, 10, sqliteUpMigrations[11:12])
+		require.NoError(t, err)
+
+		var created time.Time
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT CREATED FROM PON_USER_PHOTO WHERE ID = ?`, photoID,
+		).Scan(&created))
+		assert.WithinDuration(t, time.Now(), created, time.Minute)
+	})
+	// 0013_user_photo_storage: 11 → 12
+	// Adds STORAGE and HASH to PON_USER_PHOTO, existing photos stay in the database.
+	t.Run("0013_user_photo_storage", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 11, sqliteUpMigrations[12:13])
+		require.NoError(t, err)
+
+		var storage, hash string
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT STORAGE, HASH FROM PON_USER_PHOTO LIMIT 1`,
+		).Scan(&storage, &hash))
+		assert.Equal(t, "db", storage)
+		assert.Empty(t, hash)
+	})
+
+	// 0014_user_photo_thumbnail: 12 → 13
+	// Adds THUMBNAIL, CONTENT_TYPE, WIDTH and HEIGHT to PON_USER_PHOTO, existing photos get empty values.
+	t.Run("0014_user_photo_thumbnail", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 12, sqliteUpMigrations[13:14])
+		require.NoError(t, err)
+
+		var thumb []byte
+		var contentType string
+		var width, height int
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT THUMBNAIL, CONTENT_TYPE, WIDTH, HEIGHT FROM PON_USER_PHOTO LIMIT 1`,
+		).Scan(&thumb, &contentType, &width, &height))
+		assert.Empty(t, thumb)
+		assert.Empty(t, contentType)
+		assert.Zero(t, width)
+		assert.Zero(t, height)
+	})
+
+	// 0015_user_timezone: 13 → 14
+	// Adds TIMEZONE TEXT NOT NULL DEFAULT 'UTC' to PON_USER.
+	t.Run("0015_user_timezone", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 13, sqliteUpMigrations[14:15])
+		require.NoError(t, err)
+
+		var zone string
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT TIMEZONE FROM PON_USER WHERE ID = ?`, userID,
+		).Scan(&zone))
+		assert.Equal(t, "UTC", zone)
+	})
+
+	// 0016_user_insulin_action: 14 → 15
+	// Adds INSULIN_ACTION_CURVE, INSULIN_DURATION_MINUTES and INSULIN_PEAK_MINUTES to PON_USER.
+	t.Run("0016_user_insulin_action", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 14, sqliteUpMigrations[15:16])
+		require.NoError(t, err)
+
+		var curve string
+		var duration, peak int
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT INSULIN_ACTION_CURVE, INSULIN_DURATION_MINUTES, INSULIN_PEAK_MINUTES FROM PON_USER WHERE ID = ?`,
+			userID,
+		).Scan(&curve, &duration, &peak))
+		assert.Equal(t, "exponential", curve)
+		assert.Equal(t, 300, duration)
+		assert.Equal(t, 75, peak)
+	})
+
+	// 0017_insulin_profile: 15 → 16
+	// Creates PON_USER_INSULIN_PROFILE.
+	t.Run("0017_insulin_profile", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 15, sqliteUpMigrations[16:17])
+		require.NoError(t, err)
+
+		insert := `
+			INSERT INTO PON_USER_INSULIN_PROFILE (
+				USER_ID, START_MINUTE, INSULIN_TO_CARB_RATIO, INSULIN_SENSITIVITY_FACTOR, TARGET_BLOOD_SUGAR
+			) VALUES (?, 360, 1.5, 2, 6)
+		`
+		_, err = conn.ExecContext(ctx, insert, userID)
+		require.NoError(t, err)
+
+		// One block per start minute.
+		_, err = conn.ExecContext(ctx, insert, userID)
+		require.Error(t, err)
+
+		var ratio float64
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT INSULIN_TO_CARB_RATIO FROM PON_USER_INSULIN_PROFILE WHERE USER_ID = ?`, userID,
+		).Scan(&ratio))
+		assert.Equal(t, 1.5, ratio)
+	})
+
+	// 0018_eventlog_extended_bolus: 16 → 17
+	// Adds the fat-protein units and extended bolus columns to PON_USER_EVENTLOG.
+	t.Run("0018_eventlog_extended_bolus", func(t *testing.T) {
+		res, err := conn.ExecContext(ctx,
+			`INSERT INTO PON_USER_EVENT (USER_ID, NAME) VALUES (?, 'Pizza')`, userID)
+		require.NoError(t, err)
+		eventID, _ := res.LastInsertId()
+
+		res, err = conn.ExecContext(ctx, `
+			INSERT INTO PON_USER_EVENTLOG
+				(USER_ID, EVENT_ID, USER_TIME, EVENT,
+				 NET_CARBS, BLOOD_GLUCOSE, INSULIN_SENSITIVITY_FACTOR,
+				 INSULIN_TO_CARB_RATIO, BLOOD_GLUCOSE_TARGET,
+				 RECOMMENDED_INSULIN_AMOUNT, ACTUAL_INSULIN_TAKEN)
+			VALUES (?, ?, datetime('now'), 'Pizza', 0, 0, 0, 0, 0, 0, 0)`,
+			userID, eventID)
+		require.NoError(t, err)
+		eventlogID, _ := res.LastInsertId()
+
+		_, err = database.RunUpMigrations(ctx, conn, 16, sqliteUpMigrations[17:18])
+		require.NoError(t, err)
+
+		var fpu, recommended, taken float64
+		var recommendedMinutes, takenMinutes int
+		require.NoError(t, conn.QueryRowContext(ctx, `
+			SELECT FAT_PROTEIN_UNITS, RECOMMENDED_EXTENDED_INSULIN_AMOUNT, RECOMMENDED_EXTENDED_MINUTES,
+				ACTUAL_EXTENDED_INSULIN_TAKEN, ACTUAL_EXTENDED_MINUTES
+			FROM PON_USER_EVENTLOG WHERE ID = ?
+		`, eventlogID).Scan(&fpu, &recommended, &recommendedMinutes, &taken, &takenMinutes))
+		assert.Zero(t, fpu)
+		assert.Zero(t, recommended)
+		assert.Zero(t, recommendedMinutes)
+		assert.Zero(t, taken)
+		assert.Zero(t, takenMinutes)
+	})
+
+	// 0019_user_glucose: 17 → 18
+	// Creates PON_USER_GLUCOSE.
+	t.Run("0019_user_glucose", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 17, sqliteUpMigrations[18:19])
+		require.NoError(t, err)
+
+		insert := `INSERT INTO PON_USER_GLUCOSE (USER_ID, USER_TIME, GLUCOSE) VALUES (?, datetime('2026-01-01 08:00:00'), 6.2)`
+		_, err = conn.ExecContext(ctx, insert, userID)
+		require.NoError(t, err)
+
+		// One reading per user and time.
+		_, err = conn.ExecContext(ctx, insert, userID)
+		require.Error(t, err)
+
+		var source string
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT SOURCE FROM PON_USER_GLUCOSE WHERE USER_ID = ?`, userID,
+		).Scan(&source))
+		assert.Empty(t, source)
+	})
+
+	// 0020_user_nightscout: 18 → 19
+	// Creates PON_USER_NIGHTSCOUT.
+	t.Run("0020_user_nightscout", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 18, sqliteUpMigrations[19:20])
+		require.NoError(t, err)
+
+		_, err = conn.ExecContext(ctx, `INSERT INTO PON_USER_NIGHTSCOUT (USER_ID, API_SECRET_HASH) VALUES (?, 'abc')`, userID)
+		require.NoError(t, err)
+
+		// One secret per user.
+		_, err = conn.ExecContext(ctx, `INSERT INTO PON_USER_NIGHTSCOUT (USER_ID, API_SECRET_HASH) VALUES (?, 'def')`, userID)
+		require.Error(t, err)
+
+		var hash string
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT API_SECRET_HASH FROM PON_USER_NIGHTSCOUT WHERE USER_ID = ?`, userID,
+		).Scan(&hash))
+		assert.Equal(t, "abc", hash)
+	})
+
+	// 0021_user_glucose_unit: 19 → 20
+	// Adds GLUCOSE_UNIT to PON_USER and converts glucose entered in mg/dL to mmol/L.
+	t.Run("0021_user_glucose_unit", func(t *testing.T) {
+		res, err := conn.ExecContext(ctx, `
+			INSERT INTO PON_USER (NAME, PASSWORD, TARGET_BLOOD_SUGAR, INSULIN_SENSITIVITY_FACTOR)
+			VALUES ('bob', X'010203', 100, 50)`)
+		require.NoError(t, err)
+		bobID, _ := res.LastInsertId()
+
+		res, err = conn.ExecContext(ctx, `INSERT INTO PON_USER_EVENT (USER_ID, NAME) VALUES (?, 'Lunch')`, bobID)
+		require.NoError(t, err)
+		eventID, _ := res.LastInsertId()
+
+		res, err = conn.ExecContext(ctx, `
+			INSERT INTO PON_USER_EVENTLOG
+				(USER_ID, EVENT_ID, USER_TIME, EVENT,
+				 NET_CARBS, BLOOD_GLUCOSE, INSULIN_SENSITIVITY_FACTOR,
+				 INSULIN_TO_CARB_RATIO, BLOOD_GLUCOSE_TARGET,
+				 RECOMMENDED_INSULIN_AMOUNT, ACTUAL_INSULIN_TAKEN)
+			VALUES (?, ?, datetime('now'), 'Lunch', 40, 180, 50, 10, 100, 0, 0)`,
+			bobID, eventID)
+		require.NoError(t, err)
+		eventlogID, _ := res.LastInsertId()
+
+		// A hypo, low enough to look like mmol/L.
+		res, err = conn.ExecContext(ctx, `
+			INSERT INTO PON_USER_EVENTLOG
+				(USER_ID, EVENT_ID, USER_TIME, EVENT,
+				 NET_CARBS, BLOOD_GLUCOSE, INSULIN_SENSITIVITY_FACTOR,
+				 INSULIN_TO_CARB_RATIO, BLOOD_GLUCOSE_TARGET,
+				 RECOMMENDED_INSULIN_AMOUNT, ACTUAL_INSULIN_TAKEN)
+			VALUES (?, ?, datetime('now'), 'Lunch', 15, 30, 50, 10, 100, 0, 0)`,
+			bobID, eventID)
+		require.NoError(t, err)
+		hypoID, _ := res.LastInsertId()
+
+		_, err = conn.ExecContext(ctx, `
+			INSERT INTO PON_USER_GOAL (USER_ID, NAME, TARGET_VALUE, TARGET_COL, AGGREGATION_TYPE, VALUE_COMPARISON, TIME_EXPR)
+			VALUES (?, 'avg bg', 140, 'BLOOD_SUGAR', 'AVG', 'LESS_THAN', 'DAILY')`, bobID)
+		require.NoError(t, err)
+
+		// Readings are stored in mmol/L whatever the user's unit, e.g. from a CGM import.
+		_, err = conn.ExecContext(ctx, `
+			INSERT INTO PON_USER_GLUCOSE (USER_ID, USER_TIME, GLUCOSE) VALUES
+				(?, datetime('2026-01-01 08:00:00'), 5.5),
+				(?, datetime('2026-01-01 08:05:00'), 10.1)`, bobID, bobID)
+		require.NoError(t, err)
+
+		// An mmol/L user's goal can be over 35 without being in mg/dL.
+		_, err = conn.ExecContext(ctx, `
+			INSERT INTO PON_USER_GOAL (USER_ID, NAME, TARGET_VALUE, TARGET_COL, AGGREGATION_TYPE, VALUE_COMPARISON, TIME_EXPR)
+			VALUES (?, 'total bg', 60, 'BLOOD_SUGAR', 'SUM', 'LESS_THAN', 'DAILY')`, userID)
+		require.NoError(t, err)
+
+		_, err = database.RunUpMigrations(ctx, conn, 19, sqliteUpMigrations[20:21])
+		require.NoError(t, err)
+
+		var unit string
+		var target, isf float64
+		query := `SELECT GLUCOSE_UNIT, TARGET_BLOOD_SUGAR, INSULIN_SENSITIVITY_FACTOR FROM PON_USER WHERE ID = ?`
+
+		// mmol/L users are left alone.
+		require.NoError(t, conn.QueryRowContext(ctx, query, userID).Scan(&unit, &target, &isf))
+		assert.Equal(t, "mmol/L", unit)
+		assert.InDelta(t, 5.6, target, 1e-9)
+		assert.InDelta(t, 3, isf, 1e-9)
+
+		require.NoError(t, conn.QueryRowContext(ctx, query, bobID).Scan(&unit, &target, &isf))
+		assert.Equal(t, "mg/dL", unit)
+		assert.InDelta(t, 100/database.MgdlPerMmol, target, 1e-9)
+		assert.InDelta(t, 50/database.MgdlPerMmol, isf, 1e-9)
+
+		var bg, bgTarget float64
+		require.NoError(t, conn.QueryRowContext(ctx, `
+			SELECT BLOOD_GLUCOSE, BLOOD_GLUCOSE_TARGET, INSULIN_SENSITIVITY_FACTOR FROM PON_USER_EVENTLOG WHERE ID = ?
+		`, eventlogID).Scan(&bg, &bgTarget, &isf))
+		assert.InDelta(t, 180/database.MgdlPerMmol, bg, 1e-9)
+		assert.InDelta(t, 100/database.MgdlPerMmol, bgTarget, 1e-9)
+		assert.InDelta(t, 50/database.MgdlPerMmol, isf, 1e-9)
+
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT BLOOD_GLUCOSE FROM PON_USER_EVENTLOG WHERE ID = ?`, hypoID,
+		).Scan(&bg))
+		assert.InDelta(t, 30/database.MgdlPerMmol, bg, 1e-9)
+
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT TARGET_VALUE FROM PON_USER_GOAL WHERE USER_ID = ?`, bobID,
+		).Scan(&target))
+		assert.InDelta(t, 140/database.MgdlPerMmol, target, 1e-9)
+
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT TARGET_VALUE FROM PON_USER_GOAL WHERE USER_ID = ?`, userID,
+		).Scan(&target))
+		assert.InDelta(t, 60, target, 1e-9)
+
+		// The reading from 0019 was already in mmol/L.
+		var glucose float64
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT GLUCOSE FROM PON_USER_GLUCOSE WHERE USER_ID = ?`, userID,
+		).Scan(&glucose))
+		assert.InDelta(t, 6.2, glucose, 1e-9)
+
+		// So are the readings of the mg/dL user.
+		rows, err := conn.QueryContext(ctx,
+			`SELECT GLUCOSE FROM PON_USER_GLUCOSE WHERE USER_ID = ? ORDER BY USER_TIME`, bobID)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var readings []float64
+		for rows.Next() {
+			require.NoError(t, rows.Scan(&glucose))
+			readings = append(readings, glucose)
+		}
+		require.NoError(t, rows.Err())
+		assert.InDeltaSlice(t, []float64{5.5, 10.1}, readings, 1e-9)
+	})
+
+	// 0022_nutrients: 20 → 21
+	// Adds NUTRIENTS to PON_USER_FOOD, PON_USER_FOODLOG and PON_DATA_SOURCE_FOOD.
+	t.Run("0022_nutrients", func(t *testing.T) {
+		res, err := conn.ExecContext(ctx, `
+			INSERT INTO PON_USER_FOOD (USER_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT)
+			VALUES (?, 'Toast', 'slice', 1, 3, 14, 1, 1)`, userID)
+		require.NoError(t, err)
+		foodID, _ := res.LastInsertId()
+
+		_, err = database.RunUpMigrations(ctx, conn, 20, sqliteUpMigrations[21:22])
+		require.NoError(t, err)
+
+		// Existing foods have no nutrients.
+		var nutrients database.Nutrients
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT NUTRIENTS FROM PON_USER_FOOD WHERE ID = ?`, foodID,
+		).Scan(&nutrients))
+		assert.Nil(t, nutrients)
+
+		_, err = conn.ExecContext(ctx,
+			`UPDATE PON_USER_FOOD SET NUTRIENTS = ? WHERE ID = ?`, database.Nutrients{database.NutrientSodium: 150}, foodID)
+		require.NoError(t, err)
+
+		var sodium float64
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT json_extract(NUTRIENTS, '$.sodium') FROM PON_USER_FOOD WHERE ID = ?`, foodID,
+		).Scan(&sodium))
+		assert.InDelta(t, 150, sodium, 1e-9)
+	})
+
+	// 0023_recipe: 21 → 22
+	// Creates PON_USER_RECIPE and PON_USER_RECIPE_INGREDIENT, and adds RECIPE_ID to PON_USER_FOODLOG.
+	t.Run("0023_recipe", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 21, sqliteUpMigrations[22:23])
+		require.NoError(t, err)
+
+		// Existing foodlogs are not from a recipe.
+		var fromRecipe int
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT COUNT(RECIPE_ID) FROM PON_USER_FOODLOG`,
+		).Scan(&fromRecipe))
+		assert.Zero(t, fromRecipe)
+
+		res, err := conn.ExecContext(ctx,
+			`INSERT INTO PON_USER_RECIPE (USER_ID, NAME, UNIT, YIELD) VALUES (?, 'Soup', 'bowl', 4)`, userID)
+		require.NoError(t, err)
+		id, _ := res.LastInsertId()
+
+		var foodID int
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT ID FROM PON_USER_FOOD WHERE USER_ID = ? LIMIT 1`, userID,
+		).Scan(&foodID))
+
+		_, err = conn.ExecContext(ctx,
+			`INSERT INTO PON_USER_RECIPE_INGREDIENT (RECIPE_ID, FOOD_ID, PORTION) VALUES (?, ?, 100)`, id, foodID)
+		require.NoError(t, err)
+
+		// An ingredient is either a user food or a data source food.
+		_, err = conn.ExecContext(ctx,
+			`INSERT INTO PON_USER_RECIPE_INGREDIENT (RECIPE_ID, PORTION) VALUES (?, 100)`, id)
+		require.Error(t, err)
+
+		// A food in a recipe can't be deleted.
+		_, err = conn.ExecContext(ctx, `DELETE FROM PON_USER_FOOD WHERE ID = ?`, foodID)
+		require.Error(t, err)
+
+		// Ingredients go with the recipe.
+		_, err = conn.ExecContext(ctx, `DELETE FROM PON_USER_RECIPE WHERE ID = ?`, id)
+		require.NoError(t, err)
+
+		var count int
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM PON_USER_RECIPE_INGREDIENT WHERE RECIPE_ID = ?`, id,
+		).Scan(&count))
+		assert.Zero(t, count)
+	})
+
+	t.Run("0024_food_units", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 22, sqliteUpMigrations[23:24])
+		require.NoError(t, err)
+
+		// Existing foods have no density or household units.
+		var density float64
+		var household string
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT DENSITY, HOUSEHOLD_UNITS FROM PON_USER_FOOD WHERE USER_ID = ? LIMIT 1`, userID,
+		).Scan(&density, &household))
+		assert.Zero(t, density)
+		assert.Equal(t, "{}", household)
+	})
+
+	t.Run("0025_data_source_food_serving", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 23, sqliteUpMigrations[24:25])
+		require.NoError(t, err)
+
+		res, err := conn.ExecContext(ctx, `INSERT INTO PON_DATA_SOURCE (NAME) VALUES ('FDC')`)
+		require.NoError(t, err)
+		dsID, _ := res.LastInsertId()
+
+		res, err = conn.ExecContext(ctx,
+			`INSERT INTO PON_DATA_SOURCE_FOOD (DATA_SOURCE_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT)
+			 VALUES (?, 'Carrots', 'g', 100, 0.9, 9.6, 2.8, 0.2)`, dsID)
+		require.NoError(t, err)
+		foodID, _ := res.LastInsertId()
+
+		_, err = conn.ExecContext(ctx, `
+			INSERT INTO PON_DATA_SOURCE_FOOD_SERVING (DATA_SOURCE_FOOD_ID, NAME, AMOUNT, UNIT, PORTION)
+			VALUES (?, '1 cup, chopped', 1, 'cup, chopped', 128)`, foodID)
+		require.NoError(t, err)
+
+		// Servings go with the food.
+		_, err = conn.ExecContext(ctx, `DELETE FROM PON_DATA_SOURCE_FOOD WHERE ID = ?`, foodID)
+		require.NoError(t, err)
+
+		var count int
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM PON_DATA_SOURCE_FOOD_SERVING`,
+		).Scan(&count))
+		assert.Zero(t, count)
+	})
+
+	t.Run("0026_data_source_food_barcode", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 24, sqliteUpMigrations[25:26])
+		require.NoError(t, err)
+
+		res, err := conn.ExecContext(ctx, `INSERT INTO PON_DATA_SOURCE (NAME) VALUES ('Branded')`)
+		require.NoError(t, err)
+		dsID, _ := res.LastInsertId()
+
+		_, err = conn.ExecContext(ctx,
+			`INSERT INTO PON_DATA_SOURCE_FOOD (DATA_SOURCE_ID, NAME, UNIT, PORTION, PROTEIN, CARB, FIBRE, FAT)
+			 VALUES (?, 'Apple', 'g', 100, 0.3, 14, 2.4, 0.2)`, dsID)
+		require.NoError(t, err)
+
+		// Existing foods have no barcode.
+		var count int
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM PON_DATA_SOURCE_FOOD WHERE BARCODE <> ''`,
+		).Scan(&count))
+		assert.Zero(t, count)
+	})
+
+	t.Run("0027_eventlog_notes", func(t *testing.T) {
+		_, err := database.RunUpMigrations(ctx, conn, 25, sqliteUpMigrations[26:27])
+		require.NoError(t, err)
+
+		// Existing eventlogs have no notes.
+		var total, count int
+		require.NoError(t, conn.QueryRowContext(ctx,
+			`SELECT COUNT(*), COUNT(CASE WHEN NOTES = '' THEN 1 END) FROM PON_USER_EVENTLOG`,
+		).Scan(&total, &count))
+		assert.NotZero(t, total)
+		assert.Equal(t, total, count)
+	})
 }
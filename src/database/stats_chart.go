@@ -0,0 +1,93 @@
+package database
+
+import "errors"
+
+// ChartColumn defines the values which can be charted over time.
+// Like GoalTargetColumn these are not real columns, they are mapped to columns / calculated values by the database
+// implementation. Every nutrient also has a column, see GoalTargetColumn.
+type ChartColumn string
+
+const (
+	ChartColumnCalories             ChartColumn = "CALORIES"
+	ChartColumnNetCarbs             ChartColumn = "NET_CARBS"
+	ChartColumnFat                  ChartColumn = "FAT"
+	ChartColumnCarbs                ChartColumn = "CARBS"
+	ChartColumnFibre                ChartColumn = "FIBRE"
+	ChartColumnProtein              ChartColumn = "PROTEIN"
+	ChartColumnBodyWeightKg         ChartColumn = "BODY_WEIGHT_KG"
+	ChartColumnBodyWeightLbs        ChartColumn = "BODY_WEIGHT_LBS"
+	ChartColumnBodyFatPercent       ChartColumn = "BODY_FAT_PERCENT"
+	ChartColumnBodyHeartRate        ChartColumn = "HEART_RATE"
+	ChartColumnBodySteps            ChartColumn = "STEPS"
+	ChartColumnBodyBloodPressureSys ChartColumn = "BLOOD_PRESSURE_SYS"
+	ChartColumnBodyBloodPressureDia ChartColumn = "BLOOD_PRESSURE_DIA"
+	ChartColumnEventBloodSugar      ChartColumn = "BLOOD_SUGAR"
+	ChartColumnEventInsulin         ChartColumn = "INSULIN"
+	ChartColumnGlucose              ChartColumn = "GLUCOSE"
+)
+
+var (
+	ErrInvalidChartColumn = errors.New("invalid chart column")
+)
+
+func (c ChartColumn) IsValid() bool {
+	switch c {
+	case
+		ChartColumnCalories,
+		ChartColumnNetCarbs,
+		ChartColumnFat,
+		ChartColumnCarbs,
+		ChartColumnFibre,
+		ChartColumnProtein,
+		ChartColumnBodyWeightKg,
+		ChartColumnBodyWeightLbs,
+		ChartColumnBodyFatPercent,
+		ChartColumnBodyHeartRate,
+		ChartColumnBodySteps,
+		ChartColumnBodyBloodPressureSys, ChartColumnBodyBloodPressureDia,
+		ChartColumnEventBloodSugar,
+		ChartColumnEventInsulin,
+		ChartColumnGlucose:
+		return true
+	default:
+		_, ok := c.Nutrient()
+		return ok
+	}
+}
+
+// Nutrient returns the nutrient of a NUTRIENT_ column, like NUTRIENT_SODIUM, false for any other column.
+func (c ChartColumn) Nutrient() (Nutrient, bool) {
+	return nutrientFromColumn(string(c))
+}
+
+// ChartPoint is the aggregated value of a column over a single bucket of time.
+type ChartPoint struct {
+	Column ChartColumn `json:"column" db:"column"`
+	Bucket TimeMillis  `json:"bucket" db:"bucket"`
+	Value  float64     `json:"value"  db:"value"`
+}
+
+// IsGlucose returns if the column is a glucose value, which is stored in mmol/L.
+func (c ChartColumn) IsGlucose() bool {
+	return c == ChartColumnEventBloodSugar || c == ChartColumnGlucose
+}
+
+// ValidateChartQuery checks the columns, aggregation and bucket size of a chart query.
+func ValidateChartQuery(cols []ChartColumn, aggregation AggregationFunc, groupby GroupBy) error {
+
+	for _, c := range cols {
+		if !c.IsValid() {
+			return ErrInvalidChartColumn
+		}
+	}
+
+	if !aggregation.IsValid() {
+		return ErrInvalidAggregation
+	}
+
+	if !groupby.IsValid() {
+		return ErrInvalidGroupBy
+	}
+
+	return nil
+}
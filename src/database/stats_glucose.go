@@ -0,0 +1,148 @@
+package database
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// MgdlPerMmol converts glucose from mmol/L to mg/dL.
+const MgdlPerMmol = 18.0182
+
+// The consensus target range for CGM time-in-range, in mmol/L.
+const (
+	DefaultGlucoseRangeLow  = 3.9
+	DefaultGlucoseRangeHigh = 10.0
+)
+
+// GlucoseMetrics summarizes the glucose readings over a period, in the style of a CGM report.
+// The time in, below and above range are percentages of the readings.
+type GlucoseMetrics struct {
+	Count     int     `json:"count"`
+	RangeLow  float64 `json:"range_low"`
+	RangeHigh float64 `json:"range_high"`
+
+	TimeBelowRange float64 `json:"time_below_range"`
+	TimeInRange    float64 `json:"time_in_range"`
+	TimeAboveRange float64 `json:"time_above_range"`
+
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+	// The standard deviation as a percentage of the mean.
+	CoefficientOfVariation float64 `json:"coefficient_of_variation"`
+	// Glucose management indicator, the estimated A1c as a percentage.
+	GMI float64 `json:"gmi"`
+}
+
+// CalculateGlucoseMetrics calculates the metrics for the readings, with the range low <= glucose <= high counted in range.
+// Without readings every metric is zero.
+func CalculateGlucoseMetrics(readings []TblUserGlucose, low, high float64) GlucoseMetrics {
+
+	out := GlucoseMetrics{
+		Count:     len(readings),
+		RangeLow:  low,
+		RangeHigh: high,
+	}
+
+	if len(readings) == 0 {
+		return out
+	}
+
+	var below, above, sum float64
+
+	for _, r := range readings {
+
+		sum += r.Glucose
+
+		switch {
+		case r.Glucose < low:
+			below++
+		case r.Glucose > high:
+			above++
+		}
+	}
+
+	n := float64(len(readings))
+
+	out.TimeBelowRange = below / n * 100
+	out.TimeAboveRange = above / n * 100
+	out.TimeInRange = 100 - out.TimeBelowRange - out.TimeAboveRange
+
+	out.Mean = sum / n
+
+	if len(readings) > 1 {
+
+		var squares float64
+
+		for _, r := range readings {
+			squares += (r.Glucose - out.Mean) * (r.Glucose - out.Mean)
+		}
+
+		out.StdDev = math.Sqrt(squares / (n - 1))
+	}
+
+	if out.Mean > 0 {
+		out.CoefficientOfVariation = out.StdDev / out.Mean * 100
+	}
+
+	out.GMI = 3.31 + 0.02392*out.Mean*MgdlPerMmol
+
+	return out
+}
+
+// GlucoseHourPercentiles are the percentiles of the readings taken during one hour of the day,
+// a row of an ambulatory glucose profile (AGP).
+type GlucoseHourPercentiles struct {
+	Hour  int     `json:"hour"`
+	Count int     `json:"count"`
+	P5    float64 `json:"p5"`
+	P25   float64 `json:"p25"`
+	P50   float64 `json:"p50"`
+	P75   float64 `json:"p75"`
+	P95   float64 `json:"p95"`
+}
+
+// GlucoseHourlyProfile groups the readings by the hour of the day in loc, ignoring the date,
+// and returns the percentiles for each of the 24 hours. Hours without readings are all zero.
+func GlucoseHourlyProfile(readings []TblUserGlucose, loc *time.Location) []GlucoseHourPercentiles {
+
+	var hours [24][]float64
+
+	for _, r := range readings {
+		h := r.UserTime.Time().In(loc).Hour()
+		hours[h] = append(hours[h], r.Glucose)
+	}
+
+	out := make([]GlucoseHourPercentiles, 24)
+
+	for h, values := range hours {
+
+		sort.Float64s(values)
+
+		out[h] = GlucoseHourPercentiles{
+			Hour:  h,
+			Count: len(values),
+			P5:    percentile(values, 5),
+			P25:   percentile(values, 25),
+			P50:   percentile(values, 50),
+			P75:   percentile(values, 75),
+			P95:   percentile(values, 95),
+		}
+	}
+
+	return out
+}
+
+// percentile returns the p-th percentile of the sorted values, interpolating between the closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func glucoseReadings(start time.Time, step time.Duration, values ...float64) []TblUserGlucose {
+
+	out := make([]TblUserGlucose, len(values))
+
+	for i, v := range values {
+		out[i] = TblUserGlucose{UserTime: TimeMillis(start.Add(time.Duration(i) * step)), Glucose: v}
+	}
+
+	return out
+}
+
+func TestCalculateGlucoseMetrics(t *testing.T) {
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	readings := glucoseReadings(start, 5*time.Minute, 3.0, 3.9, 5, 7, 10, 12, 6, 8, 9, 4)
+
+	m := CalculateGlucoseMetrics(readings, DefaultGlucoseRangeLow, DefaultGlucoseRangeHigh)
+	assert.Equal(t, 10, m.Count)
+	assert.InDelta(t, 10, m.TimeBelowRange, 1e-9)
+	assert.InDelta(t, 80, m.TimeInRange, 1e-9)
+	assert.InDelta(t, 10, m.TimeAboveRange, 1e-9)
+	assert.InDelta(t, 6.79, m.Mean, 1e-9)
+	assert.InDelta(t, 2.9471, m.StdDev, 1e-4)
+	assert.InDelta(t, m.StdDev/m.Mean*100, m.CoefficientOfVariation, 1e-9)
+
+	// A mean of 154 mg/dL is a GMI of about 7%.
+	m = CalculateGlucoseMetrics(glucoseReadings(start, time.Minute, 154/MgdlPerMmol), 3.9, 10)
+	assert.InDelta(t, 6.99, m.GMI, 0.01)
+	assert.Zero(t, m.StdDev)
+
+	assert.Equal(t, GlucoseMetrics{RangeLow: 3.9, RangeHigh: 10}, CalculateGlucoseMetrics(nil, 3.9, 10))
+}
+
+func TestGlucoseHourlyProfile(t *testing.T) {
+
+	loc, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	// 08:00 local on two different days, and one reading at 09:30.
+	day := time.Date(2026, 1, 5, 8, 0, 0, 0, loc)
+	readings := append(
+		glucoseReadings(day, 10*time.Minute, 5, 6, 7, 8, 9),
+		glucoseReadings(day.AddDate(0, 0, 1), 10*time.Minute, 10)...,
+	)
+	readings = append(readings, glucoseReadings(day.Add(90*time.Minute), 0, 4)...)
+
+	profile := GlucoseHourlyProfile(readings, loc)
+	require.Len(t, profile, 24)
+
+	eight := profile[8]
+	assert.Equal(t, 8, eight.Hour)
+	assert.Equal(t, 6, eight.Count)
+	assert.InDelta(t, 7.5, eight.P50, 1e-9)
+	assert.InDelta(t, 6.25, eight.P25, 1e-9)
+	assert.InDelta(t, 9.75, eight.P95, 1e-9)
+
+	assert.Equal(t, 1, profile[9].Count)
+	assert.InDelta(t, 4, profile[9].P5, 1e-9)
+	assert.InDelta(t, 4, profile[9].P95, 1e-9)
+
+	assert.Zero(t, profile[0].Count)
+	assert.Zero(t, profile[0].P50)
+}
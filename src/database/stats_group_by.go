@@ -1,11 +1,18 @@
 package database
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrInvalidGroupBy = errors.New("invalid stats bucket granularity")
+	ErrTooManyBuckets = errors.New("too many stats buckets, group by a larger bucket or use a shorter range")
 )
 
+// The most buckets a range can be split into, timespans are summed into every bucket they overlap.
+const MaxBuckets = 100_000
+
 type GroupBy string
 
 const (
@@ -30,3 +37,122 @@ func (s GroupBy) IsValid() bool {
 		return false
 	}
 }
+
+// minBucketSize returns the shortest a bucket can be, roughly for buckets of days or longer, zero for GroupByOne.
+func (s GroupBy) minBucketSize() time.Duration {
+	switch s {
+	case GroupBySecond:
+		return time.Second
+	case GroupByMinute:
+		return time.Minute
+	case GroupByHour:
+		return time.Hour
+	case GroupByDay:
+		return 23 * time.Hour // a day losing an hour to daylight saving
+	case GroupByWeek:
+		return 7*24*time.Hour - time.Hour
+	case GroupByMonth:
+		return 28*24*time.Hour - time.Hour
+	case GroupByYear:
+		return 365*24*time.Hour - time.Hour
+	default:
+		return 0
+	}
+}
+
+// ValidateBucketCount checks the group by is valid and splits the range into at most MaxBuckets buckets.
+func ValidateBucketCount(startTime time.Time, endTime time.Time, groupby GroupBy) error {
+
+	if !groupby.IsValid() {
+		return ErrInvalidGroupBy
+	}
+
+	size := groupby.minBucketSize()
+
+	if size > 0 && endTime.Sub(startTime)/size >= MaxBuckets {
+		return ErrTooManyBuckets
+	}
+
+	return nil
+}
+
+// TruncateToBucket returns the start of the bucket the time falls into.
+//
+// Buckets follow the user's timezone and day offset the same way goals do,
+// the time is shifted back by the day offset, truncated in the user's timezone, then shifted forward again.
+// So with a 3am day offset, a day bucket runs from 3am to 3am local time.
+// Weeks start on Monday.
+//
+// Everything falls into the same bucket for GroupByOne, the zero time.
+func TruncateToBucket(t time.Time, groupby GroupBy, loc *time.Location, shift time.Duration) time.Time {
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	t = t.Add(-shift).In(loc)
+
+	var bucket time.Time
+
+	switch groupby {
+	default:
+		panic("impossible group by")
+	case GroupByOne:
+		return time.Time{}
+	case GroupBySecond:
+		bucket = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+	case GroupByMinute:
+		bucket = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+	case GroupByHour:
+		bucket = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+	case GroupByDay:
+		bucket = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	case GroupByWeek:
+		offset := (int(t.Weekday()) + 6) % 7 // ISO week starts on Monday
+		bucket = time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+	case GroupByMonth:
+		bucket = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	case GroupByYear:
+		bucket = time.Date(t.Year(), 1, 1, 0, 0, 0, 0, loc)
+	}
+
+	return bucket.Add(shift)
+}
+
+// NextBucket returns the start of the bucket after the one starting at the given bucket start,
+// see TruncateToBucket.
+//
+// GroupByOne has no next bucket, the max time is returned.
+func NextBucket(bucket time.Time, groupby GroupBy, loc *time.Location, shift time.Duration) time.Time {
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	t := bucket.Add(-shift).In(loc)
+
+	var next time.Time
+
+	switch groupby {
+	default:
+		panic("impossible group by")
+	case GroupByOne:
+		return time.Unix(1<<62, 0)
+	case GroupBySecond:
+		next = t.Add(time.Second)
+	case GroupByMinute:
+		next = t.Add(time.Minute)
+	case GroupByHour:
+		next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+	case GroupByDay:
+		next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+	case GroupByWeek:
+		next = time.Date(t.Year(), t.Month(), t.Day()+7, 0, 0, 0, 0, loc)
+	case GroupByMonth:
+		next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+	case GroupByYear:
+		next = time.Date(t.Year()+1, 1, 1, 0, 0, 0, 0, loc)
+	}
+
+	return next.Add(shift)
+}
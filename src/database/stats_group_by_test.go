@@ -0,0 +1,100 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateToBucket_UTC(t *testing.T) {
+	// Friday 2026-04-17 18:05:30 UTC.
+	at := time.Date(2026, 4, 17, 18, 5, 30, 500, time.UTC)
+
+	cases := map[GroupBy]time.Time{
+		GroupBySecond: time.Date(2026, 4, 17, 18, 5, 30, 0, time.UTC),
+		GroupByMinute: time.Date(2026, 4, 17, 18, 5, 0, 0, time.UTC),
+		GroupByHour:   time.Date(2026, 4, 17, 18, 0, 0, 0, time.UTC),
+		GroupByDay:    time.Date(2026, 4, 17, 0, 0, 0, 0, time.UTC),
+		GroupByWeek:   time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC),
+		GroupByMonth:  time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		GroupByYear:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		GroupByOne:    {},
+	}
+
+	for groupby, want := range cases {
+		got := TruncateToBucket(at, groupby, time.UTC, 0)
+		assert.True(t, want.Equal(got), "%s: want %s got %s", groupby, want, got)
+	}
+}
+
+func TestTruncateToBucket_TimezoneAndDayOffset(t *testing.T) {
+	loc, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	// The user's day starts at 3am.
+	shift := 3 * time.Hour
+
+	// The user's monday 2024-01-15 runs from 03:00 local to 03:00 local the next day.
+	dayStart := time.Date(2024, 1, 15, 3, 0, 0, 0, loc)
+
+	evening := time.Date(2024, 1, 15, 18, 0, 0, 0, loc)
+	lateNight := time.Date(2024, 1, 16, 1, 0, 0, 0, loc)
+	nextMorning := time.Date(2024, 1, 16, 4, 0, 0, 0, loc)
+
+	// In UTC the evening and the late night are on different days.
+	require.NotEqual(t, evening.UTC().Day(), lateNight.UTC().Day())
+
+	assert.True(t, dayStart.Equal(TruncateToBucket(evening, GroupByDay, loc, shift)))
+	assert.True(t, dayStart.Equal(TruncateToBucket(lateNight, GroupByDay, loc, shift)))
+	assert.True(t, dayStart.AddDate(0, 0, 1).Equal(TruncateToBucket(nextMorning, GroupByDay, loc, shift)))
+
+	// Weeks start on the user's monday.
+	sunday := time.Date(2024, 1, 22, 2, 0, 0, 0, loc)
+	assert.True(t, dayStart.Equal(TruncateToBucket(sunday, GroupByWeek, loc, shift)))
+
+	// 2am on the 1st is still the previous month.
+	assert.True(t, time.Date(2024, 1, 1, 3, 0, 0, 0, loc).Equal(
+		TruncateToBucket(time.Date(2024, 2, 1, 2, 0, 0, 0, loc), GroupByMonth, loc, shift),
+	))
+}
+
+func TestTruncateToBucket_MatchesGoalDay(t *testing.T) {
+	loc, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	shift := 3 * time.Hour
+
+	// Every half hour over a DST change.
+	start := time.Date(2024, 3, 8, 0, 0, 0, 0, loc)
+	end := time.Date(2024, 3, 12, 0, 0, 0, 0, loc)
+
+	for at := start; at.Before(end); at = at.Add(30 * time.Minute) {
+
+		goalStart, goalEnd, err := ParseGoalTimeExpression("DAILY", at.Add(-shift).In(loc), shift)
+		require.NoError(t, err)
+
+		bucket := TruncateToBucket(at, GroupByDay, loc, shift)
+
+		assert.True(t, goalStart.Equal(bucket), "at %s: goal starts %s, bucket %s", at, goalStart, bucket)
+
+		// The goal's day ends where the next bucket starts, even when DST makes the day 23 hours long.
+		next := NextBucket(bucket, GroupByDay, loc, shift)
+		assert.True(t, goalEnd.Equal(next), "at %s: goal ends %s, next bucket %s", at, goalEnd, next)
+		assert.True(t, !at.Before(goalStart) && at.Before(goalEnd), "at %s: outside %s - %s", at, goalStart, goalEnd)
+	}
+}
+
+func TestValidateBucketCount(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, ValidateBucketCount(start, start.Add(24*time.Hour), GroupBySecond))
+	assert.NoError(t, ValidateBucketCount(start, start.AddDate(1, 0, 0), GroupByHour))
+	assert.NoError(t, ValidateBucketCount(start, start.AddDate(100, 0, 0), GroupByDay))
+	assert.NoError(t, ValidateBucketCount(start, start.AddDate(1000, 0, 0), GroupByOne))
+
+	assert.ErrorIs(t, ValidateBucketCount(start, start.AddDate(1, 0, 0), GroupBySecond), ErrTooManyBuckets)
+	assert.ErrorIs(t, ValidateBucketCount(start, start.AddDate(1, 0, 0), GroupByMinute), ErrTooManyBuckets)
+	assert.ErrorIs(t, ValidateBucketCount(start, start.Add(time.Hour), GroupBy("FORTNIGHT")), ErrInvalidGroupBy)
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"sort"
+	"time"
+)
+
+// InsulinDosePoint summarizes the insulin logged in one bucket.
+//
+// Insulin taken with carbs counts as a meal bolus, without carbs as a correction,
+// the extended part of a bolus is counted separately.
+type InsulinDosePoint struct {
+	Bucket TimeMillis `json:"bucket"`
+	// The number of days in the bucket with at least one eventlog.
+	Days int `json:"days"`
+
+	TotalInsulin float64 `json:"total_insulin"`
+	// The total insulin divided by the days, only bolus insulin is logged so this excludes basal.
+	TotalDailyDose    float64 `json:"total_daily_dose"`
+	MealInsulin       float64 `json:"meal_insulin"`
+	CorrectionInsulin float64 `json:"correction_insulin"`
+	ExtendedInsulin   float64 `json:"extended_insulin"`
+
+	Carbs float64 `json:"carbs"`
+	// The grams of carbs per unit of insulin, for the meals where insulin was taken.
+	CarbsPerUnit float64 `json:"carbs_per_unit"`
+
+	// The insulin recommended and taken for the eventlogs that had a recommendation,
+	// the gap is taken minus recommended, so it is negative when less was taken.
+	RecommendedInsulin      float64 `json:"recommended_insulin"`
+	RecommendedInsulinTaken float64 `json:"recommended_insulin_taken"`
+	InsulinGap              float64 `json:"insulin_gap"`
+
+	Meals         int `json:"meals"`
+	MissedBoluses int `json:"missed_boluses"`
+}
+
+// MissedBolus is an eventlog with carbs but no insulin taken.
+type MissedBolus struct {
+	EventLogID         int        `json:"eventlog_id"`
+	UserTime           TimeMillis `json:"user_time"`
+	Event              string     `json:"event"`
+	NetCarbs           float64    `json:"net_carbs"`
+	RecommendedInsulin float64    `json:"recommended_insulin"`
+}
+
+type InsulinReport struct {
+	Points        []InsulinDosePoint `json:"points"`
+	MissedBoluses []MissedBolus      `json:"missed_boluses"`
+}
+
+// CalculateInsulinReport buckets the eventlogs and sums their insulin, carbs and recommendations,
+// listing every meal without insulin as a missed bolus.
+//
+// Buckets follow TruncateToBucket, with GroupByOne everything falls into a single bucket at the start time.
+// Days are counted in the user's timezone and day offset too, days without eventlogs don't lower the daily dose.
+//
+// Points are ordered by bucket, missed boluses keep the order of the eventlogs.
+func CalculateInsulinReport(
+	eventlogs []TblUserEventLog,
+	startTime time.Time,
+	groupby GroupBy,
+	loc *time.Location,
+	shift time.Duration,
+) InsulinReport {
+
+	type bucketSums struct {
+		InsulinDosePoint
+
+		days      map[time.Time]struct{}
+		mealCarbs float64
+		mealTaken float64
+	}
+
+	buckets := make(map[time.Time]*bucketSums)
+
+	report := InsulinReport{
+		Points:        []InsulinDosePoint{},
+		MissedBoluses: []MissedBolus{},
+	}
+
+	for i := range eventlogs {
+
+		e := &eventlogs[i]
+		at := e.UserTime.Time()
+
+		bucket := startTime
+
+		if groupby != GroupByOne {
+			bucket = TruncateToBucket(at, groupby, loc, shift)
+		}
+
+		sums, ok := buckets[bucket]
+
+		if !ok {
+			sums = &bucketSums{days: make(map[time.Time]struct{})}
+			sums.Bucket = TimeMillis(bucket)
+			buckets[bucket] = sums
+		}
+
+		sums.days[TruncateToBucket(at, GroupByDay, loc, shift)] = struct{}{}
+
+		taken := e.ActualInsulinTaken + e.ActualExtendedInsulinTaken
+		recommended := e.RecommendedInsulinAmount + e.RecommendedExtendedInsulinAmount
+
+		sums.TotalInsulin += taken
+		sums.ExtendedInsulin += e.ActualExtendedInsulinTaken
+		sums.Carbs += e.NetCarbs
+
+		if recommended > 0 {
+			sums.RecommendedInsulin += recommended
+			sums.RecommendedInsulinTaken += taken
+		}
+
+		if e.NetCarbs <= 0 {
+			sums.CorrectionInsulin += e.ActualInsulinTaken
+			continue
+		}
+
+		sums.Meals++
+
+		if taken > 0 {
+			sums.MealInsulin += e.ActualInsulinTaken
+			sums.mealCarbs += e.NetCarbs
+			sums.mealTaken += taken
+			continue
+		}
+
+		sums.MissedBoluses++
+
+		report.MissedBoluses = append(report.MissedBoluses, MissedBolus{
+			EventLogID:         e.ID,
+			UserTime:           e.UserTime,
+			Event:              e.Event,
+			NetCarbs:           e.NetCarbs,
+			RecommendedInsulin: recommended,
+		})
+	}
+
+	for _, sums := range buckets {
+
+		sums.Days = len(sums.days)
+		sums.TotalDailyDose = sums.TotalInsulin / float64(sums.Days)
+		sums.InsulinGap = sums.RecommendedInsulinTaken - sums.RecommendedInsulin
+
+		if sums.mealTaken > 0 {
+			sums.CarbsPerUnit = sums.mealCarbs / sums.mealTaken
+		}
+
+		report.Points = append(report.Points, sums.InsulinDosePoint)
+	}
+
+	sort.Slice(report.Points, func(i, j int) bool {
+		return report.Points[i].Bucket.Time().Before(report.Points[j].Bucket.Time())
+	})
+
+	return report
+}
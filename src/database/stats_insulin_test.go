@@ -0,0 +1,89 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateInsulinReport(t *testing.T) {
+
+	// A Monday.
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	at := func(day, hour int) TimeMillis {
+		return TimeMillis(start.Add(time.Duration(day*24+hour) * time.Hour))
+	}
+
+	eventlogs := []TblUserEventLog{
+		{ID: 1, UserTime: at(0, 8), Event: "Breakfast", NetCarbs: 40, RecommendedInsulinAmount: 4, ActualInsulinTaken: 4},
+		{
+			ID: 2, UserTime: at(0, 12), Event: "Lunch", NetCarbs: 60,
+			RecommendedInsulinAmount: 5, RecommendedExtendedInsulinAmount: 1,
+			ActualInsulinTaken: 4, ActualExtendedInsulinTaken: 1,
+		},
+		{ID: 3, UserTime: at(0, 15), Event: "Correction", RecommendedInsulinAmount: 1, ActualInsulinTaken: 1},
+		{ID: 4, UserTime: at(0, 18), Event: "Snack", NetCarbs: 20, RecommendedInsulinAmount: 2},
+		{ID: 5, UserTime: at(1, 9), Event: "Breakfast", NetCarbs: 30, ActualInsulinTaken: 3},
+	}
+
+	report := CalculateInsulinReport(eventlogs, start, GroupByDay, time.UTC, 0)
+	require.Len(t, report.Points, 2)
+
+	day := report.Points[0]
+	assert.Equal(t, TimeMillis(start), day.Bucket)
+	assert.Equal(t, 1, day.Days)
+	assert.InDelta(t, 10, day.TotalInsulin, 1e-9)
+	assert.InDelta(t, 10, day.TotalDailyDose, 1e-9)
+	assert.InDelta(t, 8, day.MealInsulin, 1e-9)
+	assert.InDelta(t, 1, day.CorrectionInsulin, 1e-9)
+	assert.InDelta(t, 1, day.ExtendedInsulin, 1e-9)
+	assert.InDelta(t, 120, day.Carbs, 1e-9)
+	assert.InDelta(t, 100.0/9, day.CarbsPerUnit, 1e-9)
+	assert.InDelta(t, 13, day.RecommendedInsulin, 1e-9)
+	assert.InDelta(t, 10, day.RecommendedInsulinTaken, 1e-9)
+	assert.InDelta(t, -3, day.InsulinGap, 1e-9)
+	assert.Equal(t, 3, day.Meals)
+	assert.Equal(t, 1, day.MissedBoluses)
+
+	// Without a recommendation the eventlog is not part of the gap.
+	day = report.Points[1]
+	assert.Zero(t, day.RecommendedInsulin)
+	assert.Zero(t, day.InsulinGap)
+	assert.InDelta(t, 10, day.CarbsPerUnit, 1e-9)
+
+	assert.Equal(t, []MissedBolus{{
+		EventLogID:         4,
+		UserTime:           at(0, 18),
+		Event:              "Snack",
+		NetCarbs:           20,
+		RecommendedInsulin: 2,
+	}}, report.MissedBoluses)
+
+	// The daily dose of a week is averaged over the days with eventlogs.
+	report = CalculateInsulinReport(eventlogs, start, GroupByWeek, time.UTC, 0)
+	require.Len(t, report.Points, 1)
+	assert.Equal(t, 2, report.Points[0].Days)
+	assert.InDelta(t, 13, report.Points[0].TotalInsulin, 1e-9)
+	assert.InDelta(t, 6.5, report.Points[0].TotalDailyDose, 1e-9)
+
+	// With the day starting at 10:00 the first breakfast falls on the day before and the second on the day of lunch.
+	shift := 10 * time.Hour
+	report = CalculateInsulinReport(eventlogs, start, GroupByDay, time.UTC, shift)
+	require.Len(t, report.Points, 2)
+	assert.Equal(t, TimeMillis(start.Add(shift-24*time.Hour)), report.Points[0].Bucket)
+	assert.InDelta(t, 4, report.Points[0].TotalInsulin, 1e-9)
+	assert.InDelta(t, 9, report.Points[1].TotalInsulin, 1e-9)
+	assert.Equal(t, 1, report.Points[1].Days)
+
+	report = CalculateInsulinReport(eventlogs, start, GroupByOne, time.UTC, 0)
+	require.Len(t, report.Points, 1)
+	assert.Equal(t, TimeMillis(start), report.Points[0].Bucket)
+	assert.Equal(t, 2, report.Points[0].Days)
+
+	report = CalculateInsulinReport(nil, start, GroupByDay, time.UTC, 0)
+	assert.Empty(t, report.Points)
+	assert.Empty(t, report.MissedBoluses)
+}
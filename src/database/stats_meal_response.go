@@ -0,0 +1,199 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// The glucose rise after a meal is the highest reading in this window after it.
+const (
+	MealResponseStart = time.Hour
+	MealResponseEnd   = 3 * time.Hour
+)
+
+// How far from the meal a reading can be and still be used as the glucose before it.
+const MealBaselineTolerance = 15 * time.Minute
+
+// MealResponse is how much the glucose rose after the meals with a food or event, in mmol/L.
+type MealResponse struct {
+	Name     string  `json:"name"`
+	Samples  int     `json:"samples"`
+	MeanRise float64 `json:"mean_rise"`
+	MaxRise  float64 `json:"max_rise"`
+}
+
+type MealResponses struct {
+	Foods  []MealResponse `json:"foods"`
+	Events []MealResponse `json:"events"`
+}
+
+// CalculateMealResponses finds the glucose rise after each meal, the highest reading between
+// MealResponseStart and MealResponseEnd after it minus the glucose at the meal,
+// and averages it for every food and event name.
+//
+// The glucose at the meal is the eventlog's, or otherwise the closest reading.
+// Eventlogs without food or carbs, or without the readings, are skipped.
+// Names are matched ignoring case, the first spelling seen is kept.
+//
+// Responses with fewer than minSamples are left out, the rest are ordered by the highest mean rise first.
+// The readings must be ordered oldest first.
+func CalculateMealResponses(eflogs []UserEventFoodLog, readings []TblUserGlucose, minSamples int) MealResponses {
+
+	type sums struct {
+		MealResponse
+		total float64
+	}
+
+	foods := make(map[string]*sums)
+	events := make(map[string]*sums)
+
+	add := func(m map[string]*sums, name string, rise float64) {
+
+		key := strings.ToLower(strings.TrimSpace(name))
+
+		if key == "" {
+			return
+		}
+
+		s, ok := m[key]
+
+		if !ok {
+			s = &sums{MealResponse: MealResponse{Name: strings.TrimSpace(name), MaxRise: rise}}
+			m[key] = s
+		}
+
+		s.Samples++
+		s.total += rise
+
+		if rise > s.MaxRise {
+			s.MaxRise = rise
+		}
+	}
+
+	for i := range eflogs {
+
+		e := &eflogs[i]
+
+		if len(e.Foodlogs) == 0 && e.Eventlog.NetCarbs <= 0 {
+			continue
+		}
+
+		at := e.Eventlog.UserTime.Time()
+		before := e.Eventlog.BloodGlucose
+
+		if before <= 0 {
+			before = ClosestGlucose(readings, at, MealBaselineTolerance)
+		}
+
+		peak, ok := maxGlucoseBetween(readings, at.Add(MealResponseStart), at.Add(MealResponseEnd))
+
+		if before <= 0 || !ok {
+			continue
+		}
+
+		rise := peak - before
+
+		add(events, e.Eventlog.Event, rise)
+
+		// A food logged twice in one meal is one sample.
+		seen := make(map[string]struct{}, len(e.Foodlogs))
+
+		for _, f := range e.Foodlogs {
+
+			key := strings.ToLower(strings.TrimSpace(f.Name))
+
+			if _, ok := seen[key]; ok {
+				continue
+			}
+
+			seen[key] = struct{}{}
+
+			add(foods, f.Name, rise)
+		}
+	}
+
+	ranked := func(m map[string]*sums) []MealResponse {
+
+		out := make([]MealResponse, 0, len(m))
+
+		for _, s := range m {
+
+			if s.Samples < minSamples {
+				continue
+			}
+
+			s.MeanRise = s.total / float64(s.Samples)
+			out = append(out, s.MealResponse)
+		}
+
+		sort.Slice(out, func(i, j int) bool {
+
+			if out[i].MeanRise != out[j].MeanRise {
+				return out[i].MeanRise > out[j].MeanRise
+			}
+
+			if out[i].Samples != out[j].Samples {
+				return out[i].Samples > out[j].Samples
+			}
+
+			return out[i].Name < out[j].Name
+		})
+
+		return out
+	}
+
+	return MealResponses{
+		Foods:  ranked(foods),
+		Events: ranked(events),
+	}
+}
+
+// ClosestGlucose returns the glucose of the reading closest to the time within the tolerance, or zero.
+// The readings must be ordered oldest first.
+func ClosestGlucose(readings []TblUserGlucose, at time.Time, tolerance time.Duration) float64 {
+
+	i := sort.Search(len(readings), func(i int) bool {
+		return !readings[i].UserTime.Time().Before(at)
+	})
+
+	best := 0.0
+	bestDist := tolerance + 1
+
+	for _, j := range []int{i - 1, i} {
+
+		if j < 0 || j >= len(readings) {
+			continue
+		}
+
+		dist := readings[j].UserTime.Time().Sub(at).Abs()
+
+		if dist < bestDist {
+			best = readings[j].Glucose
+			bestDist = dist
+		}
+	}
+
+	return best
+}
+
+// maxGlucoseBetween returns the highest glucose of the readings with start <= UserTime <= end,
+// false when there are none.
+func maxGlucoseBetween(readings []TblUserGlucose, start, end time.Time) (float64, bool) {
+
+	i := sort.Search(len(readings), func(i int) bool {
+		return !readings[i].UserTime.Time().Before(start)
+	})
+
+	highest := 0.0
+	found := false
+
+	for ; i < len(readings) && !readings[i].UserTime.Time().After(end); i++ {
+		if !found || readings[i].Glucose > highest {
+			highest = readings[i].Glucose
+			found = true
+		}
+	}
+
+	return highest, found
+}
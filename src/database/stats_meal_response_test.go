@@ -0,0 +1,78 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateMealResponses(t *testing.T) {
+
+	start := time.Date(2026, 1, 5, 7, 0, 0, 0, time.UTC)
+
+	// Hourly from 07:00 to 17:00.
+	readings := glucoseReadings(start, time.Hour, 5, 5.5, 8, 10, 7, 6, 6, 7, 9, 6, 12)
+
+	meal := func(hour int, event string, bg, carbs float64, foods ...string) UserEventFoodLog {
+
+		e := UserEventFoodLog{
+			Eventlog: TblUserEventLog{
+				UserTime:     TimeMillis(start.Add(time.Duration(hour-7) * time.Hour)),
+				Event:        event,
+				BloodGlucose: bg,
+				NetCarbs:     carbs,
+			},
+		}
+
+		for _, f := range foods {
+			e.Foodlogs = append(e.Foodlogs, TblUserFoodLog{Name: f})
+		}
+
+		return e
+	}
+
+	eflogs := []UserEventFoodLog{
+		meal(8, "Breakfast", 5, 50, "Oatmeal", "Banana", "oatmeal"), // peaks at 10 by 10:00
+		meal(13, "Lunch", 0, 30, "banana"),                          // 6 at 13:00, peaks at 9 by 15:00
+		meal(14, "Snack", 0, 10),                                    // 7 at 14:00, peaks at 12 by 17:00
+		meal(15, "Correction", 9, 0),                                // not a meal
+		meal(20, "Dinner", 6, 60, "Pasta"),                          // no readings after
+	}
+
+	got := CalculateMealResponses(eflogs, readings, 1)
+
+	assert.Equal(t, []MealResponse{
+		{Name: "Oatmeal", Samples: 1, MeanRise: 5, MaxRise: 5},
+		{Name: "Banana", Samples: 2, MeanRise: 4, MaxRise: 5},
+	}, got.Foods)
+
+	assert.Equal(t, []MealResponse{
+		{Name: "Breakfast", Samples: 1, MeanRise: 5, MaxRise: 5},
+		{Name: "Snack", Samples: 1, MeanRise: 5, MaxRise: 5},
+		{Name: "Lunch", Samples: 1, MeanRise: 3, MaxRise: 3},
+	}, got.Events)
+
+	got = CalculateMealResponses(eflogs, readings, 2)
+	assert.Equal(t, []MealResponse{{Name: "Banana", Samples: 2, MeanRise: 4, MaxRise: 5}}, got.Foods)
+	assert.Empty(t, got.Events)
+
+	got = CalculateMealResponses(eflogs, nil, 1)
+	assert.Empty(t, got.Foods)
+	assert.Empty(t, got.Events)
+}
+
+func TestClosestGlucose(t *testing.T) {
+
+	start := time.Date(2026, 1, 5, 7, 0, 0, 0, time.UTC)
+	readings := glucoseReadings(start, 10*time.Minute, 5, 6, 7)
+
+	assert.InDelta(t, 5, ClosestGlucose(readings, start.Add(-5*time.Minute), 5*time.Minute), 1e-9)
+	assert.InDelta(t, 6, ClosestGlucose(readings, start.Add(12*time.Minute), 5*time.Minute), 1e-9)
+	assert.InDelta(t, 7, ClosestGlucose(readings, start.Add(16*time.Minute), 5*time.Minute), 1e-9)
+
+	// Nothing within the tolerance.
+	assert.Zero(t, ClosestGlucose(readings, start.Add(-6*time.Minute), 5*time.Minute))
+	assert.Zero(t, ClosestGlucose(readings, start.Add(time.Hour), 5*time.Minute))
+	assert.Zero(t, ClosestGlucose(nil, start, time.Hour))
+}
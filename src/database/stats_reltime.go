@@ -45,8 +45,8 @@ func ParseRelativeTimeExpr(expr string, now time.Time, shift time.Duration) (tim
 		return snap.Add(shift).Add(time.Duration(n) * time.Hour), nil
 
 	case "d":
-		snap := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		return snap.Add(shift).AddDate(0, 0, n), nil
+		snap := time.Date(now.Year(), now.Month(), now.Day()+n, 0, 0, 0, 0, now.Location())
+		return snap.Add(shift), nil
 
 	case "w":
 		// Mirror ParseGoalTimeExpression baseWeek: add shift first then walk back to Monday.
@@ -57,12 +57,12 @@ func ParseRelativeTimeExpr(expr string, now time.Time, shift time.Duration) (tim
 		return base.AddDate(0, 0, n*7), nil
 
 	case "m":
-		snap := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		return snap.Add(shift).AddDate(0, n, 0), nil
+		snap := time.Date(now.Year(), now.Month()+time.Month(n), 1, 0, 0, 0, 0, now.Location())
+		return snap.Add(shift), nil
 
 	case "y":
-		snap := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
-		return snap.Add(shift).AddDate(n, 0, 0), nil
+		snap := time.Date(now.Year()+n, 1, 1, 0, 0, 0, 0, now.Location())
+		return snap.Add(shift), nil
 	}
 
 	// Unreachable: regex constrains unit to [hdwmy].
@@ -117,6 +117,26 @@ func TestParseRelativeTimeExpr_DayOffset(t *testing.T) {
 	assert.Equal(t, time.Date(2026, 4, 17, 2, 0, 0, 0, time.UTC), got)
 }
 
+func TestParseRelativeTimeExpr_Timezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	// DST starts 2026-03-08 in Toronto, days on either side start at local midnight.
+	now := time.Date(2026, 3, 9, 12, 0, 0, 0, loc)
+
+	got, err := ParseRelativeTimeExpr("now-0d", now, 0)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2026, 3, 9, 0, 0, 0, 0, loc).Equal(got), "got %s", got)
+
+	got, err = ParseRelativeTimeExpr("now-2d", now, 0)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2026, 3, 7, 0, 0, 0, 0, loc).Equal(got), "got %s", got)
+
+	got, err = ParseRelativeTimeExpr("now-1m", time.Date(2026, 4, 2, 12, 0, 0, 0, loc), 0)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2026, 3, 1, 0, 0, 0, 0, loc).Equal(got), "got %s", got)
+}
+
 func TestParseRelativeTimeExpr_Invalid(t *testing.T) {
 	cases := []string{
 		"",
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"sort"
+	"time"
+)
+
+// TagTimespan is a single timespan with one of its tags.
+type TagTimespan struct {
+	Tag       string     `db:"tag"`
+	StartTime TimeMillis `db:"start_time"`
+	StopTime  TimeMillis `db:"stop_time"`
+}
+
+// SumTimespanDurations sums how long the timespans lasted per tag and bucket.
+//
+// Timespans are clipped to the start and end time, then split across every bucket they overlap.
+// So a span from 23:00 to 07:00 counts 1 hour towards the first day and 7 hours towards the next.
+// With GroupByOne everything falls into a single bucket at the start time.
+//
+// Points are ordered by bucket, then by tag.
+func SumTimespanDurations(
+	spans []TagTimespan,
+	startTime time.Time,
+	endTime time.Time,
+	groupby GroupBy,
+	loc *time.Location,
+	shift time.Duration,
+) []TimespanTagDurationPoint {
+
+	type bucketKey struct {
+		tag    string
+		bucket time.Time
+	}
+
+	sums := make(map[bucketKey]int64)
+
+	for _, span := range spans {
+
+		cur := span.StartTime.Time()
+		stop := span.StopTime.Time()
+
+		if cur.Before(startTime) {
+			cur = startTime
+		}
+
+		if stop.After(endTime) {
+			stop = endTime
+		}
+
+		for cur.Before(stop) {
+
+			bucket := startTime
+			next := stop
+
+			if groupby != GroupByOne {
+				bucket = TruncateToBucket(cur, groupby, loc, shift)
+				next = NextBucket(bucket, groupby, loc, shift)
+			}
+
+			if next.After(stop) {
+				next = stop
+			}
+
+			k := bucketKey{tag: span.Tag, bucket: bucket}
+
+			sums[k] += next.Sub(cur).Milliseconds()
+
+			cur = next
+		}
+	}
+
+	points := make([]TimespanTagDurationPoint, 0, len(sums))
+
+	for k, durationMilli := range sums {
+		points = append(points, TimespanTagDurationPoint{
+			Tag:           k.tag,
+			Bucket:        TimeMillis(k.bucket),
+			DurationMilli: durationMilli,
+		})
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+
+		if !points[i].Bucket.Time().Equal(points[j].Bucket.Time()) {
+			return points[i].Bucket.Time().Before(points[j].Bucket.Time())
+		}
+
+		return points[i].Tag < points[j].Tag
+	})
+
+	return points
+}
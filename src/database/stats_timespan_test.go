@@ -0,0 +1,99 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextBucket(t *testing.T) {
+	loc, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	shift := 3 * time.Hour
+
+	// DST starts 2024-03-10 in Toronto, that day is only 23 hours long.
+	day := TruncateToBucket(time.Date(2024, 3, 10, 12, 0, 0, 0, loc), GroupByDay, loc, shift)
+	next := NextBucket(day, GroupByDay, loc, shift)
+
+	assert.Equal(t, 23*time.Hour, next.Sub(day))
+	assert.True(t, next.Equal(TruncateToBucket(next, GroupByDay, loc, shift)), "got %s", next)
+
+	month := time.Date(2024, 1, 1, 3, 0, 0, 0, loc)
+	assert.True(t, time.Date(2024, 2, 1, 3, 0, 0, 0, loc).Equal(NextBucket(month, GroupByMonth, loc, shift)))
+}
+
+func TestSumTimespanDurations_CrossesMidnight(t *testing.T) {
+	start := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+
+	// Sleep from 23:00 to 07:00.
+	spans := []TagTimespan{{
+		Tag:       "sleep:night",
+		StartTime: TimeMillis(start.Add(23 * time.Hour)),
+		StopTime:  TimeMillis(start.Add(31 * time.Hour)),
+	}}
+
+	got := SumTimespanDurations(spans, start, end, GroupByDay, time.UTC, 0)
+
+	require.Len(t, got, 2)
+	assert.True(t, start.Equal(got[0].Bucket.Time()))
+	assert.Equal(t, time.Hour.Milliseconds(), got[0].DurationMilli)
+	assert.True(t, start.AddDate(0, 0, 1).Equal(got[1].Bucket.Time()))
+	assert.Equal(t, (7 * time.Hour).Milliseconds(), got[1].DurationMilli)
+
+	// With a 3am day start, the sleep is split at 03:00 instead.
+	got = SumTimespanDurations(spans, start, end, GroupByDay, time.UTC, 3*time.Hour)
+
+	require.Len(t, got, 2)
+	assert.True(t, start.Add(3*time.Hour).Equal(got[0].Bucket.Time()))
+	assert.Equal(t, (4 * time.Hour).Milliseconds(), got[0].DurationMilli)
+	assert.True(t, start.Add(27*time.Hour).Equal(got[1].Bucket.Time()))
+	assert.Equal(t, (4 * time.Hour).Milliseconds(), got[1].DurationMilli)
+}
+
+func TestSumTimespanDurations_CrossesWeek(t *testing.T) {
+	// Sunday 2026-04-19 20:00 to Monday 02:00.
+	from := time.Date(2026, 4, 19, 20, 0, 0, 0, time.UTC)
+	spans := []TagTimespan{{Tag: "a:b", StartTime: TimeMillis(from), StopTime: TimeMillis(from.Add(6 * time.Hour))}}
+
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	got := SumTimespanDurations(spans, start, end, GroupByWeek, time.UTC, 0)
+
+	require.Len(t, got, 2)
+	assert.True(t, time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC).Equal(got[0].Bucket.Time()))
+	assert.Equal(t, (4 * time.Hour).Milliseconds(), got[0].DurationMilli)
+	assert.True(t, time.Date(2026, 4, 20, 0, 0, 0, 0, time.UTC).Equal(got[1].Bucket.Time()))
+	assert.Equal(t, (2 * time.Hour).Milliseconds(), got[1].DurationMilli)
+}
+
+func TestSumTimespanDurations_ClipsToWindow(t *testing.T) {
+	start := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+
+	spans := []TagTimespan{
+		// Started the day before.
+		{Tag: "a:b", StartTime: TimeMillis(start.Add(-2 * time.Hour)), StopTime: TimeMillis(start.Add(time.Hour))},
+		// Runs past the end.
+		{Tag: "a:b", StartTime: TimeMillis(end.Add(-time.Hour)), StopTime: TimeMillis(end.Add(5 * time.Hour))},
+		// Outside the window entirely.
+		{Tag: "c:d", StartTime: TimeMillis(end.Add(time.Hour)), StopTime: TimeMillis(end.Add(2 * time.Hour))},
+	}
+
+	got := SumTimespanDurations(spans, start, end, GroupByOne, time.UTC, 0)
+
+	require.Len(t, got, 1)
+	assert.Equal(t, "a:b", got[0].Tag)
+	assert.True(t, start.Equal(got[0].Bucket.Time()))
+	assert.Equal(t, (2 * time.Hour).Milliseconds(), got[0].DurationMilli)
+
+	got = SumTimespanDurations(spans, start, end, GroupByHour, time.UTC, 0)
+
+	require.Len(t, got, 2)
+	assert.True(t, start.Equal(got[0].Bucket.Time()))
+	assert.True(t, end.Add(-time.Hour).Equal(got[1].Bucket.Time()))
+}
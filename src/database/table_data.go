@@ -42,6 +42,11 @@ type TblUser struct {
 	DayTimeOffsetSeconds      int               `db:"day_time_offset_seconds"      json:"day_time_offset_seconds"`
 	FillEventLogFromLast      bool              `db:"fill_eventlog_from_last"      json:"fill_eventlog_from_last"`
 	TimespanHistoryFetchLimit int               `db:"timespan_history_fetch_limit" json:"timespan_history_fetch_limit"`
+	Timezone                  Timezone          `db:"timezone"                     json:"timezone"`
+	InsulinActionCurve        string            `db:"insulin_action_curve"         json:"insulin_action_curve"`
+	InsulinDurationMinutes    int               `db:"insulin_duration_minutes"     json:"insulin_duration_minutes"`
+	InsulinPeakMinutes        int               `db:"insulin_peak_minutes"         json:"insulin_peak_minutes"`
+	GlucoseUnit               GlucoseUnit       `db:"glucose_unit"                 json:"glucose_unit"`
 }
 
 // NewDefaultTblUser builds a TblUser with the default settings assigned to
@@ -58,6 +63,11 @@ func NewDefaultTblUser(username string, password []byte) TblUser {
 		TargetBloodSugar:          5.6,
 		FillEventLogFromLast:      false,
 		TimespanHistoryFetchLimit: 50,
+		Timezone:                  UTC,
+		InsulinActionCurve:        "exponential",
+		InsulinDurationMinutes:    300,
+		InsulinPeakMinutes:        75,
+		GlucoseUnit:               GlucoseUnitMmol,
 		SessionExpireTimeSeconds:  int64(time.Duration(time.Hour * 24 * 10).Seconds()),
 	}
 }
@@ -92,9 +102,30 @@ func (u *TblUser) Copy() *TblUser {
 		DayTimeOffsetSeconds:      u.DayTimeOffsetSeconds,
 		FillEventLogFromLast:      u.FillEventLogFromLast,
 		TimespanHistoryFetchLimit: u.TimespanHistoryFetchLimit,
+		Timezone:                  u.Timezone,
+		InsulinActionCurve:        u.InsulinActionCurve,
+		InsulinDurationMinutes:    u.InsulinDurationMinutes,
+		InsulinPeakMinutes:        u.InsulinPeakMinutes,
+		GlucoseUnit:               u.GlucoseUnit,
 	}
 }
 
+// Location returns the timezone the user's days, weeks and months are counted in.
+// A timezone sent with a request takes priority over the saved one.
+func (u *TblUser) Location(override Timezone) *time.Location {
+
+	if override.Name != "" {
+		return override.Loc()
+	}
+
+	return u.Timezone.Loc()
+}
+
+// DayShift returns the user's DayTimeOffsetSeconds as a time.Duration.
+func (u *TblUser) DayShift() time.Duration {
+	return time.Duration(u.DayTimeOffsetSeconds) * time.Second
+}
+
 type TblUserSession struct {
 	UserID    int        `db:"user_id"    json:"user_id"`
 	Created   TimeMillis `db:"created"    json:"created"`
@@ -117,6 +148,16 @@ type TblUserEventLog struct {
 	InsulinToCarbRatio       float64    `db:"insulin_to_carb_ratio"      json:"insulin_to_carb_ratio"`
 	RecommendedInsulinAmount float64    `db:"recommended_insulin_amount" json:"recommended_insulin_amount"`
 	ActualInsulinTaken       float64    `db:"actual_insulin_taken"       json:"actual_insulin_taken"`
+
+	// Extended or split bolus for the meal's fat and protein, see insulin.RecommendExtendedBolus.
+	FatProteinUnits                  float64 `db:"fat_protein_units"                   json:"fat_protein_units"`
+	RecommendedExtendedInsulinAmount float64 `db:"recommended_extended_insulin_amount" json:"recommended_extended_insulin_amount"`
+	RecommendedExtendedMinutes       int     `db:"recommended_extended_minutes"        json:"recommended_extended_minutes"`
+	ActualExtendedInsulinTaken       float64 `db:"actual_extended_insulin_taken"       json:"actual_extended_insulin_taken"`
+	ActualExtendedMinutes            int     `db:"actual_extended_minutes"             json:"actual_extended_minutes"`
+
+	// Free text from where the eventlog was imported, it is not changed when the eventlog is updated.
+	Notes string `db:"notes" json:"notes"`
 }
 
 type TblUserFood struct {
@@ -129,6 +170,12 @@ type TblUserFood struct {
 	Carb    float64 `db:"carb"    json:"carb"`
 	Fibre   float64 `db:"fibre"   json:"fibre"`
 	Fat     float64 `db:"fat"     json:"fat"`
+
+	Nutrients Nutrients `db:"nutrients" json:"nutrients"`
+
+	// Grams per millilitre to convert between mass and volume, 0 if unknown.
+	Density        float64        `db:"density"         json:"density"`
+	HouseholdUnits HouseholdUnits `db:"household_units" json:"household_units"`
 }
 
 func (f *TblUserFood) Scale() {
@@ -137,6 +184,7 @@ func (f *TblUserFood) Scale() {
 		f.Fat = float64(f.Fat) / f.Portion
 		f.Fibre = float64(f.Fibre) / f.Portion
 		f.Protein = float64(f.Protein) / f.Portion
+		f.Nutrients = f.Nutrients.Scaled(1 / f.Portion)
 		f.Portion = 1
 	}
 }
@@ -158,6 +206,32 @@ type TblUserFoodLog struct {
 	Carb    float64 `db:"carb"    json:"carb"`
 	Fibre   float64 `db:"fibre"   json:"fibre"`
 	Fat     float64 `db:"fat"     json:"fat"`
+
+	// The amounts for the portion eaten, copied from the food when none are given.
+	Nutrients Nutrients `db:"nutrients" json:"nutrients"`
+
+	// The recipe the foodlog was logged from, the amounts are a copy of the recipe's when it was logged.
+	RecipeID *int `db:"recipe_id" json:"recipe_id"`
+}
+
+// TblUserRecipe is a food made of other foods, Yield is how many Unit its ingredients make.
+type TblUserRecipe struct {
+	ID      int        `db:"id"      json:"id"`
+	UserID  int        `db:"user_id" json:"-"`
+	Created TimeMillis `db:"created" json:"created"`
+	Name    string     `db:"name"    json:"name"`
+	Unit    string     `db:"unit"    json:"unit"`
+	Yield   float64    `db:"yield"   json:"yield"`
+	Notes   string     `db:"notes"   json:"notes"`
+}
+
+// TblUserRecipeIngredient is an amount of either a user food or a data source food in a recipe.
+type TblUserRecipeIngredient struct {
+	ID               int     `db:"id"                  json:"id"`
+	RecipeID         int     `db:"recipe_id"           json:"recipe_id"`
+	FoodID           *int    `db:"food_id"             json:"food_id"`
+	DataSourceFoodID *int    `db:"data_source_food_id" json:"data_source_food_id"`
+	Portion          float64 `db:"portion"             json:"portion"` // in the unit of the food
 }
 
 type TblUserBodyLog struct {
@@ -189,9 +263,9 @@ type TblUserMedication struct {
 	Category     string     `db:"category"      json:"category"`
 	DosageAmount float64    `db:"dosage_amount" json:"dosage_amount"`
 	DosageUnit   string     `db:"dosage_unit"   json:"dosage_unit"`
-	Form         string     `db:"form"          json:"form"` // tablet, capsule, liquid
-	StartDate    TimeMillis `db:"start_date"    json:"start_date"`
-	EndDate      TimeMillis `db:"end_date"      json:"end_date"`
+	Form         string     `db:"form"          json:"form"`       // tablet, capsule, liquid
+	StartDate    TimeMillis `db:"start_date"    json:"start_date"` // calendar day in the user's timezone
+	EndDate      TimeMillis `db:"end_date"      json:"end_date"`   // last day taken, in the user's timezone
 	Notes        string     `db:"notes"         json:"notes"`
 }
 
@@ -199,10 +273,11 @@ type TblUserMedicationSchedule struct {
 	ID                int        `db:"id"                   json:"id"`
 	MedicationID      int        `db:"medication_id"        json:"medication_id"`
 	Created           TimeMillis `db:"created"              json:"created"`
-	MinutesOfHourMask int64      `db:"minutes_of_hour_mask" json:"minutes_of_hour_mask"` // BIT(60)
-	HoursOfDayMask    int32      `db:"hours_of_day_mask"    json:"hours_of_day_mask"`    // BIT(24)
-	DaysOfWeekMask    int8       `db:"days_of_week_mask"    json:"days_of_week_mask"`    // BIT(7)
-	DaysOfMonthMask   int32      `db:"days_of_month_mask"   json:"days_of_month_mask"`   // BIT(31)
+	MinutesOfHourMask int64      `db:"minutes_of_hour_mask" json:"minutes_of_hour_mask"` // 60 bits, bit 0 = minute 0
+	HoursOfDayMask    int32      `db:"hours_of_day_mask"    json:"hours_of_day_mask"`    // 24 bits, bit 0 = hour 0
+	DaysOfWeekMask    int8       `db:"days_of_week_mask"    json:"days_of_week_mask"`    // 7 bits, bit 0 = monday
+	DaysOfMonthMask   int32      `db:"days_of_month_mask"   json:"days_of_month_mask"`   // 31 bits, bit 0 = the 1st
+	MonthsOfYearMask  int16      `db:"month_of_year_mask"   json:"month_of_year_mask"`   // 12 bits, bit 0 = january
 	WithFood          bool       `db:"with_food"            json:"with_food"`
 	Fasting           bool       `db:"fasting"              json:"fasting"`
 	ReminderEnabled   bool       `db:"reminder_enabled"     json:"reminder_enabled"`
@@ -217,6 +292,27 @@ type TblUserMedicationLog struct {
 	Notes      string     `db:"notes"       json:"notes"`
 }
 
+// TblUserInsulinProfileBlock is the user's insulin ratios from StartMinute until the next block starts,
+// see ActiveInsulinProfileBlock.
+type TblUserInsulinProfileBlock struct {
+	ID                       int     `db:"id"                         json:"id"`
+	UserID                   int     `db:"user_id"                    json:"user_id"`
+	StartMinute              int     `db:"start_minute"               json:"start_minute"` // minutes after local midnight
+	InsulinToCarbRatio       float64 `db:"insulin_to_carb_ratio"      json:"insulin_to_carb_ratio"`
+	InsulinSensitivityFactor float64 `db:"insulin_sensitivity_factor" json:"insulin_sensitivity_factor"`
+	TargetBloodSugar         float64 `db:"target_blood_sugar"         json:"target_blood_sugar"`
+}
+
+// TblUserGlucose is a single blood glucose reading in mmol/L.
+type TblUserGlucose struct {
+	ID       int        `db:"id"        json:"id"`
+	UserID   int        `db:"user_id"   json:"user_id"`
+	Created  TimeMillis `db:"created"   json:"created"`
+	UserTime TimeMillis `db:"user_time" json:"user_time"`
+	Glucose  float64    `db:"glucose"   json:"glucose"`
+	Source   string     `db:"source"    json:"source"` // where the reading came from, e.g. the CGM's name
+}
+
 type TblDataSource struct {
 	ID      int        `db:"id"      json:"id"`
 	Created TimeMillis `db:"created" json:"created"`
@@ -238,6 +334,21 @@ type TblDataSourceFood struct {
 	Fibre           float64 `db:"fibre"                  json:"fibre"`
 	Fat             float64 `db:"fat"                    json:"fat"`
 	DataSourceRowID int     `db:"data_source_row_int_id" json:"data_source_row_int_id"`
+
+	Nutrients Nutrients `db:"nutrients" json:"nutrients"`
+
+	// GTIN/UPC from NormalizeBarcode, empty if the food has none.
+	Barcode string `db:"barcode" json:"barcode"`
+}
+
+// TblDataSourceFoodServing is a serving of a data source food, Amount of Unit weighing Portion of the food's unit.
+type TblDataSourceFoodServing struct {
+	ID               int     `db:"id"                  json:"id"`
+	DataSourceFoodID int     `db:"data_source_food_id" json:"data_source_food_id"`
+	Name             string  `db:"name"                json:"name"`
+	Amount           float64 `db:"amount"              json:"amount"`
+	Unit             string  `db:"unit"                json:"unit"`
+	Portion          float64 `db:"portion"             json:"portion"`
 }
 
 type TblUserGoal struct {
@@ -309,7 +420,14 @@ type TblUserTagColor struct {
 }
 
 type TblUserPhoto struct {
-	ID     int    `db:"id"      json:"id"`
-	UserID int    `db:"user_id" json:"user_id"`
-	Data   []byte `db:"data"    json:"-"`
+	ID          int        `db:"id"           json:"id"`
+	UserID      int        `db:"user_id"      json:"user_id"`
+	Data        []byte     `db:"data"         json:"-"` // empty unless Storage is 'db'
+	Thumbnail   []byte     `db:"thumbnail"    json:"-"` // empty unless Storage is 'db'
+	Created     TimeMillis `db:"created"      json:"created"`
+	Storage     string     `db:"storage"      json:"-"` // where the photo bytes are kept, see photostore.Store
+	Hash        string     `db:"hash"         json:"-"` // hex SHA-256 of the photo bytes
+	ContentType string     `db:"content_type" json:"content_type"`
+	Width       int        `db:"width"        json:"width"`
+	Height      int        `db:"height"       json:"height"`
 }
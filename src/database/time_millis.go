@@ -60,6 +60,12 @@ func (t *TimeMillis) Scan(value any) error {
 
 		return nil
 
+	case nil:
+
+		*t = TimeMillis(time.Time{})
+
+		return nil
+
 	default:
 
 		return fmt.Errorf("%w: %s", ErrInvalidTimeMilliasScanType, value)
@@ -10,8 +10,12 @@ import (
 
 var (
 	ErrInvalidTimezoneScanType = errors.New("cannot scan type into Timezone")
+	ErrInvalidTimezone         = errors.New("unknown timezone")
 )
 
+// UTC is the timezone users have until they pick one.
+var UTC = Timezone{Name: "UTC", loc: time.UTC}
+
 type Timezone struct {
 	Name string
 	loc  *time.Location
@@ -19,6 +23,11 @@ type Timezone struct {
 
 func NewTimezone(name string) (Timezone, error) {
 
+	// Local is whatever zone the server runs in, not something a user can pick.
+	if name == "Local" {
+		return Timezone{}, fmt.Errorf("%w: %s", ErrInvalidTimezone, name)
+	}
+
 	loc, err := time.LoadLocation(name)
 
 	if err != nil {
@@ -51,23 +60,39 @@ func (t *Timezone) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	if loc, err := time.LoadLocation(name); err == nil {
-		t.Name = name
-		t.loc = loc
+	if name == "" {
+		*t = Timezone{}
+		return nil
 	}
 
+	zone, err := NewTimezone(name)
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidTimezone, name)
+	}
+
+	*t = zone
+
 	return nil
 }
 
-func (t *Timezone) Value() (driver.Value, error) {
+func (t Timezone) Value() (driver.Value, error) {
+
+	if t.Name == "" {
+		return UTC.Name, nil
+	}
+
 	return t.Name, nil
 }
 
 func (t *Timezone) Scan(value any) error {
 	switch v := value.(type) {
+	case []byte:
+		return t.Scan(string(v))
+
 	case string:
 
-		if zone, err := NewTimezone(v); err != nil {
+		if zone, err := NewTimezone(v); err != nil || v == "" {
 			// always return a valid timezone
 			t.Name = "UTC"
 			t.loc = time.UTC
@@ -9,6 +9,7 @@ import (
 
 // GoalTargetColumn defines valid database table mappings for the target goal.
 // These are not real columns, they must be mapped to columns / calulated values via the database implementation.
+// Every nutrient also has a column, its name in upper case prefixed with NUTRIENT_.
 type GoalTargetColumn string
 
 const (
@@ -26,6 +27,7 @@ const (
 	TargetColumnBodyBloodPressureSys GoalTargetColumn = "BLOOD_PRESSURE_SYS"
 	TargetColumnBodyBloodPressureDia GoalTargetColumn = "BLOOD_PRESSURE_DIA"
 	TargetColumnEventBloodSugar      GoalTargetColumn = "BLOOD_SUGAR"
+	TargetColumnGlucose              GoalTargetColumn = "GLUCOSE"
 )
 
 var (
@@ -47,13 +49,25 @@ func (a GoalTargetColumn) IsValid() bool {
 		TargetColumnBodyHeartRate,
 		TargetColumnBodySteps,
 		TargetColumnBodyBloodPressureSys, TargetColumnBodyBloodPressureDia,
-		TargetColumnEventBloodSugar:
+		TargetColumnEventBloodSugar,
+		TargetColumnGlucose:
 		return true
 	default:
-		return false
+		_, ok := a.Nutrient()
+		return ok
 	}
 }
 
+// Nutrient returns the nutrient of a NUTRIENT_ column, like NUTRIENT_SODIUM, false for any other column.
+func (a GoalTargetColumn) Nutrient() (Nutrient, bool) {
+	return nutrientFromColumn(string(a))
+}
+
+// IsGlucose returns if the goal is on a glucose value, which is stored in mmol/L.
+func (a GoalTargetColumn) IsGlucose() bool {
+	return a == TargetColumnEventBloodSugar || a == TargetColumnGlucose
+}
+
 // GoalValueComparison determines how the current goal value is compared to the target goal value.
 type GoalValueComparison string
 
@@ -87,6 +101,7 @@ const (
 )
 
 // ParseGoalTimeExpression converts the base time into a range.
+// Both ends are taken from the calendar in now's location, so a day across a DST change is 23 or 25 hours.
 // Returns startTime, stopTime, or error.
 func ParseGoalTimeExpression(expr string, now time.Time, shift time.Duration) (time.Time, time.Time, error) {
 
@@ -100,7 +115,7 @@ func ParseGoalTimeExpression(expr string, now time.Time, shift time.Duration) (t
 	case baseToday:
 
 		t1 := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(shift)
-		t2 := t1.AddDate(0, 0, 1)
+		t2 := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()).Add(shift)
 
 		return t1, t2, nil
 
@@ -126,14 +141,14 @@ func ParseGoalTimeExpression(expr string, now time.Time, shift time.Duration) (t
 	case baseMonth:
 
 		t1 := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Add(shift)
-		t2 := t1.AddDate(0, 1, 0)
+		t2 := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location()).Add(shift)
 
 		return t1, t2, nil
 
 	case baseYear:
 
 		t1 := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()).Add(shift)
-		t2 := t1.AddDate(1, 0, 0)
+		t2 := time.Date(now.Year()+1, 1, 1, 0, 0, 0, 0, now.Location()).Add(shift)
 
 		return t1, t2, nil
 	}
@@ -0,0 +1,155 @@
+package insulin
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Curve is the shape of how insulin is used up after a dose.
+type Curve string
+
+const (
+	// Activity peaks at Action.Peak and tails off until Action.Duration, like most rapid acting insulins.
+	CurveExponential Curve = "exponential"
+	// Insulin is used up at a constant rate over Action.Duration.
+	CurveLinear Curve = "linear"
+	// Insulin on board is not tracked.
+	CurveNone Curve = "none"
+)
+
+const (
+	MinDuration = 2 * time.Hour
+	MaxDuration = 12 * time.Hour
+	MinPeak     = 10 * time.Minute
+)
+
+var (
+	ErrInvalidCurve    = errors.New("insulin action curve must be exponential, linear, or none")
+	ErrInvalidDuration = errors.New("insulin action duration must be between 2 and 12 hours")
+	ErrInvalidPeak     = errors.New("insulin peak must be at least 10 minutes and less than half the action duration")
+)
+
+// DefaultAction is a typical rapid acting insulin.
+var DefaultAction = Action{
+	Curve:    CurveExponential,
+	Duration: 5 * time.Hour,
+	Peak:     75 * time.Minute,
+}
+
+// Action describes how long a dose of insulin keeps working.
+type Action struct {
+	Curve Curve
+
+	// How long until a dose is used up.
+	Duration time.Duration
+
+	// When a dose is working the hardest, only used by CurveExponential.
+	Peak time.Duration
+}
+
+func (a Action) Validate() error {
+
+	switch a.Curve {
+	default:
+		return ErrInvalidCurve
+	case CurveNone:
+		return nil
+	case CurveLinear, CurveExponential:
+	}
+
+	if a.Duration < MinDuration || a.Duration > MaxDuration {
+		return ErrInvalidDuration
+	}
+
+	if a.Curve == CurveExponential && (a.Peak < MinPeak || a.Peak*2 >= a.Duration) {
+		return ErrInvalidPeak
+	}
+
+	return nil
+}
+
+// Remaining returns the fraction of a dose still on board the given time after it was taken,
+// 1 right when it's taken down to 0 once the action duration has passed.
+func (a Action) Remaining(elapsed time.Duration) float64 {
+
+	if elapsed < 0 {
+		return 1
+	}
+
+	if a.Curve == CurveNone || elapsed >= a.Duration {
+		return 0
+	}
+
+	if a.Curve == CurveLinear {
+		return 1 - elapsed.Minutes()/a.Duration.Minutes()
+	}
+
+	// The exponential curve used by OpenAPS oref0.
+	t := elapsed.Minutes()
+	td := a.Duration.Minutes()
+	tp := a.Peak.Minutes()
+
+	tau := tp * (1 - tp/td) / (1 - 2*tp/td)
+	alpha := 2 * tau / td
+	scale := 1 / (1 - alpha + (1+alpha)*math.Exp(-td/tau))
+
+	left := 1 - scale*(1-alpha)*((t*t/(tau*td*(1-alpha))-t/tau-1)*math.Exp(-t/tau)+1)
+
+	return min(1, max(0, left))
+}
+
+// MaxExtendedDuration is the longest an extended bolus is counted for,
+// so doses this much older than the action's duration are used up.
+const MaxExtendedDuration = 12 * time.Hour
+
+// extendedStep is how often an extended bolus is counted as being delivered.
+const extendedStep = 5 * time.Minute
+
+// Dose is an amount of insulin taken at a time.
+// An extended bolus is delivered evenly over the Duration from the Time.
+type Dose struct {
+	Time     time.Time
+	Units    float64
+	Duration time.Duration
+}
+
+// OnBoard returns how many units of the doses taken before now are still working.
+// Doses at or after now are not counted.
+// The part of an extended bolus which is still to be delivered is all on board.
+func OnBoard(doses []Dose, now time.Time, action Action) float64 {
+
+	var iob float64
+
+	for _, d := range doses {
+
+		if !d.Time.Before(now) || d.Units <= 0 {
+			continue
+		}
+
+		duration := min(d.Duration, MaxExtendedDuration)
+
+		if duration <= 0 {
+			iob += d.Units * action.Remaining(now.Sub(d.Time))
+			continue
+		}
+
+		steps := int((duration + extendedStep - 1) / extendedStep)
+		step := duration / time.Duration(steps)
+		units := d.Units / float64(steps)
+
+		for i := range steps {
+
+			at := d.Time.Add(step*time.Duration(i) + step/2)
+
+			if !at.Before(now) {
+				iob += units * float64(steps-i)
+				break
+			}
+
+			iob += units * action.Remaining(now.Sub(at))
+		}
+	}
+
+	return iob
+}
@@ -0,0 +1,81 @@
+package insulin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAction_Validate(t *testing.T) {
+	assert.NoError(t, DefaultAction.Validate())
+	assert.NoError(t, Action{Curve: CurveNone}.Validate())
+	assert.NoError(t, Action{Curve: CurveLinear, Duration: 4 * time.Hour}.Validate())
+
+	assert.ErrorIs(t, Action{Curve: "fast"}.Validate(), ErrInvalidCurve)
+	assert.ErrorIs(t, Action{Curve: CurveLinear, Duration: time.Hour}.Validate(), ErrInvalidDuration)
+	assert.ErrorIs(t, Action{Curve: CurveExponential, Duration: 4 * time.Hour}.Validate(), ErrInvalidPeak)
+	assert.ErrorIs(t, Action{Curve: CurveExponential, Duration: 4 * time.Hour, Peak: 2 * time.Hour}.Validate(), ErrInvalidPeak)
+}
+
+func TestAction_Remaining(t *testing.T) {
+	for _, a := range []Action{DefaultAction, {Curve: CurveLinear, Duration: 5 * time.Hour}} {
+
+		assert.InDelta(t, 1, a.Remaining(0), 1e-9, a.Curve)
+		assert.Zero(t, a.Remaining(a.Duration), a.Curve)
+		assert.Zero(t, a.Remaining(a.Duration+time.Hour), a.Curve)
+
+		// Always going down.
+		last := 1.0
+		for m := 5 * time.Minute; m < a.Duration; m += 5 * time.Minute {
+			left := a.Remaining(m)
+			assert.Less(t, left, last, "%s at %s", a.Curve, m)
+			last = left
+		}
+	}
+
+	assert.InDelta(t, 0.5, Action{Curve: CurveLinear, Duration: 4 * time.Hour}.Remaining(2*time.Hour), 1e-9)
+
+	// Rapid acting insulin is slow to start, most of it is still there after the first half hour.
+	assert.Greater(t, DefaultAction.Remaining(30*time.Minute), 0.9)
+
+	assert.Zero(t, Action{Curve: CurveNone}.Remaining(time.Minute))
+}
+
+func TestOnBoard(t *testing.T) {
+	now := time.Date(2026, 4, 13, 12, 0, 0, 0, time.UTC)
+	action := Action{Curve: CurveLinear, Duration: 4 * time.Hour}
+
+	doses := []Dose{
+		{Time: now.Add(-time.Hour), Units: 4},     // 3 hours left, 3 units
+		{Time: now.Add(-3 * time.Hour), Units: 2}, // 1 hour left, 0.5 units
+		{Time: now.Add(-5 * time.Hour), Units: 6}, // used up
+		{Time: now, Units: 10},                    // not taken before now
+	}
+
+	assert.InDelta(t, 3.5, OnBoard(doses, now, action), 1e-9)
+	assert.Zero(t, OnBoard(doses, now, Action{Curve: CurveNone}))
+}
+
+func TestOnBoardExtended(t *testing.T) {
+	now := time.Date(2026, 4, 13, 12, 0, 0, 0, time.UTC)
+	action := Action{Curve: CurveLinear, Duration: 4 * time.Hour}
+
+	// Half way through 6 units over 2 hours, the 3 delivered are on average 30 minutes old.
+	extended := Dose{Time: now.Add(-time.Hour), Units: 6, Duration: 2 * time.Hour}
+	assert.InDelta(t, 3+3*3.5/4, OnBoard([]Dose{extended}, now, action), 1e-9)
+
+	// It counts for more than the same units taken at once.
+	assert.Greater(t,
+		OnBoard([]Dose{extended}, now, action),
+		OnBoard([]Dose{{Time: extended.Time, Units: 6}}, now, action),
+	)
+
+	// Finished extended boluses are used up later than normal ones.
+	finished := Dose{Time: now.Add(-5 * time.Hour), Units: 6, Duration: 2 * time.Hour}
+	assert.Positive(t, OnBoard([]Dose{finished}, now, action))
+	assert.Zero(t, OnBoard([]Dose{{Time: finished.Time, Units: 6}}, now, action))
+
+	// Not started yet.
+	assert.Zero(t, OnBoard([]Dose{{Time: now, Units: 6, Duration: time.Hour}}, now, action))
+}
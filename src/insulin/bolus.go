@@ -0,0 +1,52 @@
+// Package insulin calculates insulin doses.
+//
+// The bolus formula matches the one used by the web UI, so every client gets the same answer.
+package insulin
+
+// BolusInput is everything needed to recommend a bolus.
+type BolusInput struct {
+	NetCarbs                 float64
+	BloodGlucose             float64
+	BloodGlucoseTarget       float64
+	InsulinToCarbRatio       float64
+	InsulinSensitivityFactor float64
+	InsulinOnBoard           float64
+}
+
+// Bolus is a recommended insulin dose and how it was made up.
+type Bolus struct {
+	// Insulin to cover the carbs.
+	Carb float64 `json:"carb"`
+	// Insulin to bring the blood glucose to target, negative when below target.
+	Correction float64 `json:"correction"`
+	// Insulin still working from earlier doses, taken off the total.
+	InsulinOnBoard float64 `json:"insulin_on_board"`
+	// The recommended dose, never negative.
+	Total float64 `json:"total"`
+}
+
+// RecommendBolus calculates the insulin for the given carbs and blood glucose.
+//
+// Without an insulin sensitivity factor nothing is recommended,
+// and without an insulin to carb ratio nothing is recommended for a meal with carbs.
+func RecommendBolus(in BolusInput) Bolus {
+
+	out := Bolus{InsulinOnBoard: in.InsulinOnBoard}
+
+	if in.InsulinSensitivityFactor == 0 {
+		return out
+	}
+
+	if in.NetCarbs != 0 && in.InsulinToCarbRatio == 0 {
+		return out
+	}
+
+	if in.NetCarbs != 0 {
+		out.Carb = in.NetCarbs / in.InsulinToCarbRatio
+	}
+
+	out.Correction = (in.BloodGlucose - in.BloodGlucoseTarget) / in.InsulinSensitivityFactor
+	out.Total = max(0, out.Carb+out.Correction-in.InsulinOnBoard)
+
+	return out
+}
@@ -0,0 +1,53 @@
+package insulin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecommendBolus(t *testing.T) {
+	in := BolusInput{
+		NetCarbs:                 60,
+		BloodGlucose:             10,
+		BloodGlucoseTarget:       6,
+		InsulinToCarbRatio:       10,
+		InsulinSensitivityFactor: 2,
+	}
+
+	got := RecommendBolus(in)
+	assert.InDelta(t, 6, got.Carb, 1e-9)
+	assert.InDelta(t, 2, got.Correction, 1e-9)
+	assert.InDelta(t, 8, got.Total, 1e-9)
+
+	in.InsulinOnBoard = 3
+	got = RecommendBolus(in)
+	assert.InDelta(t, 5, got.Total, 1e-9)
+	assert.InDelta(t, 3, got.InsulinOnBoard, 1e-9)
+
+	// More on board than is needed.
+	in.InsulinOnBoard = 20
+	assert.Zero(t, RecommendBolus(in).Total)
+}
+
+func TestRecommendBolus_MatchesWebUI(t *testing.T) {
+	// No ISF, nothing.
+	assert.Zero(t, RecommendBolus(BolusInput{NetCarbs: 50, InsulinToCarbRatio: 10}).Total)
+
+	// Carbs without an ICR, nothing.
+	assert.Zero(t, RecommendBolus(BolusInput{NetCarbs: 50, BloodGlucose: 12, BloodGlucoseTarget: 6, InsulinSensitivityFactor: 2}).Total)
+
+	// No carbs, correction only.
+	got := RecommendBolus(BolusInput{BloodGlucose: 12, BloodGlucoseTarget: 6, InsulinSensitivityFactor: 2})
+	assert.InDelta(t, 3, got.Total, 1e-9)
+
+	// Below target takes away from the carb dose.
+	got = RecommendBolus(BolusInput{
+		NetCarbs:                 30,
+		BloodGlucose:             4,
+		BloodGlucoseTarget:       6,
+		InsulinToCarbRatio:       10,
+		InsulinSensitivityFactor: 2,
+	})
+	assert.InDelta(t, 2, got.Total, 1e-9)
+}
@@ -0,0 +1,218 @@
+package insulin
+
+import (
+	"karopon/src/database"
+	"math"
+	"time"
+)
+
+const (
+	// The fewest outcomes a ratio is fitted from.
+	MinFitOutcomes = 5
+
+	// How far from the wanted time a glucose reading can be and still be used.
+	ReadingTolerance = 15 * time.Minute
+)
+
+// Event is insulin and carbs logged at a time, with the glucose measured then or zero.
+type Event struct {
+	Time    time.Time
+	Insulin float64
+	Carbs   float64
+	Glucose float64
+}
+
+// Outcome is how much the glucose changed over the action of an event's insulin and carbs.
+type Outcome struct {
+	Insulin float64
+	Carbs   float64
+	Change  float64
+}
+
+// Estimate is a fitted value with its 95% confidence interval, the interval never goes below zero.
+type Estimate struct {
+	Value float64 `json:"value"`
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+}
+
+// RatioFit is the insulin sensitivity factor and insulin to carb ratio that best explain the outcomes.
+// A ratio that could not be fitted is all zero.
+type RatioFit struct {
+	Samples                  int      `json:"samples"`
+	InsulinSensitivityFactor Estimate `json:"insulin_sensitivity_factor"`
+	InsulinToCarbRatio       Estimate `json:"insulin_to_carb_ratio"`
+}
+
+// Outcomes pairs each event with the glucose reading the window after it.
+//
+// Events are skipped unless they are the only insulin or carbs logged within the window before and after them,
+// since stacked doses and meals can't be told apart.
+// The glucose at the event is its own, or otherwise the closest reading, both readings must be within ReadingTolerance.
+//
+// The events and readings must be ordered oldest first.
+func Outcomes(events []Event, readings []database.TblUserGlucose, window time.Duration) []Outcome {
+
+	var treated []Event
+
+	for _, e := range events {
+		if e.Insulin > 0 || e.Carbs > 0 {
+			treated = append(treated, e)
+		}
+	}
+
+	var out []Outcome
+
+	for i, e := range treated {
+
+		if i > 0 && e.Time.Sub(treated[i-1].Time) < window {
+			continue
+		}
+
+		if i+1 < len(treated) && treated[i+1].Time.Sub(e.Time) < window {
+			continue
+		}
+
+		before := e.Glucose
+
+		if before <= 0 {
+			before = database.ClosestGlucose(readings, e.Time, ReadingTolerance)
+		}
+
+		after := database.ClosestGlucose(readings, e.Time.Add(window), ReadingTolerance)
+
+		if before <= 0 || after <= 0 {
+			continue
+		}
+
+		out = append(out, Outcome{
+			Insulin: e.Insulin,
+			Carbs:   e.Carbs,
+			Change:  after - before,
+		})
+	}
+
+	return out
+}
+
+// FitRatios fits the glucose change of the outcomes as rising by a fixed amount per gram of carbs
+// and falling by the insulin sensitivity factor per unit of insulin, by least squares.
+// The insulin to carb ratio is the grams of carbs one unit of insulin covers, the ratio of the two.
+//
+// Basal insulin is assumed to hold the glucose steady, the fit has no other terms.
+// Without carbs in any outcome only the insulin sensitivity factor is fitted.
+// Nothing is fitted from fewer than MinFitOutcomes, or when the best fit makes no sense,
+// like insulin raising the glucose.
+func FitRatios(outcomes []Outcome) RatioFit {
+
+	fit := RatioFit{Samples: len(outcomes)}
+
+	if len(outcomes) < MinFitOutcomes {
+		return fit
+	}
+
+	// Sums of products for the normal equations, with the insulin column negated.
+	var cc, ci, ii, cy, iy float64
+
+	for _, o := range outcomes {
+		cc += o.Carbs * o.Carbs
+		ci -= o.Carbs * o.Insulin
+		ii += o.Insulin * o.Insulin
+		cy += o.Carbs * o.Change
+		iy -= o.Insulin * o.Change
+	}
+
+	if cc == 0 {
+
+		if ii == 0 {
+			return fit
+		}
+
+		isf := iy / ii
+
+		if isf <= 0 {
+			return fit
+		}
+
+		rss := 0.0
+
+		for _, o := range outcomes {
+			r := o.Change + isf*o.Insulin
+			rss += r * r
+		}
+
+		dof := len(outcomes) - 1
+		fit.InsulinSensitivityFactor = newEstimate(isf, math.Sqrt(rss/float64(dof)/ii), dof)
+
+		return fit
+	}
+
+	det := cc*ii - ci*ci
+
+	if det <= 1e-9*cc*ii {
+		return fit
+	}
+
+	// Glucose rise per gram of carbs and fall per unit of insulin.
+	csf := (ii*cy - ci*iy) / det
+	isf := (cc*iy - ci*cy) / det
+
+	if csf <= 0 || isf <= 0 {
+		return fit
+	}
+
+	rss := 0.0
+
+	for _, o := range outcomes {
+		r := o.Change - csf*o.Carbs + isf*o.Insulin
+		rss += r * r
+	}
+
+	dof := len(outcomes) - 2
+	variance := rss / float64(dof)
+
+	varCSF := variance * ii / det
+	varISF := variance * cc / det
+	covar := -variance * ci / det
+
+	icr := isf / csf
+
+	// The delta method, the ratio's variance from the gradient of isf / csf.
+	varICR := varISF/(csf*csf) + isf*isf*varCSF/math.Pow(csf, 4) - 2*isf*covar/math.Pow(csf, 3)
+
+	fit.InsulinSensitivityFactor = newEstimate(isf, math.Sqrt(varISF), dof)
+	fit.InsulinToCarbRatio = newEstimate(icr, math.Sqrt(math.Max(varICR, 0)), dof)
+
+	return fit
+}
+
+func newEstimate(value, stdErr float64, dof int) Estimate {
+
+	margin := tCritical95(dof) * stdErr
+
+	return Estimate{
+		Value: value,
+		Low:   math.Max(value-margin, 0),
+		High:  value + margin,
+	}
+}
+
+// tCritical95 returns the two sided 95% critical value of Student's t distribution.
+func tCritical95(dof int) float64 {
+
+	table := [...]float64{
+		12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+		2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+		2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+	}
+
+	if dof < 1 {
+		return math.Inf(1)
+	}
+
+	if dof <= len(table) {
+		return table[dof-1]
+	}
+
+	return 1.96
+}
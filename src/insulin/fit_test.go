@@ -0,0 +1,86 @@
+package insulin
+
+import (
+	"karopon/src/database"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutcomes(t *testing.T) {
+	start := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+	window := 4 * time.Hour
+
+	at := func(h float64) time.Time {
+		return start.Add(time.Duration(h * float64(time.Hour)))
+	}
+
+	reading := func(t time.Time, glucose float64) database.TblUserGlucose {
+		return database.TblUserGlucose{UserTime: database.TimeMillis(t), Glucose: glucose}
+	}
+
+	var readings []database.TblUserGlucose
+	for m := 0; m < 48*60; m += 5 {
+		readings = append(readings, reading(start.Add(time.Duration(m)*time.Minute), float64(m)/60))
+	}
+
+	events := []Event{
+		{Time: at(1), Insulin: 2, Carbs: 30, Glucose: 6},
+		{Time: at(3), Insulin: 1},                // stacked on the first
+		{Time: at(8), Carbs: 15},                 // no glucose logged, the reading at 8:00 is used
+		{Time: at(12.5)},                         // nothing taken, not an event
+		{Time: at(14), Insulin: 1.5, Carbs: 20},  // 6 hours after the last event
+		{Time: at(45), Insulin: 1, Glucose: 7.5}, // no reading 4 hours later
+	}
+
+	assert.Equal(t, []Outcome{
+		{Carbs: 15, Change: 4},
+		{Insulin: 1.5, Carbs: 20, Change: 4},
+	}, Outcomes(events, readings, window))
+
+	// Readings too far from the wanted time are not used.
+	assert.Empty(t, Outcomes(events[2:3], []database.TblUserGlucose{reading(at(8).Add(-20*time.Minute), 5)}, window))
+}
+
+func TestFitRatios(t *testing.T) {
+
+	// An insulin sensitivity factor of 2 mmol/L per unit and 0.2 mmol/L per gram is an insulin to carb ratio of 10.
+	noise := []float64{0.3, -0.2, 0.1, -0.4, 0.2, 0, -0.1, 0.3, -0.3, 0.1}
+
+	var outcomes []Outcome
+	for i, n := range noise {
+		carbs := float64(10 + i*7%50)
+		insulin := carbs/10 + float64(i%3) - 1
+		outcomes = append(outcomes, Outcome{Insulin: insulin, Carbs: carbs, Change: 0.2*carbs - 2*insulin + n})
+	}
+
+	fit := FitRatios(outcomes)
+	assert.Equal(t, len(noise), fit.Samples)
+	assert.InDelta(t, 2, fit.InsulinSensitivityFactor.Value, 0.2)
+	assert.InDelta(t, 10, fit.InsulinToCarbRatio.Value, 1)
+	assert.Less(t, fit.InsulinSensitivityFactor.Low, 2.0)
+	assert.Greater(t, fit.InsulinSensitivityFactor.High, 2.0)
+	assert.Less(t, fit.InsulinToCarbRatio.Low, 10.0)
+	assert.Greater(t, fit.InsulinToCarbRatio.High, 10.0)
+
+	// Only corrections, the insulin to carb ratio can't be fitted.
+	var corrections []Outcome
+	for i, n := range noise {
+		insulin := float64(1 + i%3)
+		corrections = append(corrections, Outcome{Insulin: insulin, Change: -3*insulin + n})
+	}
+
+	fit = FitRatios(corrections)
+	assert.InDelta(t, 3, fit.InsulinSensitivityFactor.Value, 0.2)
+	assert.Zero(t, fit.InsulinToCarbRatio)
+
+	// Too few outcomes.
+	assert.Equal(t, RatioFit{Samples: 2}, FitRatios(outcomes[:2]))
+
+	// Insulin raising the glucose makes no sense.
+	for i := range corrections {
+		corrections[i].Change = -corrections[i].Change
+	}
+	assert.Equal(t, RatioFit{Samples: len(noise)}, FitRatios(corrections))
+}
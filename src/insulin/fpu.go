@@ -0,0 +1,56 @@
+package insulin
+
+import "time"
+
+const (
+	// A fat-protein unit is 100 kcal of fat and protein.
+	KcalPerFatProteinUnit = 100
+	// Each fat-protein unit is dosed like this many grams of carbs.
+	CarbsPerFatProteinUnit = 10
+
+	kcalPerGramFat     = 9
+	kcalPerGramProtein = 4
+)
+
+// FatProteinUnits returns the fat-protein units (Warsaw method) in the given grams of fat and protein.
+func FatProteinUnits(fat, protein float64) float64 {
+	return (fat*kcalPerGramFat + protein*kcalPerGramProtein) / KcalPerFatProteinUnit
+}
+
+// ExtendedBolusDuration returns how long the insulin for the given fat-protein units should be spread over.
+// 1 FPU is 3 hours, 2 FPU is 4 hours, 3 FPU is 5 hours and 4 or more is 8 hours.
+func ExtendedBolusDuration(fpu float64) time.Duration {
+
+	switch {
+	case fpu <= 0:
+		return 0
+	case fpu < 2:
+		return 3 * time.Hour
+	case fpu < 3:
+		return 4 * time.Hour
+	case fpu < 4:
+		return 5 * time.Hour
+	default:
+		return 8 * time.Hour
+	}
+}
+
+// ExtendedBolus is insulin given slowly, or as a later split dose, for the fat and protein in a meal.
+type ExtendedBolus struct {
+	Units    float64
+	Duration time.Duration
+}
+
+// RecommendExtendedBolus calculates the extended insulin for the given fat-protein units.
+// Without an insulin to carb ratio nothing is recommended.
+func RecommendExtendedBolus(fpu, insulinToCarbRatio float64) ExtendedBolus {
+
+	if fpu <= 0 || insulinToCarbRatio == 0 {
+		return ExtendedBolus{}
+	}
+
+	return ExtendedBolus{
+		Units:    fpu * CarbsPerFatProteinUnit / insulinToCarbRatio,
+		Duration: ExtendedBolusDuration(fpu),
+	}
+}
@@ -0,0 +1,34 @@
+package insulin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFatProteinUnits(t *testing.T) {
+	// 20g fat and 25g protein is 180 + 100 kcal.
+	assert.InDelta(t, 2.8, FatProteinUnits(20, 25), 1e-9)
+	assert.Zero(t, FatProteinUnits(0, 0))
+}
+
+func TestExtendedBolusDuration(t *testing.T) {
+	assert.Zero(t, ExtendedBolusDuration(0))
+	assert.Equal(t, 3*time.Hour, ExtendedBolusDuration(0.5))
+	assert.Equal(t, 3*time.Hour, ExtendedBolusDuration(1))
+	assert.Equal(t, 4*time.Hour, ExtendedBolusDuration(2))
+	assert.Equal(t, 5*time.Hour, ExtendedBolusDuration(3.5))
+	assert.Equal(t, 8*time.Hour, ExtendedBolusDuration(4))
+	assert.Equal(t, 8*time.Hour, ExtendedBolusDuration(9))
+}
+
+func TestRecommendExtendedBolus(t *testing.T) {
+	got := RecommendExtendedBolus(2.8, 10)
+	assert.InDelta(t, 2.8, got.Units, 1e-9)
+	assert.Equal(t, 4*time.Hour, got.Duration)
+
+	// No ratio, nothing.
+	assert.Zero(t, RecommendExtendedBolus(2.8, 0))
+	assert.Zero(t, RecommendExtendedBolus(0, 10))
+}
@@ -0,0 +1,320 @@
+package photostore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const (
+	// The longest side of a saved photo.
+	MaxImageDimension = 2048
+	// The longest side of a photo thumbnail.
+	ThumbnailDimension = 320
+	// Uploads with more pixels than this are rejected before decoding.
+	MaxSourcePixels = 50_000_000
+
+	jpegQuality      = 85
+	thumbnailQuality = 75
+)
+
+var (
+	ErrUnsupportedImage = errors.New("photo must be a JPEG, PNG, or WebP image")
+	ErrImageTooLarge    = errors.New("photo resolution is too large")
+)
+
+// ProcessedImage is an uploaded image after it has been re-encoded.
+type ProcessedImage struct {
+	Data        []byte
+	Thumbnail   []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+type imageFormat struct {
+	decode       func([]byte) (image.Image, error)
+	decodeConfig func([]byte) (image.Config, error)
+}
+
+var imageFormats = map[string]imageFormat{
+	"jpeg": {
+		decode:       func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) },
+		decodeConfig: func(b []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(b)) },
+	},
+	"png": {
+		decode:       func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) },
+		decodeConfig: func(b []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(b)) },
+	},
+	"webp": {
+		decode:       func(b []byte) (image.Image, error) { return webp.Decode(bytes.NewReader(b)) },
+		decodeConfig: func(b []byte) (image.Config, error) { return webp.DecodeConfig(bytes.NewReader(b)) },
+	},
+}
+
+// DetectImageFormat returns 'jpeg', 'png', or 'webp' from the magic bytes of the data,
+// or an empty string for anything else.
+func DetectImageFormat(data []byte) string {
+
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	}
+
+	return ""
+}
+
+// ProcessImage validates an uploaded JPEG, PNG, or WebP image and re-encodes it.
+//
+// The image is rotated upright using its EXIF orientation, then scaled down to fit within MaxImageDimension.
+// Re-encoding drops all metadata, such as EXIF GPS tags.
+// Opaque images are saved as JPEG, anything with transparency is saved as PNG.
+func ProcessImage(data []byte) (ProcessedImage, error) {
+
+	var out ProcessedImage
+
+	format, ok := imageFormats[DetectImageFormat(data)]
+
+	if !ok {
+		return out, ErrUnsupportedImage
+	}
+
+	// Check the size before decoding, a small file can claim a huge resolution.
+	cfg, err := format.decodeConfig(data)
+
+	if err != nil {
+		return out, errors.Join(ErrUnsupportedImage, err)
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxSourcePixels {
+		return out, ErrImageTooLarge
+	}
+
+	src, err := format.decode(data)
+
+	if err != nil {
+		return out, errors.Join(ErrUnsupportedImage, err)
+	}
+
+	img := scaleToFit(src, MaxImageDimension)
+	img = applyOrientation(img, exifOrientation(data))
+
+	thumb := scaleToFit(img, ThumbnailDimension)
+
+	// The thumbnail is served with the photo's content type, so scaling must not change how it is encoded.
+	out.ContentType = imageContentType(img)
+
+	if out.Data, err = encodeImage(img, out.ContentType, jpegQuality); err != nil {
+		return out, err
+	}
+
+	if out.Thumbnail, err = encodeImage(thumb, out.ContentType, thumbnailQuality); err != nil {
+		return out, err
+	}
+
+	out.Width = img.Bounds().Dx()
+	out.Height = img.Bounds().Dy()
+
+	return out, nil
+}
+
+// fitSize returns the size w x h is scaled to so its longest side is at most maxSide.
+func fitSize(w, h, maxSide int) (int, int) {
+
+	if w <= maxSide && h <= maxSide {
+		return w, h
+	}
+
+	if w >= h {
+		return maxSide, max(1, (h*maxSide+w/2)/w)
+	}
+
+	return max(1, (w*maxSide+h/2)/h), maxSide
+}
+
+// scaleToFit copies the image into a new RGBA image, scaled down so its longest side is at most maxSide.
+func scaleToFit(src image.Image, maxSide int) *image.RGBA {
+
+	b := src.Bounds()
+	w, h := fitSize(b.Dx(), b.Dy(), maxSide)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	if w == b.Dx() && h == b.Dy() {
+		draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	} else {
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	}
+
+	return dst
+}
+
+// imageContentType returns the content type the image is encoded as,
+// JPEG when it is opaque and PNG when it has transparency.
+func imageContentType(img *image.RGBA) string {
+
+	if img.Opaque() {
+		return "image/jpeg"
+	}
+
+	return "image/png"
+}
+
+// encodeImage encodes the image as the given content type from imageContentType.
+func encodeImage(img *image.RGBA, contentType string, quality int) ([]byte, error) {
+
+	var buf bytes.Buffer
+
+	if contentType == "image/jpeg" {
+
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+
+		return buf.Bytes(), err
+	}
+
+	err := png.Encode(&buf, img)
+
+	return buf.Bytes(), err
+}
+
+// applyOrientation rotates and flips the image by the EXIF orientation, so it displays upright.
+// Orientations 5 to 8 swap the width and height.
+func applyOrientation(src *image.RGBA, orientation int) *image.RGBA {
+
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := range h {
+		for x := range w {
+
+			var dx, dy int
+
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 270 clockwise
+				dx, dy = y, w-1-x
+			}
+
+			si := src.PixOffset(src.Rect.Min.X+x, src.Rect.Min.Y+y)
+			di := dst.PixOffset(dx, dy)
+
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+
+	return dst
+}
+
+// exifOrientation reads the orientation tag from the EXIF data of a JPEG.
+// Returns 1 (upright) when there is no EXIF data or it can't be read.
+func exifOrientation(data []byte) int {
+
+	const orientationTag = 0x0112
+
+	// Walk the JPEG segments until the image data starts, looking for the EXIF APP1 segment.
+	for i := 2; i+4 <= len(data); {
+
+		if data[i] != 0xFF {
+			return 1
+		}
+
+		marker := data[i+1]
+
+		// Padding before a marker.
+		if marker == 0xFF {
+			i++
+			continue
+		}
+
+		// Start of scan or end of image, no more metadata.
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : i+2+size]
+		i += 2 + size
+
+		if marker != 0xE1 || !bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			continue
+		}
+
+		tiff := segment[6:]
+
+		if len(tiff) < 8 {
+			return 1
+		}
+
+		var order binary.ByteOrder
+
+		switch string(tiff[:2]) {
+		case "II":
+			order = binary.LittleEndian
+		case "MM":
+			order = binary.BigEndian
+		default:
+			return 1
+		}
+
+		ifd := int(order.Uint32(tiff[4:]))
+
+		if ifd < 8 || ifd+2 > len(tiff) {
+			return 1
+		}
+
+		entries := int(order.Uint16(tiff[ifd:]))
+
+		for e := range entries {
+
+			entry := ifd + 2 + e*12
+
+			if entry+12 > len(tiff) {
+				return 1
+			}
+
+			if order.Uint16(tiff[entry:]) == orientationTag {
+				return int(order.Uint16(tiff[entry+8:]))
+			}
+		}
+
+		return 1
+	}
+
+	return 1
+}
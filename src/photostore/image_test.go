@@ -0,0 +1,206 @@
+package photostore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImage returns a w x h image, red on the left half and blue on the right.
+func testImage(w, h int, alpha uint8) *image.NRGBA {
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	for y := range h {
+		for x := range w {
+			if x < w/2 {
+				img.SetNRGBA(x, y, color.NRGBA{R: 255, A: alpha})
+			} else {
+				img.SetNRGBA(x, y, color.NRGBA{B: 255, A: alpha})
+			}
+		}
+	}
+
+	return img
+}
+
+func encodeTestJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+func encodeTestPNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// withExif inserts an EXIF APP1 segment with the given orientation and a fake GPS IFD pointer after the SOI marker.
+func withExif(jpg []byte, orientation uint16) []byte {
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(42))
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(8))
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(2))
+
+	// Orientation, SHORT, 1 value.
+	_ = binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(1))
+	_ = binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+
+	// GPSInfo, LONG, 1 value.
+	_ = binary.Write(&tiff, binary.BigEndian, []uint16{0x8825, 4})
+	_ = binary.Write(&tiff, binary.BigEndian, []uint32{1, 0})
+
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	var out bytes.Buffer
+	out.Write(jpg[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	_ = binary.Write(&out, binary.BigEndian, uint16(len(payload)+2))
+	out.Write(payload)
+	out.Write(jpg[2:])
+
+	return out.Bytes()
+}
+
+func TestDetectImageFormat(t *testing.T) {
+	assert.Equal(t, "jpeg", DetectImageFormat([]byte{0xFF, 0xD8, 0xFF, 0xE0}))
+	assert.Equal(t, "png", DetectImageFormat([]byte("\x89PNG\r\n\x1a\n....")))
+	assert.Equal(t, "webp", DetectImageFormat([]byte("RIFF\x00\x00\x00\x00WEBPVP8 ")))
+	assert.Empty(t, DetectImageFormat([]byte("RIFF\x00\x00\x00\x00WAVEfmt ")))
+	assert.Empty(t, DetectImageFormat([]byte("GIF89a")))
+	assert.Empty(t, DetectImageFormat(nil))
+}
+
+func TestProcessImage_Rejects(t *testing.T) {
+	_, err := ProcessImage([]byte("<html>not an image</html>"))
+	require.ErrorIs(t, err, ErrUnsupportedImage)
+
+	// Right magic bytes, broken image.
+	_, err = ProcessImage([]byte("\x89PNG\r\n\x1a\nnope"))
+	require.ErrorIs(t, err, ErrUnsupportedImage)
+
+	// A valid header claiming a huge resolution is rejected before decoding.
+	huge := encodeTestPNG(t, testImage(1, 1, 255))
+	binary.BigEndian.PutUint32(huge[16:], 100_000)
+	binary.BigEndian.PutUint32(huge[20:], 100_000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	_, err = ProcessImage(huge)
+	require.ErrorIs(t, err, ErrImageTooLarge)
+
+	// 65536 * 65536 wraps to 0 in a 32 bit int.
+	binary.BigEndian.PutUint32(huge[16:], 65536)
+	binary.BigEndian.PutUint32(huge[20:], 65536)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	_, err = ProcessImage(huge)
+	require.ErrorIs(t, err, ErrImageTooLarge)
+}
+
+func TestProcessImage_Bounded(t *testing.T) {
+	out, err := ProcessImage(encodeTestJPEG(t, testImage(3000, 1000, 255)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "image/jpeg", out.ContentType)
+	assert.Equal(t, MaxImageDimension, out.Width)
+	assert.Equal(t, 683, out.Height)
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(out.Data))
+	require.NoError(t, err)
+	assert.Equal(t, out.Width, cfg.Width)
+	assert.Equal(t, out.Height, cfg.Height)
+
+	cfg, err = jpeg.DecodeConfig(bytes.NewReader(out.Thumbnail))
+	require.NoError(t, err)
+	assert.Equal(t, ThumbnailDimension, cfg.Width)
+	assert.Equal(t, 107, cfg.Height)
+}
+
+func TestProcessImage_StripsExifAndRotates(t *testing.T) {
+	src := withExif(encodeTestJPEG(t, testImage(40, 20, 255)), 6)
+	require.Contains(t, string(src), "Exif")
+	require.Equal(t, 6, exifOrientation(src))
+
+	out, err := ProcessImage(src)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(out.Data), "Exif")
+	assert.Equal(t, 1, exifOrientation(out.Data))
+
+	// Rotated 90 clockwise, the red left half is now on top.
+	assert.Equal(t, 20, out.Width)
+	assert.Equal(t, 40, out.Height)
+
+	img, err := jpeg.Decode(bytes.NewReader(out.Data))
+	require.NoError(t, err)
+
+	r, _, b, _ := img.At(10, 5).RGBA()
+	assert.Greater(t, r, b)
+	r, _, b, _ = img.At(10, 35).RGBA()
+	assert.Greater(t, b, r)
+}
+
+func TestProcessImage_KeepsTransparency(t *testing.T) {
+	out, err := ProcessImage(encodeTestPNG(t, testImage(10, 10, 128)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "image/png", out.ContentType)
+	assert.Equal(t, "png", DetectImageFormat(out.Thumbnail))
+
+	out, err = ProcessImage(encodeTestPNG(t, testImage(10, 10, 255)))
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", out.ContentType)
+}
+
+func TestProcessImage_ThumbnailMatchesPhotoFormat(t *testing.T) {
+	// One barely transparent pixel, which scaling the thumbnail down averages away.
+	img := testImage(1000, 1000, 255)
+	img.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 254})
+
+	require.True(t, scaleToFit(img, ThumbnailDimension).Opaque())
+
+	out, err := ProcessImage(encodeTestPNG(t, img))
+	require.NoError(t, err)
+
+	assert.Equal(t, "image/png", out.ContentType)
+	assert.Equal(t, "png", DetectImageFormat(out.Thumbnail))
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 2x1, red then blue.
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.RGBA{R: 255, A: 255})
+	src.Set(1, 0, color.RGBA{B: 255, A: 255})
+
+	red := color.RGBA{R: 255, A: 255}
+
+	cases := map[int]image.Point{
+		1: {0, 0},
+		2: {1, 0},
+		3: {1, 0},
+		4: {0, 0},
+		5: {0, 0},
+		6: {0, 0},
+		7: {0, 1},
+		8: {0, 1},
+	}
+
+	for orientation, at := range cases {
+		out := applyOrientation(src, orientation)
+		assert.Equal(t, red, out.RGBAAt(at.X, at.Y), "orientation %d", orientation)
+	}
+
+	assert.Equal(t, image.Rect(0, 0, 1, 2), applyOrientation(src, 6).Bounds())
+}
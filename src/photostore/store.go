@@ -0,0 +1,329 @@
+// Package photostore decides where the bytes of a user photo are kept.
+//
+// The database always keeps the photo metadata in PON_USER_PHOTO,
+// the STORAGE column names the Store that holds the bytes, and HASH is the hex SHA-256 of the bytes.
+package photostore
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"karopon/src/database"
+	"sync"
+	"time"
+)
+
+const (
+	// Photo bytes are kept in the DATA column of the photo row.
+	StorageDB = "db"
+	// Photo bytes are kept in a directory, named by their hash.
+	StorageFS = "fs"
+)
+
+var (
+	ErrUnknownStorage = errors.New("photo storage is not configured")
+)
+
+// Store keeps photo bytes somewhere, keyed by their hash.
+type Store interface {
+
+	// Storage is the value saved to the STORAGE column for photos in this store.
+	Storage() string
+
+	// Put saves the photo and thumbnail bytes and sets the photo's Storage, Data and Thumbnail for the database row.
+	// The photo's Hash must already be set, the thumbnail may be empty.
+	Put(ctx context.Context, photo *database.TblUserPhoto, data []byte, thumb []byte) error
+
+	// Get returns the bytes of the given photo.
+	Get(ctx context.Context, photo *database.TblUserPhoto) ([]byte, error)
+
+	// GetThumbnail returns the thumbnail bytes of the given photo, empty if it has none.
+	GetThumbnail(ctx context.Context, photo *database.TblUserPhoto) ([]byte, error)
+
+	// Release is called after a photo row is deleted,
+	// refs is the number of photo rows in this storage that still have the same hash.
+	Release(ctx context.Context, photo *database.TblUserPhoto, refs int) error
+}
+
+// Hash returns the hex SHA-256 of the given data.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Photos reads and writes user photos through the database and the configured stores.
+type Photos struct {
+	db database.DB
+
+	// New photos are written here.
+	store Store
+
+	// Every store photos can be read from, by their Storage.
+	stores map[string]Store
+
+	// Held while adding or removing photos,
+	// so a deduplicated file can't be released while a new row is being added for it.
+	// This only covers one process, so two processes must never write to the same store.
+	lock sync.Mutex
+}
+
+// New returns Photos writing new photos to the given store.
+// Photos already kept in any of the other stores can still be read and deleted.
+func New(db database.DB, store Store, others ...Store) *Photos {
+
+	p := &Photos{
+		db:     db,
+		store:  store,
+		stores: map[string]Store{store.Storage(): store},
+	}
+
+	for _, s := range others {
+		if _, ok := p.stores[s.Storage()]; !ok {
+			p.stores[s.Storage()] = s
+		}
+	}
+
+	return p
+}
+
+// Add processes the uploaded image and saves it as a new photo for the user,
+// see ProcessImage for what is accepted.
+// Returns the saved photo, without its data.
+func (p *Photos) Add(ctx context.Context, userID int, upload []byte) (database.TblUserPhoto, error) {
+
+	img, err := ProcessImage(upload)
+
+	if err != nil {
+		return database.TblUserPhoto{}, err
+	}
+
+	photo := database.TblUserPhoto{
+		UserID:      userID,
+		Hash:        Hash(img.Data),
+		ContentType: img.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := p.store.Put(ctx, &photo, img.Data, img.Thumbnail); err != nil {
+		return photo, err
+	}
+
+	id, err := p.db.AddUserPhoto(ctx, &photo)
+
+	if err != nil {
+		// Nothing finds the bytes without a row, so drop them unless another row has the same hash.
+		return photo, errors.Join(err, p.release(ctx, &photo))
+	}
+
+	photo.ID = id
+	photo.Data = nil
+	photo.Thumbnail = nil
+
+	return photo, nil
+}
+
+// Load reads the user's photo and its bytes.
+// Returns sql.ErrNoRows if the photo does not exist or belongs to another user.
+func (p *Photos) Load(ctx context.Context, userID int, photoID int) (database.TblUserPhoto, []byte, error) {
+
+	var photo database.TblUserPhoto
+
+	if err := p.db.LoadUserPhoto(ctx, userID, photoID, &photo); err != nil {
+		return photo, nil, err
+	}
+
+	store, err := p.storeFor(&photo)
+
+	if err != nil {
+		return photo, nil, err
+	}
+
+	data, err := store.Get(ctx, &photo)
+
+	return photo, data, err
+}
+
+// LoadThumbnail reads the user's photo and its thumbnail bytes.
+// Photos without a thumbnail return the full photo instead,
+// as do photos whose thumbnail was saved in a different format than the photo's ContentType.
+func (p *Photos) LoadThumbnail(ctx context.Context, userID int, photoID int) (database.TblUserPhoto, []byte, error) {
+
+	var photo database.TblUserPhoto
+
+	if err := p.db.LoadUserPhoto(ctx, userID, photoID, &photo); err != nil {
+		return photo, nil, err
+	}
+
+	store, err := p.storeFor(&photo)
+
+	if err != nil {
+		return photo, nil, err
+	}
+
+	thumb, err := store.GetThumbnail(ctx, &photo)
+
+	if err != nil {
+		return photo, nil, err
+	}
+
+	// Older uploads could scale into an image with different transparency, and be encoded differently.
+	if len(thumb) > 0 && "image/"+DetectImageFormat(thumb) == photo.ContentType {
+		return photo, thumb, nil
+	}
+
+	data, err := store.Get(ctx, &photo)
+
+	return photo, data, err
+}
+
+// Delete removes the user's photo, and its bytes once nothing else refers to them.
+// Returns database.ErrUserDoesNotHaveThisID if the photo does not exist or belongs to another user.
+func (p *Photos) Delete(ctx context.Context, userID int, photoID int) error {
+
+	var photo database.TblUserPhoto
+
+	if err := p.db.LoadUserPhoto(ctx, userID, photoID, &photo); err != nil {
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.ErrUserDoesNotHaveThisID
+		}
+
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := p.db.DeleteUserPhoto(ctx, userID, photoID); err != nil {
+		return err
+	}
+
+	return p.release(ctx, &photo)
+}
+
+// DeleteUnlinked removes every photo created before the given time which is not linked to an eventlog.
+// Returns the number of removed photos.
+func (p *Photos) DeleteUnlinked(ctx context.Context, createdBefore time.Time) (int, error) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	photos, err := p.db.DeleteUnlinkedUserPhotos(ctx, createdBefore)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+
+	for i := range photos {
+		errs = append(errs, p.release(ctx, &photos[i]))
+	}
+
+	return len(photos), errors.Join(errs...)
+}
+
+// MoveAll moves every photo kept in the from storage into the store new photos are written to.
+// Returns the number of moved photos.
+// The server must be stopped first, its photo adds and deletes are not locked against this process.
+func (p *Photos) MoveAll(ctx context.Context, from string) (int, error) {
+
+	src, ok := p.stores[from]
+
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownStorage, from)
+	}
+
+	if src == p.store {
+		return 0, nil
+	}
+
+	var ids []int
+
+	if err := p.db.LoadPhotoIDsByStorage(ctx, from, &ids); err != nil {
+		return 0, err
+	}
+
+	for n, id := range ids {
+
+		if err := p.move(ctx, src, id); err != nil {
+			return n, fmt.Errorf("failed to move photo %d: %w", id, err)
+		}
+	}
+
+	return len(ids), nil
+}
+
+func (p *Photos) move(ctx context.Context, src Store, photoID int) error {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var photo database.TblUserPhoto
+
+	if err := p.db.LoadPhotoByID(ctx, photoID, &photo); err != nil {
+		return err
+	}
+
+	data, err := src.Get(ctx, &photo)
+
+	if err != nil {
+		return err
+	}
+
+	thumb, err := src.GetThumbnail(ctx, &photo)
+
+	if err != nil {
+		return err
+	}
+
+	old := photo
+	photo.Hash = Hash(data)
+
+	if err := p.store.Put(ctx, &photo, data, thumb); err != nil {
+		return err
+	}
+
+	if err := p.db.UpdatePhotoStorage(ctx, &photo); err != nil {
+		// The row is still in the old storage, so the bytes just put are only kept if another row shares them.
+		return errors.Join(err, p.release(ctx, &photo))
+	}
+
+	return p.release(ctx, &old)
+}
+
+func (p *Photos) storeFor(photo *database.TblUserPhoto) (Store, error) {
+
+	store, ok := p.stores[photo.Storage]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStorage, photo.Storage)
+	}
+
+	return store, nil
+}
+
+// release tells the photo's store the row is gone, the lock must be held.
+func (p *Photos) release(ctx context.Context, photo *database.TblUserPhoto) error {
+
+	store, err := p.storeFor(photo)
+
+	if err != nil {
+		return err
+	}
+
+	refs, err := p.db.CountPhotosByHash(ctx, photo.Storage, photo.Hash)
+
+	if err != nil {
+		return err
+	}
+
+	return store.Release(ctx, photo, refs)
+}
@@ -0,0 +1,37 @@
+package photostore
+
+import (
+	"context"
+	"karopon/src/database"
+)
+
+// DBStore keeps the photo bytes in the DATA column of the photo row.
+type DBStore struct{}
+
+func NewDBStore() *DBStore {
+	return &DBStore{}
+}
+
+func (s *DBStore) Storage() string {
+	return StorageDB
+}
+
+func (s *DBStore) Put(_ context.Context, photo *database.TblUserPhoto, data []byte, thumb []byte) error {
+	photo.Storage = StorageDB
+	photo.Data = data
+	photo.Thumbnail = thumb
+	return nil
+}
+
+func (s *DBStore) Get(_ context.Context, photo *database.TblUserPhoto) ([]byte, error) {
+	return photo.Data, nil
+}
+
+func (s *DBStore) GetThumbnail(_ context.Context, photo *database.TblUserPhoto) ([]byte, error) {
+	return photo.Thumbnail, nil
+}
+
+// Release does nothing, the bytes went with the row.
+func (s *DBStore) Release(_ context.Context, _ *database.TblUserPhoto, _ int) error {
+	return nil
+}
@@ -0,0 +1,170 @@
+package photostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"karopon/src/database"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// Thumbnails are kept next to their photo, with this suffix.
+	thumbnailSuffix = ".thumb"
+)
+
+var (
+	ErrInvalidHash = errors.New("invalid photo hash")
+)
+
+// FSStore keeps the photo bytes in a directory, one file per distinct hash.
+// Photos with the same bytes share a file, which is removed once no photo row refers to it.
+type FSStore struct {
+	dir string
+}
+
+// NewFSStore returns a store writing to the given directory, creating it if needed.
+func NewFSStore(dir string) (*FSStore, error) {
+
+	if dir == "" {
+		return nil, errors.New("photo directory is required for filesystem photo storage")
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	return &FSStore{dir: dir}, nil
+}
+
+func (s *FSStore) Storage() string {
+	return StorageFS
+}
+
+// path returns where the bytes with the given hash are kept.
+// The files are spread over sub directories by the first 2 characters of the hash.
+func (s *FSStore) path(hash string) (string, error) {
+
+	if len(hash) != 64 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidHash, hash)
+	}
+
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", fmt.Errorf("%w: %q", ErrInvalidHash, hash)
+		}
+	}
+
+	return filepath.Join(s.dir, hash[:2], hash), nil
+}
+
+func (s *FSStore) Put(_ context.Context, photo *database.TblUserPhoto, data []byte, thumb []byte) error {
+
+	path, err := s.path(photo.Hash)
+
+	if err != nil {
+		return err
+	}
+
+	photo.Storage = StorageFS
+	photo.Data = []byte{}
+	photo.Thumbnail = []byte{}
+
+	if err := writeFileOnce(path, data); err != nil {
+		return err
+	}
+
+	if len(thumb) == 0 {
+		return nil
+	}
+
+	return writeFileOnce(path+thumbnailSuffix, thumb)
+}
+
+// writeFileOnce writes the data to the path, unless the file already exists.
+// Files are named by their hash, so an existing file already has the same bytes.
+func writeFileOnce(path string, data []byte) error {
+
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// Write to a temp file first, so a partial write never shows up under the hash.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FSStore) Get(_ context.Context, photo *database.TblUserPhoto) ([]byte, error) {
+
+	path, err := s.path(photo.Hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path)
+}
+
+func (s *FSStore) GetThumbnail(_ context.Context, photo *database.TblUserPhoto) ([]byte, error) {
+
+	path, err := s.path(photo.Hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path + thumbnailSuffix)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return data, err
+}
+
+// Release removes the files once no photo row refers to them.
+func (s *FSStore) Release(_ context.Context, photo *database.TblUserPhoto, refs int) error {
+
+	if refs > 0 {
+		return nil
+	}
+
+	path, err := s.path(photo.Hash)
+
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []string{path + thumbnailSuffix, path} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package photostore
+
+import (
+	"context"
+	"errors"
+	"karopon/src/database"
+	"karopon/src/database/mock_db"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSStore_PutGetRelease(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+
+	store, err := NewFSStore(dir)
+	require.NoError(t, err)
+
+	data := []byte("not really a photo")
+	thumb := []byte("not really a thumbnail")
+	photo := database.TblUserPhoto{Hash: Hash(data)}
+
+	require.NoError(t, store.Put(ctx, &photo, data, thumb))
+	assert.Equal(t, StorageFS, photo.Storage)
+	assert.NotNil(t, photo.Data)
+	assert.Empty(t, photo.Data)
+	assert.NotNil(t, photo.Thumbnail)
+	assert.Empty(t, photo.Thumbnail)
+
+	path := filepath.Join(dir, photo.Hash[:2], photo.Hash)
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	// Same bytes again, same file.
+	again := database.TblUserPhoto{Hash: Hash(data)}
+	require.NoError(t, store.Put(ctx, &again, data, nil))
+
+	got, err := store.Get(ctx, &again)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	got, err = store.GetThumbnail(ctx, &again)
+	require.NoError(t, err)
+	assert.Equal(t, thumb, got)
+
+	// Still referenced by another row.
+	require.NoError(t, store.Release(ctx, &photo, 1))
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Release(ctx, &photo, 0))
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(path + thumbnailSuffix)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// Missing thumbnails are empty, not an error.
+	got, err = store.GetThumbnail(ctx, &photo)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	// Releasing a missing file is fine.
+	require.NoError(t, store.Release(ctx, &photo, 0))
+}
+
+func TestFSStore_InvalidHash(t *testing.T) {
+	ctx := t.Context()
+
+	store, err := NewFSStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, hash := range []string{"", "abc", "../../../../etc/passwd", Hash(nil)[:63] + "G"} {
+		photo := database.TblUserPhoto{Hash: hash}
+
+		require.ErrorIs(t, store.Put(ctx, &photo, []byte{1}, nil), ErrInvalidHash, hash)
+
+		_, err := store.Get(ctx, &photo)
+		require.ErrorIs(t, err, ErrInvalidHash, hash)
+	}
+}
+
+func TestNewFSStore_NoDir(t *testing.T) {
+	_, err := NewFSStore("")
+	require.Error(t, err)
+}
+
+// failingPhotoDB fails to add photo rows, and has refs rows sharing any hash.
+type failingPhotoDB struct {
+	mock_db.BaseMockDB
+	refs int
+}
+
+func (m *failingPhotoDB) AddUserPhoto(ctx context.Context, photo *database.TblUserPhoto) (int, error) {
+	return 0, errors.New("insert failed")
+}
+
+func (m *failingPhotoDB) CountPhotosByHash(ctx context.Context, storage string, hash string) (int, error) {
+	return m.refs, nil
+}
+
+func TestPhotos_AddReleasesBytesWhenInsertFails(t *testing.T) {
+	ctx := t.Context()
+	upload := encodeTestPNG(t, testImage(8, 8, 255))
+
+	for _, refs := range []int{0, 1} {
+		dir := t.TempDir()
+
+		store, err := NewFSStore(dir)
+		require.NoError(t, err)
+
+		photo, err := New(&failingPhotoDB{refs: refs}, store).Add(ctx, 1, upload)
+		require.Error(t, err)
+
+		_, err = os.Stat(filepath.Join(dir, photo.Hash[:2], photo.Hash))
+
+		if refs == 0 {
+			require.ErrorIs(t, err, os.ErrNotExist, "no row refers to the file")
+		} else {
+			require.NoError(t, err, "another row still refers to the file")
+		}
+	}
+}
+
+// photoRowDB has a single photo row.
+type photoRowDB struct {
+	mock_db.BaseMockDB
+	photo database.TblUserPhoto
+}
+
+func (m *photoRowDB) LoadUserPhoto(ctx context.Context, userID int, photoID int, out *database.TblUserPhoto) error {
+	*out = m.photo
+	return nil
+}
+
+func TestPhotos_LoadThumbnailFormatMismatch(t *testing.T) {
+	ctx := t.Context()
+
+	data := encodeTestPNG(t, testImage(8, 8, 128))
+	thumb := encodeTestJPEG(t, testImage(4, 4, 255))
+
+	db := &photoRowDB{photo: database.TblUserPhoto{
+		Storage:     StorageDB,
+		ContentType: "image/png",
+		Data:        data,
+		Thumbnail:   thumb,
+	}}
+
+	// An older upload whose thumbnail was saved as a JPEG gets the PNG photo instead.
+	_, got, err := New(db, NewDBStore()).LoadThumbnail(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	db.photo.ContentType = "image/jpeg"
+
+	_, got, err = New(db, NewDBStore()).LoadThumbnail(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, thumb, got)
+}
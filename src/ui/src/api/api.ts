@@ -11,7 +11,9 @@ import {
     UpdateUserEventLog,
     TblUserBodyLog,
     TblDataSource,
-    TblDataSourceFood,
+    DataSourceFood,
+    NutrientInfo,
+    UserRecipe,
     TblUserGoal,
     UserGoalProgress,
     CheckGoalProgress,
@@ -23,6 +25,24 @@ import {
     TblUserTagColor,
 } from './types';
 import {StatsTimeRequest, TimespanTagDurationPoint} from './types_stats_time';
+import {
+    InsulinBolus,
+    InsulinRecommendRequest,
+    InsulinReport,
+    RatioFit,
+    StatsInsulinRequest,
+    TblUserInsulinProfileBlock,
+} from './types_insulin';
+import {
+    GlucoseHourPercentiles,
+    GlucoseImportResult,
+    GlucoseMetrics,
+    GlucoseRequest,
+    MealResponses,
+    StatsGlucoseRequest,
+    StatsMealsRequest,
+    TblUserGlucose,
+} from './types_glucose';
 
 export class ApiError extends Error {
     public readonly status: number;
@@ -171,11 +191,15 @@ export const ApiGetDataSources = (): Promise<TblDataSource[]> => {
     return fetchJson(`${ApiBase}/api/datasources`);
 };
 
-export const ApiGetDataSourceFoods = (dataSourceID: number, search: string): Promise<TblDataSourceFood[]> => {
+export const ApiGetDataSourceFoods = (dataSourceID: number, search: string): Promise<DataSourceFood[]> => {
     const encodedSearch = encodeURIComponent(search);
     return fetchJson(`${ApiBase}/api/datasources/${dataSourceID}/${encodedSearch}`);
 };
 
+export const ApiGetDataSourceFoodsByBarcode = (barcode: string): Promise<DataSourceFood[]> => {
+    return fetchJson(`${ApiBase}/api/datasources/barcode/${encodeURIComponent(barcode)}`);
+};
+
 export const ApiUploadEventPhoto = (file: File): Promise<{id: number}> => {
     const formData = new FormData();
     formData.append('photo', file);
@@ -461,3 +485,166 @@ export const ApiGetStatsTime = (query: StatsTimeRequest): Promise<TimespanTagDur
         body: JSON.stringify(query),
     });
 };
+
+export const ApiInsulinRecommend = (req: InsulinRecommendRequest): Promise<InsulinBolus> => {
+    return fetchJson(`${ApiBase}/api/insulin/recommend`, {
+        headers: {
+            'content-type': 'application/json',
+        },
+        method: 'POST',
+        body: JSON.stringify(req),
+    });
+};
+
+export const ApiGetInsulinProfile = (): Promise<TblUserInsulinProfileBlock[]> => {
+    return fetchJson(`${ApiBase}/api/insulin/profile`);
+};
+
+export const ApiNewInsulinProfileBlock = (block: TblUserInsulinProfileBlock): Promise<TblUserInsulinProfileBlock> => {
+    return fetchJson(`${ApiBase}/api/insulin/profile/new`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify(block),
+    });
+};
+
+export const ApiUpdateInsulinProfileBlock = (block: TblUserInsulinProfileBlock): Promise<TblUserInsulinProfileBlock> => {
+    return fetchJson(`${ApiBase}/api/insulin/profile/update`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify(block),
+    });
+};
+
+export const ApiDeleteInsulinProfileBlock = (id: number): Promise<void> => {
+    return fetchNone(`${ApiBase}/api/insulin/profile/delete`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify({id}),
+    });
+};
+
+export const ApiGetGlucose = (req: GlucoseRequest): Promise<TblUserGlucose[]> => {
+    return fetchJson(`${ApiBase}/api/glucose`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify(req),
+    });
+};
+
+export const ApiNewGlucoseReadings = (readings: TblUserGlucose[]): Promise<{added: number}> => {
+    return fetchJson(`${ApiBase}/api/glucose/new`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify(readings),
+    });
+};
+
+export const ApiDeleteGlucose = (id: number): Promise<void> => {
+    return fetchNone(`${ApiBase}/api/glucose/delete`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify({id}),
+    });
+};
+
+export const ApiNewNightscoutSecret = (): Promise<{secret: string}> => {
+    return fetchJson(`${ApiBase}/api/nightscout/secret/new`, {
+        method: 'POST',
+    });
+};
+
+export const ApiDeleteNightscoutSecret = (): Promise<void> => {
+    return fetchNone(`${ApiBase}/api/nightscout/secret/delete`, {
+        method: 'POST',
+    });
+};
+
+export const ApiGetStatsGlucose = (req: StatsGlucoseRequest): Promise<GlucoseMetrics> => {
+    return fetchJson(`${ApiBase}/api/stats/glucose`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify(req),
+    });
+};
+
+export const ApiGetStatsGlucoseProfile = (req: GlucoseRequest): Promise<GlucoseHourPercentiles[]> => {
+    return fetchJson(`${ApiBase}/api/stats/glucose/profile`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify(req),
+    });
+};
+
+export const ApiGetStatsInsulin = (req: StatsInsulinRequest): Promise<InsulinReport> => {
+    return fetchJson(`${ApiBase}/api/stats/insulin`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify(req),
+    });
+};
+
+export const ApiGetStatsInsulinRatios = (req: GlucoseRequest): Promise<RatioFit> => {
+    return fetchJson(`${ApiBase}/api/stats/insulin/ratios`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify(req),
+    });
+};
+
+export const ApiGetStatsMeals = (req: StatsMealsRequest): Promise<MealResponses> => {
+    return fetchJson(`${ApiBase}/api/stats/meals`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify(req),
+    });
+};
+
+export const ApiImportGlucose = (
+    file: File,
+    opts: {timezone?: string; dayFirst?: boolean; ignoreErrors?: boolean} = {}
+): Promise<GlucoseImportResult> => {
+    const formData = new FormData();
+    formData.append('file', file);
+    if (opts.timezone) {
+        formData.append('timezone', opts.timezone);
+    }
+    formData.append('day_first', String(opts.dayFirst ?? false));
+    formData.append('ignore_errors', String(opts.ignoreErrors ?? false));
+    return fetchJson(`${ApiBase}/api/glucose/import`, {
+        method: 'POST',
+        body: formData,
+    });
+};
+
+export const ApiGetNutrients = (): Promise<NutrientInfo[]> => {
+    return fetchJson(`${ApiBase}/api/nutrients`);
+};
+
+export const ApiGetRecipes = (): Promise<UserRecipe[]> => {
+    return fetchJson(`${ApiBase}/api/recipes`);
+};
+
+export const ApiNewRecipe = (recipe: UserRecipe): Promise<UserRecipe> => {
+    return fetchJson(`${ApiBase}/api/recipe/new`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify(recipe),
+    });
+};
+
+export const ApiUpdateRecipe = (recipe: UserRecipe): Promise<UserRecipe> => {
+    return fetchJson(`${ApiBase}/api/recipe/update`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify(recipe),
+    });
+};
+
+export const ApiDeleteRecipe = (id: number): Promise<void> => {
+    return fetchNone(`${ApiBase}/api/recipe/delete`, {
+        headers: {'content-type': 'application/json'},
+        method: 'POST',
+        body: JSON.stringify({id}),
+    });
+};
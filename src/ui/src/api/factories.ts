@@ -48,6 +48,9 @@ export const TblUserFoodFactory = {
             carb: 0,
             fibre: 0,
             fat: 0,
+            nutrients: {},
+            density: 0,
+            household_units: {},
         };
     },
 };
@@ -69,6 +72,8 @@ export const TblUserFoodLogFactory = {
             carb: 0,
             fibre: 0,
             fat: 0,
+            nutrients: {},
+            recipe_id: null,
         };
     },
 };
@@ -90,6 +95,12 @@ export const UserEventFoodLogFactory = {
                 insulin_to_carb_ratio: 0,
                 recommended_insulin_amount: 0,
                 actual_insulin_taken: 0,
+                fat_protein_units: 0,
+                recommended_extended_insulin_amount: 0,
+                recommended_extended_minutes: 0,
+                actual_extended_insulin_taken: 0,
+                actual_extended_minutes: 0,
+                notes: '',
             },
             foodlogs: [],
             total_protein: 0,
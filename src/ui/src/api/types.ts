@@ -18,6 +18,11 @@ export type TblUser = {
     day_time_offset_seconds: number;
     fill_eventlog_from_last: boolean;
     timespan_history_fetch_limit: number;
+    timezone: string;
+    insulin_action_curve: string;
+    insulin_duration_minutes: number;
+    insulin_peak_minutes: number;
+    glucose_unit: 'mmol/L' | 'mg/dL';
 };
 
 export type TblUpdateUser = {
@@ -53,8 +58,31 @@ export type TblUserEventLog = {
     insulin_to_carb_ratio: number;
     recommended_insulin_amount: number;
     actual_insulin_taken: number;
+    fat_protein_units: number;
+    recommended_extended_insulin_amount: number;
+    recommended_extended_minutes: number;
+    actual_extended_insulin_taken: number;
+    actual_extended_minutes: number;
+    notes: string;
+};
+
+// The amount of each nutrient in the unit of its NutrientInfo, missing nutrients are unknown.
+export type Nutrients = Record<string, number>;
+
+export type NutrientInfo = {
+    nutrient: string;
+    name: string;
+    unit: string;
+};
+
+export type Quantity = {
+    amount: number;
+    unit: string;
 };
 
+// Units of a food like a slice or a scoop, by name, as an amount of a mass or volume unit.
+export type HouseholdUnits = Record<string, Quantity>;
+
 export type TblUserFood = {
     id: number;
     user_id: number;
@@ -65,6 +93,45 @@ export type TblUserFood = {
     carb: number;
     fibre: number;
     fat: number;
+    nutrients: Nutrients;
+    density: number; // grams per millilitre, 0 if unknown
+    household_units: HouseholdUnits;
+};
+
+export type TblUserRecipe = {
+    id: number;
+    created: number;
+    name: string;
+    unit: string;
+    yield: number; // how many unit the ingredients make
+    notes: string;
+};
+
+// Either food_id or data_source_food_id is set, the portion is in the unit of that food.
+export type TblUserRecipeIngredient = {
+    id: number;
+    recipe_id: number;
+    food_id: number | null;
+    data_source_food_id: number | null;
+    portion: number;
+};
+
+// The name, unit and amounts are the food's for the ingredient's portion.
+export type UserRecipeIngredient = TblUserRecipeIngredient & {
+    name: string;
+    unit: string;
+    protein: number;
+    carb: number;
+    fibre: number;
+    fat: number;
+    nutrients: Nutrients;
+};
+
+// The serving is one unit of the recipe, worked out from the ingredients.
+export type UserRecipe = {
+    recipe: TblUserRecipe;
+    ingredients: UserRecipeIngredient[];
+    serving: TblUserFood;
 };
 
 export type TblUserFoodLog = {
@@ -84,6 +151,10 @@ export type TblUserFoodLog = {
     carb: number;
     fibre: number;
     fat: number;
+    nutrients: Nutrients;
+
+    // The recipe it was logged from, the amounts are a copy of the recipe's when it was logged.
+    recipe_id: number | null;
 };
 
 export type TblUserFoodLogWithKey = TblUserFoodLog & {
@@ -113,6 +184,12 @@ export type InsertUserFoodLog = {
     carb: number;
     fibre: number;
     fat: number;
+
+    // Left out to copy the nutrients of the food.
+    nutrients?: Nutrients;
+
+    // Logs the portion of the recipe, the name, unit and amounts are filled in from it.
+    recipe_id?: number;
 };
 
 export type CreateUserEventLog = {
@@ -124,6 +201,8 @@ export type CreateUserEventLog = {
     insulin_to_carb_ratio: number;
     recommended_insulin_amount: number;
     actual_insulin_taken: number;
+    actual_extended_insulin_taken: number;
+    actual_extended_minutes: number;
     created_time: number;
     photo_ids: number[];
 };
@@ -162,7 +241,23 @@ export type TblDataSourceFood = {
     carb: number;
     fibre: number;
     fat: number;
+    nutrients: Nutrients;
     data_source_row_int_id: number;
+    barcode: string; // GTIN/UPC as 14 digits, empty if unknown
+};
+
+// A serving of a data source food, amount of unit weighing portion of the food's unit.
+export type TblDataSourceFoodServing = {
+    id: number;
+    data_source_food_id: number;
+    name: string;
+    amount: number;
+    unit: string;
+    portion: number;
+};
+
+export type DataSourceFood = TblDataSourceFood & {
+    servings: TblDataSourceFoodServing[];
 };
 
 export const GoalTargetColumnValues = [
@@ -180,6 +275,7 @@ export const GoalTargetColumnValues = [
     'BLOOD_PRESSURE_SYS',
     'BLOOD_PRESSURE_DIA',
     'BLOOD_SUGAR',
+    'GLUCOSE',
 ] as const;
 export type GoalTargetColumn = (typeof GoalTargetColumnValues)[number];
 
@@ -211,7 +307,8 @@ export type TblUserGoal = {
 };
 
 export type CheckGoalProgress = TblUserGoal & {
-    timezone: string;
+    // overrides the user's saved timezone
+    timezone?: string;
     as_of: number;
 };
 
@@ -0,0 +1,70 @@
+// in go, this is database.TblUserGlucose, glucose is in the user's glucose unit
+export type TblUserGlucose = {
+    id: number;
+    user_id: number;
+    created: number;
+    user_time: number;
+    glucose: number;
+    source: string;
+};
+
+export type GlucoseRequest = {
+    start: string;
+    end: string;
+    timezone?: string;
+};
+
+export type StatsGlucoseRequest = GlucoseRequest & {
+    range_low: number; // 0 for the default range
+    range_high: number;
+};
+
+export type GlucoseMetrics = {
+    count: number;
+    range_low: number;
+    range_high: number;
+    time_below_range: number; // percent
+    time_in_range: number;
+    time_above_range: number;
+    mean: number;
+    std_dev: number;
+    coefficient_of_variation: number; // percent
+    gmi: number; // percent
+};
+
+export type GlucoseHourPercentiles = {
+    hour: number;
+    count: number;
+    p5: number;
+    p25: number;
+    p50: number;
+    p75: number;
+    p95: number;
+};
+
+// in go, this is cgm.ImportResult
+export type GlucoseImportResult = {
+    format: string;
+    unit: string;
+    read: number;
+    added: number;
+    duplicates: number;
+    failed: number;
+};
+
+export type StatsMealsRequest = GlucoseRequest & {
+    min_samples: number;
+};
+
+// in go, this is database.MealResponse, the rise is in the user's glucose unit
+export type MealResponse = {
+    name: string;
+    samples: number;
+    mean_rise: number;
+    max_rise: number;
+};
+
+export type MealResponses = {
+    foods: MealResponse[];
+    events: MealResponse[];
+};
@@ -0,0 +1,77 @@
+import {GroupBy} from './types_stats';
+
+export type InsulinRecommendRequest = {
+    net_carbs: number;
+    blood_glucose: number;
+    blood_glucose_target: number;
+    insulin_sensitivity_factor: number;
+    insulin_to_carb_ratio: number;
+    time: number; // this is a timestamp, 0 for now
+};
+
+export type InsulinBolus = {
+    carb: number;
+    correction: number;
+    insulin_on_board: number;
+    total: number;
+};
+
+export type TblUserInsulinProfileBlock = {
+    id: number;
+    user_id: number;
+    start_minute: number; // minutes after local midnight
+    insulin_to_carb_ratio: number;
+    insulin_sensitivity_factor: number;
+    target_blood_sugar: number;
+};
+
+export type StatsInsulinRequest = {
+    start: string;
+    end: string;
+    groupby: GroupBy;
+    timezone?: string;
+};
+
+// in go, this is database.InsulinDosePoint
+export type InsulinDosePoint = {
+    bucket: number; // this is a timestamp
+    days: number; // days in the bucket with an eventlog
+    total_insulin: number;
+    total_daily_dose: number;
+    meal_insulin: number;
+    correction_insulin: number;
+    extended_insulin: number;
+    carbs: number;
+    carbs_per_unit: number;
+    recommended_insulin: number;
+    recommended_insulin_taken: number;
+    insulin_gap: number; // taken - recommended
+    meals: number;
+    missed_boluses: number;
+};
+
+export type MissedBolus = {
+    eventlog_id: number;
+    user_time: number; // this is a timestamp
+    event: string;
+    net_carbs: number;
+    recommended_insulin: number;
+};
+
+export type InsulinReport = {
+    points: InsulinDosePoint[];
+    missed_boluses: MissedBolus[];
+};
+
+export type Estimate = {
+    value: number;
+    low: number; // 95% confidence interval
+    high: number;
+};
+
+// in go, this is insulin.RatioFit, a ratio that could not be fitted is all zero
+export type RatioFit = {
+    samples: number;
+    insulin_sensitivity_factor: Estimate;
+    insulin_to_carb_ratio: Estimate;
+};
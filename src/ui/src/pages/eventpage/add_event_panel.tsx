@@ -1,4 +1,4 @@
-import {useCallback, useLayoutEffect, useRef, useState} from 'preact/hooks';
+import {useCallback, useEffect, useLayoutEffect, useRef, useState} from 'preact/hooks';
 import {
     CreateUserEventLog,
     InsertUserFoodLog,
@@ -20,7 +20,7 @@ import {ErrorDiv} from '../../components/error_div';
 import {DAY_IN_MS, TimeLocalMS} from '../../utils/time';
 import {AddFoodlogPanelRow} from '../../components/add_foodlog_row';
 import {NumberInput} from '../../components/number_input';
-import {ApiUploadEventPhoto} from '../../api/api';
+import {ApiInsulinRecommend, ApiUploadEventPhoto} from '../../api/api';
 import {TimeInput} from '../../components/time_input';
 
 type AddEventsPanelState = {
@@ -52,6 +52,9 @@ export function AddEventsPanel(p: AddEventsPanelState) {
     const [bloodSugar, setBloodSugar] = useState<number>(p.fromEvent.eventlog.blood_glucose);
     const [insulinToCarbRatio, setInsulinToCarbRatio] = useState<number>(p.fromEvent.eventlog.insulin_to_carb_ratio);
     const [insulinTaken, setInsulinTaken] = useState<number>(p.fromEvent.eventlog.actual_insulin_taken);
+    const [extendedTaken, setExtendedTaken] = useState<number>(p.fromEvent.eventlog.actual_extended_insulin_taken);
+    const [extendedMinutes, setExtendedMinutes] = useState<number>(p.fromEvent.eventlog.actual_extended_minutes);
+    const [insulinOnBoard, setInsulinOnBoard] = useState<number>(0);
     const [errorMsg, setErrorMsg] = useState<string | null>(null);
     const [saving, setSaving] = useState(false);
     type StagedPhoto = {url: string; file: File};
@@ -88,6 +91,8 @@ export function AddEventsPanel(p: AddEventsPanelState) {
             setBloodSugar(fromEvent.eventlog.blood_glucose);
             setInsulinToCarbRatio(fromEvent.eventlog.insulin_to_carb_ratio);
             setInsulinTaken(fromEvent.eventlog.actual_insulin_taken);
+            setExtendedTaken(fromEvent.eventlog.actual_extended_insulin_taken);
+            setExtendedMinutes(fromEvent.eventlog.actual_extended_minutes);
             setPhotos((prev) => {
                 for (const ph of prev) {
                     URL.revokeObjectURL(ph.url);
@@ -106,6 +111,27 @@ export function AddEventsPanel(p: AddEventsPanelState) {
         [trailingRows]
     );
 
+    // The server knows the earlier doses, ask it what is still on board at the event time.
+    useEffect(() => {
+        if (!p.user.show_diabetes) {
+            return;
+        }
+        let cancelled = false;
+        ApiInsulinRecommend({
+            net_carbs: 0,
+            blood_glucose: 0,
+            blood_glucose_target: 0,
+            insulin_sensitivity_factor: 0,
+            insulin_to_carb_ratio: 0,
+            time: eventTime.getTime(),
+        })
+            .then((bolus) => !cancelled && setInsulinOnBoard(bolus.insulin_on_board))
+            .catch(() => !cancelled && setInsulinOnBoard(0));
+        return () => {
+            cancelled = true;
+        };
+    }, [eventTime, p.user.show_diabetes]);
+
     useLayoutEffect(() => {
         setEventTime(p.copyDate ? new Date(p.fromEvent.eventlog.user_time) : new Date());
         setFromLastEvent(p.fromEvent);
@@ -136,7 +162,8 @@ export function AddEventsPanel(p: AddEventsPanelState) {
         bloodSugar,
         p.user.target_blood_sugar,
         insulinToCarbRatio,
-        p.user.insulin_sensitivity_factor
+        p.user.insulin_sensitivity_factor,
+        insulinOnBoard
     );
     const calories = CalculateCalories(
         totals.protein,
@@ -175,6 +202,8 @@ export function AddEventsPanel(p: AddEventsPanelState) {
             insulin_to_carb_ratio: insulinToCarbRatio,
             recommended_insulin_amount: insulin,
             actual_insulin_taken: insulinTaken,
+            actual_extended_insulin_taken: extendedTaken,
+            actual_extended_minutes: extendedMinutes,
             created_time: eventTime.getTime(),
             photo_ids: photoIds,
             event: {
@@ -377,6 +406,7 @@ export function AddEventsPanel(p: AddEventsPanelState) {
                         <span title="Insulin injection location and blood meter prick side (changes daily)">
                             {`${days % 2 === 0 ? 'Left' : 'Right'}-${days % 4 <= 1 ? 'Upper' : 'Lower'}`}
                         </span>
+                        <span title="Insulin still working from earlier events">IOB {insulinOnBoard.toFixed(1)}</span>
                         <span title="Insulin calculated for this event's food">Insulin Calc {insulin.toFixed(1)}</span>
                         <span title="Net carbs for this event's food">Net Carbs {netCarb.toFixed(1)}</span>
                     </>
@@ -402,6 +432,22 @@ export function AddEventsPanel(p: AddEventsPanelState) {
                         onValueChange={setInsulinTaken}
                         min={0}
                     />
+                    <NumberInput
+                        className="flex-1 flex-grow"
+                        innerClassName="w-full min-w-12"
+                        label="Extended Insulin Taken"
+                        value={extendedTaken}
+                        onValueChange={setExtendedTaken}
+                        min={0}
+                    />
+                    <NumberInput
+                        className="flex-1 flex-grow"
+                        innerClassName="w-full min-w-12"
+                        label="Extended Over (minutes)"
+                        value={extendedMinutes}
+                        onValueChange={setExtendedMinutes}
+                        min={0}
+                    />
                 </div>
             )}
             <div className="flex justify-between sm:justify-end gap-2">
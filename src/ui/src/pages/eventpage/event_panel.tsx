@@ -27,6 +27,16 @@ export const EventPanel = ({user, foodGroup, actions}: EventPanelState) => {
                     <span title="Insulin to Carb Ratio">{`ITCR ${foodGroup.eventlog.insulin_to_carb_ratio.toFixed(1)}`}</span>
                     <span title="Insulin Recommended">{`InsRec ${foodGroup.eventlog.recommended_insulin_amount.toFixed(1)}`}</span>
                     <span title="Insulin Taken">{`InsTaken ${foodGroup.eventlog.actual_insulin_taken.toFixed(1)}`}</span>
+                    {foodGroup.eventlog.fat_protein_units > 0 && (
+                        <span title="Fat-Protein Units, and the extended insulin recommended for them">
+                            {`FPU ${foodGroup.eventlog.fat_protein_units.toFixed(1)} ExtRec ${foodGroup.eventlog.recommended_extended_insulin_amount.toFixed(1)}/${foodGroup.eventlog.recommended_extended_minutes}m`}
+                        </span>
+                    )}
+                    {foodGroup.eventlog.actual_extended_insulin_taken > 0 && (
+                        <span title="Extended Insulin Taken">
+                            {`ExtTaken ${foodGroup.eventlog.actual_extended_insulin_taken.toFixed(1)}/${foodGroup.eventlog.actual_extended_minutes}m`}
+                        </span>
+                    )}
                 </div>
             )}
 
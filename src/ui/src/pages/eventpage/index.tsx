@@ -47,6 +47,10 @@ export const EventsPage = (state: BaseState) => {
                 event_id: 0,
                 user_id: 0,
                 net_carbs: 0,
+                fat_protein_units: 0,
+                recommended_extended_insulin_amount: 0,
+                recommended_extended_minutes: 0,
+                notes: '',
                 // server ignores these
                 id: eventlogId,
                 user_time: eventlog.created_time,
@@ -57,6 +61,8 @@ export const EventsPage = (state: BaseState) => {
                 insulin_to_carb_ratio: eventlog.insulin_to_carb_ratio,
                 recommended_insulin_amount: eventlog.recommended_insulin_amount,
                 actual_insulin_taken: eventlog.actual_insulin_taken,
+                actual_extended_insulin_taken: eventlog.actual_extended_insulin_taken,
+                actual_extended_minutes: eventlog.actual_extended_minutes,
             },
             foodlogs: eventlog.foods as TblUserFoodLog[],
         };
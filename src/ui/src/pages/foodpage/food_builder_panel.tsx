@@ -36,6 +36,9 @@ export const FoodBuilderPanel = (p: FoodBuilderPanelProps) => {
         carb: 0,
         fibre: 0,
         fat: 0,
+        nutrients: {},
+        density: 0,
+        household_units: {},
     });
     const foods = useMemo<TblUserFoodLogWithKey[]>(
         () => [
@@ -4,6 +4,7 @@ import {DropdownButton} from '../../components/drop_down_button';
 import {DoRender} from '../../hooks/doRender';
 import {CalculateCalories, Str2CalorieFormula} from '../../utils/calories';
 import {ErrorDiv} from '../../components/error_div';
+import {ScaleNutrients} from '../../utils/nutrients';
 import {NumberInput} from '../../components/number_input';
 
 type FoodEditPanelProps = {
@@ -32,6 +33,9 @@ export const FoodEditPanel = ({user, food, updateFood, copyFood, deleteFood}: Fo
             carb: food.carb * portion,
             fibre: food.fibre * portion,
             protein: food.protein * portion,
+            nutrients: ScaleNutrients(food.nutrients, portion),
+            density: food.density,
+            household_units: food.household_units,
             portion,
         };
         setShowUpdatePanel(true);
@@ -129,6 +133,9 @@ export const FoodEditPanel = ({user, food, updateFood, copyFood, deleteFood}: Fo
                                                     carb: food.carb * portion,
                                                     fibre: food.fibre * portion,
                                                     protein: food.protein * portion,
+                                                    nutrients: ScaleNutrients(food.nutrients, portion),
+                                                    density: food.density,
+                                                    household_units: food.household_units,
                                                     portion,
                                                 });
                                             }
@@ -17,8 +17,7 @@ export const GoalPanel = ({goal, asOf, editGoal, deleteGoal}: GoalPanelProps) =>
     useEffect(() => {
         setProgress(null);
         (async () => {
-            const timezone = Intl.DateTimeFormat().resolvedOptions().timeZone;
-            setProgress(await ApiGetUserGoalProgress({...goal, timezone, as_of: asOf}));
+            setProgress(await ApiGetUserGoalProgress({...goal, as_of: asOf}));
         })();
     }, [goal, asOf]);
 
@@ -118,7 +118,7 @@ export function SettingsPage(state: BaseState) {
                     <NumberInput
                         className="w-full input-like"
                         innerClassName="w-full text-right"
-                        label="Target Blood Sugar"
+                        label={`Target Blood Sugar (${state.user.glucose_unit})`}
                         value={userRef.current.target_blood_sugar}
                         onValueChange={(value: number) => update('target_blood_sugar', value)}
                         disabled={!isEditing}
@@ -126,11 +126,63 @@ export function SettingsPage(state: BaseState) {
                     <NumberInput
                         className="w-full input-like"
                         innerClassName="w-full text-right"
-                        label="Insulin Sensitivity Factor"
+                        label={`Insulin Sensitivity Factor (${state.user.glucose_unit})`}
                         value={userRef.current.insulin_sensitivity_factor}
                         onValueChange={(value: number) => update('insulin_sensitivity_factor', value)}
                         disabled={!isEditing}
                     />
+                    <div>
+                        {/* The glucose settings above stay in the saved unit until the change is saved. */}
+                        <div className="font-bold">Glucose Unit</div>
+                        <select
+                            className="w-full"
+                            disabled={!isEditing}
+                            value={userRef.current.glucose_unit}
+                            onInput={(e) =>
+                                update('glucose_unit', (e.target as HTMLSelectElement).value as TblUser['glucose_unit'])
+                            }
+                        >
+                            {['mmol/L', 'mg/dL'].map((x) => (
+                                <option key={x} value={x}>
+                                    {x}
+                                </option>
+                            ))}
+                        </select>
+                    </div>
+                    <div>
+                        <div className="font-bold">Insulin Action Curve</div>
+                        <select
+                            className="w-full"
+                            disabled={!isEditing}
+                            value={userRef.current.insulin_action_curve}
+                            onInput={(e) => update('insulin_action_curve', (e.target as HTMLSelectElement).value)}
+                        >
+                            {['exponential', 'linear', 'none'].map((x) => (
+                                <option key={x} value={x}>
+                                    {x}
+                                </option>
+                            ))}
+                        </select>
+                    </div>
+                    <NumberInput
+                        className="w-full input-like"
+                        innerClassName="w-full text-right"
+                        label="Insulin Duration (in Hours)"
+                        value={userRef.current.insulin_duration_minutes / 60}
+                        onValueChange={(value: number) => update('insulin_duration_minutes', Math.round(value * 60))}
+                        disabled={!isEditing}
+                        min={2}
+                        max={12}
+                    />
+                    <NumberInput
+                        className="w-full input-like"
+                        innerClassName="w-full text-right"
+                        label="Insulin Peak (in Minutes)"
+                        value={userRef.current.insulin_peak_minutes}
+                        onValueChange={(value: number) => update('insulin_peak_minutes', value)}
+                        disabled={!isEditing}
+                        min={10}
+                    />
                 </>
             )}
             <NumberInput
@@ -206,6 +258,18 @@ export function SettingsPage(state: BaseState) {
                 />
             </label>
 
+            <div>
+                <div className="font-bold">Timezone</div>
+                <input
+                    className="w-full"
+                    type="text"
+                    disabled={!isEditing}
+                    value={userRef.current.timezone}
+                    placeholder={Intl.DateTimeFormat().resolvedOptions().timeZone}
+                    onInput={(e) => update('timezone', (e.target as HTMLInputElement).value)}
+                />
+            </div>
+
             <div>
                 <div className="font-bold">Color Theme</div>
                 <select
@@ -131,7 +131,7 @@ export const DEFAULT_DASHBOARD: UserDashboard = {
         {
             id: 3,
             type: 'blood_glucose',
-            title: 'Blood Glucose (mmol/L)',
+            title: 'Blood Glucose',
             visibleMacros: [],
             selectedTags: [],
             timeRanges: CommonRanges,
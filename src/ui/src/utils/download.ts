@@ -68,6 +68,9 @@ export const GenerateEventTableText = (user: TblUser, events: UserEventFoodLog[]
                           `Insulin To Carb Ratio   ${eventlog.insulin_to_carb_ratio.toFixed(2)}`,
                           `Insulin Rec             ${eventlog.recommended_insulin_amount.toFixed(2)}`,
                           `Insulin Taken           ${eventlog.actual_insulin_taken.toFixed(2)}`,
+                          `Fat-Protein Units       ${eventlog.fat_protein_units.toFixed(2)}`,
+                          `Extended Insulin Rec    ${eventlog.recommended_extended_insulin_amount.toFixed(2)} over ${eventlog.recommended_extended_minutes} min`,
+                          `Extended Insulin Taken  ${eventlog.actual_extended_insulin_taken.toFixed(2)} over ${eventlog.actual_extended_minutes} min`,
                       ].join('\n')
                     : null,
                 `Calories                ${CalculateCalories(
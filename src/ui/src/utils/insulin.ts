@@ -3,7 +3,8 @@ export const CalcInsulin = (
     bloodGlucose: number,
     targetGlucose: number,
     insulinToCarbRatio: number,
-    insulinSensitivityFactor: number
+    insulinSensitivityFactor: number,
+    insulinOnBoard = 0
 ): number => {
     if (insulinSensitivityFactor === 0) {
         return 0;
@@ -12,7 +13,7 @@ export const CalcInsulin = (
     const adjust = (bloodGlucose - targetGlucose) / insulinSensitivityFactor;
 
     if (netCarbs === 0) {
-        return Math.max(0, adjust);
+        return Math.max(0, adjust - insulinOnBoard);
     }
 
     if (insulinToCarbRatio === 0) {
@@ -21,5 +22,5 @@ export const CalcInsulin = (
 
     const carbAmt = netCarbs / insulinToCarbRatio;
 
-    return Math.max(0, carbAmt + adjust);
+    return Math.max(0, carbAmt + adjust - insulinOnBoard);
 };
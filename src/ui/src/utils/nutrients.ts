@@ -0,0 +1,9 @@
+import {Nutrients} from '../api/types';
+
+export function ScaleNutrients(nutrients: Nutrients, factor: number): Nutrients {
+    const out: Nutrients = {};
+    for (const [k, v] of Object.entries(nutrients)) {
+        out[k] = v * factor;
+    }
+    return out;
+}
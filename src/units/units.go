@@ -0,0 +1,241 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Kind is what a unit measures.
+type Kind int
+
+const (
+	KindMass Kind = iota + 1
+	KindVolume
+)
+
+var (
+	ErrUnknownUnit          = errors.New("unknown unit")
+	ErrIncompatibleUnits    = errors.New("units cannot be converted without a density")
+	ErrInvalidDensity       = errors.New("density must be a positive number of grams per millilitre, or 0 if unknown")
+	ErrInvalidHouseholdUnit = errors.New("household units need a name and a positive amount of a mass or volume unit")
+)
+
+// Unit is a unit of mass or volume, Base is how many grams or millilitres are in one.
+type Unit struct {
+	Name string
+	Kind Kind
+	Base float64
+}
+
+// standardUnits are the known units, the volumes are US customary like on nutrition labels.
+var standardUnits = []struct {
+	unit    Unit
+	aliases []string
+}{
+	{Unit{"g", KindMass, 1}, []string{"gram", "gramme"}},
+	{Unit{"mg", KindMass, 0.001}, []string{"milligram", "milligramme"}},
+	{Unit{"kg", KindMass, 1000}, []string{"kilogram", "kilogramme", "kilo"}},
+	{Unit{"oz", KindMass, 28.349523125}, []string{"ounce"}},
+	{Unit{"lb", KindMass, 453.59237}, []string{"lbs", "pound"}},
+	{Unit{"ml", KindVolume, 1}, []string{"millilitre", "milliliter", "cc"}},
+	{Unit{"dl", KindVolume, 100}, []string{"decilitre", "deciliter"}},
+	{Unit{"l", KindVolume, 1000}, []string{"litre", "liter"}},
+	{Unit{"tsp", KindVolume, 4.92892159375}, []string{"teaspoon"}},
+	{Unit{"tbsp", KindVolume, 14.78676478125}, []string{"tablespoon", "tbs"}},
+	{Unit{"fl oz", KindVolume, 29.5735295625}, []string{"floz", "fluid ounce"}},
+	{Unit{"cup", KindVolume, 236.5882365}, nil},
+	{Unit{"pint", KindVolume, 473.176473}, []string{"pt"}},
+	{Unit{"quart", KindVolume, 946.352946}, []string{"qt"}},
+	{Unit{"gallon", KindVolume, 3785.411784}, []string{"gal"}},
+}
+
+var standardUnitByName = func() map[string]Unit {
+
+	m := make(map[string]Unit)
+
+	for _, s := range standardUnits {
+
+		m[s.unit.Name] = s.unit
+
+		for _, alias := range s.aliases {
+			m[alias] = s.unit
+		}
+	}
+
+	return m
+}()
+
+// countUnits count whole things of any size, so they are allowed as a food's unit
+// but only ever convert into themselves. Other counts, like an egg, need a household unit.
+var countUnits = map[string]struct{}{
+	"serving": {},
+	"piece":   {},
+	"item":    {},
+	"each":    {},
+}
+
+// normalizeName lowercases the name, trims it and collapses inner spaces.
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// lookup finds the name in the map, also trying it without a plural s or es.
+func lookup[T any](m map[string]T, name string) (T, bool) {
+
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+
+	for _, plural := range []string{"s", "es"} {
+		if singular, ok := strings.CutSuffix(name, plural); ok {
+			if v, ok := m[singular]; ok {
+				return v, true
+			}
+		}
+	}
+
+	var zero T
+
+	return zero, false
+}
+
+// Lookup returns the standard unit with the given name, symbol or plural, ignoring case.
+func Lookup(name string) (Unit, bool) {
+	return lookup(standardUnitByName, normalizeName(name))
+}
+
+// IsCount reports if the name is one of the count units, like a serving.
+func IsCount(name string) bool {
+
+	_, ok := lookup(countUnits, normalizeName(name))
+
+	return ok
+}
+
+// Same reports if the unit names are the same, ignoring case and spacing.
+func Same(a, b string) bool {
+	return normalizeName(a) == normalizeName(b)
+}
+
+// Quantity is an amount of a unit.
+type Quantity struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`
+}
+
+// Converter converts amounts of one food between units.
+type Converter struct {
+	// Grams per millilitre, needed to convert between mass and volume, 0 if unknown.
+	Density float64
+
+	// Units of the food like a slice or a scoop, by name, as an amount of a standard unit.
+	Household map[string]Quantity
+}
+
+// Validate checks the density and household units are usable.
+// Household units can't be named after a standard unit.
+func (c Converter) Validate() error {
+
+	if c.Density < 0 || math.IsNaN(c.Density) || math.IsInf(c.Density, 0) {
+		return ErrInvalidDensity
+	}
+
+	for name, q := range c.Household {
+
+		name = normalizeName(name)
+
+		if name == "" {
+			return ErrInvalidHouseholdUnit
+		}
+
+		if _, ok := Lookup(name); ok {
+			return fmt.Errorf("%w: %s is already a unit", ErrInvalidHouseholdUnit, name)
+		}
+
+		if _, ok := Lookup(q.Unit); !ok || !(q.Amount > 0) || math.IsInf(q.Amount, 0) {
+			return fmt.Errorf("%w: %s", ErrInvalidHouseholdUnit, name)
+		}
+	}
+
+	return nil
+}
+
+// Unit returns the standard or household unit with the given name.
+func (c Converter) Unit(name string) (Unit, bool) {
+
+	name = normalizeName(name)
+
+	if u, ok := Lookup(name); ok {
+		return u, true
+	}
+
+	household := make(map[string]Quantity, len(c.Household))
+	for k, v := range c.Household {
+		household[normalizeName(k)] = v
+	}
+
+	q, ok := lookup(household, name)
+
+	if !ok {
+		return Unit{}, false
+	}
+
+	u, ok := Lookup(q.Unit)
+
+	if !ok {
+		return Unit{}, false
+	}
+
+	return Unit{Name: name, Kind: u.Kind, Base: q.Amount * u.Base}, true
+}
+
+// ValidateUnit checks the name is a standard unit, a household unit or a count unit,
+// so amounts in other units have a chance of being converted into it.
+func (c Converter) ValidateUnit(name string) error {
+
+	if _, ok := c.Unit(name); ok || IsCount(name) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s, use a mass or volume unit, a household unit or a serving", ErrUnknownUnit, name)
+}
+
+// Convert converts the amount from one unit into another.
+// Units with the same name always convert, even if they are not known.
+func (c Converter) Convert(amount float64, from, to string) (float64, error) {
+
+	if Same(from, to) {
+		return amount, nil
+	}
+
+	f, ok := c.Unit(from)
+
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownUnit, from)
+	}
+
+	t, ok := c.Unit(to)
+
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownUnit, to)
+	}
+
+	base := amount * f.Base
+
+	if f.Kind != t.Kind {
+
+		if c.Density <= 0 {
+			return 0, fmt.Errorf("%w: %s to %s", ErrIncompatibleUnits, from, to)
+		}
+
+		if f.Kind == KindVolume {
+			base *= c.Density
+		} else {
+			base /= c.Density
+		}
+	}
+
+	return base / t.Base, nil
+}
@@ -0,0 +1,114 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+
+	for _, name := range []string{"g", "Grams", " gram ", "GRAMMES"} {
+		u, ok := Lookup(name)
+		require.True(t, ok, name)
+		assert.Equal(t, "g", u.Name)
+	}
+
+	u, ok := Lookup("Fl  Oz")
+	require.True(t, ok)
+	assert.Equal(t, KindVolume, u.Kind)
+
+	u, ok = Lookup("cups")
+	require.True(t, ok)
+	assert.Equal(t, "cup", u.Name)
+
+	_, ok = Lookup("slice")
+	assert.False(t, ok)
+
+	assert.True(t, Same("Fl oz", " fl  OZ"))
+	assert.False(t, Same("oz", "fl oz"))
+}
+
+func TestConvert(t *testing.T) {
+
+	c := Converter{}
+
+	got, err := c.Convert(3, "oz", "g")
+	require.NoError(t, err)
+	assert.InDelta(t, 85.05, got, 0.01)
+
+	got, err = c.Convert(1, "cup", "tbsp")
+	require.NoError(t, err)
+	assert.InDelta(t, 16, got, 1e-9)
+
+	// Unknown units only convert into themselves.
+	got, err = c.Convert(2, "Serving", "serving")
+	require.NoError(t, err)
+	assert.InDelta(t, 2, got, 1e-9)
+
+	_, err = c.Convert(1, "serving", "g")
+	require.ErrorIs(t, err, ErrUnknownUnit)
+
+	_, err = c.Convert(1, "cup", "g")
+	require.ErrorIs(t, err, ErrIncompatibleUnits)
+
+	// Milk.
+	c = Converter{
+		Density:   1.03,
+		Household: map[string]Quantity{"Glass": {Amount: 250, Unit: "ml"}, "carton": {Amount: 1, Unit: "l"}},
+	}
+
+	got, err = c.Convert(1, "cup", "g")
+	require.NoError(t, err)
+	assert.InDelta(t, 243.68, got, 0.01)
+
+	got, err = c.Convert(103, "g", "ml")
+	require.NoError(t, err)
+	assert.InDelta(t, 100, got, 1e-9)
+
+	got, err = c.Convert(2, "glasses", "carton")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, got, 1e-9)
+
+	got, err = c.Convert(1, "glass", "g")
+	require.NoError(t, err)
+	assert.InDelta(t, 257.5, got, 1e-9)
+
+	// Bread.
+	c = Converter{Household: map[string]Quantity{"slice": {Amount: 28, Unit: "g"}}}
+
+	got, err = c.Convert(3, "slices", "oz")
+	require.NoError(t, err)
+	assert.InDelta(t, 2.963, got, 0.001)
+}
+
+func TestConverterValidate(t *testing.T) {
+
+	assert.NoError(t, Converter{}.Validate())
+	assert.NoError(t, Converter{Density: 0.9, Household: map[string]Quantity{"slice": {28, "g"}}}.Validate())
+
+	assert.ErrorIs(t, Converter{Density: -1}.Validate(), ErrInvalidDensity)
+
+	for _, household := range []map[string]Quantity{
+		{" ": {1, "g"}},
+		{"Cups": {1, "g"}},
+		{"slice": {0, "g"}},
+		{"slice": {1, "slab"}},
+	} {
+		assert.ErrorIs(t, Converter{Household: household}.Validate(), ErrInvalidHouseholdUnit, household)
+	}
+}
+
+func TestConverterValidateUnit(t *testing.T) {
+
+	c := Converter{Household: map[string]Quantity{"egg": {50, "g"}}}
+
+	for _, name := range []string{"g", "Cups", "egg", "eggs", "serving", " Servings ", "piece"} {
+		assert.NoError(t, c.ValidateUnit(name), name)
+	}
+
+	for _, name := range []string{"", "gr", "bar", "slab"} {
+		assert.ErrorIs(t, c.ValidateUnit(name), ErrUnknownUnit, name)
+	}
+}